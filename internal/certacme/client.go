@@ -8,6 +8,7 @@ import (
 	"github.com/go-acme/lego/v5/lego"
 
 	"github.com/certimate-go/certimate/internal/app"
+	"github.com/certimate-go/certimate/internal/tracing"
 )
 
 type ACMEClient struct {
@@ -56,6 +57,11 @@ func newACMEClientWithAccount(account *ACMEAccount, configures ...func(*lego.Con
 		return nil, errors.Join(errs...)
 	}
 
+	// 启用追踪时，为 ACME 客户端发出的每个请求创建 Span
+	if legoCfg.HTTPClient != nil {
+		legoCfg.HTTPClient.Transport = tracing.WrapTransport(legoCfg.HTTPClient.Transport)
+	}
+
 	legoClient, err := lego.NewClient(legoCfg)
 	if err != nil {
 		return nil, err
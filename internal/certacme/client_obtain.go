@@ -17,9 +17,12 @@ import (
 	"github.com/go-acme/lego/v5/challenge/http01"
 	"github.com/go-acme/lego/v5/log"
 	"github.com/samber/lo"
+	"go.opentelemetry.io/otel/attribute"
 
 	"github.com/certimate-go/certimate/internal/certacme/certifiers"
 	"github.com/certimate-go/certimate/internal/domain"
+	"github.com/certimate-go/certimate/internal/metrics"
+	"github.com/certimate-go/certimate/internal/tracing"
 	xcert "github.com/certimate-go/certimate/pkg/utils/cert"
 )
 
@@ -70,6 +73,24 @@ type ObtainCertificateResponse struct {
 }
 
 func (c *ACMEClient) ObtainCertificate(ctx context.Context, request *ObtainCertificateRequest) (*ObtainCertificateResponse, error) {
+	attrs := []attribute.KeyValue{attribute.String("acme.ca", c.account.CA)}
+	if request != nil {
+		attrs = append(attrs,
+			attribute.String("acme.challenge_type", request.ChallengeType),
+			attribute.String("acme.provider", string(request.Provider)),
+			attribute.StringSlice("acme.domains", request.DomainOrIPs),
+		)
+	}
+
+	ctx, span := tracing.Start(ctx, "acme.obtain", attrs...)
+	resp, err := c.obtainCertificate(ctx, request)
+	tracing.End(span, err)
+
+	metrics.ObserveACMEOrder(c.account.CA, err)
+	return resp, err
+}
+
+func (c *ACMEClient) obtainCertificate(ctx context.Context, request *ObtainCertificateRequest) (*ObtainCertificateResponse, error) {
 	if request == nil {
 		return nil, fmt.Errorf("the request is nil")
 	}
@@ -96,10 +117,7 @@ func (c *ACMEClient) ObtainCertificate(ctx context.Context, request *ObtainCerti
 				return nil, fmt.Errorf("failed to initialize dns-01 provider '%s': %w", request.Provider, err)
 			}
 
-			opts := &dns01.Options{}
-			opts.RecursiveNameservers = request.Nameservers
-			dns01.SetDefaultClient(dns01.NewClient(opts))
-			c.client.Challenge.SetDNS01Provider(provider,
+			challengeOpts := []dns01.ChallengeOption{
 				dns01.CondOptions(
 					request.DnsPropagationWait > 0,
 					dns01.PropagationWait(time.Duration(request.DnsPropagationWait)*time.Second, true),
@@ -108,7 +126,24 @@ func (c *ACMEClient) ObtainCertificate(ctx context.Context, request *ObtainCerti
 					len(request.Nameservers) > 0 || request.DnsPropagationWait > 0,
 					dns01.DisableAuthoritativeNssPropagationRequirement(),
 				),
-			)
+			}
+			if tracing.IsEnabled() {
+				// 启用追踪时，为解析记录的预置、等待传播与清理创建 Span
+				// 传播检查函数只能设置一个，因此须由追踪的包装函数接管等待
+				var wrapPreCheck dns01.WrapPreCheckFunc
+				if request.DnsPropagationWait > 0 {
+					wrapPreCheck = waitPropagation(time.Duration(request.DnsPropagationWait) * time.Second)
+				}
+
+				tracedProvider := newTracedDns01Provider(provider)
+				provider = tracedProvider.Provider()
+				challengeOpts = append(challengeOpts, dns01.WrapPreCheck(tracedProvider.WrapPreCheck(wrapPreCheck)))
+			}
+
+			opts := &dns01.Options{}
+			opts.RecursiveNameservers = request.Nameservers
+			dns01.SetDefaultClient(dns01.NewClient(opts))
+			c.client.Challenge.SetDNS01Provider(provider, challengeOpts...)
 		}
 
 	case CHALLENGE_TYPE_HTTP01:
@@ -194,3 +229,55 @@ func (c *ACMEClient) ObtainCertificate(ctx context.Context, request *ObtainCerti
 		ARIReplaced:          req.ReplacesCertID != "",
 	}, nil
 }
+
+// 校验证书申请请求中的质询提供商及其配置项，但不会实际申请证书。
+func ValidateObtainCertificateRequest(request *ObtainCertificateRequest) error {
+	if request == nil {
+		return fmt.Errorf("the request is nil")
+	}
+
+	switch strings.ToLower(request.ChallengeType) {
+	case "dns-01":
+		{
+			providerFactory, err := certifiers.ACMEDns01Registries.Get(domain.ACMEDns01ProviderType(request.Provider))
+			if err != nil {
+				return err
+			}
+
+			if _, err := providerFactory(&certifiers.ProviderFactoryOptions{
+				ProviderAccessConfig:   request.ProviderAccessConfig,
+				ProviderExtendedConfig: request.ProviderExtendedConfig,
+				DnsPropagationTimeout:  request.DnsPropagationTimeout,
+				DnsTTL:                 request.DnsTTL,
+			}); err != nil {
+				return fmt.Errorf("failed to initialize dns-01 provider '%s': %w", request.Provider, err)
+			}
+		}
+
+	case "http-01":
+		{
+			providerFactory, err := certifiers.ACMEHttp01Registries.Get(domain.ACMEHttp01ProviderType(request.Provider))
+			if err != nil {
+				return err
+			}
+
+			if _, err := providerFactory(&certifiers.ProviderFactoryOptions{
+				ProviderAccessConfig:   request.ProviderAccessConfig,
+				ProviderExtendedConfig: request.ProviderExtendedConfig,
+			}); err != nil {
+				return fmt.Errorf("failed to initialize http-01 provider '%s': %w", request.Provider, err)
+			}
+		}
+
+	default:
+		return fmt.Errorf("unsupported challenge type: '%s'", request.ChallengeType)
+	}
+
+	if request.PrivateKeyPEM != "" {
+		if _, err := certcrypto.ParsePEMPrivateKey([]byte(request.PrivateKeyPEM)); err != nil {
+			return fmt.Errorf("failed to parse private key: %w", err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,147 @@
+package certacme
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/go-acme/lego/v5/challenge"
+	"github.com/go-acme/lego/v5/challenge/dns01"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/certimate-go/certimate/internal/tracing"
+)
+
+var errPropagationUnconfirmed = errors.New("the dns record propagation is not confirmed")
+
+// 包装 DNS-01 质询提供商，为预置、等待传播与清理解析记录分别创建 Span。
+// 等待传播的 Span 自预置完成开始、至传播检查通过（或清理）时结束，以便区分传播等待与提供商 API 的耗时。
+type tracedDns01Provider struct {
+	provider challenge.Provider
+
+	propagationSpans    map[string]trace.Span // Key: 解析记录值（同一域名及其通配符域名的质询将使用同一域名，需以记录值区分）
+	propagationSpansMtx sync.Mutex
+}
+
+// 同时实现了 Sequential 方法的提供商须保留之，lego 将据之串行处理各个质询。
+type tracedSequentialDns01Provider struct {
+	*tracedDns01Provider
+}
+
+var (
+	_ challenge.ProviderTimeout = (*tracedDns01Provider)(nil)
+	_ challenge.ProviderTimeout = (*tracedSequentialDns01Provider)(nil)
+)
+
+func newTracedDns01Provider(provider challenge.Provider) *tracedDns01Provider {
+	return &tracedDns01Provider{
+		provider:         provider,
+		propagationSpans: make(map[string]trace.Span),
+	}
+}
+
+// 返回用于替代原提供商的提供商。
+func (p *tracedDns01Provider) Provider() challenge.Provider {
+	if _, ok := p.provider.(interface{ Sequential() time.Duration }); ok {
+		return &tracedSequentialDns01Provider{p}
+	}
+
+	return p
+}
+
+func (p *tracedDns01Provider) Present(ctx context.Context, domain, token, keyAuth string) error {
+	spanCtx, span := tracing.Start(ctx, "acme.dns01.present", attribute.String("acme.domain", domain))
+	err := p.provider.Present(tracing.WithHTTPClientTrace(spanCtx), domain, token, keyAuth)
+	tracing.End(span, err)
+	if err != nil {
+		return err
+	}
+
+	_, propagationSpan := tracing.Start(ctx, "acme.dns01.propagation", attribute.String("acme.domain", domain))
+	p.propagationSpansMtx.Lock()
+	p.propagationSpans[challengeValue(keyAuth)] = propagationSpan
+	p.propagationSpansMtx.Unlock()
+
+	return nil
+}
+
+func (p *tracedDns01Provider) CleanUp(ctx context.Context, domain, token, keyAuth string) error {
+	// 传播检查未通过时（如超时），于此结束等待传播的 Span
+	p.endPropagation(challengeValue(keyAuth), errPropagationUnconfirmed)
+
+	spanCtx, span := tracing.Start(ctx, "acme.dns01.cleanup", attribute.String("acme.domain", domain))
+	err := p.provider.CleanUp(tracing.WithHTTPClientTrace(spanCtx), domain, token, keyAuth)
+	tracing.End(span, err)
+	return err
+}
+
+func (p *tracedDns01Provider) Timeout() (time.Duration, time.Duration) {
+	if provider, ok := p.provider.(challenge.ProviderTimeout); ok {
+		return provider.Timeout()
+	}
+
+	return dns01.DefaultPropagationTimeout, dns01.DefaultPollingInterval
+}
+
+func (p *tracedSequentialDns01Provider) Sequential() time.Duration {
+	return p.provider.(interface{ Sequential() time.Duration }).Sequential()
+}
+
+// 包装传播检查函数，检查通过或出错时结束等待传播的 Span，每次检查也将作为事件记录在其上。
+// wrap 为原本的传播检查函数，为空时直接检查。
+func (p *tracedDns01Provider) WrapPreCheck(wrap dns01.WrapPreCheckFunc) dns01.WrapPreCheckFunc {
+	return func(ctx context.Context, domain, fqdn, value string, check dns01.PreCheckFunc) (bool, error) {
+		var stop bool
+		var err error
+		if wrap != nil {
+			stop, err = wrap(ctx, domain, fqdn, value, check)
+		} else {
+			stop, err = check(ctx, fqdn, value)
+		}
+
+		p.propagationSpansMtx.Lock()
+		if span, ok := p.propagationSpans[value]; ok {
+			span.AddEvent("propagation.check", trace.WithAttributes(attribute.Bool("propagated", stop), attribute.Bool("error", err != nil)))
+		}
+		p.propagationSpansMtx.Unlock()
+
+		if stop || err != nil {
+			p.endPropagation(value, err)
+		}
+
+		return stop, err
+	}
+}
+
+func (p *tracedDns01Provider) endPropagation(value string, err error) {
+	p.propagationSpansMtx.Lock()
+	span, ok := p.propagationSpans[value]
+	delete(p.propagationSpans, value)
+	p.propagationSpansMtx.Unlock()
+
+	if ok {
+		tracing.End(span, err)
+	}
+}
+
+// 仅等待指定时长而不主动检查传播，与 [dns01.PropagationWait] 跳过检查时等效。
+func waitPropagation(wait time.Duration) dns01.WrapPreCheckFunc {
+	return func(ctx context.Context, _, _, _ string, _ dns01.PreCheckFunc) (bool, error) {
+		select {
+		case <-ctx.Done():
+			return false, ctx.Err()
+		case <-time.After(wait):
+			return true, nil
+		}
+	}
+}
+
+// 返回质询的解析记录值，与 [dns01.GetChallengeInfo] 的计算方式一致。
+func challengeValue(keyAuth string) string {
+	sum := sha256.Sum256([]byte(keyAuth))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
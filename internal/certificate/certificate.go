@@ -0,0 +1,5 @@
+package certificate
+
+func Setup() {
+	registerCertificateRecordEvents()
+}
@@ -0,0 +1,35 @@
+package certificate
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/pocketbase/pocketbase/core"
+
+	"github.com/certimate-go/certimate/internal/app"
+	"github.com/certimate-go/certimate/internal/domain"
+	"github.com/certimate-go/certimate/internal/eventbus"
+)
+
+func registerCertificateRecordEvents() {
+	pb := app.GetApp()
+	pb.OnRecordAfterUpdateSuccess(domain.CollectionNameCertificate).BindFunc(func(e *core.RecordEvent) error {
+		onCertificateRecordUpdate(e.Context, e.Record)
+		return e.Next()
+	})
+}
+
+func onCertificateRecordUpdate(ctx context.Context, record *core.Record) {
+	// 证书被标记为已吊销时，发布事件
+	if record.GetBool("isRevoked") && !record.Original().GetBool("isRevoked") {
+		event := domain.NewEvent(domain.EventTypeCertificateRevoked, map[string]any{
+			"certificateId":   record.Id,
+			"subjectAltNames": record.GetString("subjectAltNames"),
+			"serialNumber":    record.GetString("serialNumber"),
+			"workflowId":      record.GetString("workflowRef"),
+		})
+		if err := eventbus.GetSingletonEventBus().Publish(ctx, event); err != nil {
+			app.GetLogger().Error("failed to publish certificate revoked event", slog.Any("error", err))
+		}
+	}
+}
@@ -7,34 +7,50 @@ import (
 	"fmt"
 	"log/slog"
 	"strings"
+	"time"
 
 	"github.com/pocketbase/dbx"
 
 	"github.com/certimate-go/certimate/internal/app"
 	"github.com/certimate-go/certimate/internal/certacme"
+	"github.com/certimate-go/certimate/internal/cluster"
 	"github.com/certimate-go/certimate/internal/domain"
 	"github.com/certimate-go/certimate/internal/domain/dtos"
+	"github.com/certimate-go/certimate/internal/eventbus"
+	"github.com/certimate-go/certimate/internal/metrics"
 	"github.com/certimate-go/certimate/internal/settings"
 	xcert "github.com/certimate-go/certimate/pkg/utils/cert"
 	xcertpfx "github.com/certimate-go/certimate/pkg/utils/cert/pfx"
 )
 
 type CertificateService struct {
+	eventBus eventbus.EventBus
+
 	acmeAccountRepo acmeAccountRepository
 	certificateRepo certificateRepository
 }
 
 func NewCertificateService(acmeAccountRepo acmeAccountRepository, certificateRepo certificateRepository) *CertificateService {
 	return &CertificateService{
+		eventBus: eventbus.GetSingletonEventBus(),
+
 		acmeAccountRepo: acmeAccountRepo,
 		certificateRepo: certificateRepo,
 	}
 }
 
 func (s *CertificateService) InitSchedule(ctx context.Context) error {
-	app.GetScheduler().MustAdd("cleanupCertificateExpired", "0 0 * * *", func() {
+	app.GetScheduler().MustAdd("cleanupCertificateExpired", "0 0 * * *", cluster.LeaderOnly(func() {
 		s.cleanupExpiredCertificates(context.Background())
-	})
+	}))
+
+	// 每日检查一次即将过期的证书，订阅方按剩余天数不大于其设定天数（daysLeft <= ExpiringDays）匹配，同一证书仅触发一次
+	app.GetScheduler().MustAdd("publishCertificateExpiring", "0 0 * * *", cluster.LeaderOnly(func() {
+		s.publishExpiringCertificates(context.Background())
+	}))
+
+	// 注册证书过期时间的指标
+	s.registerMetrics()
 
 	return nil
 }
@@ -274,3 +290,49 @@ func (s *CertificateService) cleanupExpiredCertificates(ctx context.Context) err
 
 	return nil
 }
+
+func (s *CertificateService) registerMetrics() {
+	metrics.RegisterGaugeFunc("certificate", "expiry_timestamp_seconds", "Expiry time of valid certificates, in unix seconds.", []string{"certificate_id", "subject_alt_names", "workflow_id"}, func() []metrics.GaugeSample {
+		certificates, err := s.certificateRepo.ListValid(context.Background())
+		if err != nil {
+			app.GetLogger().Error("failed to list valid certificates", slog.Any("error", err))
+			return nil
+		}
+
+		samples := make([]metrics.GaugeSample, 0, len(certificates))
+		for _, certificate := range certificates {
+			samples = append(samples, metrics.GaugeSample{
+				LabelValues: []string{certificate.Id, certificate.SubjectAltNames, certificate.WorkflowId},
+				Value:       float64(certificate.ValidityNotAfter.Unix()),
+			})
+		}
+		return samples
+	})
+}
+
+func (s *CertificateService) publishExpiringCertificates(ctx context.Context) error {
+	certificates, err := s.certificateRepo.ListValid(ctx)
+	if err != nil {
+		app.GetLogger().Error("failed to list valid certificates", slog.Any("error", err))
+		return err
+	}
+
+	now := time.Now()
+	for _, certificate := range certificates {
+		daysLeft := int(certificate.ValidityNotAfter.Sub(now).Hours() / 24)
+		event := domain.NewEvent(domain.EventTypeCertificateExpiring, map[string]any{
+			"certificateId":    certificate.Id,
+			"subjectAltNames":  certificate.SubjectAltNames,
+			"serialNumber":     certificate.SerialNumber,
+			"validityNotAfter": certificate.ValidityNotAfter,
+			"daysLeft":         daysLeft,
+			"workflowId":       certificate.WorkflowId,
+		})
+		if err := s.eventBus.Publish(ctx, event); err != nil {
+			app.GetLogger().Error("failed to publish certificate expiring event", slog.Any("error", err))
+			return err
+		}
+	}
+
+	return nil
+}
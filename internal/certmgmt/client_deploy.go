@@ -3,9 +3,14 @@ package certmgmt
 import (
 	"context"
 	"fmt"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
 
 	"github.com/certimate-go/certimate/internal/certmgmt/deployers"
 	"github.com/certimate-go/certimate/internal/domain"
+	"github.com/certimate-go/certimate/internal/metrics"
+	"github.com/certimate-go/certimate/internal/tracing"
 )
 
 type DeployCertificateRequest struct {
@@ -22,6 +27,26 @@ type DeployCertificateRequest struct {
 type DeployCertificateResponse struct{}
 
 func (c *Client) DeployCertificate(ctx context.Context, request *DeployCertificateRequest) (*DeployCertificateResponse, error) {
+	attrs := make([]attribute.KeyValue, 0, 1)
+	if request != nil {
+		attrs = append(attrs, attribute.String("deployer.provider", string(request.Provider)))
+	}
+
+	// 部署器发出的 HTTP 请求的各个阶段将作为事件记录在 Span 上，以便区分各次 API 调用的耗时
+	ctx, span := tracing.Start(ctx, "deployer.deploy", attrs...)
+	ctx = tracing.WithHTTPClientTrace(ctx)
+
+	startedAt := time.Now()
+	resp, err := c.deployCertificate(ctx, request)
+	if request != nil {
+		metrics.ObserveDeployerCall(string(request.Provider), err, time.Since(startedAt))
+	}
+
+	tracing.End(span, err)
+	return resp, err
+}
+
+func (c *Client) deployCertificate(ctx context.Context, request *DeployCertificateRequest) (*DeployCertificateResponse, error) {
 	if request == nil {
 		return nil, fmt.Errorf("the request is nil")
 	}
@@ -46,3 +71,24 @@ func (c *Client) DeployCertificate(ctx context.Context, request *DeployCertifica
 
 	return &DeployCertificateResponse{}, nil
 }
+
+// 校验部署请求中的提供商及其配置项，但不会实际部署证书。
+func (c *Client) ValidateDeployCertificateRequest(request *DeployCertificateRequest) error {
+	if request == nil {
+		return fmt.Errorf("the request is nil")
+	}
+
+	providerFactory, err := deployers.Registries.Get(request.Provider)
+	if err != nil {
+		return err
+	}
+
+	if _, err := providerFactory(&deployers.ProviderFactoryOptions{
+		ProviderAccessConfig:   request.ProviderAccessConfig,
+		ProviderExtendedConfig: request.ProviderExtendedConfig,
+	}); err != nil {
+		return fmt.Errorf("failed to initialize deployment provider '%s': %w", request.Provider, err)
+	}
+
+	return nil
+}
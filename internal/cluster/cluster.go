@@ -0,0 +1,171 @@
+// Package cluster 提供多实例部署时的集群模式支持。
+//
+// 集群模式下，多个实例共享同一个数据库，并通过数据库中的租约进行协调：
+//   - 主节点选举：仅主节点执行定时任务，以免同一定时任务被多个实例重复触发；
+//   - 运行认领：每个工作流运行仅由认领到它的节点执行，节点须定期续期租约，崩溃节点的运行将在租约过期后被其他节点接管（见 internal/workflow/dispatcher）。
+//
+// 可通过以下环境变量进行配置：
+//   - CERTIMATE_CLUSTER_ENABLED：是否启用集群模式，默认为 false；
+//   - CERTIMATE_CLUSTER_NODE_ID：当前节点 ID，默认由主机名与随机字符串组成，各节点须互不相同；
+//   - CERTIMATE_CLUSTER_LEASE_TTL：租约有效期（单位：秒），默认为 30。
+//
+// 在本地以两个进程测试时，可令其使用同一个数据目录、监听不同的端口，例如：
+//
+//	CERTIMATE_CLUSTER_ENABLED=true CERTIMATE_CLUSTER_NODE_ID=node1 ./certimate serve --dir ./pb_data --http 127.0.0.1:8090
+//	CERTIMATE_CLUSTER_ENABLED=true CERTIMATE_CLUSTER_NODE_ID=node2 ./certimate serve --dir ./pb_data --http 127.0.0.1:8091
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pocketbase/pocketbase/tools/security"
+
+	"github.com/certimate-go/certimate/internal/app"
+	"github.com/certimate-go/certimate/internal/repository"
+	xenv "github.com/certimate-go/certimate/pkg/utils/env"
+)
+
+const leaderLeaseName = "leader"
+
+var (
+	envEnabled  = false
+	envNodeId   = ""
+	envLeaseTTL = 30
+)
+
+func init() {
+	envEnabled = xenv.GetOrDefaultBool("CERTIMATE_CLUSTER_ENABLED", false)
+	envNodeId = xenv.GetOrDefaultString("CERTIMATE_CLUSTER_NODE_ID", "")
+	if envNodeId == "" {
+		hostname, _ := os.Hostname()
+		envNodeId = fmt.Sprintf("%s-%s", hostname, security.RandomString(6))
+	}
+	envLeaseTTL = xenv.GetOrDefaultInt("CERTIMATE_CLUSTER_LEASE_TTL", 30)
+	if envLeaseTTL <= 0 {
+		envLeaseTTL = 30
+	}
+}
+
+type leaseRepository interface {
+	Acquire(ctx context.Context, name string, holder string, ttl time.Duration) (bool, error)
+	Release(ctx context.Context, name string, holder string) error
+}
+
+var (
+	leader     atomic.Bool
+	leaseRepo  leaseRepository
+	stopLoop   context.CancelFunc
+	setupMutex sync.Mutex
+)
+
+// 返回是否启用了集群模式。
+func IsEnabled() bool {
+	return envEnabled
+}
+
+// 返回当前节点 ID。
+func NodeId() string {
+	return envNodeId
+}
+
+// 返回租约有效期。
+func LeaseTTL() time.Duration {
+	return time.Duration(envLeaseTTL) * time.Second
+}
+
+// 返回续期租约的间隔，为租约有效期的三分之一。
+func HeartbeatInterval() time.Duration {
+	return LeaseTTL() / 3
+}
+
+// 返回当前节点是否为主节点。未启用集群模式时，始终返回 true。
+func IsLeader() bool {
+	if !envEnabled {
+		return true
+	}
+
+	return leader.Load()
+}
+
+// 包装定时任务，使其仅在主节点上执行。
+func LeaderOnly(fn func()) func() {
+	return func() {
+		if !IsLeader() {
+			return
+		}
+
+		fn()
+	}
+}
+
+func Setup() {
+	setupMutex.Lock()
+	defer setupMutex.Unlock()
+
+	if !envEnabled || stopLoop != nil {
+		return
+	}
+
+	app.GetLogger().Info(fmt.Sprintf("cluster mode enabled, node id: %s", envNodeId))
+
+	leaseRepo = repository.NewClusterLeaseRepository()
+	campaign(context.Background(), leaseRepo, envNodeId, &leader)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopLoop = cancel
+	go func() {
+		ticker := time.NewTicker(HeartbeatInterval())
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				campaign(ctx, leaseRepo, envNodeId, &leader)
+			}
+		}
+	}()
+}
+
+func Teardown() {
+	setupMutex.Lock()
+	defer setupMutex.Unlock()
+
+	if stopLoop == nil {
+		return
+	}
+
+	stopLoop()
+	stopLoop = nil
+
+	if leader.Swap(false) {
+		if err := leaseRepo.Release(context.Background(), leaderLeaseName, envNodeId); err != nil {
+			app.GetLogger().Warn("failed to release the cluster leadership", slog.Any("error", err))
+		}
+	}
+}
+
+// 竞选或续任主节点，结果将记录在 leader 中。
+func campaign(ctx context.Context, repo leaseRepository, nodeId string, leader *atomic.Bool) {
+	acquired, err := repo.Acquire(ctx, leaderLeaseName, nodeId, LeaseTTL())
+	if err != nil {
+		// 无法确认租约是否仍然有效时，为避免与其他节点同时执行定时任务，主动卸任
+		app.GetLogger().Error("failed to acquire the cluster leadership", slog.Any("error", err))
+		acquired = false
+	}
+
+	if was := leader.Swap(acquired); was != acquired {
+		if acquired {
+			app.GetLogger().Info(fmt.Sprintf("node %s became the cluster leader", nodeId))
+		} else {
+			app.GetLogger().Warn(fmt.Sprintf("node %s is no longer the cluster leader", nodeId))
+		}
+	}
+}
@@ -0,0 +1,254 @@
+package cluster
+
+import (
+	"context"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+
+	"github.com/certimate-go/certimate/internal/app"
+	"github.com/certimate-go/certimate/internal/domain"
+	"github.com/certimate-go/certimate/internal/repository"
+	_ "github.com/certimate-go/certimate/migrations"
+)
+
+// 以临时数据目录初始化应用，各测试中的节点共享同一个 SQLite 数据库。
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "certimate-cluster-test-")
+	if err != nil {
+		panic(err)
+	}
+
+	args := os.Args
+	os.Args = []string{args[0], "--dir", dir}
+	app.GetApp()
+	os.Args = args
+
+	if err := app.GetApp().Bootstrap(); err != nil {
+		panic(err)
+	}
+	if err := app.GetApp().RunAllMigrations(); err != nil {
+		panic(err)
+	}
+
+	code := m.Run()
+	app.GetApp().ResetBootstrapState()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+type testNode struct {
+	id     string
+	leader atomic.Bool
+}
+
+func TestCampaign(t *testing.T) {
+	ttl := envLeaseTTL
+	envLeaseTTL = 1
+	defer func() { envLeaseTTL = ttl }()
+
+	repo := repository.NewClusterLeaseRepository()
+	nodes := []*testNode{{id: "node1"}, {id: "node2"}}
+
+	campaignAll := func(nodes ...*testNode) {
+		var wg sync.WaitGroup
+		for _, node := range nodes {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				campaign(context.Background(), repo, node.id, &node.leader)
+			}()
+		}
+		wg.Wait()
+	}
+
+	findLeaders := func() []*testNode {
+		leaders := make([]*testNode, 0)
+		for _, node := range nodes {
+			if node.leader.Load() {
+				leaders = append(leaders, node)
+			}
+		}
+		return leaders
+	}
+
+	// 测试并发竞选及续任
+	var leader *testNode
+	for i := 0; i < 5; i++ {
+		campaignAll(nodes...)
+
+		leaders := findLeaders()
+		if len(leaders) != 1 {
+			t.Fatalf("round %d: expected 1 leader, got %d", i, len(leaders))
+		}
+		if leader != nil && leaders[0] != leader {
+			t.Errorf("round %d: expected '%s' to stay the leader, got '%s'", i, leader.id, leaders[0].id)
+		}
+		leader = leaders[0]
+	}
+
+	// 测试主节点停止续任后，其他节点于租约过期后接任
+	follower := nodes[0]
+	if follower == leader {
+		follower = nodes[1]
+	}
+
+	campaignAll(follower)
+	if follower.leader.Load() {
+		t.Fatalf("expected '%s' not to become the leader before the lease expires", follower.id)
+	}
+
+	time.Sleep(LeaseTTL() + 100*time.Millisecond)
+	campaignAll(follower)
+	if !follower.leader.Load() {
+		t.Fatalf("expected '%s' to become the leader after the lease expires", follower.id)
+	}
+
+	campaignAll(leader)
+	if leader.leader.Load() {
+		t.Errorf("expected '%s' to lose the leadership", leader.id)
+	}
+	if leaders := findLeaders(); len(leaders) != 1 {
+		t.Errorf("expected 1 leader, got %d", len(leaders))
+	}
+}
+
+func TestClaimRun(t *testing.T) {
+	repo := repository.NewWorkflowRunRepository()
+	nodeIds := []string{"node1", "node2"}
+
+	workflowCollection, err := app.GetApp().FindCollectionByNameOrId(domain.CollectionNameWorkflow)
+	if err != nil {
+		t.Fatal(err)
+	}
+	workflowRecord := core.NewRecord(workflowCollection)
+	workflowRecord.Set("name", "test")
+	if err := app.GetApp().Save(workflowRecord); err != nil {
+		t.Fatalf("failed to save workflow: %v", err)
+	}
+
+	runCollection, err := app.GetApp().FindCollectionByNameOrId(domain.CollectionNameWorkflowRun)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 10; i++ {
+		runRecord := core.NewRecord(runCollection)
+		runRecord.Set("workflowRef", workflowRecord.Id)
+		runRecord.Set("status", domain.WorkflowRunStatusTypePending.String())
+		if err := app.GetApp().Save(runRecord); err != nil {
+			t.Fatalf("failed to save workflow run: %v", err)
+		}
+
+		// 测试多个节点并发认领同一运行
+		var wg sync.WaitGroup
+		var claims atomic.Int32
+		var winner atomic.Value
+		for _, nodeId := range nodeIds {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+
+				claimed, err := repo.Claim(context.Background(), runRecord.Id, domain.WorkflowRunStatusTypePending, nodeId, time.Minute)
+				if err != nil {
+					t.Errorf("failed to claim workflow run: %v", err)
+				} else if claimed {
+					claims.Add(1)
+					winner.Store(nodeId)
+				}
+			}()
+		}
+		wg.Wait()
+
+		if claims.Load() != 1 {
+			t.Fatalf("run %d: expected 1 claim, got %d", i, claims.Load())
+		}
+
+		workflowRun, err := repo.GetById(context.Background(), runRecord.Id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if workflowRun.ClaimedBy != winner.Load() {
+			t.Errorf("run %d: expected claimed by '%s', got '%s'", i, winner.Load(), workflowRun.ClaimedBy)
+		}
+
+		// 测试租约有效期内其他节点无法认领
+		for _, nodeId := range nodeIds {
+			if nodeId == workflowRun.ClaimedBy {
+				continue
+			}
+
+			claimed, err := repo.Claim(context.Background(), runRecord.Id, domain.WorkflowRunStatusTypePending, nodeId, time.Minute)
+			if err != nil {
+				t.Fatal(err)
+			} else if claimed {
+				t.Errorf("run %d: expected '%s' not to claim the run held by '%s'", i, nodeId, workflowRun.ClaimedBy)
+			}
+		}
+	}
+}
+
+func TestSaveWaitingRun(t *testing.T) {
+	repo := repository.NewWorkflowRunRepository()
+
+	workflowCollection, err := app.GetApp().FindCollectionByNameOrId(domain.CollectionNameWorkflow)
+	if err != nil {
+		t.Fatal(err)
+	}
+	workflowRecord := core.NewRecord(workflowCollection)
+	workflowRecord.Set("name", "test")
+	if err := app.GetApp().Save(workflowRecord); err != nil {
+		t.Fatalf("failed to save workflow: %v", err)
+	}
+
+	workflowRun, err := repo.Save(context.Background(), &domain.WorkflowRun{
+		WorkflowId: workflowRecord.Id,
+		Status:     domain.WorkflowRunStatusTypeWaiting,
+		Trigger:    domain.WorkflowTriggerTypeManual,
+		StartedAt:  time.Now(),
+	})
+	if err != nil {
+		t.Fatalf("failed to save workflow run: %v", err)
+	}
+
+	// 测试多个节点并发处理同一审批请求
+	var wg sync.WaitGroup
+	var saves atomic.Int32
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			run, err := repo.GetById(context.Background(), workflowRun.Id)
+			if err != nil {
+				t.Errorf("failed to get workflow run: %v", err)
+				return
+			}
+
+			run.Status = domain.WorkflowRunStatusTypePending
+			saved, err := repo.SaveWithCascadingIfUnchanged(context.Background(), run, domain.WorkflowRunStatusTypeWaiting)
+			if err != nil {
+				t.Errorf("failed to save workflow run: %v", err)
+			} else if saved {
+				saves.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if saves.Load() != 1 {
+		t.Errorf("expected 1 save, got %d", saves.Load())
+	}
+
+	// 测试以过时的运行保存
+	workflowRun.Status = domain.WorkflowRunStatusTypePending
+	if saved, err := repo.SaveWithCascadingIfUnchanged(context.Background(), workflowRun, domain.WorkflowRunStatusTypeWaiting); err != nil {
+		t.Fatal(err)
+	} else if saved {
+		t.Errorf("expected the stale workflow run not to be saved")
+	}
+}
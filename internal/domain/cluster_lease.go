@@ -0,0 +1,15 @@
+package domain
+
+import (
+	"time"
+)
+
+const CollectionNameClusterLease = "cluster_lease"
+
+// 集群模式下多个节点竞争持有的租约，例如主节点选举。
+type ClusterLease struct {
+	Meta
+	Name      string    `db:"name"      json:"name"`
+	Holder    string    `db:"holder"    json:"holder"` // 持有租约的节点 ID
+	ExpiresAt time.Time `db:"expiresAt" json:"expiresAt"`
+}
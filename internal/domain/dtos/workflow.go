@@ -1,12 +1,15 @@
 package dtos
 
 import (
+	"time"
+
 	"github.com/certimate-go/certimate/internal/domain"
 )
 
 type WorkflowStartRunReq struct {
 	WorkflowId string                     `json:"-"`
 	RunTrigger domain.WorkflowTriggerType `json:"trigger"`
+	RunInputs  map[string]any             `json:"-"`
 }
 
 type WorkflowStartRunResp struct {
@@ -20,8 +23,141 @@ type WorkflowCancelRunReq struct {
 
 type WorkflowCancelRunResp struct{}
 
+type WorkflowResumeRunReq struct {
+	WorkflowId string `bind:"path" json:"-"`
+	RunId      string `bind:"path" json:"-"`
+}
+
+type WorkflowResumeRunResp struct {
+	RunId string `json:"runId"`
+}
+
+type WorkflowStreamRunEventsReq struct {
+	WorkflowId  string `json:"-"`
+	RunId       string `json:"-"`
+	LastEventId string `json:"-"` // 客户端最后收到的事件 ID，用于断线后续传
+}
+
+type WorkflowDecideApprovalReq struct {
+	WorkflowId string                              `json:"-"`
+	RunId      string                              `json:"-"`
+	NodeId     string                              `json:"nodeId,omitempty"` // 审批节点 ID，为空时表示运行中唯一一个等待审批的节点
+	Token      string                              `json:"-"`                // 审批令牌，仅当通过审批链接进行审批时有值
+	Decision   domain.WorkflowApprovalDecisionType `json:"decision"`
+	Decider    string                              `json:"-"`
+	Comment    string                              `json:"comment,omitempty"`
+}
+
+type WorkflowDecideApprovalResp struct {
+	RunId string `json:"runId"`
+}
+
+type WorkflowPlanRunReq struct {
+	WorkflowId string         `bind:"path" json:"-"`
+	UseDraft   bool           `json:"useDraft"`
+	RunInputs  map[string]any `json:"inputs"`
+}
+
+type WorkflowPlanRunResp struct {
+	*domain.WorkflowPlan
+}
+
+type WorkflowValidateGraphReq struct {
+	WorkflowId string                `bind:"path" json:"-"`
+	UseDraft   bool                  `json:"useDraft"`
+	Graph      *domain.WorkflowGraph `json:"graph,omitempty"` // 待校验的工作流图，为空时校验已保存的草稿或已发布内容
+}
+
+type WorkflowValidateGraphResp struct {
+	Valid bool `json:"valid"`
+	*domain.WorkflowGraphValidationError
+}
+
+type WorkflowPreviewScheduleReq struct {
+	WorkflowId         string                             `json:"-"`
+	TriggerCron        string                             `json:"triggerCron,omitempty"` // 待预览的 Cron 表达式，为空时预览已保存的定时触发计划
+	TriggerCronOptions *domain.WorkflowTriggerCronOptions `json:"triggerCronOptions,omitempty"`
+	Count              int                                `json:"count,omitempty"`
+}
+
+type WorkflowPreviewScheduleResp struct {
+	Timezone string                  `json:"timezone"`
+	Runs     []*WorkflowScheduledRun `json:"runs"`
+}
+
+type WorkflowScheduledRun struct {
+	ScheduledAt time.Time `json:"scheduledAt"` // 按 Cron 表达式到期的时间
+	EarliestAt  time.Time `json:"earliestAt"`  // 考虑随机延迟与禁止运行的时间窗口后，最早的运行时间
+	LatestAt    time.Time `json:"latestAt"`    // 考虑随机延迟与禁止运行的时间窗口后，最晚的运行时间
+	Deferred    bool      `json:"deferred"`    // 是否因禁止运行的时间窗口而被推迟
+}
+
+type WorkflowListVersionsReq struct {
+	WorkflowId string `json:"-"`
+}
+
+type WorkflowListVersionsResp struct {
+	CurrentVersion int                       `json:"currentVersion"`
+	Items          []*domain.WorkflowVersion `json:"items"`
+}
+
+type WorkflowDiffVersionsReq struct {
+	WorkflowId  string `json:"-"`
+	FromVersion int    `json:"-"`
+	ToVersion   int    `json:"-"`
+}
+
+type WorkflowDiffVersionsResp struct {
+	FromVersion int                               `json:"fromVersion"`
+	ToVersion   int                               `json:"toVersion"`
+	Changes     []*domain.WorkflowGraphNodeChange `json:"changes"`
+}
+
+type WorkflowRestoreVersionReq struct {
+	WorkflowId string `json:"-"`
+	Version    int    `json:"-"`
+	Author     string `json:"-"`
+	Note       string `json:"note"`
+}
+
+type WorkflowRestoreVersionResp struct {
+	Version int `json:"version"`
+}
+
+type WorkflowTriggerWebhookReq struct {
+	WorkflowId string `json:"-"`
+	Token      string `json:"-"`
+	Signature  string `json:"-"`
+	Timestamp  string `json:"-"`
+	Payload    []byte `json:"-"`
+}
+
+type WorkflowTriggerWebhookResp struct {
+	RunId string `json:"runId"`
+}
+
+type WorkflowRotateWebhookSecretReq struct {
+	WorkflowId string `json:"-"`
+}
+
+type WorkflowRotateWebhookSecretResp struct {
+	Secret string `json:"secret"`
+}
+
 type WorkflowStatisticsResp struct {
-	Concurrency      int      `json:"concurrency"`
-	PendingRunIds    []string `json:"pendingRunIds"`
-	ProcessingRunIds []string `json:"processingRunIds"`
+	Concurrency      int                      `json:"concurrency"`
+	PendingRunIds    []string                 `json:"pendingRunIds"`
+	ProcessingRunIds []string                 `json:"processingRunIds"`
+	PendingRuns      []*WorkflowStatisticsRun `json:"pendingRuns"`
+	ProcessingRuns   []*WorkflowStatisticsRun `json:"processingRuns"`
+}
+
+type WorkflowStatisticsRun struct {
+	WorkflowId   string     `json:"workflowId"`
+	RunId        string     `json:"runId"`
+	Priority     int        `json:"priority"`
+	Position     int        `json:"position,omitempty"` // 在等待队列中的位置，从 1 开始
+	EnqueuedAt   time.Time  `json:"enqueuedAt"`
+	DispatchedAt *time.Time `json:"dispatchedAt,omitempty"`
+	WaitTime     int64      `json:"waitTime"` // 等待时长（单位：毫秒）
 }
@@ -2,6 +2,7 @@ package domain
 
 var (
 	ErrInvalidParams  = NewError(400, "invalid params")
+	ErrUnauthorized   = NewError(401, "unauthorized")
 	ErrRecordNotFound = NewError(404, "record not found")
 )
 
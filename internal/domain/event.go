@@ -0,0 +1,41 @@
+package domain
+
+import (
+	"time"
+)
+
+type Event struct {
+	Type       EventType      `json:"type"`
+	Data       map[string]any `json:"data"`
+	OccurredAt time.Time      `json:"occurredAt"`
+}
+
+func NewEvent(eventType EventType, data map[string]any) *Event {
+	if data == nil {
+		data = make(map[string]any)
+	}
+
+	return &Event{
+		Type:       eventType,
+		Data:       data,
+		OccurredAt: time.Now(),
+	}
+}
+
+type EventType string
+
+func (t EventType) String() string {
+	return string(t)
+}
+
+const (
+	// 证书即将过期。
+	// 每日检查一次，事件数据包含 "certificateId"、"subjectAltNames"、"validityNotAfter"、"daysLeft" 等。
+	EventTypeCertificateExpiring = EventType("certificate.expiring")
+	// 证书已吊销。
+	// 事件数据包含 "certificateId"、"subjectAltNames"、"serialNumber" 等。
+	EventTypeCertificateRevoked = EventType("certificate.revoked")
+	// 工作流运行已结束。
+	// 事件数据包含 "workflowId"、"runId"、"status"、"error" 等。
+	EventTypeWorkflowRunFinished = EventType("workflow.run.finished")
+)
@@ -1,15 +1,23 @@
 package expr
 
 import (
+	"bytes"
+	"cmp"
 	"encoding/json"
 	"fmt"
+	"math"
+	"regexp"
+	"slices"
 	"strconv"
+	"strings"
+	"time"
 )
 
 type (
 	ExprType               string
 	ExprComparisonOperator string
 	ExprLogicalOperator    string
+	ExprArithmeticOperator string
 	ExprValueType          string
 )
 
@@ -20,42 +28,89 @@ const (
 	LessOrEqual    ExprComparisonOperator = "lte"
 	Equal          ExprComparisonOperator = "eq"
 	NotEqual       ExprComparisonOperator = "neq"
+	Is             ExprComparisonOperator = "is" // 兼容旧值，等同于 [Equal]
+	Contains       ExprComparisonOperator = "contains"
+	NotContains    ExprComparisonOperator = "notContains"
+	StartsWith     ExprComparisonOperator = "startsWith"
+	EndsWith       ExprComparisonOperator = "endsWith"
+	Matches        ExprComparisonOperator = "matches"
+	In             ExprComparisonOperator = "in"
+	NotIn          ExprComparisonOperator = "notIn"
 
 	And ExprLogicalOperator = "and"
 	Or  ExprLogicalOperator = "or"
 	Not ExprLogicalOperator = "not"
 
-	Number  ExprValueType = "number"
-	String  ExprValueType = "string"
-	Boolean ExprValueType = "boolean"
+	Add      ExprArithmeticOperator = "add"
+	Subtract ExprArithmeticOperator = "sub"
+	Multiply ExprArithmeticOperator = "mul"
+	Divide   ExprArithmeticOperator = "div"
+	Modulo   ExprArithmeticOperator = "mod"
+
+	Number   ExprValueType = "number"
+	String   ExprValueType = "string"
+	Boolean  ExprValueType = "boolean"
+	DateTime ExprValueType = "datetime" // 日期时间，字符串形式时须为 RFC3339 格式
+	Duration ExprValueType = "duration" // 时间间隔，字符串形式时形如 "720h"、"30d"，数值形式时单位为秒
+	List     ExprValueType = "list"     // 列表，字符串形式时以半角分号分隔
+	Null     ExprValueType = "null"
 
 	ConstantExprType   ExprType = "const"
 	VariantExprType    ExprType = "var"
 	ComparisonExprType ExprType = "comparison"
 	LogicalExprType    ExprType = "logical"
 	NotExprType        ExprType = "not"
+	ArithmeticExprType ExprType = "arithmetic"
+	ExistsExprType     ExprType = "exists"
+	NowExprType        ExprType = "now"
 )
 
+// 获取当前时间，仅为便于测试而定义为变量。
+var nowFunc = time.Now
+
 type EvalResult struct {
 	Type  ExprValueType
 	Value any
 }
 
+func newBooleanResult(value bool) *EvalResult {
+	return &EvalResult{
+		Type:  Boolean,
+		Value: value,
+	}
+}
+
+func (e *EvalResult) IsNull() bool {
+	return e.Type == Null || e.Value == nil
+}
+
 func (e *EvalResult) GetFloat64() (float64, error) {
 	if e.Type != Number {
 		return 0, fmt.Errorf("type mismatch: %s", e.Type)
 	}
 
-	stringValue, ok := e.Value.(string)
-	if !ok {
-		return 0, fmt.Errorf("value is not a string: %v", e.Value)
+	switch value := e.Value.(type) {
+	case string:
+		floatValue, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return 0, fmt.Errorf("failed to parse float64: %w", err)
+		}
+		return floatValue, nil
+	case json.Number:
+		return value.Float64()
+	case float64:
+		return value, nil
+	case float32:
+		return float64(value), nil
+	case int:
+		return float64(value), nil
+	case int32:
+		return float64(value), nil
+	case int64:
+		return float64(value), nil
 	}
 
-	floatValue, err := strconv.ParseFloat(stringValue, 64)
-	if err != nil {
-		return 0, fmt.Errorf("failed to parse float64: %w", err)
-	}
-	return floatValue, nil
+	return 0, fmt.Errorf("value is not a number: %v", e.Value)
 }
 
 func (e *EvalResult) GetBool() (bool, error) {
@@ -81,234 +136,551 @@ func (e *EvalResult) GetBool() (bool, error) {
 	return boolValue, nil
 }
 
-func (e *EvalResult) GreaterThan(other *EvalResult) (*EvalResult, error) {
+func (e *EvalResult) GetString() (string, error) {
+	if e.Type != String {
+		return "", fmt.Errorf("type mismatch: %s", e.Type)
+	}
+
+	strValue, ok := e.Value.(string)
+	if !ok {
+		return "", fmt.Errorf("value is not a string: %v", e.Value)
+	}
+
+	return strValue, nil
+}
+
+func (e *EvalResult) GetTime() (time.Time, error) {
+	if e.Type != DateTime {
+		return time.Time{}, fmt.Errorf("type mismatch: %s", e.Type)
+	}
+
+	switch value := e.Value.(type) {
+	case time.Time:
+		return value, nil
+	case string:
+		for _, layout := range []string{time.RFC3339Nano, time.DateTime, time.DateOnly} {
+			if timeValue, err := time.Parse(layout, value); err == nil {
+				return timeValue, nil
+			}
+		}
+		return time.Time{}, fmt.Errorf("failed to parse datetime: %s", value)
+	}
+
+	return time.Time{}, fmt.Errorf("value is not a datetime: %v", e.Value)
+}
+
+func (e *EvalResult) GetDuration() (time.Duration, error) {
+	if e.Type != Duration {
+		return 0, fmt.Errorf("type mismatch: %s", e.Type)
+	}
+
+	switch value := e.Value.(type) {
+	case time.Duration:
+		return value, nil
+	case string:
+		value = strings.TrimSpace(value)
+		if days, ok := strings.CutSuffix(value, "d"); ok {
+			if floatValue, err := strconv.ParseFloat(days, 64); err == nil {
+				return time.Duration(floatValue * float64(24*time.Hour)), nil
+			}
+		}
+		if seconds, err := strconv.ParseFloat(value, 64); err == nil {
+			return time.Duration(seconds * float64(time.Second)), nil
+		}
+		durationValue, err := time.ParseDuration(value)
+		if err != nil {
+			return 0, fmt.Errorf("failed to parse duration: %w", err)
+		}
+		return durationValue, nil
+	default:
+		seconds, err := (&EvalResult{Type: Number, Value: e.Value}).GetFloat64()
+		if err != nil {
+			return 0, fmt.Errorf("value is not a duration: %v", e.Value)
+		}
+		return time.Duration(seconds * float64(time.Second)), nil
+	}
+}
+
+func (e *EvalResult) GetList() ([]string, error) {
+	if e.Type != List {
+		return nil, fmt.Errorf("type mismatch: %s", e.Type)
+	}
+
+	switch value := e.Value.(type) {
+	case []string:
+		return value, nil
+	case []any:
+		list := make([]string, 0, len(value))
+		for _, item := range value {
+			list = append(list, fmt.Sprintf("%v", item))
+		}
+		return list, nil
+	case string:
+		list := make([]string, 0)
+		for _, item := range strings.Split(value, ";") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+		return list, nil
+	}
+
+	return nil, fmt.Errorf("value is not a list: %v", e.Value)
+}
+
+func (e *EvalResult) compare(other *EvalResult) (int, error) {
 	if e.Type != other.Type {
-		return nil, fmt.Errorf("type mismatch: %s vs %s", e.Type, other.Type)
+		return 0, fmt.Errorf("type mismatch: %s vs %s", e.Type, other.Type)
 	}
 
 	switch e.Type {
 	case String:
-		return &EvalResult{
-			Type:  Boolean,
-			Value: e.Value.(string) > other.Value.(string),
-		}, nil
+		left, err := e.GetString()
+		if err != nil {
+			return 0, err
+		}
+
+		right, err := other.GetString()
+		if err != nil {
+			return 0, err
+		}
+
+		return strings.Compare(left, right), nil
 
 	case Number:
 		left, err := e.GetFloat64()
 		if err != nil {
-			return nil, err
+			return 0, err
 		}
 
 		right, err := other.GetFloat64()
 		if err != nil {
-			return nil, err
+			return 0, err
 		}
 
-		return &EvalResult{
-			Type:  Boolean,
-			Value: left > right,
-		}, nil
+		return cmp.Compare(left, right), nil
+
+	case DateTime:
+		left, err := e.GetTime()
+		if err != nil {
+			return 0, err
+		}
+
+		right, err := other.GetTime()
+		if err != nil {
+			return 0, err
+		}
+
+		return left.Compare(right), nil
+
+	case Duration:
+		left, err := e.GetDuration()
+		if err != nil {
+			return 0, err
+		}
+
+		right, err := other.GetDuration()
+		if err != nil {
+			return 0, err
+		}
+
+		return cmp.Compare(left, right), nil
 
 	default:
-		return nil, fmt.Errorf("unsupported value type: %s", e.Type)
+		return 0, fmt.Errorf("unsupported value type: %s", e.Type)
+	}
+}
+
+func (e *EvalResult) GreaterThan(other *EvalResult) (*EvalResult, error) {
+	c, err := e.compare(other)
+	if err != nil {
+		return nil, err
 	}
+
+	return newBooleanResult(c > 0), nil
 }
 
 func (e *EvalResult) GreaterOrEqual(other *EvalResult) (*EvalResult, error) {
+	c, err := e.compare(other)
+	if err != nil {
+		return nil, err
+	}
+
+	return newBooleanResult(c >= 0), nil
+}
+
+func (e *EvalResult) LessThan(other *EvalResult) (*EvalResult, error) {
+	c, err := e.compare(other)
+	if err != nil {
+		return nil, err
+	}
+
+	return newBooleanResult(c < 0), nil
+}
+
+func (e *EvalResult) LessOrEqual(other *EvalResult) (*EvalResult, error) {
+	c, err := e.compare(other)
+	if err != nil {
+		return nil, err
+	}
+
+	return newBooleanResult(c <= 0), nil
+}
+
+func (e *EvalResult) Equal(other *EvalResult) (*EvalResult, error) {
+	if e.IsNull() || other.IsNull() {
+		return newBooleanResult(e.IsNull() && other.IsNull()), nil
+	}
+
 	if e.Type != other.Type {
 		return nil, fmt.Errorf("type mismatch: %s vs %s", e.Type, other.Type)
 	}
 
 	switch e.Type {
-	case String:
-		return &EvalResult{
-			Type:  Boolean,
-			Value: e.Value.(string) >= other.Value.(string),
-		}, nil
+	case Boolean:
+		left, err := e.GetBool()
+		if err != nil {
+			return nil, err
+		}
 
-	case Number:
-		left, err := e.GetFloat64()
+		right, err := other.GetBool()
 		if err != nil {
 			return nil, err
 		}
 
-		right, err := other.GetFloat64()
+		return newBooleanResult(left == right), nil
+
+	case List:
+		left, err := e.GetList()
 		if err != nil {
 			return nil, err
 		}
 
-		return &EvalResult{
-			Type:  Boolean,
-			Value: left >= right,
-		}, nil
+		right, err := other.GetList()
+		if err != nil {
+			return nil, err
+		}
+
+		return newBooleanResult(slices.Equal(left, right)), nil
 
 	default:
-		return nil, fmt.Errorf("unsupported value type: %s", e.Type)
+		c, err := e.compare(other)
+		if err != nil {
+			return nil, err
+		}
+
+		return newBooleanResult(c == 0), nil
 	}
 }
 
-func (e *EvalResult) LessThan(other *EvalResult) (*EvalResult, error) {
-	if e.Type != other.Type {
-		return nil, fmt.Errorf("type mismatch: %s vs %s", e.Type, other.Type)
+func (e *EvalResult) NotEqual(other *EvalResult) (*EvalResult, error) {
+	res, err := e.Equal(other)
+	if err != nil {
+		return nil, err
+	}
+
+	return res.Not()
+}
+
+func (e *EvalResult) Contains(other *EvalResult) (*EvalResult, error) {
+	if e.IsNull() || other.IsNull() {
+		return newBooleanResult(false), nil
 	}
 
 	switch e.Type {
 	case String:
-		return &EvalResult{
-			Type:  Boolean,
-			Value: e.Value.(string) < other.Value.(string),
-		}, nil
+		left, err := e.GetString()
+		if err != nil {
+			return nil, err
+		}
 
-	case Number:
-		left, err := e.GetFloat64()
+		right, err := other.GetString()
 		if err != nil {
 			return nil, err
 		}
 
-		right, err := other.GetFloat64()
+		return newBooleanResult(strings.Contains(left, right)), nil
+
+	case List:
+		items, err := e.GetList()
 		if err != nil {
 			return nil, err
 		}
 
-		return &EvalResult{
-			Type:  Boolean,
-			Value: left < right,
-		}, nil
+		for _, item := range items {
+			res, err := (&EvalResult{Type: other.Type, Value: item}).Equal(other)
+			if err == nil && res.Value == true {
+				return newBooleanResult(true), nil
+			}
+		}
+
+		return newBooleanResult(false), nil
 
 	default:
 		return nil, fmt.Errorf("unsupported value type: %s", e.Type)
 	}
 }
 
-func (e *EvalResult) LessOrEqual(other *EvalResult) (*EvalResult, error) {
-	if e.Type != other.Type {
-		return nil, fmt.Errorf("type mismatch: %s vs %s", e.Type, other.Type)
+func (e *EvalResult) StartsWith(other *EvalResult) (*EvalResult, error) {
+	return e.matchString(other, strings.HasPrefix)
+}
+
+func (e *EvalResult) EndsWith(other *EvalResult) (*EvalResult, error) {
+	return e.matchString(other, strings.HasSuffix)
+}
+
+func (e *EvalResult) Matches(other *EvalResult) (*EvalResult, error) {
+	if e.IsNull() || other.IsNull() {
+		return newBooleanResult(false), nil
 	}
 
-	switch e.Type {
-	case String:
-		return &EvalResult{
-			Type:  Boolean,
-			Value: e.Value.(string) <= other.Value.(string),
-		}, nil
+	left, err := e.GetString()
+	if err != nil {
+		return nil, err
+	}
 
-	case Number:
-		left, err := e.GetFloat64()
+	pattern, err := other.GetString()
+	if err != nil {
+		return nil, err
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid regular expression: %w", err)
+	}
+
+	return newBooleanResult(re.MatchString(left)), nil
+}
+
+func (e *EvalResult) In(other *EvalResult) (*EvalResult, error) {
+	if other.Type != List {
+		return nil, fmt.Errorf("type mismatch: %s is not a list", other.Type)
+	}
+
+	return other.Contains(e)
+}
+
+func (e *EvalResult) matchString(other *EvalResult, match func(s, t string) bool) (*EvalResult, error) {
+	if e.IsNull() || other.IsNull() {
+		return newBooleanResult(false), nil
+	}
+
+	left, err := e.GetString()
+	if err != nil {
+		return nil, err
+	}
+
+	right, err := other.GetString()
+	if err != nil {
+		return nil, err
+	}
+
+	return newBooleanResult(match(left, right)), nil
+}
+
+func (e *EvalResult) Add(other *EvalResult) (*EvalResult, error) {
+	resType, err := inferArithmeticType(Add, e.Type, other.Type)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case e.Type == Number:
+		return e.calcNumber(other, func(l, r float64) (float64, error) { return l + r, nil })
+
+	case e.Type == String:
+		left, err := e.GetString()
 		if err != nil {
 			return nil, err
 		}
 
-		right, err := other.GetFloat64()
+		right, err := other.GetString()
 		if err != nil {
 			return nil, err
 		}
 
-		return &EvalResult{
-			Type:  Boolean,
-			Value: left <= right,
-		}, nil
+		return &EvalResult{Type: String, Value: left + right}, nil
+
+	case resType == DateTime:
+		timeOperand, durationOperand := e, other
+		if e.Type == Duration {
+			timeOperand, durationOperand = other, e
+		}
+
+		t, err := timeOperand.GetTime()
+		if err != nil {
+			return nil, err
+		}
+
+		d, err := durationOperand.GetDuration()
+		if err != nil {
+			return nil, err
+		}
+
+		return &EvalResult{Type: DateTime, Value: t.Add(d)}, nil
 
 	default:
-		return nil, fmt.Errorf("unsupported value type: %s", e.Type)
+		return e.calcDuration(other, func(l, r time.Duration) time.Duration { return l + r })
 	}
 }
 
-func (e *EvalResult) Equal(other *EvalResult) (*EvalResult, error) {
-	if e.Type != other.Type {
-		return nil, fmt.Errorf("type mismatch: %s vs %s", e.Type, other.Type)
+func (e *EvalResult) Subtract(other *EvalResult) (*EvalResult, error) {
+	if _, err := inferArithmeticType(Subtract, e.Type, other.Type); err != nil {
+		return nil, err
 	}
 
-	switch e.Type {
-	case String:
-		return &EvalResult{
-			Type:  Boolean,
-			Value: e.Value.(string) == other.Value.(string),
-		}, nil
+	switch {
+	case e.Type == Number:
+		return e.calcNumber(other, func(l, r float64) (float64, error) { return l - r, nil })
 
-	case Number:
-		left, err := e.GetFloat64()
+	case e.Type == DateTime && other.Type == DateTime:
+		left, err := e.GetTime()
 		if err != nil {
 			return nil, err
 		}
 
-		right, err := other.GetFloat64()
+		right, err := other.GetTime()
 		if err != nil {
 			return nil, err
 		}
 
-		return &EvalResult{
-			Type:  Boolean,
-			Value: left == right,
-		}, nil
+		return &EvalResult{Type: Duration, Value: left.Sub(right)}, nil
 
-	case Boolean:
-		left, err := e.GetBool()
+	case e.Type == DateTime:
+		t, err := e.GetTime()
 		if err != nil {
 			return nil, err
 		}
 
-		right, err := other.GetBool()
+		d, err := other.GetDuration()
 		if err != nil {
 			return nil, err
 		}
 
-		return &EvalResult{
-			Type:  Boolean,
-			Value: left == right,
-		}, nil
+		return &EvalResult{Type: DateTime, Value: t.Add(-d)}, nil
 
 	default:
-		return nil, fmt.Errorf("unsupported value type: %s", e.Type)
+		return e.calcDuration(other, func(l, r time.Duration) time.Duration { return l - r })
 	}
 }
 
-func (e *EvalResult) NotEqual(other *EvalResult) (*EvalResult, error) {
-	if e.Type != other.Type {
-		return nil, fmt.Errorf("type mismatch: %s vs %s", e.Type, other.Type)
+func (e *EvalResult) Multiply(other *EvalResult) (*EvalResult, error) {
+	if _, err := inferArithmeticType(Multiply, e.Type, other.Type); err != nil {
+		return nil, err
 	}
 
-	switch e.Type {
-	case String:
-		return &EvalResult{
-			Type:  Boolean,
-			Value: e.Value.(string) != other.Value.(string),
-		}, nil
+	if e.Type == Number && other.Type == Number {
+		return e.calcNumber(other, func(l, r float64) (float64, error) { return l * r, nil })
+	}
 
-	case Number:
-		left, err := e.GetFloat64()
+	durationOperand, numberOperand := e, other
+	if e.Type == Number {
+		durationOperand, numberOperand = other, e
+	}
+
+	d, err := durationOperand.GetDuration()
+	if err != nil {
+		return nil, err
+	}
+
+	n, err := numberOperand.GetFloat64()
+	if err != nil {
+		return nil, err
+	}
+
+	return &EvalResult{Type: Duration, Value: time.Duration(float64(d) * n)}, nil
+}
+
+func (e *EvalResult) Divide(other *EvalResult) (*EvalResult, error) {
+	if _, err := inferArithmeticType(Divide, e.Type, other.Type); err != nil {
+		return nil, err
+	}
+
+	switch {
+	case e.Type == Number:
+		return e.calcNumber(other, func(l, r float64) (float64, error) {
+			if r == 0 {
+				return 0, fmt.Errorf("division by zero")
+			}
+			return l / r, nil
+		})
+
+	case other.Type == Duration:
+		left, err := e.GetDuration()
 		if err != nil {
 			return nil, err
 		}
 
-		right, err := other.GetFloat64()
+		right, err := other.GetDuration()
 		if err != nil {
 			return nil, err
+		} else if right == 0 {
+			return nil, fmt.Errorf("division by zero")
 		}
 
-		return &EvalResult{
-			Type:  Boolean,
-			Value: left != right,
-		}, nil
+		return &EvalResult{Type: Number, Value: float64(left) / float64(right)}, nil
 
-	case Boolean:
-		left, err := e.GetBool()
+	default:
+		d, err := e.GetDuration()
 		if err != nil {
 			return nil, err
 		}
 
-		right, err := other.GetBool()
+		n, err := other.GetFloat64()
 		if err != nil {
 			return nil, err
+		} else if n == 0 {
+			return nil, fmt.Errorf("division by zero")
 		}
 
-		return &EvalResult{
-			Type:  Boolean,
-			Value: left != right,
-		}, nil
+		return &EvalResult{Type: Duration, Value: time.Duration(float64(d) / n)}, nil
+	}
+}
 
-	default:
-		return nil, fmt.Errorf("unsupported value type: %s", e.Type)
+func (e *EvalResult) Modulo(other *EvalResult) (*EvalResult, error) {
+	if _, err := inferArithmeticType(Modulo, e.Type, other.Type); err != nil {
+		return nil, err
 	}
+
+	return e.calcNumber(other, func(l, r float64) (float64, error) {
+		if r == 0 {
+			return 0, fmt.Errorf("division by zero")
+		}
+		return math.Mod(l, r), nil
+	})
+}
+
+func (e *EvalResult) calcNumber(other *EvalResult, calc func(l, r float64) (float64, error)) (*EvalResult, error) {
+	left, err := e.GetFloat64()
+	if err != nil {
+		return nil, err
+	}
+
+	right, err := other.GetFloat64()
+	if err != nil {
+		return nil, err
+	}
+
+	value, err := calc(left, right)
+	if err != nil {
+		return nil, err
+	}
+
+	return &EvalResult{Type: Number, Value: value}, nil
+}
+
+func (e *EvalResult) calcDuration(other *EvalResult, calc func(l, r time.Duration) time.Duration) (*EvalResult, error) {
+	left, err := e.GetDuration()
+	if err != nil {
+		return nil, err
+	}
+
+	right, err := other.GetDuration()
+	if err != nil {
+		return nil, err
+	}
+
+	return &EvalResult{Type: Duration, Value: calc(left, right)}, nil
 }
 
 func (e *EvalResult) And(other *EvalResult) (*EvalResult, error) {
@@ -395,17 +767,92 @@ type ConstantExpr struct {
 	Type      ExprType      `json:"type"`
 	Value     string        `json:"value"`
 	ValueType ExprValueType `json:"valueType"`
+	Values    []string      `json:"-"` // 列表类型的常量值，仅当值类型为 [List] 时有效
 }
 
 func (c ConstantExpr) GetType() ExprType { return c.Type }
 
 func (c ConstantExpr) Eval(variables map[string]map[string]any) (*EvalResult, error) {
+	switch c.ValueType {
+	case Null:
+		return &EvalResult{Type: Null}, nil
+
+	case List:
+		if c.Values != nil {
+			return &EvalResult{Type: List, Value: c.Values}, nil
+		}
+	}
+
 	return &EvalResult{
 		Type:  c.ValueType,
 		Value: c.Value,
 	}, nil
 }
 
+func (c ConstantExpr) MarshalJSON() ([]byte, error) {
+	var value any = c.Value
+	if c.ValueType == Null {
+		value = nil
+	} else if c.ValueType == List && c.Values != nil {
+		value = c.Values
+	}
+
+	return json.Marshal(struct {
+		Type      ExprType      `json:"type"`
+		Value     any           `json:"value"`
+		ValueType ExprValueType `json:"valueType"`
+	}{
+		Type:      c.Type,
+		Value:     value,
+		ValueType: c.ValueType,
+	})
+}
+
+// 常量值在 JSON 中可以是字符串、数值、布尔值、null 或数组，统一转换为字符串形式存储。
+func (c *ConstantExpr) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Type      ExprType      `json:"type"`
+		Value     any           `json:"value"`
+		ValueType ExprValueType `json:"valueType"`
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&raw); err != nil {
+		return err
+	}
+
+	c.Type = raw.Type
+	c.ValueType = raw.ValueType
+	c.Value = ""
+	c.Values = nil
+
+	switch value := raw.Value.(type) {
+	case nil:
+		if c.ValueType == "" {
+			c.ValueType = Null
+		}
+	case string:
+		c.Value = value
+	case bool:
+		c.Value = strconv.FormatBool(value)
+	case json.Number:
+		c.Value = value.String()
+	case []any:
+		c.Values = make([]string, 0, len(value))
+		for _, item := range value {
+			c.Values = append(c.Values, fmt.Sprintf("%v", item))
+		}
+		if c.ValueType == "" {
+			c.ValueType = List
+		}
+	default:
+		return fmt.Errorf("unsupported constant value: %v", raw.Value)
+	}
+
+	return nil
+}
+
 type VariantExpr struct {
 	Type     ExprType          `json:"type"`
 	Selector ExprValueSelector `json:"selector"`
@@ -428,9 +875,15 @@ func (v VariantExpr) Eval(variables map[string]map[string]any) (*EvalResult, err
 	if _, ok := variables[v.Selector.Id][v.Selector.Name]; !ok {
 		return nil, fmt.Errorf("variable %s not found in node %s", v.Selector.Name, v.Selector.Id)
 	}
+
+	value := variables[v.Selector.Id][v.Selector.Name]
+	if isNullValue(v.Selector.Type, value) {
+		return &EvalResult{Type: Null}, nil
+	}
+
 	return &EvalResult{
 		Type:  v.Selector.Type,
-		Value: variables[v.Selector.Id][v.Selector.Name],
+		Value: value,
 	}, nil
 }
 
@@ -462,10 +915,24 @@ func (c ComparisonExpr) Eval(variables map[string]map[string]any) (*EvalResult,
 		return left.GreaterOrEqual(right)
 	case LessOrEqual:
 		return left.LessOrEqual(right)
-	case Equal:
+	case Equal, Is:
 		return left.Equal(right)
 	case NotEqual:
 		return left.NotEqual(right)
+	case Contains:
+		return left.Contains(right)
+	case NotContains:
+		return negate(left.Contains(right))
+	case StartsWith:
+		return left.StartsWith(right)
+	case EndsWith:
+		return left.EndsWith(right)
+	case Matches:
+		return left.Matches(right)
+	case In:
+		return left.In(right)
+	case NotIn:
+		return negate(left.In(right))
 	default:
 		return nil, fmt.Errorf("unknown expression operator: %s", c.Operator)
 	}
@@ -481,10 +948,24 @@ type LogicalExpr struct {
 func (l LogicalExpr) GetType() ExprType { return l.Type }
 
 func (l LogicalExpr) Eval(variables map[string]map[string]any) (*EvalResult, error) {
+	if l.Operator != And && l.Operator != Or {
+		return nil, fmt.Errorf("unknown expression operator: %s", l.Operator)
+	}
+
 	left, err := l.Left.Eval(variables)
 	if err != nil {
 		return nil, err
 	}
+
+	// 短路求值，以便可以先检查变量是否存在、再对其进行比较
+	if leftValue, err := left.GetBool(); err == nil {
+		if l.Operator == And && !leftValue {
+			return newBooleanResult(false), nil
+		} else if l.Operator == Or && leftValue {
+			return newBooleanResult(true), nil
+		}
+	}
+
 	right, err := l.Right.Eval(variables)
 	if err != nil {
 		return nil, err
@@ -493,10 +974,8 @@ func (l LogicalExpr) Eval(variables map[string]map[string]any) (*EvalResult, err
 	switch l.Operator {
 	case And:
 		return left.And(right)
-	case Or:
-		return left.Or(right)
 	default:
-		return nil, fmt.Errorf("unknown expression operator: %s", l.Operator)
+		return left.Or(right)
 	}
 }
 
@@ -515,6 +994,100 @@ func (n NotExpr) Eval(variables map[string]map[string]any) (*EvalResult, error)
 	return inner.Not()
 }
 
+type ArithmeticExpr struct {
+	Type     ExprType               `json:"type"` // arithmetic
+	Operator ExprArithmeticOperator `json:"operator"`
+	Left     Expr                   `json:"left"`
+	Right    Expr                   `json:"right"`
+}
+
+func (a ArithmeticExpr) GetType() ExprType { return a.Type }
+
+func (a ArithmeticExpr) Eval(variables map[string]map[string]any) (*EvalResult, error) {
+	left, err := a.Left.Eval(variables)
+	if err != nil {
+		return nil, err
+	}
+	right, err := a.Right.Eval(variables)
+	if err != nil {
+		return nil, err
+	}
+
+	if left.IsNull() || right.IsNull() {
+		return nil, fmt.Errorf("null operand in arithmetic expression: %s", a.Operator)
+	}
+
+	switch a.Operator {
+	case Add:
+		return left.Add(right)
+	case Subtract:
+		return left.Subtract(right)
+	case Multiply:
+		return left.Multiply(right)
+	case Divide:
+		return left.Divide(right)
+	case Modulo:
+		return left.Modulo(right)
+	default:
+		return nil, fmt.Errorf("unknown expression operator: %s", a.Operator)
+	}
+}
+
+type ExistsExpr struct {
+	Type     ExprType          `json:"type"` // exists
+	Selector ExprValueSelector `json:"selector"`
+}
+
+func (e ExistsExpr) GetType() ExprType { return e.Type }
+
+func (e ExistsExpr) Eval(variables map[string]map[string]any) (*EvalResult, error) {
+	if e.Selector.Id == "" {
+		return nil, fmt.Errorf("node id is empty")
+	}
+	if e.Selector.Name == "" {
+		return nil, fmt.Errorf("name is empty")
+	}
+
+	value, ok := variables[e.Selector.Id][e.Selector.Name]
+	return newBooleanResult(ok && !isNullValue(e.Selector.Type, value)), nil
+}
+
+type NowExpr struct {
+	Type ExprType `json:"type"` // now
+}
+
+func (n NowExpr) GetType() ExprType { return n.Type }
+
+func (n NowExpr) Eval(variables map[string]map[string]any) (*EvalResult, error) {
+	return &EvalResult{
+		Type:  DateTime,
+		Value: nowFunc(),
+	}, nil
+}
+
+func isNullValue(valueType ExprValueType, value any) bool {
+	if value == nil {
+		return true
+	}
+
+	// 工作流变量中的零值日期时间将被转换为 "-"
+	if valueType == DateTime {
+		if strValue, ok := value.(string); ok && (strValue == "" || strValue == "-") {
+			return true
+		}
+	}
+
+	return false
+}
+
+func negate(res *EvalResult, err error) (*EvalResult, error) {
+	if err != nil {
+		return nil, err
+	}
+
+	return res.Not()
+}
+
 type rawExpr struct {
 	Type ExprType `json:"type"`
 }
@@ -560,6 +1133,24 @@ func UnmarshalExpr(data []byte) (Expr, error) {
 			return nil, err
 		}
 		return e.ToNotExpr()
+	case ArithmeticExprType:
+		var e ArithmeticExprRaw
+		if err := json.Unmarshal(data, &e); err != nil {
+			return nil, err
+		}
+		return e.ToArithmeticExpr()
+	case ExistsExprType:
+		var e ExistsExpr
+		if err := json.Unmarshal(data, &e); err != nil {
+			return nil, err
+		}
+		return e, nil
+	case NowExprType:
+		var e NowExpr
+		if err := json.Unmarshal(data, &e); err != nil {
+			return nil, err
+		}
+		return e, nil
 	default:
 		return nil, fmt.Errorf("unknown expression type: %s", typ.Type)
 	}
@@ -628,3 +1219,27 @@ func (r NotExprRaw) ToNotExpr() (NotExpr, error) {
 		Expr: inner,
 	}, nil
 }
+
+type ArithmeticExprRaw struct {
+	Type     ExprType               `json:"type"`
+	Operator ExprArithmeticOperator `json:"operator"`
+	Left     json.RawMessage        `json:"left"`
+	Right    json.RawMessage        `json:"right"`
+}
+
+func (r ArithmeticExprRaw) ToArithmeticExpr() (ArithmeticExpr, error) {
+	left, err := UnmarshalExpr(r.Left)
+	if err != nil {
+		return ArithmeticExpr{}, err
+	}
+	right, err := UnmarshalExpr(r.Right)
+	if err != nil {
+		return ArithmeticExpr{}, err
+	}
+	return ArithmeticExpr{
+		Type:     r.Type,
+		Operator: r.Operator,
+		Left:     left,
+		Right:    right,
+	}, nil
+}
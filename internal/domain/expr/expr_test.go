@@ -1,7 +1,12 @@
 package expr
 
 import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
 	"testing"
+	"time"
 )
 
 func TestLogicalEval(t *testing.T) {
@@ -125,3 +130,401 @@ func TestExpr_Eval(t *testing.T) {
 		})
 	}
 }
+
+func TestConstantExpr_UnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		name          string
+		data          string
+		wantValue     string
+		wantValues    []string
+		wantValueType ExprValueType
+	}{
+		{name: "string", data: `{"type":"const","value":"abc","valueType":"string"}`, wantValue: "abc", wantValueType: String},
+		{name: "number", data: `{"type":"const","value":2.5,"valueType":"number"}`, wantValue: "2.5", wantValueType: Number},
+		{name: "large integer", data: `{"type":"const","value":12345678901234567,"valueType":"number"}`, wantValue: "12345678901234567", wantValueType: Number},
+		{name: "boolean", data: `{"type":"const","value":true,"valueType":"boolean"}`, wantValue: "true", wantValueType: Boolean},
+		{name: "null", data: `{"type":"const","value":null}`, wantValueType: Null},
+		{name: "list", data: `{"type":"const","value":["a",1,true]}`, wantValues: []string{"a", "1", "true"}, wantValueType: List},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, err := UnmarshalExpr([]byte(tt.data))
+			if err != nil {
+				t.Fatalf("UnmarshalExpr() error = %v", err)
+			}
+
+			c, ok := e.(ConstantExpr)
+			if !ok {
+				t.Fatalf("UnmarshalExpr() got = %T, want ConstantExpr", e)
+			}
+			if c.Value != tt.wantValue {
+				t.Errorf("ConstantExpr.Value = %v, want %v", c.Value, tt.wantValue)
+			}
+			if !slices.Equal(c.Values, tt.wantValues) {
+				t.Errorf("ConstantExpr.Values = %v, want %v", c.Values, tt.wantValues)
+			}
+			if c.ValueType != tt.wantValueType {
+				t.Errorf("ConstantExpr.ValueType = %v, want %v", c.ValueType, tt.wantValueType)
+			}
+		})
+	}
+}
+
+func TestMarshalExpr(t *testing.T) {
+	tests := []string{
+		`{"type":"const","value":"abc","valueType":"string"}`,
+		`{"type":"const","value":["a","b"],"valueType":"list"}`,
+		`{"type":"const","value":null,"valueType":"null"}`,
+		`{"type":"comparison","operator":"contains","left":{"type":"var","selector":{"id":"n1","name":"certificate.subjectAltNames","type":"list"}},"right":{"type":"const","value":"example.com","valueType":"string"}}`,
+		`{"type":"arithmetic","operator":"sub","left":{"type":"now"},"right":{"type":"var","selector":{"id":"n1","name":"certificate.notAfter","type":"datetime"}}}`,
+		`{"type":"exists","selector":{"id":"n1","name":"certificate.notAfter","type":"datetime"}}`,
+	}
+	for _, data := range tests {
+		t.Run(data, func(t *testing.T) {
+			e, err := UnmarshalExpr([]byte(data))
+			if err != nil {
+				t.Fatalf("UnmarshalExpr() error = %v", err)
+			}
+
+			got, err := MarshalExpr(e)
+			if err != nil {
+				t.Fatalf("MarshalExpr() error = %v", err)
+			}
+
+			var gotAny, wantAny any
+			json.Unmarshal(got, &gotAny)
+			json.Unmarshal([]byte(data), &wantAny)
+			if !reflect.DeepEqual(gotAny, wantAny) {
+				t.Errorf("MarshalExpr() got = %s, want %s", got, data)
+			}
+		})
+	}
+}
+
+func TestComparisonEval(t *testing.T) {
+	variables := map[string]map[string]any{
+		"n1": {
+			"certificate.commonName":      "www.example.com",
+			"certificate.subjectAltNames": "www.example.com;example.com",
+			"certificate.notBefore":       "2025-01-01T00:00:00Z",
+			"certificate.notAfter":        "2025-04-01T00:00:00Z",
+			"certificate.daysLeft":        "10",
+			"certificate.validity":        "true",
+		},
+		"n2": {
+			"certificate.notAfter": "-",
+		},
+	}
+
+	constant := func(value string, valueType ExprValueType) string {
+		return fmt.Sprintf(`{"type":"const","value":%q,"valueType":%q}`, value, valueType)
+	}
+	variant := func(id, name string, valueType ExprValueType) string {
+		return fmt.Sprintf(`{"type":"var","selector":{"id":%q,"name":%q,"type":%q}}`, id, name, valueType)
+	}
+	comparison := func(left string, operator ExprComparisonOperator, right string) string {
+		return fmt.Sprintf(`{"type":"comparison","operator":%q,"left":%s,"right":%s}`, operator, left, right)
+	}
+
+	tests := []struct {
+		name    string
+		data    string
+		want    bool
+		wantErr bool
+	}{
+		{name: "number gt", data: comparison(variant("n1", "certificate.daysLeft", Number), GreaterThan, constant("5", Number)), want: true},
+		{name: "number lte", data: comparison(variant("n1", "certificate.daysLeft", Number), LessOrEqual, constant("5", Number)), want: false},
+		{name: "legacy is", data: comparison(variant("n1", "certificate.validity", Boolean), Is, `{"type":"const","value":true,"valueType":"boolean"}`), want: true},
+		{name: "boolean gt", data: comparison(variant("n1", "certificate.validity", Boolean), GreaterThan, constant("true", Boolean)), wantErr: true},
+		{name: "type mismatch", data: comparison(variant("n1", "certificate.daysLeft", Number), Equal, constant("10", String)), wantErr: true},
+		{name: "string contains", data: comparison(variant("n1", "certificate.commonName", String), Contains, constant("example", String)), want: true},
+		{name: "string not contains", data: comparison(variant("n1", "certificate.commonName", String), NotContains, constant("example", String)), want: false},
+		{name: "list contains", data: comparison(variant("n1", "certificate.subjectAltNames", List), Contains, constant("example.com", String)), want: true},
+		{name: "list not contains", data: comparison(variant("n1", "certificate.subjectAltNames", List), Contains, constant("example.org", String)), want: false},
+		{name: "starts with", data: comparison(variant("n1", "certificate.commonName", String), StartsWith, constant("www.", String)), want: true},
+		{name: "ends with", data: comparison(variant("n1", "certificate.commonName", String), EndsWith, constant(".org", String)), want: false},
+		{name: "matches", data: comparison(variant("n1", "certificate.commonName", String), Matches, constant(`^www\.example\.(com|org)$`, String)), want: true},
+		{name: "matches invalid regexp", data: comparison(variant("n1", "certificate.commonName", String), Matches, constant(`(`, String)), wantErr: true},
+		{name: "in", data: comparison(variant("n1", "certificate.commonName", String), In, `{"type":"const","value":["example.com","www.example.com"],"valueType":"list"}`), want: true},
+		{name: "not in", data: comparison(variant("n1", "certificate.commonName", String), NotIn, `{"type":"const","value":["example.com"],"valueType":"list"}`), want: true},
+		{name: "number in", data: comparison(variant("n1", "certificate.daysLeft", Number), In, `{"type":"const","value":[1,10.0,30],"valueType":"list"}`), want: true},
+		{name: "in non-list", data: comparison(variant("n1", "certificate.commonName", String), In, constant("www.example.com", String)), wantErr: true},
+		{name: "datetime lt", data: comparison(variant("n1", "certificate.notBefore", DateTime), LessThan, variant("n1", "certificate.notAfter", DateTime)), want: true},
+		{name: "datetime eq date only", data: comparison(variant("n1", "certificate.notAfter", DateTime), Equal, constant("2025-04-01", DateTime)), want: true},
+		{name: "duration gte", data: comparison(constant("30d", Duration), GreaterOrEqual, constant("720h", Duration)), want: true},
+		{name: "null eq null", data: comparison(variant("n2", "certificate.notAfter", DateTime), Equal, `{"type":"const","value":null}`), want: true},
+		{name: "value neq null", data: comparison(variant("n1", "certificate.notAfter", DateTime), NotEqual, `{"type":"const","value":null}`), want: true},
+		{name: "null contains", data: comparison(variant("n2", "certificate.notAfter", DateTime), Contains, constant("x", String)), want: false},
+		{name: "unknown operator", data: comparison(constant("a", String), "like", constant("a", String)), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, err := UnmarshalExpr([]byte(tt.data))
+			if err != nil {
+				t.Fatalf("UnmarshalExpr() error = %v", err)
+			}
+
+			got, err := e.Eval(variables)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ComparisonExpr.Eval() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && got.Value != tt.want {
+				t.Errorf("ComparisonExpr.Eval() got = %v, want %v", got.Value, tt.want)
+			}
+		})
+	}
+}
+
+func TestArithmeticEval(t *testing.T) {
+	nowFunc = func() time.Time { return time.Date(2025, 3, 22, 0, 0, 0, 0, time.UTC) }
+	defer func() { nowFunc = time.Now }()
+
+	variables := map[string]map[string]any{
+		"n1": {
+			"certificate.notAfter": "2025-04-01T00:00:00Z",
+			"certificate.daysLeft": "10",
+		},
+	}
+
+	tests := []struct {
+		name      string
+		data      string
+		wantType  ExprValueType
+		wantValue any
+		wantErr   bool
+	}{
+		{
+			name:      "number add",
+			data:      `{"type":"arithmetic","operator":"add","left":{"type":"var","selector":{"id":"n1","name":"certificate.daysLeft","type":"number"}},"right":{"type":"const","value":5,"valueType":"number"}}`,
+			wantType:  Number,
+			wantValue: float64(15),
+		},
+		{
+			name:      "number mod",
+			data:      `{"type":"arithmetic","operator":"mod","left":{"type":"const","value":10,"valueType":"number"},"right":{"type":"const","value":3,"valueType":"number"}}`,
+			wantType:  Number,
+			wantValue: float64(1),
+		},
+		{
+			name:    "division by zero",
+			data:    `{"type":"arithmetic","operator":"div","left":{"type":"const","value":10,"valueType":"number"},"right":{"type":"const","value":0,"valueType":"number"}}`,
+			wantErr: true,
+		},
+		{
+			name:      "string concat",
+			data:      `{"type":"arithmetic","operator":"add","left":{"type":"const","value":"foo","valueType":"string"},"right":{"type":"const","value":"bar","valueType":"string"}}`,
+			wantType:  String,
+			wantValue: "foobar",
+		},
+		{
+			name:      "datetime sub datetime",
+			data:      `{"type":"arithmetic","operator":"sub","left":{"type":"var","selector":{"id":"n1","name":"certificate.notAfter","type":"datetime"}},"right":{"type":"now"}}`,
+			wantType:  Duration,
+			wantValue: 10 * 24 * time.Hour,
+		},
+		{
+			name:      "datetime add duration",
+			data:      `{"type":"arithmetic","operator":"add","left":{"type":"now"},"right":{"type":"const","value":"10d","valueType":"duration"}}`,
+			wantType:  DateTime,
+			wantValue: time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:      "duration div duration",
+			data:      `{"type":"arithmetic","operator":"div","left":{"type":"const","value":"48h","valueType":"duration"},"right":{"type":"const","value":"1d","valueType":"duration"}}`,
+			wantType:  Number,
+			wantValue: float64(2),
+		},
+		{
+			name:      "duration mul number",
+			data:      `{"type":"arithmetic","operator":"mul","left":{"type":"const","value":"1h","valueType":"duration"},"right":{"type":"const","value":3,"valueType":"number"}}`,
+			wantType:  Duration,
+			wantValue: 3 * time.Hour,
+		},
+		{
+			name:    "type mismatch",
+			data:    `{"type":"arithmetic","operator":"add","left":{"type":"const","value":"1","valueType":"string"},"right":{"type":"const","value":1,"valueType":"number"}}`,
+			wantErr: true,
+		},
+		{
+			name:    "null operand",
+			data:    `{"type":"arithmetic","operator":"add","left":{"type":"const","value":null},"right":{"type":"const","value":1,"valueType":"number"}}`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, err := UnmarshalExpr([]byte(tt.data))
+			if err != nil {
+				t.Fatalf("UnmarshalExpr() error = %v", err)
+			}
+
+			got, err := e.Eval(variables)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ArithmeticExpr.Eval() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if got.Type != tt.wantType {
+				t.Errorf("ArithmeticExpr.Eval() got type = %v, want %v", got.Type, tt.wantType)
+			}
+			if wantTime, ok := tt.wantValue.(time.Time); ok {
+				if gotTime, _ := got.Value.(time.Time); !gotTime.Equal(wantTime) {
+					t.Errorf("ArithmeticExpr.Eval() got = %v, want %v", got.Value, tt.wantValue)
+				}
+			} else if got.Value != tt.wantValue {
+				t.Errorf("ArithmeticExpr.Eval() got = %v, want %v", got.Value, tt.wantValue)
+			}
+		})
+	}
+}
+
+func TestDateArithmeticCondition(t *testing.T) {
+	nowFunc = func() time.Time { return time.Date(2025, 3, 22, 0, 0, 0, 0, time.UTC) }
+	defer func() { nowFunc = time.Now }()
+
+	// certificate.notAfter - now < 30d
+	data := `{"type":"comparison","operator":"lt","left":{"type":"arithmetic","operator":"sub","left":{"type":"var","selector":{"id":"n1","name":"certificate.notAfter","type":"datetime"}},"right":{"type":"now"}},"right":{"type":"const","value":"30d","valueType":"duration"}}`
+	e, err := UnmarshalExpr([]byte(data))
+	if err != nil {
+		t.Fatalf("UnmarshalExpr() error = %v", err)
+	}
+
+	got, err := e.Eval(map[string]map[string]any{"n1": {"certificate.notAfter": "2025-04-01T00:00:00Z"}})
+	if err != nil {
+		t.Fatalf("Eval() error = %v", err)
+	}
+	if got.Value != true {
+		t.Errorf("Eval() got = %v, want %v", got.Value, true)
+	}
+}
+
+func TestExistsEval(t *testing.T) {
+	variables := map[string]map[string]any{
+		"n1": {
+			"certificate.commonName": "example.com",
+			"certificate.notAfter":   "-",
+			"error.message":          "",
+		},
+	}
+
+	tests := []struct {
+		name string
+		data string
+		want bool
+	}{
+		{name: "present", data: `{"type":"exists","selector":{"id":"n1","name":"certificate.commonName","type":"string"}}`, want: true},
+		{name: "empty string", data: `{"type":"exists","selector":{"id":"n1","name":"error.message","type":"string"}}`, want: true},
+		{name: "zero datetime", data: `{"type":"exists","selector":{"id":"n1","name":"certificate.notAfter","type":"datetime"}}`, want: false},
+		{name: "missing variable", data: `{"type":"exists","selector":{"id":"n1","name":"certificate.daysLeft","type":"number"}}`, want: false},
+		{name: "missing node", data: `{"type":"exists","selector":{"id":"n2","name":"certificate.daysLeft","type":"number"}}`, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, err := UnmarshalExpr([]byte(tt.data))
+			if err != nil {
+				t.Fatalf("UnmarshalExpr() error = %v", err)
+			}
+
+			got, err := e.Eval(variables)
+			if err != nil {
+				t.Fatalf("ExistsExpr.Eval() error = %v", err)
+			}
+			if got.Value != tt.want {
+				t.Errorf("ExistsExpr.Eval() got = %v, want %v", got.Value, tt.want)
+			}
+		})
+	}
+}
+
+func TestLogicalEval_ShortCircuit(t *testing.T) {
+	// 变量不存在时，右侧的比较表达式求值将出错，但由于短路求值不会被执行
+	data := `{"type":"logical","operator":"and","left":{"type":"exists","selector":{"id":"n1","name":"certificate.daysLeft","type":"number"}},"right":{"type":"comparison","operator":"lt","left":{"type":"var","selector":{"id":"n1","name":"certificate.daysLeft","type":"number"}},"right":{"type":"const","value":30,"valueType":"number"}}}`
+	e, err := UnmarshalExpr([]byte(data))
+	if err != nil {
+		t.Fatalf("UnmarshalExpr() error = %v", err)
+	}
+
+	got, err := e.Eval(map[string]map[string]any{"n1": {}})
+	if err != nil {
+		t.Fatalf("LogicalExpr.Eval() error = %v", err)
+	}
+	if got.Value != false {
+		t.Errorf("LogicalExpr.Eval() got = %v, want %v", got.Value, false)
+	}
+
+	got, err = e.Eval(map[string]map[string]any{"n1": {"certificate.daysLeft": "7"}})
+	if err != nil {
+		t.Fatalf("LogicalExpr.Eval() error = %v", err)
+	}
+	if got.Value != true {
+		t.Errorf("LogicalExpr.Eval() got = %v, want %v", got.Value, true)
+	}
+}
+
+func TestInferType(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		wantType ExprValueType
+		wantErr  bool
+	}{
+		{
+			name:     "comparison",
+			data:     `{"type":"comparison","operator":"gt","left":{"type":"var","selector":{"id":"n1","name":"certificate.daysLeft","type":"number"}},"right":{"type":"const","value":1,"valueType":"number"}}`,
+			wantType: Boolean,
+		},
+		{
+			name:    "comparison type mismatch",
+			data:    `{"type":"comparison","operator":"gt","left":{"type":"var","selector":{"id":"n1","name":"certificate.daysLeft","type":"number"}},"right":{"type":"const","value":"1","valueType":"string"}}`,
+			wantErr: true,
+		},
+		{
+			name:    "matches on number",
+			data:    `{"type":"comparison","operator":"matches","left":{"type":"const","value":1,"valueType":"number"},"right":{"type":"const","value":"1","valueType":"string"}}`,
+			wantErr: true,
+		},
+		{
+			name:     "date arithmetic",
+			data:     `{"type":"arithmetic","operator":"sub","left":{"type":"now"},"right":{"type":"var","selector":{"id":"n1","name":"certificate.notAfter","type":"datetime"}}}`,
+			wantType: Duration,
+		},
+		{
+			name:    "invalid arithmetic",
+			data:    `{"type":"arithmetic","operator":"mul","left":{"type":"now"},"right":{"type":"const","value":2,"valueType":"number"}}`,
+			wantErr: true,
+		},
+		{
+			name:    "logical on non-boolean",
+			data:    `{"type":"logical","operator":"and","left":{"type":"const","value":1,"valueType":"number"},"right":{"type":"const","value":true,"valueType":"boolean"}}`,
+			wantErr: true,
+		},
+		{
+			name:    "unresolvable variable",
+			data:    `{"type":"exists","selector":{"id":"n2","name":"certificate.daysLeft","type":"number"}}`,
+			wantErr: true,
+		},
+	}
+	resolve := func(selector ExprValueSelector) error {
+		if selector.Id != "n1" {
+			return fmt.Errorf("node %s not found", selector.Id)
+		}
+		return nil
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, err := UnmarshalExpr([]byte(tt.data))
+			if err != nil {
+				t.Fatalf("UnmarshalExpr() error = %v", err)
+			}
+
+			got, err := InferType(e, resolve)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("InferType() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && got != tt.wantType {
+				t.Errorf("InferType() got = %v, want %v", got, tt.wantType)
+			}
+		})
+	}
+}
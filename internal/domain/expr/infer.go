@@ -0,0 +1,170 @@
+package expr
+
+import (
+	"fmt"
+)
+
+// 静态推断表达式的值类型，并检查各运算符的运算数类型是否匹配，不会对表达式求值。
+// 参数 resolve 用于检查变量选择器是否有效，为 nil 时不做检查。
+func InferType(e Expr, resolve func(selector ExprValueSelector) error) (ExprValueType, error) {
+	switch e := e.(type) {
+	case ConstantExpr:
+		return e.ValueType, nil
+
+	case VariantExpr:
+		if e.Selector.Id == "" || e.Selector.Name == "" {
+			return "", fmt.Errorf("the variable selector is incomplete")
+		}
+		if resolve != nil {
+			if err := resolve(e.Selector); err != nil {
+				return "", err
+			}
+		}
+		return e.Selector.Type, nil
+
+	case ExistsExpr:
+		if e.Selector.Id == "" || e.Selector.Name == "" {
+			return "", fmt.Errorf("the variable selector is incomplete")
+		}
+		if resolve != nil {
+			if err := resolve(e.Selector); err != nil {
+				return "", err
+			}
+		}
+		return Boolean, nil
+
+	case NowExpr:
+		return DateTime, nil
+
+	case ComparisonExpr:
+		leftType, err := InferType(e.Left, resolve)
+		if err != nil {
+			return "", err
+		}
+		rightType, err := InferType(e.Right, resolve)
+		if err != nil {
+			return "", err
+		}
+		if err := inferComparisonType(e.Operator, leftType, rightType); err != nil {
+			return "", err
+		}
+		return Boolean, nil
+
+	case LogicalExpr:
+		if e.Operator != And && e.Operator != Or {
+			return "", fmt.Errorf("unknown logical operator '%s'", e.Operator)
+		}
+		leftType, err := InferType(e.Left, resolve)
+		if err != nil {
+			return "", err
+		}
+		rightType, err := InferType(e.Right, resolve)
+		if err != nil {
+			return "", err
+		}
+		if leftType != Boolean || rightType != Boolean {
+			return "", fmt.Errorf("operands of '%s' must be boolean values", e.Operator)
+		}
+		return Boolean, nil
+
+	case NotExpr:
+		innerType, err := InferType(e.Expr, resolve)
+		if err != nil {
+			return "", err
+		}
+		if innerType != Boolean {
+			return "", fmt.Errorf("operand of 'not' must be a boolean value")
+		}
+		return Boolean, nil
+
+	case ArithmeticExpr:
+		leftType, err := InferType(e.Left, resolve)
+		if err != nil {
+			return "", err
+		}
+		rightType, err := InferType(e.Right, resolve)
+		if err != nil {
+			return "", err
+		}
+		return inferArithmeticType(e.Operator, leftType, rightType)
+	}
+
+	return "", fmt.Errorf("unknown expression type '%s'", e.GetType())
+}
+
+func inferComparisonType(operator ExprComparisonOperator, leftType, rightType ExprValueType) error {
+	switch operator {
+	case Equal, NotEqual, Is:
+		if leftType == Null || rightType == Null || leftType == rightType {
+			return nil
+		}
+
+	case GreaterThan, GreaterOrEqual, LessThan, LessOrEqual:
+		if leftType == rightType && (leftType == String || leftType == Number || leftType == DateTime || leftType == Duration) {
+			return nil
+		}
+
+	case Contains, NotContains:
+		if (leftType == String && rightType == String) || (leftType == List && rightType != List) {
+			return nil
+		}
+
+	case StartsWith, EndsWith, Matches:
+		if leftType == String && rightType == String {
+			return nil
+		}
+
+	case In, NotIn:
+		if leftType != List && rightType == List {
+			return nil
+		}
+
+	default:
+		return fmt.Errorf("unknown comparison operator '%s'", operator)
+	}
+
+	return fmt.Errorf("operator '%s' is not supported for operands of type %s and %s", operator, leftType, rightType)
+}
+
+var arithmeticTypeRules = map[ExprArithmeticOperator]map[[2]ExprValueType]ExprValueType{
+	Add: {
+		{Number, Number}:     Number,
+		{String, String}:     String,
+		{DateTime, Duration}: DateTime,
+		{Duration, DateTime}: DateTime,
+		{Duration, Duration}: Duration,
+	},
+	Subtract: {
+		{Number, Number}:     Number,
+		{DateTime, DateTime}: Duration,
+		{DateTime, Duration}: DateTime,
+		{Duration, Duration}: Duration,
+	},
+	Multiply: {
+		{Number, Number}:   Number,
+		{Duration, Number}: Duration,
+		{Number, Duration}: Duration,
+	},
+	Divide: {
+		{Number, Number}:     Number,
+		{Duration, Number}:   Duration,
+		{Duration, Duration}: Number,
+	},
+	Modulo: {
+		{Number, Number}: Number,
+	},
+}
+
+func inferArithmeticType(operator ExprArithmeticOperator, leftType, rightType ExprValueType) (ExprValueType, error) {
+	rules, ok := arithmeticTypeRules[operator]
+	if !ok {
+		return "", fmt.Errorf("unknown arithmetic operator '%s'", operator)
+	}
+
+	resType, ok := rules[[2]ExprValueType{leftType, rightType}]
+	if !ok {
+		return "", fmt.Errorf("operator '%s' is not supported for operands of type %s and %s", operator, leftType, rightType)
+	}
+
+	return resType, nil
+}
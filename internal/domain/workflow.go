@@ -13,18 +13,28 @@ const CollectionNameWorkflow = "workflow"
 
 type Workflow struct {
 	Meta
-	Name          string                `db:"name"          json:"name"`
-	Description   string                `db:"description"   json:"description"`
-	Trigger       WorkflowTriggerType   `db:"trigger"       json:"trigger"`
-	TriggerCron   string                `db:"triggerCron"   json:"triggerCron"`
-	Enabled       bool                  `db:"enabled"       json:"enabled"`
-	GraphDraft    *WorkflowGraph        `db:"graphDraft"    json:"graphDraft"`
-	GraphContent  *WorkflowGraph        `db:"graphContent"  json:"graphContent"`
-	HasDraft      bool                  `db:"hasDraft"      json:"hasDraft"`
-	HasContent    bool                  `db:"hasContent"    json:"hasContent"`
-	LastRunId     string                `db:"lastRunRef"    json:"lastRunId"`
-	LastRunStatus WorkflowRunStatusType `db:"lastRunStatus" json:"lastRunStatus"`
-	LastRunTime   time.Time             `db:"lastRunTime"   json:"lastRunTime"`
+	Name               string                        `db:"name"          json:"name"`
+	Description        string                        `db:"description"   json:"description"`
+	Trigger            WorkflowTriggerType           `db:"trigger"       json:"trigger"`
+	TriggerCron        string                        `db:"triggerCron"   json:"triggerCron"`
+	TriggerCronOptions *WorkflowTriggerCronOptions   `db:"triggerCronOptions" json:"triggerCronOptions"`
+	TriggerEvent       *WorkflowTriggerEventConfig   `db:"triggerEvent"  json:"triggerEvent"`
+	WebhookSecret      string                        `db:"webhookSecret" json:"webhookSecret"`
+	RunTimeout         int                           `db:"runTimeout"        json:"runTimeout"`        // 运行超时时间（单位：秒），零值时表示不限制
+	ConcurrencyPolicy  WorkflowConcurrencyPolicyType `db:"concurrencyPolicy" json:"concurrencyPolicy"` // 同一工作流已有运行时新运行的处理策略，零值时等同于排队
+	MutexGroups        []string                      `db:"mutexGroups"       json:"mutexGroups"`       // 互斥组，同组的工作流同一时间只能有一个运行在执行
+	Priority           int                           `db:"priority"          json:"priority"`          // 调度优先级，数值越大越优先
+	SchedulingGroup    string                        `db:"schedulingGroup"   json:"schedulingGroup"`   // 调度分组，分组间公平地分享并发数，为空时以工作流自身为一组
+	Enabled            bool                          `db:"enabled"           json:"enabled"`
+	GraphDraft         *WorkflowGraph                `db:"graphDraft"        json:"graphDraft"`
+	GraphContent       *WorkflowGraph                `db:"graphContent"      json:"graphContent"`
+	HasDraft           bool                          `db:"hasDraft"          json:"hasDraft"`
+	HasContent         bool                          `db:"hasContent"        json:"hasContent"`
+	GraphVersion       int                           `db:"graphVersion"      json:"graphVersion"` // 当前已发布的工作流图的版本号，零值时表示尚未发布
+	LastRunId          string                        `db:"lastRunRef"        json:"lastRunId"`
+	LastRunStatus      WorkflowRunStatusType         `db:"lastRunStatus"     json:"lastRunStatus"`
+	LastRunTime        time.Time                     `db:"lastRunTime"       json:"lastRunTime"`
+	DeferredRunAt      time.Time                     `db:"deferredRunAt"     json:"deferredRunAt"` // 因随机延迟或禁止运行的时间窗口而推迟的定时运行的开始时间，零值时表示没有
 }
 
 type WorkflowGraph struct {
@@ -78,8 +88,51 @@ func (t WorkflowTriggerType) String() string {
 const (
 	WorkflowTriggerTypeScheduled = WorkflowTriggerType("scheduled")
 	WorkflowTriggerTypeManual    = WorkflowTriggerType("manual")
+	WorkflowTriggerTypeWebhook   = WorkflowTriggerType("webhook")
+	WorkflowTriggerTypeEvent     = WorkflowTriggerType("event")
 )
 
+type WorkflowConcurrencyPolicyType string
+
+func (t WorkflowConcurrencyPolicyType) String() string {
+	return string(t)
+}
+
+const (
+	WorkflowConcurrencyPolicyTypeAllow         = WorkflowConcurrencyPolicyType("allow")         // 允许同时执行
+	WorkflowConcurrencyPolicyTypeSkip          = WorkflowConcurrencyPolicyType("skip")          // 跳过新运行
+	WorkflowConcurrencyPolicyTypeQueue         = WorkflowConcurrencyPolicyType("queue")         // 新运行排队等待
+	WorkflowConcurrencyPolicyTypeCancelRunning = WorkflowConcurrencyPolicyType("cancelRunning") // 取消已有运行
+)
+
+type WorkflowTriggerCronOptions struct {
+	// 时区，取值为 IANA 时区名称，为空时表示服务器本地时区。
+	Timezone string `json:"timezone,omitempty"`
+	// 随机延迟的最大时长（单位：秒），到期后将随机延迟零至该时长再运行，以免大量工作流同时运行。
+	Jitter int `json:"jitter,omitempty"`
+	// 禁止运行的时间窗口（例如变更冻结期），窗口内到期的运行将被推迟至窗口结束后。
+	Blackouts []*WorkflowTriggerBlackoutWindow `json:"blackouts,omitempty"`
+}
+
+type WorkflowTriggerBlackoutWindow struct {
+	// 固定的时间窗口：起止时间。
+	StartsAt time.Time `json:"startsAt,omitempty"`
+	EndsAt   time.Time `json:"endsAt,omitempty"`
+	// 周期性的时间窗口：以 Cron 表达式表示开始时间（按工作流所指定的时区），并持续指定时长（单位：秒）。
+	Cron     string `json:"cron,omitempty"`
+	Duration int    `json:"duration,omitempty"`
+}
+
+type WorkflowTriggerEventConfig struct {
+	EventType EventType `json:"eventType"`
+	// 证书即将过期事件：剩余天数小于等于该值时触发，同一证书仅触发一次。
+	ExpiringDays int `json:"expiringDays,omitempty"`
+	// 工作流运行已结束事件：指定来源工作流 ID，为空时表示任意工作流。
+	WorkflowId string `json:"workflowId,omitempty"`
+	// 工作流运行已结束事件：指定运行状态，为空时表示失败。
+	RunStatus WorkflowRunStatusType `json:"runStatus,omitempty"`
+}
+
 type WorkflowNode struct {
 	Id     string           `json:"id"` // 节点 ID 只在该工作流中唯一，在全局中不保证唯一性
 	Type   WorkflowNodeType `json:"type"`
@@ -94,27 +147,53 @@ func (t WorkflowNodeType) String() string {
 }
 
 const (
-	WorkflowNodeTypeStart       = WorkflowNodeType("start")
-	WorkflowNodeTypeEnd         = WorkflowNodeType("end")
-	WorkflowNodeTypeCondition   = WorkflowNodeType("condition")
-	WorkflowNodeTypeBranchBlock = WorkflowNodeType("branchBlock")
-	WorkflowNodeTypeTryCatch    = WorkflowNodeType("tryCatch")
-	WorkflowNodeTypeTryBlock    = WorkflowNodeType("tryBlock")
-	WorkflowNodeTypeCatchBlock  = WorkflowNodeType("catchBlock")
-	WorkflowNodeTypeDelay       = WorkflowNodeType("delay")
-	WorkflowNodeTypeBizApply    = WorkflowNodeType("bizApply")
-	WorkflowNodeTypeBizUpload   = WorkflowNodeType("bizUpload")
-	WorkflowNodeTypeBizMonitor  = WorkflowNodeType("bizMonitor")
-	WorkflowNodeTypeBizDeploy   = WorkflowNodeType("bizDeploy")
-	WorkflowNodeTypeBizNotify   = WorkflowNodeType("bizNotify")
+	WorkflowNodeTypeStart         = WorkflowNodeType("start")
+	WorkflowNodeTypeEnd           = WorkflowNodeType("end")
+	WorkflowNodeTypeCondition     = WorkflowNodeType("condition")
+	WorkflowNodeTypeBranchBlock   = WorkflowNodeType("branchBlock")
+	WorkflowNodeTypeTryCatch      = WorkflowNodeType("tryCatch")
+	WorkflowNodeTypeTryBlock      = WorkflowNodeType("tryBlock")
+	WorkflowNodeTypeCatchBlock    = WorkflowNodeType("catchBlock")
+	WorkflowNodeTypeParallel      = WorkflowNodeType("parallel")
+	WorkflowNodeTypeParallelBlock = WorkflowNodeType("parallelBlock")
+	WorkflowNodeTypeForEach       = WorkflowNodeType("forEach")
+	WorkflowNodeTypeCallWorkflow  = WorkflowNodeType("callWorkflow")
+	WorkflowNodeTypeDelay         = WorkflowNodeType("delay")
+	WorkflowNodeTypeScript        = WorkflowNodeType("script")
+	WorkflowNodeTypeHttpRequest   = WorkflowNodeType("httpRequest")
+	WorkflowNodeTypeApproval      = WorkflowNodeType("approval")
+	WorkflowNodeTypeSetVariable   = WorkflowNodeType("setVariable")
+	WorkflowNodeTypeBizApply      = WorkflowNodeType("bizApply")
+	WorkflowNodeTypeBizUpload     = WorkflowNodeType("bizUpload")
+	WorkflowNodeTypeBizMonitor    = WorkflowNodeType("bizMonitor")
+	WorkflowNodeTypeBizDeploy     = WorkflowNodeType("bizDeploy")
+	WorkflowNodeTypeBizNotify     = WorkflowNodeType("bizNotify")
 )
 
 type WorkflowNodeData struct {
-	Name     string             `json:"name"`
-	Disabled bool               `json:"disabled,omitempty,omitzero"`
-	Config   WorkflowNodeConfig `json:"config,omitempty,omitzero"`
+	Name     string                   `json:"name"`
+	Disabled bool                     `json:"disabled,omitempty,omitzero"`
+	Config   WorkflowNodeConfig       `json:"config,omitempty,omitzero"`
+	Retry    *WorkflowNodeRetryPolicy `json:"retry,omitempty"`
+	Timeout  int                      `json:"timeout,omitempty"` // 执行超时时间（单位：秒），零值时表示不限制；配置重试策略时，对每次尝试分别计时
+}
+
+type WorkflowNodeRetryPolicy struct {
+	MaxAttempts int                      `json:"maxAttempts"`           // 最大尝试次数（包括首次执行），小于等于 1 时表示不重试
+	Backoff     WorkflowNodeRetryBackoff `json:"backoff,omitempty"`     // 退避策略，零值时表示固定间隔
+	Interval    int                      `json:"interval,omitempty"`    // 重试间隔（单位：秒），指数退避时表示初始间隔
+	MaxInterval int                      `json:"maxInterval,omitempty"` // 最大重试间隔（单位：秒），仅指数退避时有效，零值时表示不限制
+	Jitter      float64                  `json:"jitter,omitempty"`      // 随机抖动比例，取值范围 [0, 1]
+	RetryOn     []string                 `json:"retryOn,omitempty"`     // 可重试的错误信息（正则表达式），为空时表示任意错误均可重试
 }
 
+type WorkflowNodeRetryBackoff string
+
+const (
+	WorkflowNodeRetryBackoffFixed       = WorkflowNodeRetryBackoff("fixed")
+	WorkflowNodeRetryBackoffExponential = WorkflowNodeRetryBackoff("exponential")
+)
+
 type WorkflowNodeConfig map[string]any
 
 func (c WorkflowNodeConfig) AsDelay() WorkflowNodeConfigForDelay {
@@ -123,6 +202,66 @@ func (c WorkflowNodeConfig) AsDelay() WorkflowNodeConfigForDelay {
 	}
 }
 
+func (c WorkflowNodeConfig) AsScript() WorkflowNodeConfigForScript {
+	return WorkflowNodeConfigForScript{
+		Source:          xmaps.GetString(c, "source"),
+		TimeLimit:       xmaps.GetOrDefaultInt(c, "timeLimit", 10),
+		HeapGuard:       xmaps.GetOrDefaultInt(c, "memoryLimit", 64),
+		AllowNetwork:    xmaps.GetBool(c, "allowNetwork"),
+		AllowFileSystem: xmaps.GetBool(c, "allowFileSystem"),
+		AllowedPaths:    xmaps.GetStringsBySplit(c, "allowedPaths", ";"),
+	}
+}
+
+func (c WorkflowNodeConfig) AsHttpRequest() WorkflowNodeConfigForHttpRequest {
+	extractors := make([]*WorkflowNodeHttpRequestExtractor, 0)
+	if raw := c["extractors"]; raw != nil {
+		extractorsRaw, _ := json.Marshal(raw)
+		if err := json.Unmarshal(extractorsRaw, &extractors); err != nil {
+			extractors = make([]*WorkflowNodeHttpRequestExtractor, 0)
+		}
+	}
+
+	return WorkflowNodeConfigForHttpRequest{
+		Method:           xmaps.GetOrDefaultString(c, "method", "GET"),
+		Url:              xmaps.GetString(c, "url"),
+		Headers:          xmaps.GetString(c, "headers"),
+		Body:             xmaps.GetString(c, "body"),
+		ProviderAccessId: xmaps.GetString(c, "providerAccessId"),
+		Timeout:          xmaps.GetOrDefaultInt(c, "timeout", 30),
+		ExpectedStatus:   xmaps.GetOrDefaultStringsBySplit(c, "expectedStatus", ";", []string{"2xx"}),
+		Extractors:       extractors,
+		StrictTemplate:   xmaps.GetBool(c, "strictTemplate"),
+	}
+}
+
+func (c WorkflowNodeConfig) AsApproval() WorkflowNodeConfigForApproval {
+	return WorkflowNodeConfigForApproval{
+		Provider:         xmaps.GetString(c, "provider"),
+		ProviderAccessId: xmaps.GetString(c, "providerAccessId"),
+		ProviderConfig:   xmaps.GetKVMapAny(c, "providerConfig"),
+		Subject:          xmaps.GetString(c, "subject"),
+		Message:          xmaps.GetString(c, "message"),
+		StrictTemplate:   xmaps.GetBool(c, "strictTemplate"),
+		Timeout:          xmaps.GetOrDefaultInt(c, "timeout", 86400),
+	}
+}
+
+func (c WorkflowNodeConfig) AsSetVariable() WorkflowNodeConfigForSetVariable {
+	assignments := make([]*WorkflowNodeSetVariableAssignment, 0)
+	if raw := c["assignments"]; raw != nil {
+		assignmentsRaw, _ := json.Marshal(raw)
+		if err := json.Unmarshal(assignmentsRaw, &assignments); err != nil {
+			assignments = make([]*WorkflowNodeSetVariableAssignment, 0)
+		}
+	}
+
+	return WorkflowNodeConfigForSetVariable{
+		Assignments:    assignments,
+		StrictTemplate: xmaps.GetBool(c, "strictTemplate"),
+	}
+}
+
 func (c WorkflowNodeConfig) AsBranchBlock() WorkflowNodeConfigForBranchBlock {
 	expression := c["expression"]
 	if expression == nil {
@@ -140,6 +279,41 @@ func (c WorkflowNodeConfig) AsBranchBlock() WorkflowNodeConfigForBranchBlock {
 	}
 }
 
+func (c WorkflowNodeConfig) AsParallel() WorkflowNodeConfigForParallel {
+	return WorkflowNodeConfigForParallel{
+		Concurrency: xmaps.GetInt(c, "concurrency"),
+		JoinPolicy:  xmaps.GetOrDefaultString(c, "joinPolicy", WorkflowParallelJoinPolicyAll),
+		MaxFailures: xmaps.GetInt(c, "maxFailures"),
+	}
+}
+
+func (c WorkflowNodeConfig) AsForEach() WorkflowNodeConfigForForEach {
+	return WorkflowNodeConfigForForEach{
+		Source:          xmaps.GetOrDefaultString(c, "source", WorkflowForEachSourceStatic),
+		Items:           xmaps.GetStringsBySplit(c, "items", ";"),
+		VariableScope:   xmaps.GetString(c, "variableScope"),
+		VariableName:    xmaps.GetString(c, "variableName"),
+		Separator:       xmaps.GetOrDefaultString(c, "separator", ";"),
+		ContinueOnError: xmaps.GetBool(c, "continueOnError"),
+	}
+}
+
+func (c WorkflowNodeConfig) AsCallWorkflow() WorkflowNodeConfigForCallWorkflow {
+	inputs := make([]*WorkflowNodeCallWorkflowInput, 0)
+	if raw := c["inputs"]; raw != nil {
+		inputsRaw, _ := json.Marshal(raw)
+		if err := json.Unmarshal(inputsRaw, &inputs); err != nil {
+			inputs = make([]*WorkflowNodeCallWorkflowInput, 0)
+		}
+	}
+
+	return WorkflowNodeConfigForCallWorkflow{
+		WorkflowId: xmaps.GetString(c, "workflowId"),
+		Mode:       xmaps.GetOrDefaultString(c, "mode", WorkflowCallWorkflowModeChild),
+		Inputs:     inputs,
+	}
+}
+
 func (c WorkflowNodeConfig) AsBizApply() WorkflowNodeConfigForBizApply {
 	return WorkflowNodeConfigForBizApply{
 		Domains:               xmaps.GetStringsBySplit(c, "domains", ";"),
@@ -205,6 +379,7 @@ func (c WorkflowNodeConfig) AsBizNotify() WorkflowNodeConfigForBizNotify {
 		ProviderConfig:       xmaps.GetKVMapAny(c, "providerConfig"),
 		Subject:              xmaps.GetString(c, "subject"),
 		Message:              xmaps.GetString(c, "message"),
+		StrictTemplate:       xmaps.GetBool(c, "strictTemplate"),
 		SkipOnAllPrevSkipped: xmaps.GetBool(c, "skipOnAllPrevSkipped"),
 	}
 }
@@ -213,10 +388,134 @@ type WorkflowNodeConfigForDelay struct {
 	Wait int `json:"wait"` // 等待时间
 }
 
+type WorkflowNodeConfigForScript struct {
+	Source          string   `json:"source"`                    // 脚本源码（JavaScript）
+	TimeLimit       int      `json:"timeLimit,omitempty"`       // 执行时间限制（单位：秒，零值时默认值 10）
+	HeapGuard       int      `json:"memoryLimit,omitempty"`     // 进程堆内存增量的保护阈值（单位：MiB，零值时默认值 64），尽力而为，并非单个脚本的内存限制；沿用 memoryLimit 字段名以兼容已有配置
+	AllowNetwork    bool     `json:"allowNetwork,omitempty"`    // 是否允许脚本发起 HTTP 请求
+	AllowFileSystem bool     `json:"allowFileSystem,omitempty"` // 是否允许脚本读写文件
+	AllowedPaths    []string `json:"allowedPaths,omitempty"`    // 允许读写的目录列表，以半角分号分隔，允许读写文件时须至少指定一个
+}
+
+type WorkflowNodeConfigForHttpRequest struct {
+	Method           string                              `json:"method"`                     // 请求方法（零值时默认值 "GET"）
+	Url              string                              `json:"url"`                        // 请求地址，支持模板。为以 "/" 开头的相对路径时将拼接在授权记录中的地址之后
+	Headers          string                              `json:"headers,omitempty"`          // 请求标头，每行一个，形如 "Key: Value"，支持模板
+	Body             string                              `json:"body,omitempty"`             // 请求体，支持模板
+	ProviderAccessId string                              `json:"providerAccessId,omitempty"` // 授权记录 ID（仅支持 Webhook 类型），其中的请求标头将用于身份认证
+	Timeout          int                                 `json:"timeout,omitempty"`          // 请求超时（单位：秒，零值时默认值 30）
+	ExpectedStatus   []string                            `json:"expectedStatus,omitempty"`   // 期望的响应状态码，以半角分号分隔，支持形如 "2xx" 的通配（零值时默认值 "2xx"）
+	Extractors       []*WorkflowNodeHttpRequestExtractor `json:"extractors,omitempty"`       // 从 JSON 响应中提取的变量
+	StrictTemplate   bool                                `json:"strictTemplate"`             // 是否以严格模式渲染模板，严格模式下引用不存在的变量将导致出错
+}
+
+type WorkflowNodeHttpRequestExtractor struct {
+	Name      string `json:"name"`                // 变量名
+	Path      string `json:"path"`                // JSONPath 表达式，如 "$.data.id"
+	ValueType string `json:"valueType,omitempty"` // 变量值类型（零值时默认值 "string"）
+}
+
+type WorkflowNodeConfigForApproval struct {
+	Provider         string         `json:"provider"`                 // 通知提供商
+	ProviderAccessId string         `json:"providerAccessId"`         // 通知提供商授权记录 ID
+	ProviderConfig   map[string]any `json:"providerConfig,omitempty"` // 通知提供商额外配置
+	Subject          string         `json:"subject,omitempty"`        // 通知主题，支持模板（零值时使用内置模板）
+	Message          string         `json:"message,omitempty"`        // 通知内容，支持模板（零值时使用内置模板）。审批链接将附加在其末尾
+	StrictTemplate   bool           `json:"strictTemplate"`           // 是否以严格模式渲染通知模板，严格模式下引用不存在的变量将导致出错
+	Timeout          int            `json:"timeout,omitempty"`        // 审批超时（单位：秒，零值时默认值 86400），超时未审批时将视为拒绝
+}
+
+type WorkflowNodeConfigForSetVariable struct {
+	Assignments    []*WorkflowNodeSetVariableAssignment `json:"assignments"`    // 变量赋值列表，按顺序依次赋值
+	StrictTemplate bool                                 `json:"strictTemplate"` // 是否以严格模式渲染模板，严格模式下引用不存在的变量将导致出错
+}
+
+type WorkflowNodeSetVariableAssignment struct {
+	Name       string    `json:"name"`                 // 变量名
+	Global     bool      `json:"global,omitempty"`     // 是否同时设置为全局变量，否则仅设置为当前节点的作用域变量
+	Source     string    `json:"source"`               // 值来源，可取值 "constant"、"expression"、"template"（零值时默认值 "constant"）
+	Value      string    `json:"value,omitempty"`      // 常量值或模板，仅当值来源为 "constant" 或 "template" 时有效
+	Expression expr.Expr `json:"expression,omitempty"` // 表达式，仅当值来源为 "expression" 时有效
+	ValueType  string    `json:"valueType,omitempty"`  // 变量值类型（零值时，常量及模板默认值 "string"，表达式则取其求值结果的类型）
+}
+
+func (a *WorkflowNodeSetVariableAssignment) UnmarshalJSON(data []byte) error {
+	type alias WorkflowNodeSetVariableAssignment
+	var raw struct {
+		alias
+		Expression json.RawMessage `json:"expression,omitempty"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	*a = WorkflowNodeSetVariableAssignment(raw.alias)
+	a.Expression = nil
+	if len(raw.Expression) > 0 && string(raw.Expression) != "null" {
+		expression, err := expr.UnmarshalExpr(raw.Expression)
+		if err != nil {
+			return err
+		}
+		a.Expression = expression
+	}
+
+	return nil
+}
+
+const (
+	WorkflowSetVariableSourceConstant   = "constant"
+	WorkflowSetVariableSourceExpression = "expression"
+	WorkflowSetVariableSourceTemplate   = "template"
+)
+
 type WorkflowNodeConfigForBranchBlock struct {
 	Expression expr.Expr `json:"expression"` // 条件表达式
 }
 
+type WorkflowNodeConfigForParallel struct {
+	Concurrency int    `json:"concurrency,omitempty"` // 最大并发数（零值时不限制）
+	JoinPolicy  string `json:"joinPolicy"`            // 汇合策略，可取值 "all"、"failFast"、"tolerate"（零值时默认值 "all"）
+	MaxFailures int    `json:"maxFailures,omitempty"` // 可容忍的失败分支数，仅当汇合策略为 "tolerate" 时有效
+}
+
+const (
+	WorkflowParallelJoinPolicyAll      = "all"      // 等待所有分支执行完毕，任一分支失败则失败
+	WorkflowParallelJoinPolicyFailFast = "failFast" // 任一分支失败时立即取消其余分支
+	WorkflowParallelJoinPolicyTolerate = "tolerate" // 失败分支数不超过 MaxFailures 时视为成功
+)
+
+type WorkflowNodeConfigForForEach struct {
+	Source          string   `json:"source"`                    // 列表来源，可取值 "static"、"variable"（零值时默认值 "static"）
+	Items           []string `json:"items,omitempty"`           // 静态列表，以半角分号分隔
+	VariableScope   string   `json:"variableScope,omitempty"`   // 变量作用域（即节点 ID，零值时表示全局变量）
+	VariableName    string   `json:"variableName,omitempty"`    // 变量名
+	Separator       string   `json:"separator,omitempty"`       // 变量值的分隔符（零值时默认值 ";"）
+	ContinueOnError bool     `json:"continueOnError,omitempty"` // 某次迭代失败时是否继续执行后续迭代
+}
+
+const (
+	WorkflowForEachSourceStatic   = "static"
+	WorkflowForEachSourceVariable = "variable"
+)
+
+type WorkflowNodeConfigForCallWorkflow struct {
+	WorkflowId string                           `json:"workflowId"`       // 被调用的工作流 ID
+	Mode       string                           `json:"mode"`             // 调用方式，可取值 "inline"、"child"（零值时默认值 "child"）
+	Inputs     []*WorkflowNodeCallWorkflowInput `json:"inputs,omitempty"` // 输入变量
+}
+
+type WorkflowNodeCallWorkflowInput struct {
+	Name      string                  `json:"name"`                // 变量名
+	Value     string                  `json:"value,omitempty"`     // 常量值
+	ValueType string                  `json:"valueType,omitempty"` // 常量值类型（零值时默认值 "string"）
+	Selector  *expr.ExprValueSelector `json:"selector,omitempty"`  // 引用的变量，非空时优先于常量值。其中节点 ID 为零值时表示全局变量
+}
+
+const (
+	WorkflowCallWorkflowModeInline = "inline" // 在当前运行中内联执行
+	WorkflowCallWorkflowModeChild  = "child"  // 作为子运行执行，生成独立的运行记录
+)
+
 type WorkflowNodeConfigForBizApply struct {
 	Domains               []string       `json:"domains"`                         // 域名列表，以半角分号分隔
 	IPAddrs               []string       `json:"ipaddrs"`                         // IP 地址列表，以半角分号分隔
@@ -272,5 +571,6 @@ type WorkflowNodeConfigForBizNotify struct {
 	ProviderConfig       map[string]any `json:"providerConfig,omitempty"` // 通知提供商额外配置
 	Subject              string         `json:"subject"`                  // 通知主题
 	Message              string         `json:"message"`                  // 通知内容
+	StrictTemplate       bool           `json:"strictTemplate"`           // 是否以严格模式渲染通知模板，严格模式下引用不存在的变量将导致出错
 	SkipOnAllPrevSkipped bool           `json:"skipOnAllPrevSkipped"`     // 前序节点均已跳过时是否跳过
 }
@@ -17,6 +17,7 @@ type WorkflowLog struct {
 	Level          int32          `db:"level"       json:"level"`
 	Message        string         `db:"message"     json:"message"`
 	Data           map[string]any `db:"data"        json:"data"`
+	TraceId        string         `db:"traceId"     json:"traceId"`
 }
 
 type WorkflowLogs []WorkflowLog
@@ -0,0 +1,29 @@
+package domain
+
+// 工作流试运行的执行计划。
+type WorkflowPlan struct {
+	WorkflowId string              `json:"workflowId"`
+	Valid      bool                `json:"valid"`
+	Steps      []*WorkflowPlanStep `json:"steps"`
+}
+
+type WorkflowPlanStep struct {
+	NodeId   string                 `json:"nodeId"`
+	NodeName string                 `json:"nodeName"`
+	NodeType WorkflowNodeType       `json:"nodeType"`
+	Action   WorkflowPlanActionType `json:"action"`
+	Reason   string                 `json:"reason,omitempty"`
+	Error    string                 `json:"error,omitempty"`
+}
+
+type WorkflowPlanActionType string
+
+const (
+	WorkflowPlanActionTypeExecute = WorkflowPlanActionType("execute")
+	WorkflowPlanActionTypeSkip    = WorkflowPlanActionType("skip")
+	WorkflowPlanActionTypeInvalid = WorkflowPlanActionType("invalid")
+)
+
+func (t WorkflowPlanActionType) String() string {
+	return string(t)
+}
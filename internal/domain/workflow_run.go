@@ -8,13 +8,75 @@ const CollectionNameWorkflowRun = "workflow_run"
 
 type WorkflowRun struct {
 	Meta
-	WorkflowId string                `db:"workflowRef" json:"workflowId"`
-	Status     WorkflowRunStatusType `db:"status"      json:"status"`
-	Trigger    WorkflowTriggerType   `db:"trigger"     json:"trigger"`
-	StartedAt  time.Time             `db:"startedAt"   json:"startedAt"`
-	EndedAt    time.Time             `db:"endedAt"     json:"endedAt"`
-	Graph      *WorkflowGraph        `db:"graph"       json:"graph"`
-	Error      string                `db:"error"       json:"error"`
+	WorkflowId     string                `db:"workflowRef"  json:"workflowId"`
+	ParentRunId    string                `db:"parentRunRef" json:"parentRunId"` // 父级运行 ID，仅当由其他工作流调用时有值
+	Status         WorkflowRunStatusType `db:"status"       json:"status"`
+	Trigger        WorkflowTriggerType   `db:"trigger"      json:"trigger"`
+	StartedAt      time.Time             `db:"startedAt"    json:"startedAt"`
+	EndedAt        time.Time             `db:"endedAt"      json:"endedAt"`
+	Graph          *WorkflowGraph        `db:"graph"        json:"graph"`
+	GraphVersion   int                   `db:"graphVersion" json:"graphVersion"` // 运行时所使用的工作流图的版本号
+	Inputs         map[string]any        `db:"inputs"       json:"inputs"`       // 运行时的初始变量，例如由 Webhook 传入的参数
+	State          *WorkflowRunState     `db:"state"        json:"state"`        // 运行结束时的引擎状态快照，用于从失败的节点处续跑
+	Attempt        int                   `db:"attempt"      json:"attempt"`      // 第几次执行，从 1 开始，续跑或因中断而重启时递增
+	Error          string                `db:"error"        json:"error"`
+	ClaimedBy      string                `db:"claimedBy"      json:"claimedBy"`      // 集群模式下认领该运行的节点 ID
+	LeaseExpiresAt time.Time             `db:"leaseExpiresAt" json:"leaseExpiresAt"` // 集群模式下认领租约的过期时间，过期后可被其他节点接管
+}
+
+type WorkflowRunState struct {
+	CompletedNodeIds []string                    `json:"completedNodeIds"`
+	Variables        []*WorkflowRunStateVariable `json:"variables"`
+	Outputs          []*WorkflowRunStateOutput   `json:"outputs"`             // 仅包含非持久化的输出，持久化的输出可从 WorkflowOutput 中获取
+	Approvals        []*WorkflowRunStateApproval `json:"approvals,omitempty"` // 尚未被审批节点处理的审批请求
+}
+
+type WorkflowRunStateVariable struct {
+	Scope     string `json:"scope,omitempty"`
+	Key       string `json:"key"`
+	Value     string `json:"value"`
+	ValueType string `json:"valueType"`
+}
+
+type WorkflowRunStateOutput struct {
+	NodeId    string `json:"nodeId"`
+	Type      string `json:"type"`
+	Name      string `json:"name"`
+	Value     string `json:"value"`
+	ValueType string `json:"valueType"`
+}
+
+type WorkflowRunStateApproval struct {
+	NodeId    string                       `json:"nodeId"`
+	Token     string                       `json:"token"`
+	ExpiresAt time.Time                    `json:"expiresAt"`
+	Decision  WorkflowApprovalDecisionType `json:"decision,omitempty"` // 零值时表示仍在等待审批
+	Decider   string                       `json:"decider,omitempty"`
+	Comment   string                       `json:"comment,omitempty"`
+	DecidedAt time.Time                    `json:"decidedAt,omitempty"`
+}
+
+type WorkflowApprovalDecisionType string
+
+const (
+	WorkflowApprovalDecisionTypeApproved = WorkflowApprovalDecisionType("approved")
+	WorkflowApprovalDecisionTypeRejected = WorkflowApprovalDecisionType("rejected")
+	WorkflowApprovalDecisionTypeExpired  = WorkflowApprovalDecisionType("expired")
+)
+
+// 获取指定节点的审批请求。
+func (s *WorkflowRunState) GetApproval(nodeId string) (*WorkflowRunStateApproval, bool) {
+	if s == nil {
+		return nil, false
+	}
+
+	for _, approval := range s.Approvals {
+		if approval.NodeId == nodeId {
+			return approval, true
+		}
+	}
+
+	return nil, false
 }
 
 type WorkflowRunStatusType string
@@ -24,9 +86,48 @@ func (t WorkflowRunStatusType) String() string {
 }
 
 const (
-	WorkflowRunStatusTypePending    WorkflowRunStatusType = "pending"
-	WorkflowRunStatusTypeProcessing WorkflowRunStatusType = "processing"
-	WorkflowRunStatusTypeSucceeded  WorkflowRunStatusType = "succeeded"
-	WorkflowRunStatusTypeFailed     WorkflowRunStatusType = "failed"
-	WorkflowRunStatusTypeCanceled   WorkflowRunStatusType = "canceled"
+	WorkflowRunStatusTypePending     WorkflowRunStatusType = "pending"
+	WorkflowRunStatusTypeProcessing  WorkflowRunStatusType = "processing"
+	WorkflowRunStatusTypeWaiting     WorkflowRunStatusType = "waiting" // 等待审批，此时不占用调度器的并发数
+	WorkflowRunStatusTypeSucceeded   WorkflowRunStatusType = "succeeded"
+	WorkflowRunStatusTypeFailed      WorkflowRunStatusType = "failed"
+	WorkflowRunStatusTypeCanceled    WorkflowRunStatusType = "canceled"
+	WorkflowRunStatusTypeTimeout     WorkflowRunStatusType = "timeout"
+	WorkflowRunStatusTypeInterrupted WorkflowRunStatusType = "interrupted" // 执行时进程意外退出
+)
+
+func (t WorkflowRunStatusType) IsFinished() bool {
+	switch t {
+	case WorkflowRunStatusTypeSucceeded,
+		WorkflowRunStatusTypeFailed,
+		WorkflowRunStatusTypeCanceled,
+		WorkflowRunStatusTypeTimeout,
+		WorkflowRunStatusTypeInterrupted:
+		return true
+	}
+
+	return false
+}
+
+// 运行过程中的实时事件，用于向客户端推送运行日志与状态变化。
+type WorkflowRunEvent struct {
+	Id        string                `json:"id"` // 事件 ID，用于断线后续传
+	Type      WorkflowRunEventType  `json:"type"`
+	RunId     string                `json:"runId"`
+	NodeId    string                `json:"nodeId,omitempty"`
+	NodeName  string                `json:"nodeName,omitempty"`
+	Status    WorkflowRunStatusType `json:"status,omitempty"` // 仅当类型为状态变化时有值
+	Error     string                `json:"error,omitempty"`
+	Log       *WorkflowLog          `json:"log,omitempty"` // 仅当类型为日志时有值
+	Timestamp time.Time             `json:"timestamp"`
+}
+
+type WorkflowRunEventType string
+
+const (
+	WorkflowRunEventTypeLog       WorkflowRunEventType = "log"
+	WorkflowRunEventTypeNodeStart WorkflowRunEventType = "nodeStart"
+	WorkflowRunEventTypeNodeEnd   WorkflowRunEventType = "nodeEnd"
+	WorkflowRunEventTypeNodeError WorkflowRunEventType = "nodeError"
+	WorkflowRunEventTypeStatus    WorkflowRunEventType = "status"
 )
@@ -0,0 +1,66 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+)
+
+// 工作流图静态校验的错误，按节点归集，以便前端及 API 逐个节点地展示。
+type WorkflowGraphValidationError struct {
+	Errors []string                       `json:"errors,omitempty"` // 与具体节点无关的错误
+	Nodes  []*WorkflowNodeValidationError `json:"nodes,omitempty"`  // 各节点的错误列表
+}
+
+type WorkflowNodeValidationError struct {
+	NodeId   string                          `json:"nodeId"`
+	NodeName string                          `json:"nodeName"`
+	NodeType WorkflowNodeType                `json:"nodeType"`
+	Errors   []*WorkflowFieldValidationError `json:"errors"`
+}
+
+type WorkflowFieldValidationError struct {
+	Field   string `json:"field,omitempty"` // 节点配置中的字段名，零值时表示节点本身
+	Message string `json:"message"`
+}
+
+func (e *WorkflowGraphValidationError) Error() string {
+	messages := make([]string, 0)
+	messages = append(messages, e.Errors...)
+	for _, node := range e.Nodes {
+		for _, fieldErr := range node.Errors {
+			if fieldErr.Field == "" {
+				messages = append(messages, fmt.Sprintf("node #%s: %s", node.NodeId, fieldErr.Message))
+			} else {
+				messages = append(messages, fmt.Sprintf("node #%s: %s: %s", node.NodeId, fieldErr.Field, fieldErr.Message))
+			}
+		}
+	}
+
+	return fmt.Sprintf("workflow graph is invalid: %s", strings.Join(messages, "; "))
+}
+
+func (e *WorkflowGraphValidationError) HasErrors() bool {
+	return len(e.Errors) > 0 || len(e.Nodes) > 0
+}
+
+func (e *WorkflowGraphValidationError) AddError(message string) {
+	e.Errors = append(e.Errors, message)
+}
+
+func (e *WorkflowGraphValidationError) AddNodeError(node *WorkflowNode, field string, message string) {
+	fieldErr := &WorkflowFieldValidationError{Field: field, Message: message}
+
+	for _, nodeErr := range e.Nodes {
+		if nodeErr.NodeId == node.Id {
+			nodeErr.Errors = append(nodeErr.Errors, fieldErr)
+			return
+		}
+	}
+
+	e.Nodes = append(e.Nodes, &WorkflowNodeValidationError{
+		NodeId:   node.Id,
+		NodeName: node.Data.Name,
+		NodeType: node.Type,
+		Errors:   []*WorkflowFieldValidationError{fieldErr},
+	})
+}
@@ -0,0 +1,185 @@
+package domain
+
+import (
+	"encoding/json"
+	"maps"
+	"reflect"
+	"slices"
+)
+
+const CollectionNameWorkflowVersion = "workflow_version"
+
+type WorkflowVersion struct {
+	Meta
+	WorkflowId string         `db:"workflowRef" json:"workflowId"`
+	Version    int            `db:"version"     json:"version"`
+	Graph      *WorkflowGraph `db:"graph"       json:"graph"`
+	Author     string         `db:"author"      json:"author"`
+	Note       string         `db:"note"        json:"note"`
+}
+
+type WorkflowGraphNodeChange struct {
+	NodeId     string                      `json:"nodeId"`
+	NodeName   string                      `json:"nodeName"`
+	NodeType   WorkflowNodeType            `json:"nodeType"`
+	ChangeType WorkflowGraphNodeChangeType `json:"changeType"`
+	Fields     []string                    `json:"fields,omitempty"` // 发生变化的字段，仅当变更类型为 modified 时有值
+	Before     *WorkflowNodeData           `json:"before,omitempty"`
+	After      *WorkflowNodeData           `json:"after,omitempty"`
+}
+
+type WorkflowGraphNodeChangeType string
+
+const (
+	WorkflowGraphNodeChangeTypeAdded    = WorkflowGraphNodeChangeType("added")
+	WorkflowGraphNodeChangeTypeRemoved  = WorkflowGraphNodeChangeType("removed")
+	WorkflowGraphNodeChangeTypeModified = WorkflowGraphNodeChangeType("modified")
+)
+
+func (t WorkflowGraphNodeChangeType) String() string {
+	return string(t)
+}
+
+type workflowGraphFlattenNode struct {
+	node     *WorkflowNode
+	parentId string
+}
+
+// 逐节点比较两个工作流图，返回其间的差异。
+// 新增及修改的节点按目标图中的顺序排列，删除的节点按源图中的顺序排列在最后。
+func DiffWorkflowGraphs(from, to *WorkflowGraph) []*WorkflowGraphNodeChange {
+	fromNodes := flattenWorkflowGraph(from)
+	toNodes := flattenWorkflowGraph(to)
+
+	fromIndex := make(map[string]workflowGraphFlattenNode, len(fromNodes))
+	for _, item := range fromNodes {
+		fromIndex[item.node.Id] = item
+	}
+	toIndex := make(map[string]workflowGraphFlattenNode, len(toNodes))
+	for _, item := range toNodes {
+		toIndex[item.node.Id] = item
+	}
+
+	changes := make([]*WorkflowGraphNodeChange, 0)
+	for _, item := range toNodes {
+		after := item.node.Data
+		prev, ok := fromIndex[item.node.Id]
+		if !ok {
+			changes = append(changes, &WorkflowGraphNodeChange{
+				NodeId:     item.node.Id,
+				NodeName:   item.node.Data.Name,
+				NodeType:   item.node.Type,
+				ChangeType: WorkflowGraphNodeChangeTypeAdded,
+				After:      &after,
+			})
+			continue
+		}
+
+		fields := diffWorkflowNodes(prev, item)
+		if len(fields) > 0 {
+			before := prev.node.Data
+			changes = append(changes, &WorkflowGraphNodeChange{
+				NodeId:     item.node.Id,
+				NodeName:   item.node.Data.Name,
+				NodeType:   item.node.Type,
+				ChangeType: WorkflowGraphNodeChangeTypeModified,
+				Fields:     fields,
+				Before:     &before,
+				After:      &after,
+			})
+		}
+	}
+
+	for _, item := range fromNodes {
+		if _, ok := toIndex[item.node.Id]; !ok {
+			before := item.node.Data
+			changes = append(changes, &WorkflowGraphNodeChange{
+				NodeId:     item.node.Id,
+				NodeName:   item.node.Data.Name,
+				NodeType:   item.node.Type,
+				ChangeType: WorkflowGraphNodeChangeTypeRemoved,
+				Before:     &before,
+			})
+		}
+	}
+
+	return changes
+}
+
+func flattenWorkflowGraph(graph *WorkflowGraph) []workflowGraphFlattenNode {
+	nodes := make([]workflowGraphFlattenNode, 0)
+	if graph == nil {
+		return nodes
+	}
+
+	var walk func(blocks []*WorkflowNode, parentId string)
+	walk = func(blocks []*WorkflowNode, parentId string) {
+		for _, node := range blocks {
+			if node == nil {
+				continue
+			}
+
+			nodes = append(nodes, workflowGraphFlattenNode{node: node, parentId: parentId})
+			if len(node.Blocks) > 0 {
+				walk(node.Blocks, node.Id)
+			}
+		}
+	}
+	walk(graph.Nodes, "")
+
+	return nodes
+}
+
+func diffWorkflowNodes(from, to workflowGraphFlattenNode) []string {
+	fields := make([]string, 0)
+
+	if from.node.Type != to.node.Type {
+		fields = append(fields, "type")
+	}
+	if from.parentId != to.parentId {
+		fields = append(fields, "parent")
+	}
+	if from.node.Data.Name != to.node.Data.Name {
+		fields = append(fields, "name")
+	}
+	if from.node.Data.Disabled != to.node.Data.Disabled {
+		fields = append(fields, "disabled")
+	}
+	if from.node.Data.Timeout != to.node.Data.Timeout {
+		fields = append(fields, "timeout")
+	}
+	if !reflect.DeepEqual(normalizeJSONValue(from.node.Data.Retry), normalizeJSONValue(to.node.Data.Retry)) {
+		fields = append(fields, "retry")
+	}
+
+	fromConfig := map[string]any(from.node.Data.Config)
+	toConfig := map[string]any(to.node.Data.Config)
+	keys := slices.Sorted(maps.Keys(fromConfig))
+	for _, key := range slices.Sorted(maps.Keys(toConfig)) {
+		if _, ok := fromConfig[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	for _, key := range keys {
+		if !reflect.DeepEqual(normalizeJSONValue(fromConfig[key]), normalizeJSONValue(toConfig[key])) {
+			fields = append(fields, "config."+key)
+		}
+	}
+
+	return fields
+}
+
+// 将值经由 JSON 序列化再反序列化，以消除数值类型等差异，便于比较。
+func normalizeJSONValue(v any) any {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return v
+	}
+
+	var res any
+	if err := json.Unmarshal(data, &res); err != nil {
+		return v
+	}
+
+	return res
+}
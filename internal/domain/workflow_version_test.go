@@ -0,0 +1,92 @@
+package domain
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestDiffWorkflowGraphs(t *testing.T) {
+	newNode := func(id string, nodeType WorkflowNodeType, config WorkflowNodeConfig, blocks ...*WorkflowNode) *WorkflowNode {
+		return &WorkflowNode{Id: id, Type: nodeType, Data: WorkflowNodeData{Name: id, Config: config}, Blocks: blocks}
+	}
+	newGraph := func(nodes ...*WorkflowNode) *WorkflowGraph {
+		return &WorkflowGraph{Nodes: nodes}
+	}
+	// 以 "节点 ID:变更类型:字段" 的形式描述差异，便于比较
+	formatChanges := func(changes []*WorkflowGraphNodeChange) []string {
+		res := make([]string, 0, len(changes))
+		for _, change := range changes {
+			res = append(res, fmt.Sprintf("%s:%s:%s", change.NodeId, change.ChangeType, strings.Join(change.Fields, ",")))
+		}
+		return res
+	}
+
+	tests := []struct {
+		name string
+		from *WorkflowGraph
+		to   *WorkflowGraph
+		want []string
+	}{
+		{
+			name: "identical",
+			from: newGraph(newNode("start", WorkflowNodeTypeStart, nil), newNode("n1", WorkflowNodeTypeDelay, WorkflowNodeConfig{"wait": 1})),
+			to:   newGraph(newNode("start", WorkflowNodeTypeStart, nil), newNode("n1", WorkflowNodeTypeDelay, WorkflowNodeConfig{"wait": 1})),
+			want: []string{},
+		},
+		{
+			name: "numeric types are normalized",
+			from: newGraph(newNode("n1", WorkflowNodeTypeDelay, WorkflowNodeConfig{"wait": 1})),
+			to:   newGraph(newNode("n1", WorkflowNodeTypeDelay, WorkflowNodeConfig{"wait": float64(1)})),
+			want: []string{},
+		},
+		{
+			name: "added and removed",
+			from: newGraph(newNode("start", WorkflowNodeTypeStart, nil), newNode("n1", WorkflowNodeTypeDelay, nil), newNode("n2", WorkflowNodeTypeDelay, nil)),
+			to:   newGraph(newNode("start", WorkflowNodeTypeStart, nil), newNode("n3", WorkflowNodeTypeDelay, nil), newNode("n2", WorkflowNodeTypeDelay, nil)),
+			want: []string{"n3:added:", "n1:removed:"},
+		},
+		{
+			name: "modified config",
+			from: newGraph(newNode("n1", WorkflowNodeTypeDelay, WorkflowNodeConfig{"wait": 1, "removed": "a"})),
+			to:   newGraph(newNode("n1", WorkflowNodeTypeDelay, WorkflowNodeConfig{"wait": 2, "added": "b"})),
+			want: []string{"n1:modified:config.removed,config.wait,config.added"},
+		},
+		{
+			name: "modified data",
+			from: newGraph(&WorkflowNode{Id: "n1", Type: WorkflowNodeTypeDelay, Data: WorkflowNodeData{Name: "a", Retry: &WorkflowNodeRetryPolicy{MaxAttempts: 2}}}),
+			to:   newGraph(&WorkflowNode{Id: "n1", Type: WorkflowNodeTypeCallWorkflow, Data: WorkflowNodeData{Name: "b", Disabled: true, Timeout: 10, Retry: &WorkflowNodeRetryPolicy{MaxAttempts: 3}}}),
+			want: []string{"n1:modified:type,name,disabled,timeout,retry"},
+		},
+		{
+			name: "nested nodes",
+			from: newGraph(
+				newNode("cond1", WorkflowNodeTypeCondition, nil,
+					newNode("branch1", WorkflowNodeTypeBranchBlock, nil, newNode("n1", WorkflowNodeTypeDelay, nil)),
+					newNode("branch2", WorkflowNodeTypeBranchBlock, nil),
+				),
+			),
+			to: newGraph(
+				newNode("cond1", WorkflowNodeTypeCondition, nil,
+					newNode("branch1", WorkflowNodeTypeBranchBlock, nil),
+					newNode("branch2", WorkflowNodeTypeBranchBlock, nil, newNode("n1", WorkflowNodeTypeDelay, nil), newNode("n2", WorkflowNodeTypeDelay, nil)),
+				),
+			),
+			want: []string{"n1:modified:parent", "n2:added:"},
+		},
+		{
+			name: "from empty graph",
+			from: nil,
+			to:   newGraph(newNode("start", WorkflowNodeTypeStart, nil)),
+			want: []string{"start:added:"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatChanges(DiffWorkflowGraphs(tt.from, tt.to)); !slices.Equal(got, tt.want) {
+				t.Errorf("DiffWorkflowGraphs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
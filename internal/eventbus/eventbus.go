@@ -0,0 +1,110 @@
+package eventbus
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+
+	"github.com/certimate-go/certimate/internal/app"
+	"github.com/certimate-go/certimate/internal/domain"
+)
+
+const eventQueueSize = 1024
+
+type Handler func(ctx context.Context, event *domain.Event)
+
+type EventBus interface {
+	// 订阅指定类型的事件。
+	// 返回值用于取消订阅。
+	Subscribe(eventType domain.EventType, handler Handler) (unsubscribe func())
+	// 发布事件。
+	// 事件将被放入队列中由后台协程按顺序分发给订阅者，因此不会阻塞发布方（除非队列已满）。
+	Publish(ctx context.Context, event *domain.Event) error
+}
+
+type subscriber struct {
+	handler Handler
+}
+
+type eventBus struct {
+	subscribers    map[domain.EventType][]*subscriber
+	subscribersMtx sync.RWMutex
+
+	queue     chan *domain.Event
+	queueOnce sync.Once
+}
+
+var _ EventBus = (*eventBus)(nil)
+
+func (eb *eventBus) Subscribe(eventType domain.EventType, handler Handler) func() {
+	if handler == nil {
+		panic("eventbus: nil handler")
+	}
+
+	sub := &subscriber{handler: handler}
+
+	eb.subscribersMtx.Lock()
+	eb.subscribers[eventType] = append(eb.subscribers[eventType], sub)
+	eb.subscribersMtx.Unlock()
+
+	return func() {
+		eb.subscribersMtx.Lock()
+		defer eb.subscribersMtx.Unlock()
+
+		subs := eb.subscribers[eventType]
+		for i, s := range subs {
+			if s == sub {
+				eb.subscribers[eventType] = append(subs[:i:i], subs[i+1:]...)
+				break
+			}
+		}
+	}
+}
+
+func (eb *eventBus) Publish(ctx context.Context, event *domain.Event) error {
+	if event == nil {
+		return fmt.Errorf("eventbus: nil event")
+	}
+
+	eb.queueOnce.Do(func() {
+		go eb.loop()
+	})
+
+	select {
+	case eb.queue <- event:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (eb *eventBus) loop() {
+	for event := range eb.queue {
+		eb.subscribersMtx.RLock()
+		subs := make([]*subscriber, len(eb.subscribers[event.Type]))
+		copy(subs, eb.subscribers[event.Type])
+		eb.subscribersMtx.RUnlock()
+
+		for _, sub := range subs {
+			eb.dispatch(sub, event)
+		}
+	}
+}
+
+func (eb *eventBus) dispatch(sub *subscriber, event *domain.Event) {
+	defer func() {
+		if r := recover(); r != nil {
+			app.GetLogger().Error(fmt.Sprintf("eventbus: panic in the handler of event '%s'", event.Type), slog.Any("error", r))
+		}
+	}()
+
+	sub.handler(context.Background(), event)
+}
+
+func newEventBus() EventBus {
+	return &eventBus{
+		subscribers: make(map[domain.EventType][]*subscriber),
+		queue:       make(chan *domain.Event, eventQueueSize),
+	}
+}
@@ -0,0 +1,42 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type GaugeSample struct {
+	LabelValues []string
+	Value       float64
+}
+
+// 注册在采集时由回调函数计算的仪表盘指标。
+// 回调函数返回各组标签值及其对应的值，标签值的顺序须与 labelNames 一致。
+func RegisterGaugeFunc(subsystem string, name string, help string, labelNames []string, fn func() []GaugeSample) {
+	registry.MustRegister(&gaugeFuncCollector{
+		desc: prometheus.NewDesc(prometheus.BuildFQName(namespace, subsystem, name), help, labelNames, nil),
+		fn:   fn,
+	})
+}
+
+type gaugeFuncCollector struct {
+	desc *prometheus.Desc
+	fn   func() []GaugeSample
+}
+
+var _ prometheus.Collector = (*gaugeFuncCollector)(nil)
+
+func (c *gaugeFuncCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+func (c *gaugeFuncCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, sample := range c.fn() {
+		metric, err := prometheus.NewConstMetric(c.desc, prometheus.GaugeValue, sample.Value, sample.LabelValues...)
+		if err != nil {
+			ch <- prometheus.NewInvalidMetric(c.desc, err)
+			continue
+		}
+
+		ch <- metric
+	}
+}
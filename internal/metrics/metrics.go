@@ -0,0 +1,135 @@
+// Package metrics 以 Prometheus 格式暴露运行指标。
+//
+// 各模块通过本包提供的函数记录指标，无需直接依赖 Prometheus 客户端；
+// 需要在采集时实时计算的指标（如调度器队列深度、证书过期时间），则由各模块在启动时注册回调函数。
+package metrics
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "certimate"
+
+const (
+	ResultSuccess = "success"
+	ResultFailure = "failure"
+)
+
+var registry = prometheus.NewRegistry()
+
+var (
+	workflowRunsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "workflow",
+		Name:      "runs_total",
+		Help:      "Total number of finished workflow runs.",
+	}, []string{"status", "trigger"})
+
+	workflowRunDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "workflow",
+		Name:      "run_duration_seconds",
+		Help:      "Duration of finished workflow runs.",
+		Buckets:   []float64{1, 5, 15, 30, 60, 120, 300, 600, 1800, 3600},
+	}, []string{"status", "trigger"})
+
+	workflowNodeDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "workflow",
+		Name:      "node_duration_seconds",
+		Help:      "Duration of workflow node executions.",
+		Buckets:   []float64{0.1, 0.5, 1, 5, 15, 30, 60, 120, 300, 600},
+	}, []string{"node_type", "provider", "result"})
+
+	acmeOrdersTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "acme",
+		Name:      "orders_total",
+		Help:      "Total number of ACME certificate orders.",
+	}, []string{"ca", "result"})
+
+	deployerCallsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "deployer",
+		Name:      "calls_total",
+		Help:      "Total number of certificate deployment calls.",
+	}, []string{"provider", "result"})
+
+	deployerCallDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "deployer",
+		Name:      "call_duration_seconds",
+		Help:      "Duration of certificate deployment calls.",
+		Buckets:   []float64{0.1, 0.5, 1, 5, 15, 30, 60, 120, 300},
+	}, []string{"provider", "result"})
+
+	notifierSendsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "notifier",
+		Name:      "sends_total",
+		Help:      "Total number of notification sends.",
+	}, []string{"provider", "result"})
+)
+
+func init() {
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		workflowRunsTotal,
+		workflowRunDuration,
+		workflowNodeDuration,
+		acmeOrdersTotal,
+		deployerCallsTotal,
+		deployerCallDuration,
+		notifierSendsTotal,
+	)
+}
+
+// 返回以 Prometheus 文本格式输出全部指标的 HTTP 处理器。
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
+
+// 按错误返回结果标签。
+func Result(err error) string {
+	if err != nil {
+		return ResultFailure
+	}
+
+	return ResultSuccess
+}
+
+// 记录已结束的工作流运行。
+func ObserveWorkflowRun(status string, trigger string, duration time.Duration) {
+	workflowRunsTotal.WithLabelValues(status, trigger).Inc()
+	if duration > 0 {
+		workflowRunDuration.WithLabelValues(status, trigger).Observe(duration.Seconds())
+	}
+}
+
+// 记录工作流节点的执行。provider 为节点所使用的提供商，无时为空。
+func ObserveWorkflowNode(nodeType string, provider string, err error, duration time.Duration) {
+	workflowNodeDuration.WithLabelValues(nodeType, provider, Result(err)).Observe(duration.Seconds())
+}
+
+// 记录 ACME 证书订单。
+func ObserveACMEOrder(ca string, err error) {
+	acmeOrdersTotal.WithLabelValues(ca, Result(err)).Inc()
+}
+
+// 记录证书部署的调用。
+func ObserveDeployerCall(provider string, err error, duration time.Duration) {
+	result := Result(err)
+	deployerCallsTotal.WithLabelValues(provider, result).Inc()
+	deployerCallDuration.WithLabelValues(provider, result).Observe(duration.Seconds())
+}
+
+// 记录通知的推送。
+func ObserveNotifierSend(provider string, err error) {
+	notifierSendsTotal.WithLabelValues(provider, Result(err)).Inc()
+}
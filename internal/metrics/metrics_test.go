@@ -0,0 +1,47 @@
+package metrics
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHandler(t *testing.T) {
+	ObserveWorkflowRun("succeeded", "manual", 3*time.Second)
+	ObserveWorkflowNode("bizDeploy", "aliyun-cdn", errors.New("test error"), time.Second)
+	ObserveACMEOrder("letsencrypt", nil)
+	ObserveDeployerCall("aliyun-cdn", nil, time.Second)
+	ObserveNotifierSend("email", errors.New("test error"))
+	RegisterGaugeFunc("test", "samples", "Test samples.", []string{"name"}, func() []GaugeSample {
+		return []GaugeSample{
+			{LabelValues: []string{"a"}, Value: 1},
+			{LabelValues: []string{"b"}, Value: 2},
+		}
+	})
+
+	rec := httptest.NewRecorder()
+	Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Handler() status = %d, want %d", rec.Code, http.StatusOK)
+	}
+
+	body := rec.Body.String()
+	for _, want := range []string{
+		`certimate_workflow_runs_total{status="succeeded",trigger="manual"} 1`,
+		`certimate_workflow_run_duration_seconds_count{status="succeeded",trigger="manual"} 1`,
+		`certimate_workflow_node_duration_seconds_count{node_type="bizDeploy",provider="aliyun-cdn",result="failure"} 1`,
+		`certimate_acme_orders_total{ca="letsencrypt",result="success"} 1`,
+		`certimate_deployer_calls_total{provider="aliyun-cdn",result="success"} 1`,
+		`certimate_notifier_sends_total{provider="email",result="failure"} 1`,
+		`certimate_test_samples{name="a"} 1`,
+		`certimate_test_samples{name="b"} 2`,
+		`go_goroutines`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Handler() output does not contain '%s'", want)
+		}
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 
 	"github.com/certimate-go/certimate/internal/domain"
+	"github.com/certimate-go/certimate/internal/metrics"
 	"github.com/certimate-go/certimate/internal/notify/notifiers"
 )
 
@@ -22,6 +23,14 @@ type SendNotificationRequest struct {
 type SendNotificationResponse struct{}
 
 func (c *Client) SendNotification(ctx context.Context, request *SendNotificationRequest) (*SendNotificationResponse, error) {
+	resp, err := c.sendNotification(ctx, request)
+	if request != nil {
+		metrics.ObserveNotifierSend(string(request.Provider), err)
+	}
+	return resp, err
+}
+
+func (c *Client) sendNotification(ctx context.Context, request *SendNotificationRequest) (*SendNotificationResponse, error) {
 	if request == nil {
 		return nil, fmt.Errorf("the request is nil")
 	}
@@ -46,3 +55,24 @@ func (c *Client) SendNotification(ctx context.Context, request *SendNotification
 
 	return &SendNotificationResponse{}, nil
 }
+
+// 校验通知请求中的提供商及其配置项，但不会实际推送通知。
+func (c *Client) ValidateSendNotificationRequest(request *SendNotificationRequest) error {
+	if request == nil {
+		return fmt.Errorf("the request is nil")
+	}
+
+	providerFactory, err := notifiers.Registries.Get(request.Provider)
+	if err != nil {
+		return err
+	}
+
+	if _, err := providerFactory(&notifiers.ProviderFactoryOptions{
+		ProviderAccessConfig:   request.ProviderAccessConfig,
+		ProviderExtendedConfig: request.ProviderExtendedConfig,
+	}); err != nil {
+		return fmt.Errorf("failed to initialize notification provider '%s': %w", request.Provider, err)
+	}
+
+	return nil
+}
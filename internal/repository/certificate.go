@@ -34,6 +34,30 @@ func (r *CertificateRepository) GetById(ctx context.Context, id string) (*domain
 	return r.castRecordToModel(record)
 }
 
+func (r *CertificateRepository) ListValid(ctx context.Context) ([]*domain.Certificate, error) {
+	records, err := app.GetApp().FindRecordsByFilter(
+		domain.CollectionNameCertificate,
+		"validityNotAfter>@now && isRenewed=false && isRevoked=false && deleted=null",
+		"validityNotAfter",
+		0, 0,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	certificates := make([]*domain.Certificate, 0)
+	for _, record := range records {
+		certificate, err := r.castRecordToModel(record)
+		if err != nil {
+			return nil, err
+		}
+
+		certificates = append(certificates, certificate)
+	}
+
+	return certificates, nil
+}
+
 func (r *CertificateRepository) GetByWorkflowIdAndNodeId(ctx context.Context, workflowId string, workflowNodeId string) (*domain.Certificate, error) {
 	records, err := app.GetApp().FindRecordsByFilter(
 		domain.CollectionNameCertificate,
@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/types"
+
+	"github.com/certimate-go/certimate/internal/app"
+	"github.com/certimate-go/certimate/internal/domain"
+)
+
+type ClusterLeaseRepository struct{}
+
+func NewClusterLeaseRepository() *ClusterLeaseRepository {
+	return &ClusterLeaseRepository{}
+}
+
+// 尝试获取或续期租约。
+// 仅当租约不存在、已由该节点持有或已过期时获取成功。
+func (r *ClusterLeaseRepository) Acquire(ctx context.Context, name string, holder string, ttl time.Duration) (bool, error) {
+	now := types.NowDateTime()
+
+	res, err := app.GetDB().
+		Update(
+			domain.CollectionNameClusterLease,
+			dbx.Params{
+				"holder":    holder,
+				"expiresAt": now.Add(ttl).String(),
+				"updated":   now.String(),
+			},
+			dbx.And(
+				dbx.HashExp{"name": name},
+				dbx.Or(
+					dbx.HashExp{"holder": holder},
+					dbx.NewExp("expiresAt<{:now}", dbx.Params{"now": now.String()}),
+				),
+			),
+		).
+		WithContext(ctx).
+		Execute()
+	if err != nil {
+		return false, err
+	} else if affected, _ := res.RowsAffected(); affected > 0 {
+		return true, nil
+	}
+
+	// 租约不存在时创建之，名称上有唯一索引，并发创建时仅有一个节点能成功
+	exists, err := app.GetApp().CountRecords(domain.CollectionNameClusterLease, dbx.HashExp{"name": name})
+	if err != nil {
+		return false, err
+	} else if exists > 0 {
+		return false, nil
+	}
+
+	collection, err := app.GetApp().FindCollectionByNameOrId(domain.CollectionNameClusterLease)
+	if err != nil {
+		return false, err
+	}
+
+	record := core.NewRecord(collection)
+	record.Set("name", name)
+	record.Set("holder", holder)
+	record.Set("expiresAt", now.Add(ttl))
+	if err := app.GetApp().Save(record); err != nil {
+		// 其他节点已抢先创建时，视为获取失败
+		if isUniqueConflict(err, "name") {
+			return false, nil
+		}
+
+		return false, err
+	}
+
+	return true, nil
+}
+
+// 释放由该节点持有的租约，以便其他节点无需等待过期即可获取之。
+func (r *ClusterLeaseRepository) Release(ctx context.Context, name string, holder string) error {
+	_, err := app.GetDB().
+		Update(
+			domain.CollectionNameClusterLease,
+			dbx.Params{"expiresAt": ""},
+			dbx.HashExp{"name": name, "holder": holder},
+		).
+		WithContext(ctx).
+		Execute()
+	return err
+}
+
+// 判断是否为字段上的唯一约束冲突。
+// 保存前的校验与数据库的唯一索引均可能报告此冲突，须兼顾两者。
+func isUniqueConflict(err error, field string) bool {
+	var verrs validation.Errors
+	if errors.As(err, &verrs) {
+		var verr validation.Error
+		if errors.As(verrs[field], &verr) && verr.Code() == "validation_not_unique" {
+			return true
+		}
+	}
+
+	return strings.Contains(strings.ToLower(err.Error()), "unique constraint failed")
+}
@@ -5,11 +5,13 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/certimate-go/certimate/internal/app"
 	"github.com/certimate-go/certimate/internal/domain"
 	"github.com/pocketbase/dbx"
 	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/types"
 )
 
 type WorkflowRepository struct{}
@@ -43,6 +45,56 @@ func (r *WorkflowRepository) ListEnabledScheduled(ctx context.Context) ([]*domai
 	return workflows, nil
 }
 
+func (r *WorkflowRepository) ListEnabledEventTriggered(ctx context.Context, eventType domain.EventType) ([]*domain.Workflow, error) {
+	records, err := app.GetApp().FindRecordsByFilter(
+		domain.CollectionNameWorkflow,
+		"enabled={:enabled} && trigger={:trigger} && triggerEvent.eventType={:eventType}",
+		"-created",
+		0, 0,
+		dbx.Params{"enabled": true, "trigger": domain.WorkflowTriggerTypeEvent.String(), "eventType": eventType.String()},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	workflows := make([]*domain.Workflow, 0)
+	for _, record := range records {
+		workflow, err := r.castRecordToModel(record)
+		if err != nil {
+			return nil, err
+		}
+
+		workflows = append(workflows, workflow)
+	}
+
+	return workflows, nil
+}
+
+func (r *WorkflowRepository) ListDeferredRunDue(ctx context.Context, before time.Time) ([]*domain.Workflow, error) {
+	records, err := app.GetApp().FindRecordsByFilter(
+		domain.CollectionNameWorkflow,
+		"deferredRunAt!='' && deferredRunAt<={:before}",
+		"deferredRunAt",
+		0, 0,
+		dbx.Params{"before": formatDateTime(before)},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	workflows := make([]*domain.Workflow, 0)
+	for _, record := range records {
+		workflow, err := r.castRecordToModel(record)
+		if err != nil {
+			return nil, err
+		}
+
+		workflows = append(workflows, workflow)
+	}
+
+	return workflows, nil
+}
+
 func (r *WorkflowRepository) GetById(ctx context.Context, id string) (*domain.Workflow, error) {
 	record, err := app.GetApp().FindRecordById(domain.CollectionNameWorkflow, id)
 	if err != nil {
@@ -56,21 +108,50 @@ func (r *WorkflowRepository) GetById(ctx context.Context, id string) (*domain.Wo
 }
 
 func (r *WorkflowRepository) Save(ctx context.Context, workflow *domain.Workflow) (*domain.Workflow, error) {
-	collection, err := app.GetApp().FindCollectionByNameOrId(domain.CollectionNameWorkflow)
+	if err := r.save(app.GetApp(), workflow); err != nil {
+		return workflow, err
+	}
+
+	return workflow, nil
+}
+
+// 保存工作流，并在同一事务中为其创建版本记录。
+func (r *WorkflowRepository) SaveWithVersion(ctx context.Context, workflow *domain.Workflow, workflowVersion *domain.WorkflowVersion) (*domain.Workflow, error) {
+	if workflowVersion.Id != "" {
+		return workflow, fmt.Errorf("the workflow version is immutable")
+	}
+
+	err := app.GetApp().RunInTransaction(func(txApp core.App) error {
+		if err := r.save(txApp, workflow); err != nil {
+			return err
+		}
+
+		workflowVersion.WorkflowId = workflow.Id
+		return saveWorkflowVersionRecord(txApp, workflowVersion)
+	})
 	if err != nil {
 		return workflow, err
 	}
 
+	return workflow, nil
+}
+
+func (r *WorkflowRepository) save(txApp core.App, workflow *domain.Workflow) error {
+	collection, err := txApp.FindCollectionByNameOrId(domain.CollectionNameWorkflow)
+	if err != nil {
+		return err
+	}
+
 	var record *core.Record
 	if workflow.Id == "" {
 		record = core.NewRecord(collection)
 	} else {
-		record, err = app.GetApp().FindRecordById(collection, workflow.Id)
+		record, err = txApp.FindRecordById(collection, workflow.Id)
 		if err != nil {
 			if errors.Is(err, sql.ErrNoRows) {
-				return workflow, domain.ErrRecordNotFound
+				return domain.ErrRecordNotFound
 			}
-			return workflow, err
+			return err
 		}
 	}
 
@@ -78,24 +159,146 @@ func (r *WorkflowRepository) Save(ctx context.Context, workflow *domain.Workflow
 	record.Set("description", workflow.Description)
 	record.Set("trigger", workflow.Trigger.String())
 	record.Set("triggerCron", workflow.TriggerCron)
+	record.Set("triggerCronOptions", workflow.TriggerCronOptions)
+	record.Set("triggerEvent", workflow.TriggerEvent)
+	record.Set("webhookSecret", workflow.WebhookSecret)
+	record.Set("runTimeout", workflow.RunTimeout)
+	record.Set("concurrencyPolicy", workflow.ConcurrencyPolicy.String())
+	record.Set("mutexGroups", workflow.MutexGroups)
+	record.Set("priority", workflow.Priority)
+	record.Set("schedulingGroup", workflow.SchedulingGroup)
 	record.Set("enabled", workflow.Enabled)
 	record.Set("graphDraft", workflow.GraphDraft)
 	record.Set("graphContent", workflow.GraphContent)
 	record.Set("hasDraft", workflow.HasDraft)
 	record.Set("hasContent", workflow.HasContent)
+	record.Set("graphVersion", workflow.GraphVersion)
 	record.Set("lastRunRef", workflow.LastRunId)
 	record.Set("lastRunStatus", workflow.LastRunStatus.String())
 	record.Set("lastRunTime", workflow.LastRunTime)
-	if err := app.GetApp().Save(record); err != nil {
-		return workflow, err
+	if err := txApp.Save(record); err != nil {
+		return err
 	}
 
 	workflow.Id = record.Id
 	workflow.CreatedAt = record.GetDateTime("created").Time()
 	workflow.UpdatedAt = record.GetDateTime("updated").Time()
-	return workflow, nil
+	return nil
 }
 
+// 记录推迟的定时运行。已有推迟中的运行时不做处理，即与之合并。返回值表示是否记录成功。
+// 直接更新数据库而不经由记录的钩子，以免重新注册定时任务。
+func (r *WorkflowRepository) DeferRun(ctx context.Context, id string, runAt time.Time) (bool, error) {
+	res, err := app.GetDB().
+		Update(
+			domain.CollectionNameWorkflow,
+			dbx.Params{"deferredRunAt": formatDateTime(runAt)},
+			dbx.And(
+				dbx.HashExp{"id": id},
+				dbx.Or(dbx.HashExp{"deferredRunAt": ""}, dbx.HashExp{"deferredRunAt": nil}),
+			),
+		).
+		WithContext(ctx).
+		Execute()
+	if err != nil {
+		return false, err
+	}
+
+	affected, _ := res.RowsAffected()
+	return affected > 0, nil
+}
+
+// 认领推迟的定时运行并清除之。仅当记录的开始时间与之一致时认领成功，多个节点并发认领时仅有一个能成功。
+func (r *WorkflowRepository) ClaimDeferredRun(ctx context.Context, id string, runAt time.Time) (bool, error) {
+	res, err := app.GetDB().
+		Update(
+			domain.CollectionNameWorkflow,
+			dbx.Params{"deferredRunAt": ""},
+			dbx.HashExp{"id": id, "deferredRunAt": formatDateTime(runAt)},
+		).
+		WithContext(ctx).
+		Execute()
+	if err != nil {
+		return false, err
+	}
+
+	affected, _ := res.RowsAffected()
+	return affected > 0, nil
+}
+
+// 取消推迟的定时运行。
+func (r *WorkflowRepository) CancelDeferredRun(ctx context.Context, id string) error {
+	_, err := app.GetDB().
+		Update(
+			domain.CollectionNameWorkflow,
+			dbx.Params{"deferredRunAt": ""},
+			dbx.HashExp{"id": id},
+		).
+		WithContext(ctx).
+		Execute()
+	return err
+}
+
+// 记录证书即将过期事件已触发该工作流，以免同一证书在剩余天数内每天重复触发。
+// 仅当该证书（以其过期时间区分）尚未触发过时记录成功，记录与判断在同一语句中原子地完成。
+func (r *WorkflowRepository) MarkCertificateExpiringFired(ctx context.Context, id string, certificateId string, validityNotAfter time.Time) (bool, error) {
+	const firedExpr = workflowTriggerEventFiredExpr
+
+	params := dbx.Params{
+		"id":    id,
+		"path":  fmt.Sprintf("$.\"%s\"", certificateId),
+		"value": formatDateTime(validityNotAfter),
+	}
+	res, err := app.GetDB().
+		Update(
+			domain.CollectionNameWorkflow,
+			dbx.Params{"triggerEventFired": dbx.NewExp("json_set("+firedExpr+", {:path}, {:value})", params)},
+			dbx.And(
+				dbx.HashExp{"id": id},
+				dbx.NewExp("COALESCE(json_extract("+firedExpr+", {:path}), '')!={:value}", params),
+			),
+		).
+		WithContext(ctx).
+		Execute()
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected > 0, nil
+}
+
+// 清除证书即将过期事件已触发该工作流的记录，以便下次检查时重新触发。
+// 仅当记录的仍是该证书的同一过期时间时清除，用于触发后启动运行失败的情形。
+func (r *WorkflowRepository) ClearCertificateExpiringFired(ctx context.Context, id string, certificateId string, validityNotAfter time.Time) error {
+	const firedExpr = workflowTriggerEventFiredExpr
+
+	params := dbx.Params{
+		"id":    id,
+		"path":  fmt.Sprintf("$.\"%s\"", certificateId),
+		"value": formatDateTime(validityNotAfter),
+	}
+	_, err := app.GetDB().
+		Update(
+			domain.CollectionNameWorkflow,
+			dbx.Params{"triggerEventFired": dbx.NewExp("json_remove("+firedExpr+", {:path})", params)},
+			dbx.And(
+				dbx.HashExp{"id": id},
+				dbx.NewExp("COALESCE(json_extract("+firedExpr+", {:path}), '')={:value}", params),
+			),
+		).
+		WithContext(ctx).
+		Execute()
+	return err
+}
+
+// 工作流的 triggerEventFired 字段，字段值不是合法的 JSON 对象时视为空对象。
+const workflowTriggerEventFiredExpr = "(CASE WHEN json_valid([[triggerEventFired]]) THEN (CASE WHEN json_type([[triggerEventFired]])='object' THEN [[triggerEventFired]] ELSE '{}' END) ELSE '{}' END)"
+
 func (r *WorkflowRepository) castRecordToModel(record *core.Record) (*domain.Workflow, error) {
 	if record == nil {
 		return nil, fmt.Errorf("the record is nil")
@@ -111,24 +314,60 @@ func (r *WorkflowRepository) castRecordToModel(record *core.Record) (*domain.Wor
 		return nil, fmt.Errorf("field 'graphContent' is malformed")
 	}
 
+	var triggerCronOptions *domain.WorkflowTriggerCronOptions
+	if raw := record.GetString("triggerCronOptions"); raw != "" && raw != "null" {
+		triggerCronOptions = &domain.WorkflowTriggerCronOptions{}
+		if err := record.UnmarshalJSONField("triggerCronOptions", triggerCronOptions); err != nil {
+			return nil, fmt.Errorf("field 'triggerCronOptions' is malformed")
+		}
+	}
+
+	var triggerEvent *domain.WorkflowTriggerEventConfig
+	if raw := record.GetString("triggerEvent"); raw != "" && raw != "null" {
+		triggerEvent = &domain.WorkflowTriggerEventConfig{}
+		if err := record.UnmarshalJSONField("triggerEvent", triggerEvent); err != nil {
+			return nil, fmt.Errorf("field 'triggerEvent' is malformed")
+		}
+	}
+
+	mutexGroups := make([]string, 0)
+	if err := record.UnmarshalJSONField("mutexGroups", &mutexGroups); err != nil {
+		return nil, fmt.Errorf("field 'mutexGroups' is malformed")
+	}
+
 	workflow := &domain.Workflow{
 		Meta: domain.Meta{
 			Id:        record.Id,
 			CreatedAt: record.GetDateTime("created").Time(),
 			UpdatedAt: record.GetDateTime("updated").Time(),
 		},
-		Name:          record.GetString("name"),
-		Description:   record.GetString("description"),
-		Trigger:       domain.WorkflowTriggerType(record.GetString("trigger")),
-		TriggerCron:   record.GetString("triggerCron"),
-		Enabled:       record.GetBool("enabled"),
-		GraphDraft:    graphDraft,
-		GraphContent:  graphContent,
-		HasDraft:      record.GetBool("hasDraft"),
-		HasContent:    record.GetBool("hasContent"),
-		LastRunId:     record.GetString("lastRunRef"),
-		LastRunStatus: domain.WorkflowRunStatusType(record.GetString("lastRunStatus")),
-		LastRunTime:   record.GetDateTime("lastRunTime").Time(),
+		Name:               record.GetString("name"),
+		Description:        record.GetString("description"),
+		Trigger:            domain.WorkflowTriggerType(record.GetString("trigger")),
+		TriggerCron:        record.GetString("triggerCron"),
+		TriggerCronOptions: triggerCronOptions,
+		TriggerEvent:       triggerEvent,
+		WebhookSecret:      record.GetString("webhookSecret"),
+		RunTimeout:         record.GetInt("runTimeout"),
+		ConcurrencyPolicy:  domain.WorkflowConcurrencyPolicyType(record.GetString("concurrencyPolicy")),
+		MutexGroups:        mutexGroups,
+		Priority:           record.GetInt("priority"),
+		SchedulingGroup:    record.GetString("schedulingGroup"),
+		Enabled:            record.GetBool("enabled"),
+		GraphDraft:         graphDraft,
+		GraphContent:       graphContent,
+		HasDraft:           record.GetBool("hasDraft"),
+		HasContent:         record.GetBool("hasContent"),
+		GraphVersion:       record.GetInt("graphVersion"),
+		LastRunId:          record.GetString("lastRunRef"),
+		LastRunStatus:      domain.WorkflowRunStatusType(record.GetString("lastRunStatus")),
+		LastRunTime:        record.GetDateTime("lastRunTime").Time(),
+		DeferredRunAt:      record.GetDateTime("deferredRunAt").Time(),
 	}
 	return workflow, nil
 }
+
+func formatDateTime(t time.Time) string {
+	dt, _ := types.ParseDateTime(t)
+	return dt.String()
+}
@@ -70,6 +70,7 @@ func (r *WorkflowLogRepository) Save(ctx context.Context, workflowLog *domain.Wo
 	record.Set("level", workflowLog.Level)
 	record.Set("message", workflowLog.Message)
 	record.Set("data", workflowLog.Data)
+	record.Set("traceId", workflowLog.TraceId)
 	record.Set("created", workflowLog.CreatedAt)
 	err = app.GetApp().Save(record)
 	if err != nil {
@@ -107,6 +108,7 @@ func (r *WorkflowLogRepository) castRecordToModel(record *core.Record) (*domain.
 		Level:          int32(record.GetInt("level")),
 		Message:        record.GetString("message"),
 		Data:           logdata,
+		TraceId:        record.GetString("traceId"),
 	}
 	return workflowLog, nil
 }
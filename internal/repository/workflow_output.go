@@ -62,6 +62,31 @@ func (r *WorkflowOutputRepository) GetByWorkflowRunIdAndNodeId(ctx context.Conte
 	return r.castRecordToModel(records[0])
 }
 
+func (r *WorkflowOutputRepository) ListByWorkflowRunId(ctx context.Context, workflowRunId string) ([]*domain.WorkflowOutput, error) {
+	records, err := app.GetApp().FindRecordsByFilter(
+		domain.CollectionNameWorkflowOutput,
+		"runRef={:workflowRunId}",
+		"created",
+		0, 0,
+		dbx.Params{"workflowRunId": workflowRunId},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	workflowOutputs := make([]*domain.WorkflowOutput, 0)
+	for _, record := range records {
+		workflowOutput, err := r.castRecordToModel(record)
+		if err != nil {
+			return nil, err
+		}
+
+		workflowOutputs = append(workflowOutputs, workflowOutput)
+	}
+
+	return workflowOutputs, nil
+}
+
 func (r *WorkflowOutputRepository) Save(ctx context.Context, workflowOutput *domain.WorkflowOutput) (*domain.WorkflowOutput, error) {
 	record, err := r.saveRecord(workflowOutput)
 	if err != nil {
@@ -5,11 +5,13 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/certimate-go/certimate/internal/app"
 	"github.com/certimate-go/certimate/internal/domain"
 	"github.com/pocketbase/dbx"
 	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/types"
 )
 
 type WorkflowRunRepository struct{}
@@ -18,6 +20,62 @@ func NewWorkflowRunRepository() *WorkflowRunRepository {
 	return &WorkflowRunRepository{}
 }
 
+func (r *WorkflowRunRepository) ListByStatus(ctx context.Context, status domain.WorkflowRunStatusType) ([]*domain.WorkflowRun, error) {
+	records, err := app.GetApp().FindRecordsByFilter(
+		domain.CollectionNameWorkflowRun,
+		"status={:status}",
+		"created",
+		0, 0,
+		dbx.Params{"status": status.String()},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	workflowRuns := make([]*domain.WorkflowRun, 0)
+	for _, record := range records {
+		workflowRun, err := r.castRecordToModel(record)
+		if err != nil {
+			return nil, err
+		}
+
+		workflowRuns = append(workflowRuns, workflowRun)
+	}
+
+	return workflowRuns, nil
+}
+
+// 列出指定工作流中尚未结束的运行，包括等待调度、执行中（含由其他节点执行）及等待审批的运行。
+func (r *WorkflowRunRepository) ListActiveByWorkflowId(ctx context.Context, workflowId string) ([]*domain.WorkflowRun, error) {
+	records, err := app.GetApp().FindRecordsByFilter(
+		domain.CollectionNameWorkflowRun,
+		"workflowRef={:workflowId} && (status={:pending} || status={:processing} || status={:waiting})",
+		"created",
+		0, 0,
+		dbx.Params{
+			"workflowId": workflowId,
+			"pending":    domain.WorkflowRunStatusTypePending.String(),
+			"processing": domain.WorkflowRunStatusTypeProcessing.String(),
+			"waiting":    domain.WorkflowRunStatusTypeWaiting.String(),
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	workflowRuns := make([]*domain.WorkflowRun, 0)
+	for _, record := range records {
+		workflowRun, err := r.castRecordToModel(record)
+		if err != nil {
+			return nil, err
+		}
+
+		workflowRuns = append(workflowRuns, workflowRun)
+	}
+
+	return workflowRuns, nil
+}
+
 func (r *WorkflowRunRepository) GetById(ctx context.Context, id string) (*domain.WorkflowRun, error) {
 	record, err := app.GetApp().FindRecordById(domain.CollectionNameWorkflowRun, id)
 	if err != nil {
@@ -50,11 +108,16 @@ func (r *WorkflowRunRepository) Save(ctx context.Context, workflowRun *domain.Wo
 	}
 
 	record.Set("workflowRef", workflowRun.WorkflowId)
+	record.Set("parentRunRef", workflowRun.ParentRunId)
 	record.Set("trigger", workflowRun.Trigger.String())
 	record.Set("status", workflowRun.Status.String())
 	record.Set("startedAt", workflowRun.StartedAt)
 	record.Set("endedAt", workflowRun.EndedAt)
 	record.Set("graph", workflowRun.Graph)
+	record.Set("graphVersion", workflowRun.GraphVersion)
+	record.Set("inputs", workflowRun.Inputs)
+	record.Set("state", workflowRun.State)
+	record.Set("attempt", workflowRun.Attempt)
 	record.Set("error", workflowRun.Error)
 	err = app.GetApp().Save(record)
 	if err != nil {
@@ -87,51 +150,107 @@ func (r *WorkflowRunRepository) SaveWithCascading(ctx context.Context, workflowR
 	}
 
 	err = app.GetApp().RunInTransaction(func(txApp core.App) error {
-		record.Set("workflowRef", workflowRun.WorkflowId)
-		record.Set("trigger", workflowRun.Trigger.String())
-		record.Set("status", workflowRun.Status.String())
-		record.Set("startedAt", workflowRun.StartedAt)
-		record.Set("endedAt", workflowRun.EndedAt)
-		record.Set("graph", workflowRun.Graph)
-		record.Set("error", workflowRun.Error)
-		err = txApp.Save(record)
+		return r.saveWithCascading(txApp, record, workflowRun)
+	})
+	if err != nil {
+		return workflowRun, err
+	}
+
+	return workflowRun, nil
+}
+
+// 仅当运行仍处于指定状态、且自读取后未被修改时保存之，并级联更新所属工作流的最后运行记录。
+// 条件判断与保存在同一事务中完成，多个节点并发保存同一运行时至多只有一个能成功。
+func (r *WorkflowRunRepository) SaveWithCascadingIfUnchanged(ctx context.Context, workflowRun *domain.WorkflowRun, status domain.WorkflowRunStatusType) (bool, error) {
+	if workflowRun.Id == "" {
+		return false, fmt.Errorf("the workflow run id is empty")
+	}
+
+	updated, err := types.ParseDateTime(workflowRun.UpdatedAt)
+	if err != nil {
+		return false, err
+	}
+
+	saved := false
+	err = app.GetApp().RunInTransaction(func(txApp core.App) error {
+		record, err := txApp.FindRecordById(domain.CollectionNameWorkflowRun, workflowRun.Id)
 		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return domain.ErrRecordNotFound
+			}
 			return err
 		}
 
-		workflowRun.Id = record.Id
-		workflowRun.CreatedAt = record.GetDateTime("created").Time()
-		workflowRun.UpdatedAt = record.GetDateTime("updated").Time()
-
-		// 事务级联更新所属工作流的最后运行记录
-		workflowRecord, err := txApp.FindRecordById(domain.CollectionNameWorkflow, workflowRun.WorkflowId)
+		// 以条件更新判断运行是否未被修改，同时取得数据库的写锁，以免与其他事务交错
+		res, err := txApp.DB().
+			Update(
+				domain.CollectionNameWorkflowRun,
+				dbx.Params{"status": status.String()},
+				dbx.HashExp{"id": workflowRun.Id, "status": status.String(), "updated": updated.String()},
+			).
+			WithContext(ctx).
+			Execute()
 		if err != nil {
 			return err
-		} else if workflowRun.Id == workflowRecord.GetString("lastRunRef") {
-			workflowRecord.IgnoreUnchangedFields(true)
-			workflowRecord.Set("lastRunStatus", record.GetString("status"))
-			err = txApp.Save(workflowRecord)
-			if err != nil {
-				return err
-			}
-		} else if workflowRecord.GetDateTime("lastRunTime").Time().IsZero() || workflowRun.StartedAt.After(workflowRecord.GetDateTime("lastRunTime").Time()) {
-			workflowRecord.IgnoreUnchangedFields(true)
-			workflowRecord.Set("lastRunRef", record.Id)
-			workflowRecord.Set("lastRunStatus", record.GetString("status"))
-			workflowRecord.Set("lastRunTime", record.GetString("startedAt"))
-			err = txApp.Save(workflowRecord)
-			if err != nil {
-				return err
-			}
+		} else if affected, _ := res.RowsAffected(); affected == 0 {
+			return nil
 		}
 
-		return nil
+		saved = true
+		return r.saveWithCascading(txApp, record, workflowRun)
 	})
 	if err != nil {
-		return workflowRun, err
+		return false, err
 	}
 
-	return workflowRun, nil
+	return saved, nil
+}
+
+func (r *WorkflowRunRepository) saveWithCascading(txApp core.App, record *core.Record, workflowRun *domain.WorkflowRun) error {
+	record.Set("workflowRef", workflowRun.WorkflowId)
+	record.Set("parentRunRef", workflowRun.ParentRunId)
+	record.Set("trigger", workflowRun.Trigger.String())
+	record.Set("status", workflowRun.Status.String())
+	record.Set("startedAt", workflowRun.StartedAt)
+	record.Set("endedAt", workflowRun.EndedAt)
+	record.Set("graph", workflowRun.Graph)
+	record.Set("graphVersion", workflowRun.GraphVersion)
+	record.Set("inputs", workflowRun.Inputs)
+	record.Set("state", workflowRun.State)
+	record.Set("attempt", workflowRun.Attempt)
+	record.Set("error", workflowRun.Error)
+	err := txApp.Save(record)
+	if err != nil {
+		return err
+	}
+
+	workflowRun.Id = record.Id
+	workflowRun.CreatedAt = record.GetDateTime("created").Time()
+	workflowRun.UpdatedAt = record.GetDateTime("updated").Time()
+
+	// 事务级联更新所属工作流的最后运行记录
+	workflowRecord, err := txApp.FindRecordById(domain.CollectionNameWorkflow, workflowRun.WorkflowId)
+	if err != nil {
+		return err
+	} else if workflowRun.Id == workflowRecord.GetString("lastRunRef") {
+		workflowRecord.IgnoreUnchangedFields(true)
+		workflowRecord.Set("lastRunStatus", record.GetString("status"))
+		err = txApp.Save(workflowRecord)
+		if err != nil {
+			return err
+		}
+	} else if workflowRecord.GetDateTime("lastRunTime").Time().IsZero() || workflowRun.StartedAt.After(workflowRecord.GetDateTime("lastRunTime").Time()) {
+		workflowRecord.IgnoreUnchangedFields(true)
+		workflowRecord.Set("lastRunRef", record.Id)
+		workflowRecord.Set("lastRunStatus", record.GetString("status"))
+		workflowRecord.Set("lastRunTime", record.GetString("startedAt"))
+		err = txApp.Save(workflowRecord)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
 func (r *WorkflowRunRepository) DeleteWithExprs(ctx context.Context, exprs ...dbx.Expression) (int, error) {
@@ -157,36 +276,69 @@ func (r *WorkflowRunRepository) DeleteWithExprs(ctx context.Context, exprs ...db
 	return ret, nil
 }
 
-func (r *WorkflowRunRepository) ResetStatusIfHanging(ctx context.Context) error {
-	return app.GetApp().RunInTransaction(func(txApp core.App) error {
-		var err error
-
-		_, err = txApp.DB().
-			NewQuery(fmt.Sprintf("UPDATE %s SET lastRunStatus = '%s' WHERE lastRunStatus = '%s' OR lastRunStatus = '%s'",
-				domain.CollectionNameWorkflow,
-				domain.WorkflowRunStatusTypeCanceled.String(),
-				domain.WorkflowRunStatusTypePending.String(),
-				domain.WorkflowRunStatusTypeProcessing.String(),
-			)).
-			Execute()
-		if err != nil {
-			return err
-		}
+// 以租约的形式认领运行，仅当运行处于指定状态、且未被其他节点持有有效租约时认领成功。
+// 可额外传入条件表达式，认领与条件判断在同一语句中原子地完成。
+// 认领相关字段仅由此方法及 RenewLease 写入，Save 与 SaveWithCascading 不会覆盖之。
+func (r *WorkflowRunRepository) Claim(ctx context.Context, runId string, status domain.WorkflowRunStatusType, nodeId string, leaseTTL time.Duration, exprs ...dbx.Expression) (bool, error) {
+	now := types.NowDateTime()
 
-		_, err = txApp.DB().
-			NewQuery(fmt.Sprintf("UPDATE %s SET lastRunStatus = '%s' WHERE lastRunStatus = '%s' OR lastRunStatus = '%s'",
-				domain.CollectionNameWorkflow,
-				domain.WorkflowRunStatusTypeCanceled.String(),
-				domain.WorkflowRunStatusTypePending.String(),
-				domain.WorkflowRunStatusTypeProcessing.String(),
-			)).
-			Execute()
-		if err != nil {
-			return err
-		}
+	conds := []dbx.Expression{
+		dbx.HashExp{"id": runId, "status": status.String()},
+		dbx.Or(
+			dbx.HashExp{"claimedBy": ""},
+			dbx.HashExp{"claimedBy": nodeId},
+			dbx.NewExp("leaseExpiresAt<{:now}", dbx.Params{"now": now.String()}),
+		),
+	}
+	conds = append(conds, exprs...)
 
-		return nil
-	})
+	res, err := app.GetDB().
+		Update(
+			domain.CollectionNameWorkflowRun,
+			dbx.Params{
+				"claimedBy":      nodeId,
+				"leaseExpiresAt": now.Add(leaseTTL).String(),
+			},
+			dbx.And(conds...),
+		).
+		WithContext(ctx).
+		Execute()
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected > 0, nil
+}
+
+// 续期由该节点认领的运行的租约。
+// 运行已结束、已被取消或已被其他节点接管时续期失败。
+func (r *WorkflowRunRepository) RenewLease(ctx context.Context, runId string, nodeId string, leaseTTL time.Duration) (bool, error) {
+	res, err := app.GetDB().
+		Update(
+			domain.CollectionNameWorkflowRun,
+			dbx.Params{"leaseExpiresAt": types.NowDateTime().Add(leaseTTL).String()},
+			dbx.And(
+				dbx.HashExp{"id": runId, "claimedBy": nodeId},
+				dbx.In("status", domain.WorkflowRunStatusTypePending.String(), domain.WorkflowRunStatusTypeProcessing.String()),
+			),
+		).
+		WithContext(ctx).
+		Execute()
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected > 0, nil
 }
 
 func (r *WorkflowRunRepository) castRecordToModel(record *core.Record) (*domain.WorkflowRun, error) {
@@ -199,19 +351,36 @@ func (r *WorkflowRunRepository) castRecordToModel(record *core.Record) (*domain.
 		return nil, fmt.Errorf("field 'graph' is malformed")
 	}
 
+	inputs := make(map[string]any)
+	if err := record.UnmarshalJSONField("inputs", &inputs); err != nil {
+		return nil, fmt.Errorf("field 'inputs' is malformed")
+	}
+
+	var state *domain.WorkflowRunState
+	if err := record.UnmarshalJSONField("state", &state); err != nil {
+		return nil, fmt.Errorf("field 'state' is malformed")
+	}
+
 	workflowRun := &domain.WorkflowRun{
 		Meta: domain.Meta{
 			Id:        record.Id,
 			CreatedAt: record.GetDateTime("created").Time(),
 			UpdatedAt: record.GetDateTime("updated").Time(),
 		},
-		WorkflowId: record.GetString("workflowRef"),
-		Status:     domain.WorkflowRunStatusType(record.GetString("status")),
-		Trigger:    domain.WorkflowTriggerType(record.GetString("trigger")),
-		StartedAt:  record.GetDateTime("startedAt").Time(),
-		EndedAt:    record.GetDateTime("endedAt").Time(),
-		Graph:      graph,
-		Error:      record.GetString("error"),
+		WorkflowId:     record.GetString("workflowRef"),
+		ParentRunId:    record.GetString("parentRunRef"),
+		Status:         domain.WorkflowRunStatusType(record.GetString("status")),
+		Trigger:        domain.WorkflowTriggerType(record.GetString("trigger")),
+		StartedAt:      record.GetDateTime("startedAt").Time(),
+		EndedAt:        record.GetDateTime("endedAt").Time(),
+		Graph:          graph,
+		GraphVersion:   record.GetInt("graphVersion"),
+		Inputs:         inputs,
+		State:          state,
+		Attempt:        record.GetInt("attempt"),
+		Error:          record.GetString("error"),
+		ClaimedBy:      record.GetString("claimedBy"),
+		LeaseExpiresAt: record.GetDateTime("leaseExpiresAt").Time(),
 	}
 	return workflowRun, nil
 }
@@ -0,0 +1,126 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/certimate-go/certimate/internal/app"
+	"github.com/certimate-go/certimate/internal/domain"
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+type WorkflowVersionRepository struct{}
+
+func NewWorkflowVersionRepository() *WorkflowVersionRepository {
+	return &WorkflowVersionRepository{}
+}
+
+func (r *WorkflowVersionRepository) ListByWorkflowId(ctx context.Context, workflowId string) ([]*domain.WorkflowVersion, error) {
+	records, err := app.GetApp().FindRecordsByFilter(
+		domain.CollectionNameWorkflowVersion,
+		"workflowRef={:workflowId}",
+		"-version",
+		0, 0,
+		dbx.Params{"workflowId": workflowId},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	workflowVersions := make([]*domain.WorkflowVersion, 0)
+	for _, record := range records {
+		workflowVersion, err := r.castRecordToModel(record)
+		if err != nil {
+			return nil, err
+		}
+
+		workflowVersions = append(workflowVersions, workflowVersion)
+	}
+
+	return workflowVersions, nil
+}
+
+func (r *WorkflowVersionRepository) GetByWorkflowIdAndVersion(ctx context.Context, workflowId string, version int) (*domain.WorkflowVersion, error) {
+	records, err := app.GetApp().FindRecordsByFilter(
+		domain.CollectionNameWorkflowVersion,
+		"workflowRef={:workflowId} && version={:version}",
+		"-created",
+		1, 0,
+		dbx.Params{"workflowId": workflowId},
+		dbx.Params{"version": version},
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrRecordNotFound
+		}
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, domain.ErrRecordNotFound
+	}
+
+	return r.castRecordToModel(records[0])
+}
+
+func (r *WorkflowVersionRepository) Save(ctx context.Context, workflowVersion *domain.WorkflowVersion) (*domain.WorkflowVersion, error) {
+	// 版本一经创建即不可变更
+	if workflowVersion.Id != "" {
+		return workflowVersion, fmt.Errorf("the workflow version is immutable")
+	}
+
+	if err := saveWorkflowVersionRecord(app.GetApp(), workflowVersion); err != nil {
+		return workflowVersion, err
+	}
+
+	return workflowVersion, nil
+}
+
+func saveWorkflowVersionRecord(txApp core.App, workflowVersion *domain.WorkflowVersion) error {
+	collection, err := txApp.FindCollectionByNameOrId(domain.CollectionNameWorkflowVersion)
+	if err != nil {
+		return err
+	}
+
+	record := core.NewRecord(collection)
+	record.Set("workflowRef", workflowVersion.WorkflowId)
+	record.Set("version", workflowVersion.Version)
+	record.Set("graph", workflowVersion.Graph)
+	record.Set("author", workflowVersion.Author)
+	record.Set("note", workflowVersion.Note)
+	if err := txApp.Save(record); err != nil {
+		return err
+	}
+
+	workflowVersion.Id = record.Id
+	workflowVersion.CreatedAt = record.GetDateTime("created").Time()
+	workflowVersion.UpdatedAt = record.GetDateTime("updated").Time()
+	return nil
+}
+
+func (r *WorkflowVersionRepository) castRecordToModel(record *core.Record) (*domain.WorkflowVersion, error) {
+	if record == nil {
+		return nil, fmt.Errorf("the record is nil")
+	}
+
+	graph := &domain.WorkflowGraph{}
+	if err := record.UnmarshalJSONField("graph", graph); err != nil {
+		return nil, fmt.Errorf("field 'graph' is malformed")
+	}
+
+	workflowVersion := &domain.WorkflowVersion{
+		Meta: domain.Meta{
+			Id:        record.Id,
+			CreatedAt: record.GetDateTime("created").Time(),
+			UpdatedAt: record.GetDateTime("updated").Time(),
+		},
+		WorkflowId: record.GetString("workflowRef"),
+		Version:    record.GetInt("version"),
+		Graph:      graph,
+		Author:     record.GetString("author"),
+		Note:       record.GetString("note"),
+	}
+	return workflowVersion, nil
+}
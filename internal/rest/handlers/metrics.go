@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"strings"
+
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/router"
+
+	"github.com/certimate-go/certimate/internal/metrics"
+	xenv "github.com/certimate-go/certimate/pkg/utils/env"
+)
+
+type MetricsHandler struct {
+	token string
+}
+
+// 指标采集端点。
+// 设置了环境变量 CERTIMATE_METRICS_TOKEN 时，须以之作为 Bearer 令牌访问；否则须超级管理员认证。
+func NewMetricsHandler(router *router.RouterGroup[*core.RequestEvent]) {
+	handler := &MetricsHandler{
+		token: xenv.GetOrDefaultString("CERTIMATE_METRICS_TOKEN", ""),
+	}
+
+	route := router.GET("/metrics", handler.getMetrics)
+	if handler.token != "" {
+		route.BindFunc(handler.requireToken)
+	} else {
+		route.Bind(apis.RequireSuperuserAuth())
+	}
+}
+
+func (handler *MetricsHandler) getMetrics(e *core.RequestEvent) error {
+	metrics.Handler().ServeHTTP(e.Response, e.Request)
+	return nil
+}
+
+func (handler *MetricsHandler) requireToken(e *core.RequestEvent) error {
+	token, ok := strings.CutPrefix(e.Request.Header.Get("Authorization"), "Bearer ")
+	if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(handler.token)) != 1 {
+		return e.UnauthorizedError("The request requires a valid metrics token.", nil)
+	}
+
+	return e.Next()
+}
@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pocketbase/pocketbase/tools/router"
+)
+
+func TestMetricsHandlerRequireToken(t *testing.T) {
+	tests := []struct {
+		name    string
+		header  string
+		wantErr bool
+	}{
+		{
+			name:   "valid token",
+			header: "Bearer s3cr3t",
+		},
+		{
+			name:    "invalid token",
+			header:  "Bearer s3cr3",
+			wantErr: true,
+		},
+		{
+			name:    "token with another scheme",
+			header:  "Basic s3cr3t",
+			wantErr: true,
+		},
+		{
+			name:    "no token",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := &MetricsHandler{token: "s3cr3t"}
+
+			req := httptest.NewRequest(http.MethodGet, "/api/metrics", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			e, _ := newTestRequestEvent(req)
+			err := handler.requireToken(e)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("requireToken() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			var apiErr *router.ApiError
+			if err != nil && (!errors.As(err, &apiErr) || apiErr.Status != http.StatusUnauthorized) {
+				t.Errorf("requireToken() error = %v, want an unauthorized error", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"context"
+	"html/template"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/router"
+
+	"github.com/certimate-go/certimate/internal/domain"
+	"github.com/certimate-go/certimate/internal/domain/dtos"
+	"github.com/certimate-go/certimate/internal/rest/resp"
+)
+
+const webhookPayloadMaxSize = 1 << 20
+
+type webhookService interface {
+	TriggerWebhook(ctx context.Context, req *dtos.WorkflowTriggerWebhookReq) (*dtos.WorkflowTriggerWebhookResp, error)
+	DecideApprovalByToken(ctx context.Context, req *dtos.WorkflowDecideApprovalReq) (*dtos.WorkflowDecideApprovalResp, error)
+}
+
+type WebhooksHandler struct {
+	service webhookService
+}
+
+func NewWebhooksHandler(router *router.RouterGroup[*core.RequestEvent], service webhookService) {
+	handler := &WebhooksHandler{
+		service: service,
+	}
+
+	group := router.Group("/webhooks")
+	group.POST("/workflows/{workflowId}", handler.triggerWorkflow)
+	// 审批链接会被邮件安全扫描、聊天软件链接预览、浏览器预加载等自动访问，
+	// 因此 GET 请求仅渲染确认页面，只有 POST 请求才会提交审批结果
+	group.GET("/approvals/{runId}", handler.confirmApproval)
+	group.POST("/approvals/{runId}/approve", handler.decideApproval(domain.WorkflowApprovalDecisionTypeApproved))
+	group.POST("/approvals/{runId}/reject", handler.decideApproval(domain.WorkflowApprovalDecisionTypeRejected))
+}
+
+func (handler *WebhooksHandler) triggerWorkflow(e *core.RequestEvent) error {
+	req := &dtos.WorkflowTriggerWebhookReq{}
+	req.WorkflowId = e.Request.PathValue("workflowId")
+	// 仅从请求标头中读取令牌，以免其随网址被记录在访问日志、代理日志中
+	if auth := e.Request.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+		req.Token = strings.TrimPrefix(auth, "Bearer ")
+	}
+	req.Signature = e.Request.Header.Get("X-Certimate-Signature-256")
+	req.Timestamp = e.Request.Header.Get("X-Certimate-Timestamp")
+
+	payload, err := io.ReadAll(io.LimitReader(e.Request.Body, webhookPayloadMaxSize+1))
+	if err != nil {
+		return resp.Err(e, err)
+	} else if len(payload) > webhookPayloadMaxSize {
+		return resp.Err(e, domain.NewError(413, "the webhook payload is too large"))
+	}
+	req.Payload = payload
+
+	res, err := handler.service.TriggerWebhook(e.Request.Context(), req)
+	if err != nil {
+		return resp.Err(e, err)
+	}
+
+	return resp.Ok(e, res)
+}
+
+func (handler *WebhooksHandler) confirmApproval(e *core.RequestEvent) error {
+	runId := e.Request.PathValue("runId")
+
+	// 确认页面不校验令牌，以免泄露运行状态；令牌将随表单提交
+	return renderApprovalPage(e, approvalPageData{
+		RunId:      runId,
+		Token:      e.Request.URL.Query().Get("token"),
+		ApproveUrl: "/api/webhooks/approvals/" + url.PathEscape(runId) + "/approve",
+		RejectUrl:  "/api/webhooks/approvals/" + url.PathEscape(runId) + "/reject",
+	})
+}
+
+func (handler *WebhooksHandler) decideApproval(decision domain.WorkflowApprovalDecisionType) func(e *core.RequestEvent) error {
+	return func(e *core.RequestEvent) error {
+		req := &dtos.WorkflowDecideApprovalReq{}
+		req.RunId = e.Request.PathValue("runId")
+		// 确认页面以表单提交令牌，其他客户端亦可在请求标头中携带之；令牌仅对单个审批请求有效且用后即失效
+		req.Token = e.Request.FormValue("token")
+		if auth := e.Request.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+			req.Token = strings.TrimPrefix(auth, "Bearer ")
+		}
+		req.Decision = decision
+		req.Decider = "approval link"
+		req.Comment = e.Request.FormValue("comment")
+
+		res, err := handler.service.DecideApprovalByToken(e.Request.Context(), req)
+
+		// 由确认页面提交时，以页面展示审批结果
+		if mediaType, _, _ := mime.ParseMediaType(e.Request.Header.Get("Content-Type")); mediaType == "application/x-www-form-urlencoded" {
+			data := approvalPageData{RunId: req.RunId, Decision: string(decision)}
+			if err != nil {
+				data.Error = err.Error()
+			}
+			return renderApprovalPage(e, data)
+		}
+
+		if err != nil {
+			return resp.Err(e, err)
+		}
+
+		return resp.Ok(e, res)
+	}
+}
+
+type approvalPageData struct {
+	RunId      string
+	Token      string
+	ApproveUrl string
+	RejectUrl  string
+	Decision   string
+	Error      string
+}
+
+var approvalPageTemplate = template.Must(template.New("approval").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta name="robots" content="noindex, nofollow">
+<title>Certimate - Approval</title>
+</head>
+<body>
+<h1>Workflow run #{{ .RunId }}</h1>
+{{- if .Error }}
+<p>Failed to submit the decision: {{ .Error }}</p>
+{{- else if .Decision }}
+<p>The run has been {{ .Decision }}.</p>
+{{- else }}
+<p>This workflow run is waiting for your approval.</p>
+<form method="post">
+<input type="hidden" name="token" value="{{ .Token }}">
+<p><textarea name="comment" rows="4" cols="50" placeholder="Comment (optional)"></textarea></p>
+<p>
+<button type="submit" formaction="{{ .ApproveUrl }}">Approve</button>
+<button type="submit" formaction="{{ .RejectUrl }}">Reject</button>
+</p>
+</form>
+{{- end }}
+</body>
+</html>
+`))
+
+func renderApprovalPage(e *core.RequestEvent, data approvalPageData) error {
+	var sb strings.Builder
+	if err := approvalPageTemplate.Execute(&sb, data); err != nil {
+		return err
+	}
+
+	e.Response.Header().Set("Cache-Control", "no-store")
+	e.Response.Header().Set("Referrer-Policy", "no-referrer")
+	return e.HTML(http.StatusOK, sb.String())
+}
@@ -0,0 +1,271 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/pocketbase/pocketbase/core"
+
+	"github.com/certimate-go/certimate/internal/domain"
+	"github.com/certimate-go/certimate/internal/domain/dtos"
+	"github.com/certimate-go/certimate/internal/rest/resp"
+)
+
+type testWebhookService struct {
+	triggerReqs  []*dtos.WorkflowTriggerWebhookReq
+	decisionReqs []*dtos.WorkflowDecideApprovalReq
+	decisionErr  error
+}
+
+func (s *testWebhookService) TriggerWebhook(ctx context.Context, req *dtos.WorkflowTriggerWebhookReq) (*dtos.WorkflowTriggerWebhookResp, error) {
+	s.triggerReqs = append(s.triggerReqs, req)
+	return &dtos.WorkflowTriggerWebhookResp{RunId: "run1"}, nil
+}
+
+func (s *testWebhookService) DecideApprovalByToken(ctx context.Context, req *dtos.WorkflowDecideApprovalReq) (*dtos.WorkflowDecideApprovalResp, error) {
+	s.decisionReqs = append(s.decisionReqs, req)
+	if s.decisionErr != nil {
+		return nil, s.decisionErr
+	}
+
+	return &dtos.WorkflowDecideApprovalResp{RunId: req.RunId}, nil
+}
+
+func newTestRequestEvent(req *http.Request) (*core.RequestEvent, *httptest.ResponseRecorder) {
+	rec := httptest.NewRecorder()
+	e := &core.RequestEvent{}
+	e.Request = req
+	e.Response = rec
+	return e, rec
+}
+
+func TestWebhooksHandlerTriggerWorkflow(t *testing.T) {
+	tests := []struct {
+		name          string
+		url           string
+		header        map[string]string
+		body          string
+		wantCode      int
+		wantToken     string
+		wantSignature string
+		wantTimestamp string
+	}{
+		{
+			name:      "bearer token",
+			url:       "/api/webhooks/workflows/wf1",
+			header:    map[string]string{"Authorization": "Bearer s3cr3t"},
+			body:      `{"domain":"example.com"}`,
+			wantToken: "s3cr3t",
+		},
+		{
+			name:   "token in query",
+			url:    "/api/webhooks/workflows/wf1?token=s3cr3t",
+			header: map[string]string{"Authorization": "Basic s3cr3t"},
+			body:   `{"domain":"example.com"}`,
+		},
+		{
+			name:          "signature",
+			url:           "/api/webhooks/workflows/wf1",
+			header:        map[string]string{"X-Certimate-Signature-256": "sha256=abc", "X-Certimate-Timestamp": "1767225600"},
+			body:          `{"domain":"example.com"}`,
+			wantSignature: "sha256=abc",
+			wantTimestamp: "1767225600",
+		},
+		{
+			name:     "payload too large",
+			url:      "/api/webhooks/workflows/wf1",
+			header:   map[string]string{"Authorization": "Bearer s3cr3t"},
+			body:     strings.Repeat("a", webhookPayloadMaxSize+1),
+			wantCode: 413,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &testWebhookService{}
+			handler := &WebhooksHandler{service: service}
+
+			req := httptest.NewRequest(http.MethodPost, tt.url, strings.NewReader(tt.body))
+			req.SetPathValue("workflowId", "wf1")
+			for key, value := range tt.header {
+				req.Header.Set(key, value)
+			}
+			e, rec := newTestRequestEvent(req)
+			if err := handler.triggerWorkflow(e); err != nil {
+				t.Fatalf("triggerWorkflow() error = %v", err)
+			}
+
+			res := &resp.Response{}
+			if err := json.Unmarshal(rec.Body.Bytes(), res); err != nil {
+				t.Fatalf("failed to unmarshal response: %v", err)
+			}
+			if res.Code != tt.wantCode {
+				t.Fatalf("triggerWorkflow() code = %d, want %d", res.Code, tt.wantCode)
+			}
+			if tt.wantCode != 0 {
+				if len(service.triggerReqs) != 0 {
+					t.Errorf("triggerWorkflow() should not trigger the workflow")
+				}
+				return
+			}
+
+			if len(service.triggerReqs) != 1 {
+				t.Fatalf("triggerWorkflow() triggered %d times, want 1", len(service.triggerReqs))
+			}
+			triggerReq := service.triggerReqs[0]
+			if triggerReq.WorkflowId != "wf1" {
+				t.Errorf("triggerWorkflow() workflow id = %s, want %s", triggerReq.WorkflowId, "wf1")
+			}
+			if triggerReq.Token != tt.wantToken {
+				t.Errorf("triggerWorkflow() token = '%s', want '%s'", triggerReq.Token, tt.wantToken)
+			}
+			if triggerReq.Signature != tt.wantSignature {
+				t.Errorf("triggerWorkflow() signature = '%s', want '%s'", triggerReq.Signature, tt.wantSignature)
+			}
+			if triggerReq.Timestamp != tt.wantTimestamp {
+				t.Errorf("triggerWorkflow() timestamp = '%s', want '%s'", triggerReq.Timestamp, tt.wantTimestamp)
+			}
+			if string(triggerReq.Payload) != tt.body {
+				t.Errorf("triggerWorkflow() payload = '%s', want '%s'", triggerReq.Payload, tt.body)
+			}
+		})
+	}
+}
+
+func TestWebhooksHandlerConfirmApproval(t *testing.T) {
+	service := &testWebhookService{}
+	handler := &WebhooksHandler{service: service}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/webhooks/approvals/run1?token=%22%3E%3Cscript%3E", nil)
+	req.SetPathValue("runId", "run1")
+	e, rec := newTestRequestEvent(req)
+	if err := handler.confirmApproval(e); err != nil {
+		t.Fatalf("confirmApproval() error = %v", err)
+	}
+
+	// 访问审批链接不应提交审批结果
+	if len(service.decisionReqs) != 0 {
+		t.Errorf("confirmApproval() should not decide the approval")
+	}
+
+	if rec.Header().Get("Cache-Control") != "no-store" {
+		t.Errorf("confirmApproval() Cache-Control = '%s', want 'no-store'", rec.Header().Get("Cache-Control"))
+	}
+	body := rec.Body.String()
+	for _, want := range []string{
+		`<form method="post">`,
+		`formaction="/api/webhooks/approvals/run1/approve"`,
+		`formaction="/api/webhooks/approvals/run1/reject"`,
+		`name="token" value="&#34;&gt;&lt;script&gt;"`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("confirmApproval() body does not contain '%s'", want)
+		}
+	}
+}
+
+func TestWebhooksHandlerDecideApproval(t *testing.T) {
+	tests := []struct {
+		name         string
+		decision     domain.WorkflowApprovalDecisionType
+		form         url.Values
+		header       map[string]string
+		decisionErr  error
+		wantHTML     bool
+		wantCode     int
+		wantToken    string
+		wantComment  string
+		wantContains string
+	}{
+		{
+			name:         "approve by form",
+			decision:     domain.WorkflowApprovalDecisionTypeApproved,
+			form:         url.Values{"token": {"t1"}, "comment": {"LGTM"}},
+			wantHTML:     true,
+			wantToken:    "t1",
+			wantComment:  "LGTM",
+			wantContains: "The run has been approved.",
+		},
+		{
+			name:         "reject by form with invalid token",
+			decision:     domain.WorkflowApprovalDecisionTypeRejected,
+			form:         url.Values{"token": {"t0"}},
+			decisionErr:  domain.ErrUnauthorized,
+			wantHTML:     true,
+			wantToken:    "t0",
+			wantContains: "Failed to submit the decision",
+		},
+		{
+			name:      "approve by bearer token",
+			decision:  domain.WorkflowApprovalDecisionTypeApproved,
+			header:    map[string]string{"Authorization": "Bearer t1"},
+			wantToken: "t1",
+		},
+		{
+			name:        "reject by bearer token with invalid token",
+			decision:    domain.WorkflowApprovalDecisionTypeRejected,
+			header:      map[string]string{"Authorization": "Bearer t0"},
+			decisionErr: domain.ErrUnauthorized,
+			wantCode:    401,
+			wantToken:   "t0",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &testWebhookService{decisionErr: tt.decisionErr}
+			handler := &WebhooksHandler{service: service}
+
+			var req *http.Request
+			if tt.form != nil {
+				req = httptest.NewRequest(http.MethodPost, "/api/webhooks/approvals/run1/"+string(tt.decision), strings.NewReader(tt.form.Encode()))
+				req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			} else {
+				req = httptest.NewRequest(http.MethodPost, "/api/webhooks/approvals/run1/"+string(tt.decision), nil)
+			}
+			req.SetPathValue("runId", "run1")
+			for key, value := range tt.header {
+				req.Header.Set(key, value)
+			}
+			e, rec := newTestRequestEvent(req)
+			if err := handler.decideApproval(tt.decision)(e); err != nil {
+				t.Fatalf("decideApproval() error = %v", err)
+			}
+
+			if len(service.decisionReqs) != 1 {
+				t.Fatalf("decideApproval() decided %d times, want 1", len(service.decisionReqs))
+			}
+			decisionReq := service.decisionReqs[0]
+			if decisionReq.RunId != "run1" || decisionReq.Decision != tt.decision {
+				t.Errorf("decideApproval() req = %+v, want run1 %s", decisionReq, tt.decision)
+			}
+			if decisionReq.Token != tt.wantToken {
+				t.Errorf("decideApproval() token = '%s', want '%s'", decisionReq.Token, tt.wantToken)
+			}
+			if decisionReq.Comment != tt.wantComment {
+				t.Errorf("decideApproval() comment = '%s', want '%s'", decisionReq.Comment, tt.wantComment)
+			}
+
+			if tt.wantHTML {
+				if !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/html") {
+					t.Errorf("decideApproval() Content-Type = '%s', want 'text/html'", rec.Header().Get("Content-Type"))
+				}
+				if !strings.Contains(rec.Body.String(), tt.wantContains) {
+					t.Errorf("decideApproval() body does not contain '%s'", tt.wantContains)
+				}
+				return
+			}
+
+			res := &resp.Response{}
+			if err := json.Unmarshal(rec.Body.Bytes(), res); err != nil {
+				t.Fatalf("failed to unmarshal response: %v", err)
+			}
+			if res.Code != tt.wantCode {
+				t.Errorf("decideApproval() code = %d, want %d", res.Code, tt.wantCode)
+			}
+		})
+	}
+}
@@ -2,7 +2,14 @@ package handlers
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/pocketbase/pocketbase/core"
 	"github.com/pocketbase/pocketbase/tools/router"
@@ -16,6 +23,16 @@ type workflowService interface {
 	GetStatistics(ctx context.Context) (*dtos.WorkflowStatisticsResp, error)
 	StartRun(ctx context.Context, req *dtos.WorkflowStartRunReq) (*dtos.WorkflowStartRunResp, error)
 	CancelRun(ctx context.Context, req *dtos.WorkflowCancelRunReq) (*dtos.WorkflowCancelRunResp, error)
+	ResumeRun(ctx context.Context, req *dtos.WorkflowResumeRunReq) (*dtos.WorkflowResumeRunResp, error)
+	StreamRunEvents(ctx context.Context, req *dtos.WorkflowStreamRunEventsReq, emit func(event *domain.WorkflowRunEvent) error) error
+	DecideApproval(ctx context.Context, req *dtos.WorkflowDecideApprovalReq) (*dtos.WorkflowDecideApprovalResp, error)
+	PlanRun(ctx context.Context, req *dtos.WorkflowPlanRunReq) (*dtos.WorkflowPlanRunResp, error)
+	ValidateGraph(ctx context.Context, req *dtos.WorkflowValidateGraphReq) (*dtos.WorkflowValidateGraphResp, error)
+	PreviewSchedule(ctx context.Context, req *dtos.WorkflowPreviewScheduleReq) (*dtos.WorkflowPreviewScheduleResp, error)
+	ListVersions(ctx context.Context, req *dtos.WorkflowListVersionsReq) (*dtos.WorkflowListVersionsResp, error)
+	DiffVersions(ctx context.Context, req *dtos.WorkflowDiffVersionsReq) (*dtos.WorkflowDiffVersionsResp, error)
+	RestoreVersion(ctx context.Context, req *dtos.WorkflowRestoreVersionReq) (*dtos.WorkflowRestoreVersionResp, error)
+	RotateWebhookSecret(ctx context.Context, req *dtos.WorkflowRotateWebhookSecretReq) (*dtos.WorkflowRotateWebhookSecretResp, error)
 	Shutdown(ctx context.Context)
 }
 
@@ -32,6 +49,16 @@ func NewWorkflowsHandler(router *router.RouterGroup[*core.RequestEvent], service
 	group.GET("/stats", handler.getStatistics)
 	group.POST("/{workflowId}/runs", handler.startRun)
 	group.POST("/{workflowId}/runs/{runId}/cancel", handler.cancelRun)
+	group.POST("/{workflowId}/runs/{runId}/resume", handler.resumeRun)
+	group.POST("/{workflowId}/runs/{runId}/approval", handler.decideApproval)
+	group.GET("/{workflowId}/runs/{runId}/events", handler.streamRunEvents)
+	group.POST("/{workflowId}/plan", handler.planRun)
+	group.POST("/{workflowId}/validate", handler.validateGraph)
+	group.POST("/{workflowId}/schedule/preview", handler.previewSchedule)
+	group.GET("/{workflowId}/versions", handler.listVersions)
+	group.GET("/{workflowId}/versions/diff", handler.diffVersions)
+	group.POST("/{workflowId}/versions/{version}/restore", handler.restoreVersion)
+	group.POST("/{workflowId}/webhook/rotate", handler.rotateWebhookSecret)
 }
 
 func (handler *WorkflowsHandler) getStatistics(e *core.RequestEvent) error {
@@ -73,3 +100,205 @@ func (handler *WorkflowsHandler) cancelRun(e *core.RequestEvent) error {
 
 	return resp.Ok(e, res)
 }
+
+func (handler *WorkflowsHandler) resumeRun(e *core.RequestEvent) error {
+	req := &dtos.WorkflowResumeRunReq{}
+	req.WorkflowId = e.Request.PathValue("workflowId")
+	req.RunId = e.Request.PathValue("runId")
+
+	res, err := handler.service.ResumeRun(e.Request.Context(), req)
+	if err != nil {
+		return resp.Err(e, err)
+	}
+
+	return resp.Ok(e, res)
+}
+
+// 以 SSE（Server-Sent Events）推送运行的实时事件，事件名即为事件类型，数据为事件的 JSON。
+// 客户端断线重连时可通过 Last-Event-ID 请求头（或 lastEventId 查询参数）续传。
+func (handler *WorkflowsHandler) streamRunEvents(e *core.RequestEvent) error {
+	req := &dtos.WorkflowStreamRunEventsReq{}
+	req.WorkflowId = e.Request.PathValue("workflowId")
+	req.RunId = e.Request.PathValue("runId")
+	req.LastEventId = e.Request.Header.Get("Last-Event-ID")
+	if req.LastEventId == "" {
+		req.LastEventId = e.Request.URL.Query().Get("lastEventId")
+	}
+
+	// SSE 连接不受全局写超时的限制
+	if err := http.NewResponseController(e.Response).SetWriteDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return resp.Err(e, err)
+	}
+
+	started := false
+	err := handler.service.StreamRunEvents(e.Request.Context(), req, func(event *domain.WorkflowRunEvent) error {
+		if !started {
+			started = true
+			e.Response.Header().Set("Content-Type", "text/event-stream")
+			e.Response.Header().Set("Cache-Control", "no-store")
+			e.Response.Header().Set("X-Accel-Buffering", "no")
+			e.Response.WriteHeader(http.StatusOK)
+		}
+
+		if event == nil {
+			if _, err := io.WriteString(e.Response, ": ping\n\n"); err != nil {
+				return err
+			}
+			return e.Flush()
+		}
+
+		data, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+
+		var buf strings.Builder
+		if event.Id != "" {
+			buf.WriteString("id: " + event.Id + "\n")
+		}
+		buf.WriteString("event: " + string(event.Type) + "\n")
+		buf.WriteString("data: " + string(data) + "\n\n")
+		if _, err := io.WriteString(e.Response, buf.String()); err != nil {
+			return err
+		}
+		return e.Flush()
+	})
+	if err != nil && !started {
+		return resp.Err(e, err)
+	}
+
+	return nil
+}
+
+func (handler *WorkflowsHandler) decideApproval(e *core.RequestEvent) error {
+	req := &dtos.WorkflowDecideApprovalReq{}
+	req.WorkflowId = e.Request.PathValue("workflowId")
+	req.RunId = e.Request.PathValue("runId")
+	if e.Auth != nil {
+		req.Decider = e.Auth.Email()
+	}
+	if err := e.BindBody(req); err != nil {
+		return resp.Err(e, err)
+	}
+
+	res, err := handler.service.DecideApproval(e.Request.Context(), req)
+	if err != nil {
+		return resp.Err(e, err)
+	}
+
+	return resp.Ok(e, res)
+}
+
+func (handler *WorkflowsHandler) planRun(e *core.RequestEvent) error {
+	req := &dtos.WorkflowPlanRunReq{}
+	req.WorkflowId = e.Request.PathValue("workflowId")
+	if err := e.BindBody(req); err != nil {
+		return resp.Err(e, err)
+	}
+
+	res, err := handler.service.PlanRun(e.Request.Context(), req)
+	if err != nil {
+		return resp.Err(e, err)
+	}
+
+	return resp.Ok(e, res)
+}
+
+func (handler *WorkflowsHandler) validateGraph(e *core.RequestEvent) error {
+	req := &dtos.WorkflowValidateGraphReq{}
+	req.WorkflowId = e.Request.PathValue("workflowId")
+	if err := e.BindBody(req); err != nil {
+		return resp.Err(e, err)
+	}
+
+	res, err := handler.service.ValidateGraph(e.Request.Context(), req)
+	if err != nil {
+		return resp.Err(e, err)
+	}
+
+	return resp.Ok(e, res)
+}
+
+func (handler *WorkflowsHandler) previewSchedule(e *core.RequestEvent) error {
+	req := &dtos.WorkflowPreviewScheduleReq{}
+	req.WorkflowId = e.Request.PathValue("workflowId")
+	if err := e.BindBody(req); err != nil {
+		return resp.Err(e, err)
+	}
+
+	res, err := handler.service.PreviewSchedule(e.Request.Context(), req)
+	if err != nil {
+		return resp.Err(e, err)
+	}
+
+	return resp.Ok(e, res)
+}
+
+func (handler *WorkflowsHandler) listVersions(e *core.RequestEvent) error {
+	req := &dtos.WorkflowListVersionsReq{}
+	req.WorkflowId = e.Request.PathValue("workflowId")
+
+	res, err := handler.service.ListVersions(e.Request.Context(), req)
+	if err != nil {
+		return resp.Err(e, err)
+	}
+
+	return resp.Ok(e, res)
+}
+
+func (handler *WorkflowsHandler) diffVersions(e *core.RequestEvent) error {
+	req := &dtos.WorkflowDiffVersionsReq{}
+	req.WorkflowId = e.Request.PathValue("workflowId")
+	if v, err := strconv.Atoi(e.Request.URL.Query().Get("from")); err != nil {
+		return resp.Err(e, fmt.Errorf("invalid parameters: the value of 'from' must be a version number"))
+	} else {
+		req.FromVersion = v
+	}
+	if v, err := strconv.Atoi(e.Request.URL.Query().Get("to")); err != nil {
+		return resp.Err(e, fmt.Errorf("invalid parameters: the value of 'to' must be a version number"))
+	} else {
+		req.ToVersion = v
+	}
+
+	res, err := handler.service.DiffVersions(e.Request.Context(), req)
+	if err != nil {
+		return resp.Err(e, err)
+	}
+
+	return resp.Ok(e, res)
+}
+
+func (handler *WorkflowsHandler) restoreVersion(e *core.RequestEvent) error {
+	req := &dtos.WorkflowRestoreVersionReq{}
+	req.WorkflowId = e.Request.PathValue("workflowId")
+	if v, err := strconv.Atoi(e.Request.PathValue("version")); err != nil {
+		return resp.Err(e, fmt.Errorf("invalid parameters: the value of 'version' must be a version number"))
+	} else {
+		req.Version = v
+	}
+	if e.Auth != nil {
+		req.Author = e.Auth.Email()
+	}
+	if err := e.BindBody(req); err != nil {
+		return resp.Err(e, err)
+	}
+
+	res, err := handler.service.RestoreVersion(e.Request.Context(), req)
+	if err != nil {
+		return resp.Err(e, err)
+	}
+
+	return resp.Ok(e, res)
+}
+
+func (handler *WorkflowsHandler) rotateWebhookSecret(e *core.RequestEvent) error {
+	req := &dtos.WorkflowRotateWebhookSecretReq{}
+	req.WorkflowId = e.Request.PathValue("workflowId")
+
+	res, err := handler.service.RotateWebhookSecret(e.Request.Context(), req)
+	if err != nil {
+		return resp.Err(e, err)
+	}
+
+	return resp.Ok(e, res)
+}
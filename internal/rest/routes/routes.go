@@ -24,15 +24,24 @@ func BindRouter(router *router.Router[*core.RequestEvent]) {
 	accessRepo := repository.NewAccessRepository()
 	workflowRepo := repository.NewWorkflowRepository()
 	workflowRunRepo := repository.NewWorkflowRunRepository()
+	workflowVersionRepo := repository.NewWorkflowVersionRepository()
+	workflowLogRepo := repository.NewWorkflowLogRepository()
 	acmeAccountRepo := repository.NewACMEAccountRepository()
 	certificateRepo := repository.NewCertificateRepository()
 	statisticsRepo := repository.NewStatisticsRepository()
 
 	certificateSvc = certificate.NewCertificateService(acmeAccountRepo, certificateRepo)
-	workflowSvc = workflow.NewWorkflowService(workflowRepo, workflowRunRepo)
+	workflowSvc = workflow.NewWorkflowService(workflowRepo, workflowRunRepo, workflowVersionRepo, workflowLogRepo)
 	statisticsSvc = statistics.NewStatisticsService(statisticsRepo)
 	notifySvc = notify.NewNotifyService(accessRepo)
 
+	// 指标采集端点自行校验令牌或超级管理员认证
+	handlers.NewMetricsHandler(router.RouterGroup)
+
+	// Webhook 自行校验令牌或签名，无需超级管理员认证
+	publicGroup := router.Group("/api")
+	handlers.NewWebhooksHandler(publicGroup, workflowSvc)
+
 	group := router.Group("/api")
 	group.Bind(apis.RequireSuperuserAuth())
 	handlers.NewCertificatesHandler(group, certificateSvc)
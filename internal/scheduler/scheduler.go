@@ -12,10 +12,12 @@ import (
 func Setup() {
 	workflowRepo := repository.NewWorkflowRepository()
 	workflowRunRepo := repository.NewWorkflowRunRepository()
+	workflowVersionRepo := repository.NewWorkflowVersionRepository()
+	workflowLogRepo := repository.NewWorkflowLogRepository()
 	acmeAccountRepo := repository.NewACMEAccountRepository()
 	certificateRepo := repository.NewCertificateRepository()
 
-	workflowSvc := workflow.NewWorkflowService(workflowRepo, workflowRunRepo)
+	workflowSvc := workflow.NewWorkflowService(workflowRepo, workflowRunRepo, workflowVersionRepo, workflowLogRepo)
 	certificateSvc := certificate.NewCertificateService(acmeAccountRepo, certificateRepo)
 
 	if err := initWorkflowScheduler(workflowSvc); err != nil {
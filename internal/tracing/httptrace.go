@@ -0,0 +1,83 @@
+package tracing
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http/httptrace"
+	"strconv"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// 返回附带 HTTP 客户端追踪钩子的上下文，以此上下文发出的 HTTP 请求的各个阶段都将作为事件记录在上下文中的 Span 上，
+// 包括获取连接、DNS 解析、建立连接、TLS 握手、发出请求、收到响应的首个字节等。
+//
+// 追踪钩子无从得知其所属的请求，以同一上下文并发发出的请求无法相互区分，因此本函数不为各请求创建子 Span。
+// 若 HTTP 客户端由本项目控制，应使用 [WrapTransport] 为每个请求创建子 Span；本函数适用于各类云服务商 SDK，但仅对以上下文发出请求的 SDK 有效。
+// 未启用追踪、或上下文中的 Span 未在记录时，原样返回上下文。
+func WithHTTPClientTrace(ctx context.Context) context.Context {
+	span := trace.SpanFromContext(ctx)
+	if !IsEnabled() || !span.IsRecording() {
+		return ctx
+	}
+
+	t := &httpClientTracer{span: span}
+	return httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		GetConn:              t.getConn,
+		GotConn:              t.gotConn,
+		DNSDone:              t.dnsDone,
+		ConnectDone:          t.connectDone,
+		TLSHandshakeDone:     t.tlsHandshakeDone,
+		WroteRequest:         t.wroteRequest,
+		GotFirstResponseByte: t.gotFirstResponseByte,
+	})
+}
+
+type httpClientTracer struct {
+	span trace.Span
+}
+
+func (t *httpClientTracer) getConn(hostPort string) {
+	attrs := make([]attribute.KeyValue, 0, 2)
+	if host, port, err := net.SplitHostPort(hostPort); err == nil {
+		attrs = append(attrs, attribute.String("server.address", host))
+		if p, err := strconv.Atoi(port); err == nil {
+			attrs = append(attrs, attribute.Int("server.port", p))
+		}
+	} else {
+		attrs = append(attrs, attribute.String("server.address", hostPort))
+	}
+
+	t.span.AddEvent("http.conn.get", trace.WithAttributes(attrs...))
+}
+
+func (t *httpClientTracer) gotConn(info httptrace.GotConnInfo) {
+	attrs := []attribute.KeyValue{attribute.Bool("http.connection.reused", info.Reused)}
+	if info.Conn != nil {
+		attrs = append(attrs, attribute.String("network.peer.address", info.Conn.RemoteAddr().String()))
+	}
+
+	t.span.AddEvent("http.conn.got", trace.WithAttributes(attrs...))
+}
+
+func (t *httpClientTracer) dnsDone(info httptrace.DNSDoneInfo) {
+	t.span.AddEvent("http.dns.done", trace.WithAttributes(attribute.Bool("error", info.Err != nil)))
+}
+
+func (t *httpClientTracer) connectDone(network, addr string, err error) {
+	t.span.AddEvent("http.connect.done", trace.WithAttributes(attribute.String("network.peer.address", addr), attribute.Bool("error", err != nil)))
+}
+
+func (t *httpClientTracer) tlsHandshakeDone(_ tls.ConnectionState, err error) {
+	t.span.AddEvent("http.tls.done", trace.WithAttributes(attribute.Bool("error", err != nil)))
+}
+
+func (t *httpClientTracer) wroteRequest(info httptrace.WroteRequestInfo) {
+	t.span.AddEvent("http.request.sent", trace.WithAttributes(attribute.Bool("error", info.Err != nil)))
+}
+
+func (t *httpClientTracer) gotFirstResponseByte() {
+	t.span.AddEvent("http.response.first_byte")
+}
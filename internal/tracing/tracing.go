@@ -0,0 +1,154 @@
+// Package tracing 提供基于 OpenTelemetry 的分布式追踪支持。
+//
+// 启用后，每次工作流运行将生成一条追踪链路：以运行为根 Span，各节点的执行为其子 Span，
+// 节点中的 ACME 客户端请求、DNS 质询的预置/等待传播/清理、部署器的调用、HTTP 请求节点发出的请求等再作为节点的子 Span。
+// 工作流日志中将记录其所属的追踪 ID，以便与追踪后端中的链路相互对照。
+//
+// 可通过以下环境变量进行配置：
+//   - CERTIMATE_TRACING_ENABLED：是否启用追踪，默认为 false；
+//   - OTEL_EXPORTER_OTLP_ENDPOINT、OTEL_EXPORTER_OTLP_TRACES_ENDPOINT、OTEL_EXPORTER_OTLP_HEADERS 等：OTLP/HTTP 导出器的标准配置项；
+//   - OTEL_SERVICE_NAME、OTEL_RESOURCE_ATTRIBUTES：服务名称及其他资源属性，服务名称默认为 certimate。
+package tracing
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/certimate-go/certimate/internal/app"
+	xenv "github.com/certimate-go/certimate/pkg/utils/env"
+)
+
+const (
+	tracerName  = "github.com/certimate-go/certimate"
+	serviceName = "certimate"
+)
+
+var envEnabled = false
+
+func init() {
+	envEnabled = xenv.GetOrDefaultBool("CERTIMATE_TRACING_ENABLED", false)
+}
+
+var (
+	enabled    atomic.Bool
+	provider   *sdktrace.TracerProvider
+	setupMutex sync.Mutex
+)
+
+// 返回是否已启用追踪。
+func IsEnabled() bool {
+	return enabled.Load()
+}
+
+func Setup() {
+	if !envEnabled {
+		return
+	}
+
+	exporter, err := otlptracehttp.New(context.Background())
+	if err != nil {
+		app.GetLogger().Error("failed to create the tracing exporter", slog.Any("error", err))
+		return
+	}
+
+	setup(sdktrace.WithBatcher(exporter))
+	app.GetLogger().Info("tracing enabled")
+}
+
+// 使用指定的导出器启用追踪，且每个 Span 结束时即同步导出。
+// 主要用于以进程内的导出器（如 tracetest.InMemoryExporter）验证追踪结果。
+func SetupWithExporter(exporter sdktrace.SpanExporter) {
+	setup(sdktrace.WithSyncer(exporter))
+}
+
+func setup(opt sdktrace.TracerProviderOption) {
+	setupMutex.Lock()
+	defer setupMutex.Unlock()
+
+	res, _ := resource.Merge(
+		resource.NewSchemaless(attribute.String("service.name", serviceName)),
+		resource.Environment(),
+	)
+
+	prev := provider
+	provider = sdktrace.NewTracerProvider(opt, sdktrace.WithResource(res))
+	otel.SetTracerProvider(provider)
+	enabled.Store(true)
+
+	if prev != nil {
+		prev.Shutdown(context.Background())
+	}
+}
+
+// 停止追踪，并导出尚未导出的 Span。
+func Teardown() {
+	setupMutex.Lock()
+	defer setupMutex.Unlock()
+
+	if provider == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := provider.Shutdown(ctx); err != nil {
+		app.GetLogger().Warn("failed to shutdown the tracing provider", slog.Any("error", err))
+	}
+
+	provider = nil
+	enabled.Store(false)
+}
+
+// 开始一个 Span，上下文中已有 Span 时将作为其子 Span。
+// 未启用追踪时，返回的 Span 不做任何记录。
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// 结束 Span，err 不为空时将其记录为 Span 的错误。
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	span.End()
+}
+
+// 返回上下文中的 Span 所属的追踪 ID，无时返回空字符串。
+func TraceId(ctx context.Context) string {
+	spanCtx := trace.SpanContextFromContext(ctx)
+	if !spanCtx.HasTraceID() {
+		return ""
+	}
+
+	return spanCtx.TraceID().String()
+}
+
+// 包装 HTTP 传输层，为经其发出的每个请求创建子 Span。
+// 未启用追踪时原样返回。
+func WrapTransport(rt http.RoundTripper) http.RoundTripper {
+	if !IsEnabled() {
+		return rt
+	}
+
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+
+	return otelhttp.NewTransport(rt)
+}
@@ -0,0 +1,179 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/samber/lo"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestSpans(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	SetupWithExporter(exporter)
+	defer Teardown()
+
+	if !IsEnabled() {
+		t.Fatal("expected tracing to be enabled")
+	}
+
+	// 测试父子 Span 及追踪 ID
+	runCtx, runSpan := Start(context.Background(), "workflow.run")
+	nodeCtx, nodeSpan := Start(runCtx, "workflow.node")
+	if TraceId(runCtx) == "" {
+		t.Fatal("expected a non-empty trace id")
+	}
+	if TraceId(nodeCtx) != TraceId(runCtx) {
+		t.Errorf("expected the same trace id, got %s and %s", TraceId(nodeCtx), TraceId(runCtx))
+	}
+	End(nodeSpan, errors.New("test error"))
+	End(runSpan, nil)
+
+	spans := exporter.GetSpans()
+	if len(spans) != 2 {
+		t.Fatalf("expected 2 spans, got %d", len(spans))
+	}
+	if spans[0].Name != "workflow.node" || spans[1].Name != "workflow.run" {
+		t.Errorf("unexpected span names: %s, %s", spans[0].Name, spans[1].Name)
+	}
+	if spans[0].Parent.SpanID() != spans[1].SpanContext.SpanID() {
+		t.Errorf("expected 'workflow.node' to be a child of 'workflow.run'")
+	}
+	if spans[0].Status.Code != codes.Error || len(spans[0].Events) == 0 {
+		t.Errorf("expected 'workflow.node' to record the error")
+	}
+	if spans[1].Status.Code == codes.Error {
+		t.Errorf("expected 'workflow.run' not to record any error")
+	}
+
+	// 测试无 Span 时的追踪 ID
+	if TraceId(context.Background()) != "" {
+		t.Errorf("expected an empty trace id")
+	}
+}
+
+func TestHTTPClientTrace(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	SetupWithExporter(exporter)
+	defer Teardown()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	// 测试以同一上下文并发发出的请求，各阶段均应作为事件记录在上下文中的 Span 上
+	ctx, span := Start(context.Background(), "deployer.deploy")
+	httpCtx := WithHTTPClientTrace(ctx)
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			req, _ := http.NewRequestWithContext(httpCtx, http.MethodGet, server.URL, nil)
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Errorf("failed to send request: %v", err)
+				return
+			}
+			resp.Body.Close()
+		}()
+	}
+	wg.Wait()
+	End(span, nil)
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("expected 1 span, got %d", len(spans))
+	}
+	events := lo.CountValues(lo.Map(spans[0].Events, func(e sdktrace.Event, _ int) string { return e.Name }))
+	if events["http.conn.get"] != 3 || events["http.request.sent"] != 3 || events["http.response.first_byte"] != 3 {
+		t.Errorf("unexpected events: %v", events)
+	}
+
+	// 测试未收到响应的请求
+	exporter.Reset()
+	ctx, span = Start(context.Background(), "deployer.deploy")
+	req, _ := http.NewRequestWithContext(WithHTTPClientTrace(ctx), http.MethodGet, "http://127.0.0.1:1", nil)
+	if resp, err := http.DefaultClient.Do(req); err == nil {
+		resp.Body.Close()
+		t.Skip("unexpected response from 127.0.0.1:1")
+	}
+	End(span, nil)
+
+	spans = exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("expected 1 span, got %d", len(spans))
+	}
+	connectDone, ok := lo.Find(spans[0].Events, func(e sdktrace.Event) bool { return e.Name == "http.connect.done" })
+	if !ok || !lo.Contains(connectDone.Attributes, attribute.Bool("error", true)) {
+		t.Errorf("expected the failed connection to be recorded")
+	}
+	if lo.ContainsBy(spans[0].Events, func(e sdktrace.Event) bool { return e.Name == "http.response.first_byte" }) {
+		t.Errorf("expected no response to be recorded")
+	}
+}
+
+func TestWrapTransport(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	SetupWithExporter(exporter)
+	defer Teardown()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	// 测试并发的请求，每个请求都应有其自身的子 Span
+	client := &http.Client{Transport: WrapTransport(nil)}
+	ctx, span := Start(context.Background(), "workflow.node")
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+			resp, err := client.Do(req)
+			if err != nil {
+				t.Errorf("failed to send request: %v", err)
+				return
+			}
+			resp.Body.Close()
+		}()
+	}
+	wg.Wait()
+	End(span, nil)
+
+	spans := exporter.GetSpans()
+	if len(spans) != 4 {
+		t.Fatalf("expected 4 spans, got %d", len(spans))
+	}
+	parent := spans[len(spans)-1]
+	for _, s := range spans[:3] {
+		if s.SpanKind != trace.SpanKindClient {
+			t.Errorf("expected a client span, got '%s'", s.SpanKind)
+		}
+		if s.Parent.SpanID() != parent.SpanContext.SpanID() {
+			t.Errorf("expected '%s' to be a child of 'workflow.node'", s.Name)
+		}
+	}
+	if spanIds := lo.Uniq(lo.Map(spans[:3], func(s tracetest.SpanStub, _ int) trace.SpanID { return s.SpanContext.SpanID() })); len(spanIds) != 3 {
+		t.Errorf("expected 3 distinct spans, got %d", len(spanIds))
+	}
+
+	// 测试未启用追踪时原样返回
+	Teardown()
+	if rt := WrapTransport(http.DefaultTransport); rt != http.DefaultTransport {
+		t.Errorf("expected the transport to be returned as is")
+	}
+}
@@ -0,0 +1,81 @@
+package workflow
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"time"
+
+	"github.com/certimate-go/certimate/internal/domain"
+)
+
+const approvalDeciderSystem = "system"
+
+// 查找运行中指定节点尚未审批的审批请求。
+// 节点 ID 为空时，要求运行中有且仅有一个尚未审批的审批请求。
+func findPendingApproval(workflowRun *domain.WorkflowRun, nodeId string) (*domain.WorkflowRunStateApproval, error) {
+	if workflowRun.Status != domain.WorkflowRunStatusTypeWaiting {
+		return nil, fmt.Errorf("workflow run is not waiting for approval")
+	}
+
+	pendings := listPendingApprovals(workflowRun)
+	if nodeId == "" {
+		if len(pendings) != 1 {
+			return nil, fmt.Errorf("workflow run has %d pending approvals, please specify the node id", len(pendings))
+		}
+		return pendings[0], nil
+	}
+
+	for _, approval := range pendings {
+		if approval.NodeId == nodeId {
+			return approval, nil
+		}
+	}
+
+	return nil, fmt.Errorf("workflow run has no pending approval for node #%s", nodeId)
+}
+
+// 以审批令牌查找运行中尚未审批的审批请求。
+// 审批请求已超时的，其令牌即失效，即使超时处理尚未执行。
+func findPendingApprovalByToken(workflowRun *domain.WorkflowRun, token string, now time.Time) (*domain.WorkflowRunStateApproval, bool) {
+	if workflowRun.Status != domain.WorkflowRunStatusTypeWaiting || token == "" {
+		return nil, false
+	}
+
+	for _, approval := range listPendingApprovals(workflowRun) {
+		if subtle.ConstantTimeCompare([]byte(approval.Token), []byte(token)) == 1 {
+			if !approval.ExpiresAt.IsZero() && now.After(approval.ExpiresAt) {
+				return nil, false
+			}
+			return approval, true
+		}
+	}
+
+	return nil, false
+}
+
+// 查找运行中已超时、但尚未审批的审批请求。
+func findExpiredApprovals(workflowRun *domain.WorkflowRun, now time.Time) []*domain.WorkflowRunStateApproval {
+	expired := make([]*domain.WorkflowRunStateApproval, 0)
+	for _, approval := range listPendingApprovals(workflowRun) {
+		if !approval.ExpiresAt.IsZero() && now.After(approval.ExpiresAt) {
+			expired = append(expired, approval)
+		}
+	}
+
+	return expired
+}
+
+func listPendingApprovals(workflowRun *domain.WorkflowRun) []*domain.WorkflowRunStateApproval {
+	pendings := make([]*domain.WorkflowRunStateApproval, 0)
+	if workflowRun.State == nil {
+		return pendings
+	}
+
+	for _, approval := range workflowRun.State.Approvals {
+		if approval.Decision == "" {
+			pendings = append(pendings, approval)
+		}
+	}
+
+	return pendings
+}
@@ -0,0 +1,156 @@
+package workflow
+
+import (
+	"testing"
+	"time"
+
+	"github.com/certimate-go/certimate/internal/domain"
+)
+
+func newTestWaitingRun(approvals ...*domain.WorkflowRunStateApproval) *domain.WorkflowRun {
+	return &domain.WorkflowRun{
+		Meta:   domain.Meta{Id: "run1"},
+		Status: domain.WorkflowRunStatusTypeWaiting,
+		State:  &domain.WorkflowRunState{Approvals: approvals},
+	}
+}
+
+func TestFindPendingApproval(t *testing.T) {
+	tests := []struct {
+		name       string
+		run        *domain.WorkflowRun
+		nodeId     string
+		wantNodeId string
+		wantErr    bool
+	}{
+		{
+			name:       "the only pending approval",
+			run:        newTestWaitingRun(&domain.WorkflowRunStateApproval{NodeId: "n1", Decision: domain.WorkflowApprovalDecisionTypeApproved}, &domain.WorkflowRunStateApproval{NodeId: "n2"}),
+			wantNodeId: "n2",
+		},
+		{
+			name:    "multiple pending approvals",
+			run:     newTestWaitingRun(&domain.WorkflowRunStateApproval{NodeId: "n1"}, &domain.WorkflowRunStateApproval{NodeId: "n2"}),
+			wantErr: true,
+		},
+		{
+			name:       "specified node",
+			run:        newTestWaitingRun(&domain.WorkflowRunStateApproval{NodeId: "n1"}, &domain.WorkflowRunStateApproval{NodeId: "n2"}),
+			nodeId:     "n1",
+			wantNodeId: "n1",
+		},
+		{
+			name:    "specified node already decided",
+			run:     newTestWaitingRun(&domain.WorkflowRunStateApproval{NodeId: "n1", Decision: domain.WorkflowApprovalDecisionTypeRejected}),
+			nodeId:  "n1",
+			wantErr: true,
+		},
+		{
+			name:    "not waiting",
+			run:     &domain.WorkflowRun{Status: domain.WorkflowRunStatusTypeProcessing, State: &domain.WorkflowRunState{Approvals: []*domain.WorkflowRunStateApproval{{NodeId: "n1"}}}},
+			wantErr: true,
+		},
+		{
+			name:    "no state",
+			run:     &domain.WorkflowRun{Status: domain.WorkflowRunStatusTypeWaiting},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			approval, err := findPendingApproval(tt.run, tt.nodeId)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("findPendingApproval() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && approval.NodeId != tt.wantNodeId {
+				t.Errorf("findPendingApproval() node id = %s, want %s", approval.NodeId, tt.wantNodeId)
+			}
+		})
+	}
+}
+
+func TestFindPendingApprovalByToken(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		run        *domain.WorkflowRun
+		token      string
+		wantNodeId string
+		wantOk     bool
+	}{
+		{
+			name:       "valid token",
+			run:        newTestWaitingRun(&domain.WorkflowRunStateApproval{NodeId: "n1", Token: "t1", ExpiresAt: now.Add(time.Hour)}, &domain.WorkflowRunStateApproval{NodeId: "n2", Token: "t2", ExpiresAt: now.Add(time.Hour)}),
+			token:      "t2",
+			wantNodeId: "n2",
+			wantOk:     true,
+		},
+		{
+			name:  "invalid token",
+			run:   newTestWaitingRun(&domain.WorkflowRunStateApproval{NodeId: "n1", Token: "t1", ExpiresAt: now.Add(time.Hour)}),
+			token: "t",
+		},
+		{
+			name:  "empty token",
+			run:   newTestWaitingRun(&domain.WorkflowRunStateApproval{NodeId: "n1", Token: "", ExpiresAt: now.Add(time.Hour)}),
+			token: "",
+		},
+		{
+			name:  "token of decided approval",
+			run:   newTestWaitingRun(&domain.WorkflowRunStateApproval{NodeId: "n1", Token: "t1", ExpiresAt: now.Add(time.Hour), Decision: domain.WorkflowApprovalDecisionTypeApproved}),
+			token: "t1",
+		},
+		{
+			name:  "token of expired approval",
+			run:   newTestWaitingRun(&domain.WorkflowRunStateApproval{NodeId: "n1", Token: "t1", ExpiresAt: now.Add(-time.Second)}),
+			token: "t1",
+		},
+		{
+			name:       "token at the moment of expiry",
+			run:        newTestWaitingRun(&domain.WorkflowRunStateApproval{NodeId: "n1", Token: "t1", ExpiresAt: now}),
+			token:      "t1",
+			wantNodeId: "n1",
+			wantOk:     true,
+		},
+		{
+			name:       "token without expiry",
+			run:        newTestWaitingRun(&domain.WorkflowRunStateApproval{NodeId: "n1", Token: "t1"}),
+			token:      "t1",
+			wantNodeId: "n1",
+			wantOk:     true,
+		},
+		{
+			name:  "run not waiting",
+			run:   &domain.WorkflowRun{Status: domain.WorkflowRunStatusTypeCanceled, State: &domain.WorkflowRunState{Approvals: []*domain.WorkflowRunStateApproval{{NodeId: "n1", Token: "t1"}}}},
+			token: "t1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			approval, ok := findPendingApprovalByToken(tt.run, tt.token, now)
+			if ok != tt.wantOk {
+				t.Fatalf("findPendingApprovalByToken() ok = %v, want %v", ok, tt.wantOk)
+			}
+			if ok && approval.NodeId != tt.wantNodeId {
+				t.Errorf("findPendingApprovalByToken() node id = %s, want %s", approval.NodeId, tt.wantNodeId)
+			}
+		})
+	}
+}
+
+func TestFindExpiredApprovals(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	run := newTestWaitingRun(
+		&domain.WorkflowRunStateApproval{NodeId: "n1", ExpiresAt: now.Add(-time.Second)},
+		&domain.WorkflowRunStateApproval{NodeId: "n2", ExpiresAt: now.Add(time.Second)},
+		&domain.WorkflowRunStateApproval{NodeId: "n3", ExpiresAt: now.Add(-time.Second), Decision: domain.WorkflowApprovalDecisionTypeApproved},
+		&domain.WorkflowRunStateApproval{NodeId: "n4"},
+	)
+
+	expired := findExpiredApprovals(run, now)
+	if len(expired) != 1 || expired[0].NodeId != "n1" {
+		t.Errorf("findExpiredApprovals() = %v, want [n1]", expired)
+	}
+}
@@ -0,0 +1,161 @@
+package dispatcher
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/tools/types"
+	"github.com/samber/lo"
+
+	"github.com/certimate-go/certimate/internal/cluster"
+	"github.com/certimate-go/certimate/internal/domain"
+)
+
+// 集群模式下运行的租约续期失败时用于取消执行中任务的原因。
+// 此时运行已被取消或已被其他节点接管，当前节点不应再更新其状态。
+var errLeaseLost = errors.New("the lease of workflow run has been lost")
+
+// 集群模式下认领待调度的任务，调用方须持有锁。
+// 除非并发策略允许，同一工作流或同一互斥组中已有运行正由其他节点执行时认领失败。
+func (wd *workflowDispatcher) claimTask(ctx context.Context, task *taskInfo) (bool, error) {
+	if !cluster.IsEnabled() {
+		return true, nil
+	}
+
+	params := dbx.Params{
+		"claimRunId":  task.RunId,
+		"claimNodeId": cluster.NodeId(),
+		"claimNow":    types.NowDateTime().String(),
+	}
+	activeRunCond := "r.id!={:claimRunId} AND r.status='processing' AND r.claimedBy!={:claimNodeId} AND r.leaseExpiresAt>={:claimNow}"
+
+	exprs := make([]dbx.Expression, 0)
+	if task.ConcurrencyPolicy != domain.WorkflowConcurrencyPolicyTypeAllow {
+		params["claimWorkflowId"] = task.WorkflowId
+		exprs = append(exprs, dbx.NewExp(fmt.Sprintf("NOT EXISTS (SELECT 1 FROM %s AS r WHERE r.workflowRef={:claimWorkflowId} AND %s)", domain.CollectionNameWorkflowRun, activeRunCond), params))
+	}
+	if len(task.MutexGroups) > 0 {
+		mutexGroups, _ := json.Marshal(task.MutexGroups)
+		params["claimMutexGroups"] = string(mutexGroups)
+		exprs = append(exprs, dbx.NewExp(fmt.Sprintf("NOT EXISTS (SELECT 1 FROM %s AS r JOIN %s AS w ON w.id=r.workflowRef, json_each(CASE WHEN json_valid(w.mutexGroups) THEN w.mutexGroups ELSE '[]' END) AS g WHERE %s AND g.value IN (SELECT value FROM json_each({:claimMutexGroups})))", domain.CollectionNameWorkflowRun, domain.CollectionNameWorkflow, activeRunCond), params))
+	}
+
+	return wd.workflowRunRepo.Claim(ctx, task.RunId, domain.WorkflowRunStatusTypePending, cluster.NodeId(), cluster.LeaseTTL(), exprs...)
+}
+
+// 集群模式下接管执行中但租约已过期的运行，调用方须持有锁。
+func (wd *workflowDispatcher) takeOverRun(ctx context.Context, workflowRun *domain.WorkflowRun) (bool, error) {
+	if !cluster.IsEnabled() {
+		return true, nil
+	}
+
+	// 子运行不会被认领，仅当其父运行已不在执行中时才接管之
+	if workflowRun.ParentRunId != "" {
+		parentRun, err := wd.workflowRunRepo.GetById(ctx, workflowRun.ParentRunId)
+		if err != nil && !errors.Is(err, domain.ErrRecordNotFound) {
+			return false, err
+		} else if parentRun != nil && (parentRun.Status == domain.WorkflowRunStatusTypePending || parentRun.Status == domain.WorkflowRunStatusTypeProcessing) {
+			return false, nil
+		}
+	}
+
+	return wd.workflowRunRepo.Claim(ctx, workflowRun.Id, domain.WorkflowRunStatusTypeProcessing, cluster.NodeId(), cluster.LeaseTTL())
+}
+
+// 集群模式下认领失败时，判断任务是否应从本节点的等待队列中移除，调用方须持有锁。
+// 若运行已不在等待状态，或已被其他节点持有有效租约，则应移除之；否则仅是暂时与其他节点上的运行冲突，应继续等待。
+func (wd *workflowDispatcher) shouldDropUnclaimedTask(ctx context.Context, task *taskInfo) bool {
+	workflowRun, err := wd.workflowRunRepo.GetById(ctx, task.RunId)
+	if err != nil {
+		return errors.Is(err, domain.ErrRecordNotFound)
+	}
+
+	if workflowRun.Status != domain.WorkflowRunStatusTypePending {
+		return true
+	}
+
+	return workflowRun.ClaimedBy != cluster.NodeId() && workflowRun.LeaseExpiresAt.After(time.Now())
+}
+
+// 集群模式下的心跳循环，直至上下文被取消。
+func (wd *workflowDispatcher) heartbeatLoop(ctx context.Context) {
+	ticker := time.NewTicker(cluster.HeartbeatInterval())
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			wd.heartbeat(ctx)
+		}
+	}
+}
+
+// 续期本节点执行中运行的租约，接管崩溃节点的运行，并发现由其他节点入队但尚未被执行的运行。
+func (wd *workflowDispatcher) heartbeat(ctx context.Context) {
+	wd.taskMtx.Lock()
+	defer wd.taskMtx.Unlock()
+
+	// 续期租约，续期失败说明运行已被取消或已被其他节点接管
+	for runId, task := range wd.processingTasks {
+		if ok, err := wd.workflowRunRepo.RenewLease(ctx, runId, cluster.NodeId(), cluster.LeaseTTL()); err != nil {
+			wd.syslog.Error(fmt.Sprintf("failed to renew the lease of workrun #%s", runId), slog.Any("error", err))
+		} else if !ok {
+			task.cancel(errLeaseLost)
+			delete(wd.processingTasks, runId)
+
+			wd.syslog.Warn(fmt.Sprintf("workrun #%s was stopped, because its lease has been lost", runId))
+		}
+	}
+
+	// 接管租约已过期的运行
+	processingRuns, err := wd.workflowRunRepo.ListByStatus(ctx, domain.WorkflowRunStatusTypeProcessing)
+	if err != nil {
+		wd.syslog.Error("failed to list processing workruns", slog.Any("error", err))
+		return
+	}
+	for _, workflowRun := range processingRuns {
+		if _, exists := wd.processingTasks[workflowRun.Id]; exists || workflowRun.LeaseExpiresAt.After(time.Now()) {
+			continue
+		}
+
+		if restarted, err := wd.recoverInterruptedRun(ctx, workflowRun); err != nil {
+			wd.syslog.Error(fmt.Sprintf("failed to take over workrun #%s", workflowRun.Id), slog.Any("error", err))
+		} else if restarted {
+			if err := wd.enqueueRecoveredRun(ctx, workflowRun, workflowRun.StartedAt); err != nil {
+				wd.syslog.Error(fmt.Sprintf("failed to enqueue workrun #%s", workflowRun.Id), slog.Any("error", err))
+			}
+		}
+	}
+
+	// 发现其他节点入队的运行
+	// 新入队的运行通常会很快被其入队节点执行，稍后再参与竞争，以免无谓的认领冲突
+	pendingRuns, err := wd.workflowRunRepo.ListByStatus(ctx, domain.WorkflowRunStatusTypePending)
+	if err != nil {
+		wd.syslog.Error("failed to list pending workruns", slog.Any("error", err))
+		return
+	}
+	for _, workflowRun := range pendingRuns {
+		if _, exists := wd.processingTasks[workflowRun.Id]; exists {
+			continue
+		} else if lo.ContainsBy(wd.pendingRunQueue, func(t *taskInfo) bool { return t.RunId == workflowRun.Id }) {
+			continue
+		} else if time.Since(workflowRun.UpdatedAt) < cluster.HeartbeatInterval() {
+			continue
+		} else if workflowRun.ClaimedBy != "" && workflowRun.ClaimedBy != cluster.NodeId() && workflowRun.LeaseExpiresAt.After(time.Now()) {
+			continue
+		}
+
+		if err := wd.enqueueRecoveredRun(ctx, workflowRun, workflowRun.UpdatedAt); err != nil {
+			wd.syslog.Error(fmt.Sprintf("failed to enqueue workrun #%s", workflowRun.Id), slog.Any("error", err))
+		}
+	}
+
+	go func() { wd.tryNextAsync() }()
+}
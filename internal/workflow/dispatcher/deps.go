@@ -2,6 +2,9 @@ package dispatcher
 
 import (
 	"context"
+	"time"
+
+	"github.com/pocketbase/dbx"
 
 	"github.com/certimate-go/certimate/internal/domain"
 )
@@ -12,12 +15,16 @@ type workflowRepository interface {
 }
 
 type workflowRunRepository interface {
+	ListByStatus(ctx context.Context, status domain.WorkflowRunStatusType) ([]*domain.WorkflowRun, error)
+	ListActiveByWorkflowId(ctx context.Context, workflowId string) ([]*domain.WorkflowRun, error)
 	GetById(ctx context.Context, id string) (*domain.WorkflowRun, error)
 	Save(ctx context.Context, workflowRun *domain.WorkflowRun) (*domain.WorkflowRun, error)
 	SaveWithCascading(ctx context.Context, workflowRun *domain.WorkflowRun) (*domain.WorkflowRun, error)
-	ResetStatusIfHanging(ctx context.Context) error
+	Claim(ctx context.Context, runId string, status domain.WorkflowRunStatusType, nodeId string, leaseTTL time.Duration, exprs ...dbx.Expression) (bool, error)
+	RenewLease(ctx context.Context, runId string, nodeId string, leaseTTL time.Duration) (bool, error)
 }
 
 type workflowLogRepository interface {
+	ListByWorkflowRunId(ctx context.Context, workflowRunId string) ([]*domain.WorkflowLog, error)
 	Save(ctx context.Context, workflowLog *domain.WorkflowLog) (*domain.WorkflowLog, error)
 }
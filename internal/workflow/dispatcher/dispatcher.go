@@ -12,11 +12,15 @@ import (
 	"time"
 
 	"github.com/samber/lo"
+	"go.opentelemetry.io/otel/attribute"
 
 	"github.com/certimate-go/certimate/internal/app"
+	"github.com/certimate-go/certimate/internal/cluster"
 	"github.com/certimate-go/certimate/internal/domain"
 	"github.com/certimate-go/certimate/internal/repository"
+	"github.com/certimate-go/certimate/internal/tracing"
 	"github.com/certimate-go/certimate/internal/workflow/engine"
+	"github.com/certimate-go/certimate/internal/workflow/livelog"
 	"github.com/certimate-go/certimate/pkg/logging"
 	xenv "github.com/certimate-go/certimate/pkg/utils/env"
 )
@@ -41,22 +45,39 @@ type WorkflowDispatcher interface {
 
 type Statistics struct {
 	Concurrency      int
-	PendingRunIds    []string
+	PendingRunIds    []string // 按调度顺序排列
 	ProcessingRunIds []string
+	PendingRuns      []StatisticsRun
+	ProcessingRuns   []StatisticsRun
+}
+
+type StatisticsRun struct {
+	WorkflowId   string
+	RunId        string
+	Priority     int
+	Position     int // 在等待队列中的位置，从 1 开始；执行中的运行为零值
+	EnqueuedAt   time.Time
+	DispatchedAt time.Time
+	WaitTime     time.Duration // 等待中的运行为已等待的时长，执行中的运行为从入队到被调度的时长
 }
 
 type workflowDispatcher struct {
 	booted      bool
 	concurrency int
 
-	taskMtx         sync.RWMutex
-	pendingRunQueue []string
-	processingTasks map[string]*taskInfo // Key: RunId
+	taskMtx              sync.RWMutex
+	pendingRunQueue      []*taskInfo
+	processingTasks      map[string]*taskInfo // Key: RunId
+	groupLastDispatchAts map[string]time.Time // Key: SchedulingGroup
+
+	heartbeatCancel context.CancelFunc // 仅集群模式下有值
 
 	workflowRepo    workflowRepository
 	workflowRunRepo workflowRunRepository
 	workflowLogRepo workflowLogRepository
 
+	liveLogHub livelog.Hub
+
 	syslog *slog.Logger
 }
 
@@ -66,16 +87,35 @@ func (wd *workflowDispatcher) GetStatistics() Statistics {
 	wd.taskMtx.RLock()
 	defer wd.taskMtx.RUnlock()
 
+	now := time.Now()
 	stats := Statistics{
 		Concurrency:      wd.concurrency,
 		PendingRunIds:    make([]string, 0),
 		ProcessingRunIds: make([]string, 0),
+		PendingRuns:      make([]StatisticsRun, 0),
+		ProcessingRuns:   make([]StatisticsRun, 0),
 	}
-	for _, pendingRunId := range wd.pendingRunQueue {
-		stats.PendingRunIds = append(stats.PendingRunIds, pendingRunId)
+	for i, pendingTask := range wd.sortPendingTasks() {
+		stats.PendingRunIds = append(stats.PendingRunIds, pendingTask.RunId)
+		stats.PendingRuns = append(stats.PendingRuns, StatisticsRun{
+			WorkflowId: pendingTask.WorkflowId,
+			RunId:      pendingTask.RunId,
+			Priority:   pendingTask.Priority,
+			Position:   i + 1,
+			EnqueuedAt: pendingTask.EnqueuedAt,
+			WaitTime:   now.Sub(pendingTask.EnqueuedAt),
+		})
 	}
-	for _, processingRunId := range wd.processingTasks {
-		stats.ProcessingRunIds = append(stats.ProcessingRunIds, processingRunId.RunId)
+	for _, processingTask := range wd.processingTasks {
+		stats.ProcessingRunIds = append(stats.ProcessingRunIds, processingTask.RunId)
+		stats.ProcessingRuns = append(stats.ProcessingRuns, StatisticsRun{
+			WorkflowId:   processingTask.WorkflowId,
+			RunId:        processingTask.RunId,
+			Priority:     processingTask.Priority,
+			EnqueuedAt:   processingTask.EnqueuedAt,
+			DispatchedAt: processingTask.DispatchedAt,
+			WaitTime:     processingTask.DispatchedAt.Sub(processingTask.EnqueuedAt),
+		})
 	}
 
 	return stats
@@ -89,11 +129,18 @@ func (wd *workflowDispatcher) Bootup(ctx context.Context) error {
 	wd.taskMtx.Lock()
 	defer wd.taskMtx.Unlock()
 
-	if err := wd.workflowRunRepo.ResetStatusIfHanging(ctx); err != nil {
+	if err := wd.recoverRuns(ctx); err != nil {
 		return err
 	}
 
+	if cluster.IsEnabled() {
+		ctxHeartbeat, ctxCancel := context.WithCancel(context.Background())
+		wd.heartbeatCancel = ctxCancel
+		go func() { wd.heartbeatLoop(ctxHeartbeat) }()
+	}
+
 	wd.booted = true
+	go func() { wd.tryNextAsync() }()
 
 	return nil
 }
@@ -106,14 +153,20 @@ func (wd *workflowDispatcher) Shutdown(ctx context.Context) error {
 	wd.taskMtx.Lock()
 	defer wd.taskMtx.Unlock()
 
+	if wd.heartbeatCancel != nil {
+		wd.heartbeatCancel()
+		wd.heartbeatCancel = nil
+	}
+
 	for runId, task := range wd.processingTasks {
-		task.cancel()
+		task.cancel(errDispatcherShutdown)
 		delete(wd.processingTasks, runId)
 	}
 
 	wd.booted = false
-	wd.pendingRunQueue = make([]string, 0)
+	wd.pendingRunQueue = make([]*taskInfo, 0)
 	wd.processingTasks = make(map[string]*taskInfo)
+	wd.groupLastDispatchAts = make(map[string]time.Time)
 	return nil
 }
 
@@ -125,13 +178,50 @@ func (wd *workflowDispatcher) Start(ctx context.Context, runId string) error {
 		return fmt.Errorf("workflow run %s is already processing", runId)
 	}
 
-	for _, pendingRunId := range wd.pendingRunQueue {
-		if pendingRunId == runId {
+	for _, pendingTask := range wd.pendingRunQueue {
+		if pendingTask.RunId == runId {
 			return fmt.Errorf("workflow run %s is already in the queue", runId)
 		}
 	}
 
-	wd.pendingRunQueue = append(wd.pendingRunQueue, runId)
+	workflowRun, err := wd.workflowRunRepo.GetById(ctx, runId)
+	if err != nil {
+		return err
+	}
+
+	workflow, err := wd.workflowRepo.GetById(ctx, workflowRun.WorkflowId)
+	if err != nil {
+		return err
+	}
+
+	// 按并发策略处理同一工作流中已有的运行
+	activeRunIds, err := wd.listActiveRunIds(ctx, workflow.Id, workflowRun.Id)
+	if err != nil {
+		return err
+	}
+	if len(activeRunIds) > 0 {
+		switch workflow.ConcurrencyPolicy {
+		case domain.WorkflowConcurrencyPolicyTypeSkip:
+			workflowRun.Status = domain.WorkflowRunStatusTypeCanceled
+			workflowRun.EndedAt = time.Now()
+			workflowRun.Error = fmt.Sprintf("skipped, because workrun #%s that belonging to the same workflow already exists", activeRunIds[0])
+			if _, err := wd.workflowRunRepo.Save(ctx, workflowRun); err != nil {
+				return err
+			}
+
+			wd.syslog.Info(fmt.Sprintf("workflow #%s's run #%s was skipped", workflowRun.WorkflowId, workflowRun.Id))
+			return nil
+
+		case domain.WorkflowConcurrencyPolicyTypeCancelRunning:
+			for _, activeRunId := range activeRunIds {
+				if err := wd.cancel(ctx, activeRunId); err != nil {
+					wd.syslog.Warn(fmt.Sprintf("failed to cancel workrun #%s", activeRunId), slog.Any("error", err))
+				}
+			}
+		}
+	}
+
+	wd.pendingRunQueue = append(wd.pendingRunQueue, newTaskInfo(workflow, workflowRun))
 	go func() { wd.tryNextAsync() }()
 
 	return nil
@@ -141,10 +231,20 @@ func (wd *workflowDispatcher) Cancel(ctx context.Context, runId string) error {
 	wd.taskMtx.Lock()
 	defer wd.taskMtx.Unlock()
 
+	if err := wd.cancel(ctx, runId); err != nil {
+		return err
+	}
+
+	go func() { wd.tryNextAsync() }()
+
+	return nil
+}
+
+func (wd *workflowDispatcher) cancel(ctx context.Context, runId string) error {
 	workflowRun, err := wd.workflowRunRepo.GetById(ctx, runId)
 	if err != nil {
 		return err
-	} else if workflowRun.Status != domain.WorkflowRunStatusTypePending && workflowRun.Status != domain.WorkflowRunStatusTypeProcessing {
+	} else if workflowRun.Status != domain.WorkflowRunStatusTypePending && workflowRun.Status != domain.WorkflowRunStatusTypeProcessing && workflowRun.Status != domain.WorkflowRunStatusTypeWaiting {
 		return fmt.Errorf("workrun #%s is already completed", workflowRun.Id)
 	}
 
@@ -167,22 +267,41 @@ func (wd *workflowDispatcher) Cancel(ctx context.Context, runId string) error {
 	}
 
 	if task, exists := wd.processingTasks[runId]; exists {
-		task.cancel()
+		task.cancel(nil)
 		delete(wd.processingTasks, runId)
 
 		wd.syslog.Info(fmt.Sprintf("workrun #%s was canceled", task.RunId))
 	}
 
-	for i, pendingRunId := range wd.pendingRunQueue {
-		if pendingRunId == runId {
-			wd.pendingRunQueue = append(wd.pendingRunQueue[:i], wd.pendingRunQueue[i+1:]...)
-			break
+	wd.pendingRunQueue = lo.Filter(wd.pendingRunQueue, func(t *taskInfo, _ int) bool { return t.RunId != runId })
+
+	return nil
+}
+
+// 列出指定工作流中尚未结束的运行 ID（不含 excludeRunId），调用方须持有锁。
+// 除本节点队列中的任务外，还包括由其他节点执行、等待审批等仅记录在数据库中的运行。
+func (wd *workflowDispatcher) listActiveRunIds(ctx context.Context, workflowId string, excludeRunId string) ([]string, error) {
+	activeRunIds := make([]string, 0)
+	for _, processingTask := range wd.processingTasks {
+		if processingTask.WorkflowId == workflowId {
+			activeRunIds = append(activeRunIds, processingTask.RunId)
+		}
+	}
+	for _, pendingTask := range wd.pendingRunQueue {
+		if pendingTask.WorkflowId == workflowId {
+			activeRunIds = append(activeRunIds, pendingTask.RunId)
 		}
 	}
 
-	go func() { wd.tryNextAsync() }()
+	activeRuns, err := wd.workflowRunRepo.ListActiveByWorkflowId(ctx, workflowId)
+	if err != nil {
+		return nil, err
+	}
+	for _, activeRun := range activeRuns {
+		activeRunIds = append(activeRunIds, activeRun.Id)
+	}
 
-	return nil
+	return lo.Without(lo.Uniq(activeRunIds), excludeRunId), nil
 }
 
 func (wd *workflowDispatcher) tryExecuteAsync(task *taskInfo) {
@@ -224,6 +343,10 @@ func (wd *workflowDispatcher) tryExecuteAsync(task *taskInfo) {
 		return
 	} else {
 		if workflowRun.Status == domain.WorkflowRunStatusTypePending {
+			// 开启实时事件日志，续跑时以此前已持久化的日志作为最初的事件
+			wd.openLiveLog(task.ctx, workflowRun.Id)
+			defer wd.liveLogHub.Close(workflowRun.Id)
+
 			workflowRun.Status = domain.WorkflowRunStatusTypeProcessing
 			wd.workflowRunRepo.SaveWithCascading(task.ctx, workflowRun)
 		} else {
@@ -241,9 +364,14 @@ func (wd *workflowDispatcher) tryExecuteAsync(task *taskInfo) {
 
 	// 初始化工作流引擎
 	logsBuf := make(domain.WorkflowLogs, 0)
+	logsMtx := sync.Mutex{} // 并行节点可能会并发写入日志
 	we := engine.NewWorkflowEngine()
 	we.OnEnd(func(ctx context.Context) error {
-		if errmsg := logsBuf.ErrorString(); errmsg == "" {
+		logsMtx.Lock()
+		errmsg := logsBuf.ErrorString()
+		logsMtx.Unlock()
+
+		if errmsg == "" {
 			workflowRun.Status = domain.WorkflowRunStatusTypeSucceeded
 			workflowRun.EndedAt = time.Now()
 		} else {
@@ -256,7 +384,23 @@ func (wd *workflowDispatcher) tryExecuteAsync(task *taskInfo) {
 		return nil
 	})
 	we.OnError(func(ctx context.Context, err error) error {
-		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		if errors.Is(err, engine.ErrWaiting) {
+			// 运行被挂起，待收到外部决定后再重新入队续跑，期间不占用并发数
+			workflowRun.Status = domain.WorkflowRunStatusTypeWaiting
+			wd.workflowRunRepo.SaveWithCascading(context.Background(), workflowRun)
+			wd.syslog.Info(fmt.Sprintf("workflow #%s's run #%s is waiting", task.WorkflowId, task.RunId))
+		} else if errors.Is(err, engine.ErrRunTimeout) {
+			workflowRun.Status = domain.WorkflowRunStatusTypeTimeout
+			workflowRun.EndedAt = time.Now()
+			workflowRun.Error = err.Error()
+			wd.workflowRunRepo.SaveWithCascading(context.Background(), workflowRun)
+		} else if errors.Is(context.Cause(task.ctx), errDispatcherShutdown) {
+			// 调度器停止导致的取消不更新运行状态，留待下次启动时按中断的运行恢复
+			wd.syslog.Warn(fmt.Sprintf("workflow #%s's run #%s was interrupted", task.WorkflowId, task.RunId))
+		} else if errors.Is(context.Cause(task.ctx), errLeaseLost) {
+			// 租约丢失导致的取消不更新运行状态，运行已被取消或已被其他节点接管
+			wd.syslog.Warn(fmt.Sprintf("workflow #%s's run #%s was stopped", task.WorkflowId, task.RunId))
+		} else if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
 			workflowRun.Status = domain.WorkflowRunStatusTypeCanceled
 			wd.workflowRunRepo.SaveWithCascading(context.Background(), workflowRun)
 		} else {
@@ -268,6 +412,24 @@ func (wd *workflowDispatcher) tryExecuteAsync(task *taskInfo) {
 
 		return nil
 	})
+	we.OnNodeStart(func(ctx context.Context, node *engine.Node) error {
+		runId := task.RunId
+		if run, ok := engine.GetExecutingRun(ctx); ok {
+			runId = run.RunId
+		}
+
+		wd.liveLogHub.Publish(livelog.NewNodeEvent(domain.WorkflowRunEventTypeNodeStart, runId, node.Id, node.Data.Name, nil))
+		return nil
+	})
+	we.OnNodeEnd(func(ctx context.Context, node *engine.Node, res *engine.NodeExecutionResult) error {
+		runId := task.RunId
+		if run, ok := engine.GetExecutingRun(ctx); ok {
+			runId = run.RunId
+		}
+
+		wd.liveLogHub.Publish(livelog.NewNodeEvent(domain.WorkflowRunEventTypeNodeEnd, runId, node.Id, node.Data.Name, nil))
+		return nil
+	})
 	we.OnNodeError(func(ctx context.Context, node *engine.Node, err error) error {
 		if errors.Is(err, engine.ErrTerminated) || errors.Is(err, engine.ErrBlocksException) {
 			return nil
@@ -282,12 +444,23 @@ func (wd *workflowDispatcher) tryExecuteAsync(task *taskInfo) {
 		log.Level = int32(slog.LevelError)
 		log.Message = err.Error()
 		log.CreatedAt = time.Now()
-		logsBuf = append(logsBuf, log)
+		log.TraceId = tracing.TraceId(ctx)
+		if run, ok := engine.GetExecutingRun(ctx); ok {
+			log.WorkflowId = run.WorkflowId
+			log.RunId = run.RunId
+		}
+		if log.RunId == task.RunId {
+			logsMtx.Lock()
+			logsBuf = append(logsBuf, log)
+			logsMtx.Unlock()
+		}
 
 		if _, err := wd.workflowLogRepo.Save(ctx, &log); err != nil {
 			wd.syslog.Error(err.Error())
 		}
 
+		wd.liveLogHub.Publish(livelog.NewLogEvent(&log))
+		wd.liveLogHub.Publish(livelog.NewNodeEvent(domain.WorkflowRunEventTypeNodeError, log.RunId, node.Id, node.Data.Name, err))
 		return nil
 	})
 	we.OnNodeLogging(func(ctx context.Context, node *engine.Node, record logging.Record) error {
@@ -301,81 +474,147 @@ func (wd *workflowDispatcher) tryExecuteAsync(task *taskInfo) {
 		log.Message = record.Message
 		log.Data = record.Data()
 		log.CreatedAt = time.Now()
-		logsBuf = append(logsBuf, log)
+		log.TraceId = tracing.TraceId(ctx)
+		if run, ok := engine.GetExecutingRun(ctx); ok {
+			log.WorkflowId = run.WorkflowId
+			log.RunId = run.RunId
+		}
+		if log.RunId == task.RunId {
+			logsMtx.Lock()
+			logsBuf = append(logsBuf, log)
+			logsMtx.Unlock()
+		}
 
 		if _, err := wd.workflowLogRepo.Save(ctx, &log); err != nil {
 			wd.syslog.Error(err.Error())
 		}
 
+		wd.liveLogHub.Publish(livelog.NewLogEvent(&log))
 		return nil
 	})
 
+	// 记录运行状态快照，以便失败后续跑
+	if workflowRun.State == nil {
+		workflowRun.State = &domain.WorkflowRunState{}
+	}
+
+	// 每次运行对应一条追踪链路，各节点的执行将作为其子 Span
+	runCtx, runSpan := tracing.Start(task.ctx, "workflow.run",
+		attribute.String("workflow.id", workflow.Id),
+		attribute.String("workflow.name", workflow.Name),
+		attribute.String("workflow.run.id", workflowRun.Id),
+		attribute.String("workflow.run.trigger", string(workflowRun.Trigger)),
+		attribute.Int("workflow.run.attempt", max(workflowRun.Attempt, 1)),
+	)
+	defer func() {
+		runSpan.SetAttributes(attribute.String("workflow.run.status", string(workflowRun.Status)))
+		if workflowRun.Error != "" {
+			tracing.End(runSpan, errors.New(workflowRun.Error))
+		} else {
+			tracing.End(runSpan, nil)
+		}
+	}()
+
 	// 执行工作流
 	wd.syslog.Info(fmt.Sprintf("workflow #%s's run #%s started", task.WorkflowId, task.RunId))
-	we.Invoke(task.ctx, engine.WorkflowExecution{
+	we.Invoke(runCtx, engine.WorkflowExecution{
 		WorkflowId:          workflow.Id,
 		WorkflowName:        workflow.Name,
 		WorkflowDescription: workflow.Description,
 		RunId:               workflowRun.Id,
 		RunTrigger:          workflowRun.Trigger,
 		RunAt:               workflowRun.StartedAt,
+		RunInputs:           workflowRun.Inputs,
+		RunState:            workflowRun.State,
+		RunTimeout:          time.Duration(workflow.RunTimeout) * time.Second,
 		Graph:               workflowRun.Graph,
 	})
 	wd.syslog.Info(fmt.Sprintf("workflow #%s's run #%s stopped", task.WorkflowId, task.RunId))
 }
 
+// 按调度顺序依次调度等待队列中的任务，直至没有可调度的任务或并发数已满。
 func (wd *workflowDispatcher) tryNextAsync() {
-	wd.taskMtx.RLock()
-
-	for _, pendingRunId := range wd.pendingRunQueue {
-		workflowRun, err := wd.workflowRunRepo.GetById(context.Background(), pendingRunId)
-		if err != nil {
-			wd.syslog.Error(fmt.Sprintf("failed to get workrun #%s record", pendingRunId), slog.Any("error", err))
-			continue
-		}
+	wd.taskMtx.Lock()
+	defer wd.taskMtx.Unlock()
 
-		var hasSameWorkflowTask bool // 相同 Workflow 的任务同一时间只能有一个 Run 在执行
+	for _, pendingTask := range wd.sortPendingTasks() {
+		var hasSameWorkflowTask bool // 除非并发策略允许，相同 Workflow 的任务同一时间只能有一个 Run 在执行
+		var sameMutexGroup string    // 相同互斥组的任务同一时间只能有一个 Run 在执行
 		for _, processingTask := range wd.processingTasks {
-			if processingTask.WorkflowId == workflowRun.WorkflowId {
+			if processingTask.WorkflowId == pendingTask.WorkflowId && pendingTask.ConcurrencyPolicy != domain.WorkflowConcurrencyPolicyTypeAllow {
 				hasSameWorkflowTask = true
 				break
 			}
+
+			if group, ok := lo.Find(pendingTask.MutexGroups, func(g string) bool { return lo.Contains(processingTask.MutexGroups, g) }); ok {
+				sameMutexGroup = group
+				break
+			}
 		}
 
 		if hasSameWorkflowTask {
-			wd.syslog.Warn(fmt.Sprintf("workflow #%s's run #%s is pending, because tasks that belonging to the same workflow already exists", workflowRun.WorkflowId, workflowRun.Id))
+			wd.syslog.Warn(fmt.Sprintf("workflow #%s's run #%s is pending, because tasks that belonging to the same workflow already exists", pendingTask.WorkflowId, pendingTask.RunId))
+		} else if sameMutexGroup != "" {
+			wd.syslog.Warn(fmt.Sprintf("workflow #%s's run #%s is pending, because tasks that belonging to the same mutex group '%s' already exists", pendingTask.WorkflowId, pendingTask.RunId, sameMutexGroup))
 		} else if len(wd.processingTasks) >= wd.concurrency && wd.concurrency > 0 {
-			wd.syslog.Warn(fmt.Sprintf("workflow #%s's run #%s is pending, because the maximum concurrency (limit: %d) has been reached", workflowRun.WorkflowId, workflowRun.Id, wd.concurrency))
+			wd.syslog.Warn(fmt.Sprintf("workflow #%s's run #%s is pending, because the maximum concurrency (limit: %d) has been reached", pendingTask.WorkflowId, pendingTask.RunId, wd.concurrency))
+			return
 		} else {
-			wd.taskMtx.RUnlock()
+			// 集群模式下须先认领之，以免同一运行被多个节点执行
+			if ok, err := wd.claimTask(context.Background(), pendingTask); err != nil {
+				wd.syslog.Error(fmt.Sprintf("failed to claim workrun #%s", pendingTask.RunId), slog.Any("error", err))
+				continue
+			} else if !ok {
+				if wd.shouldDropUnclaimedTask(context.Background(), pendingTask) {
+					wd.pendingRunQueue = lo.Filter(wd.pendingRunQueue, func(t *taskInfo, _ int) bool { return t.RunId != pendingTask.RunId })
+					wd.syslog.Info(fmt.Sprintf("workflow #%s's run #%s is taken by another node", pendingTask.WorkflowId, pendingTask.RunId))
+				} else {
+					wd.syslog.Warn(fmt.Sprintf("workflow #%s's run #%s is pending, because tasks that conflicting with it are running on other nodes", pendingTask.WorkflowId, pendingTask.RunId))
+				}
+				continue
+			}
 
-			wd.taskMtx.Lock()
-			ctxRun, ctxCancel := context.WithCancel(context.Background())
-			task := &taskInfo{WorkflowId: workflowRun.WorkflowId, RunId: workflowRun.Id, ctx: ctxRun, cancel: ctxCancel}
-			wd.pendingRunQueue = lo.Filter(wd.pendingRunQueue, func(s string, _ int) bool { return s != pendingRunId })
-			wd.processingTasks[pendingRunId] = task
+			task := pendingTask
+			task.ctx, task.cancel = context.WithCancelCause(context.Background())
+			task.DispatchedAt = time.Now()
+			wd.pendingRunQueue = lo.Filter(wd.pendingRunQueue, func(t *taskInfo, _ int) bool { return t.RunId != task.RunId })
+			wd.processingTasks[task.RunId] = task
+			wd.groupLastDispatchAts[task.schedulingGroup()] = task.DispatchedAt
 			wd.syslog.Info(fmt.Sprintf("workflow #%s's run #%s is being dispatched ...", task.WorkflowId, task.RunId))
-			wd.taskMtx.Unlock()
 
 			go func() { wd.tryExecuteAsync(task) }()
-			return
 		}
 	}
+}
+
+// 开启运行的实时事件日志，以此前已持久化的日志作为最初的事件。
+func (wd *workflowDispatcher) openLiveLog(ctx context.Context, runId string) {
+	logs, err := wd.workflowLogRepo.ListByWorkflowRunId(ctx, runId)
+	if err != nil {
+		wd.syslog.Warn(fmt.Sprintf("failed to list logs of workrun #%s", runId), slog.Any("error", err))
+	}
 
-	wd.taskMtx.RUnlock()
+	initial := make([]*domain.WorkflowRunEvent, 0, len(logs))
+	for _, log := range logs {
+		initial = append(initial, livelog.NewLogEvent(log))
+	}
+	wd.liveLogHub.Open(runId, initial)
 }
 
 func newWorkflowDispatcher() WorkflowDispatcher {
 	return &workflowDispatcher{
 		concurrency: envMaxWorkers,
 
-		pendingRunQueue: make([]string, 0),
-		processingTasks: make(map[string]*taskInfo),
+		pendingRunQueue:      make([]*taskInfo, 0),
+		processingTasks:      make(map[string]*taskInfo),
+		groupLastDispatchAts: make(map[string]time.Time),
 
 		workflowRepo:    repository.NewWorkflowRepository(),
 		workflowRunRepo: repository.NewWorkflowRunRepository(),
 		workflowLogRepo: repository.NewWorkflowLogRepository(),
 
+		liveLogHub: livelog.GetSingletonHub(),
+
 		syslog: app.GetLogger(),
 	}
 }
@@ -0,0 +1,384 @@
+package dispatcher
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pocketbase/dbx"
+	"github.com/samber/lo"
+
+	"github.com/certimate-go/certimate/internal/domain"
+)
+
+type testWorkflowRepository struct {
+	workflows map[string]*domain.Workflow
+}
+
+func (r *testWorkflowRepository) GetById(ctx context.Context, id string) (*domain.Workflow, error) {
+	if workflow, ok := r.workflows[id]; ok {
+		return workflow, nil
+	}
+	return nil, domain.ErrRecordNotFound
+}
+
+func (r *testWorkflowRepository) Save(ctx context.Context, workflow *domain.Workflow) (*domain.Workflow, error) {
+	r.workflows[workflow.Id] = workflow
+	return workflow, nil
+}
+
+type testWorkflowRunRepository struct {
+	mtx  sync.Mutex
+	runs map[string]*domain.WorkflowRun
+}
+
+func (r *testWorkflowRunRepository) ListByStatus(ctx context.Context, status domain.WorkflowRunStatusType) ([]*domain.WorkflowRun, error) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	runs := make([]*domain.WorkflowRun, 0)
+	for _, run := range r.runs {
+		if run.Status == status {
+			runs = append(runs, lo.ToPtr(*run))
+		}
+	}
+	return runs, nil
+}
+
+func (r *testWorkflowRunRepository) ListActiveByWorkflowId(ctx context.Context, workflowId string) ([]*domain.WorkflowRun, error) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	runs := make([]*domain.WorkflowRun, 0)
+	for _, run := range r.runs {
+		if run.WorkflowId == workflowId && (run.Status == domain.WorkflowRunStatusTypePending || run.Status == domain.WorkflowRunStatusTypeProcessing || run.Status == domain.WorkflowRunStatusTypeWaiting) {
+			runs = append(runs, lo.ToPtr(*run))
+		}
+	}
+	return runs, nil
+}
+
+func (r *testWorkflowRunRepository) GetById(ctx context.Context, id string) (*domain.WorkflowRun, error) {
+	// 被调度的任务将以其自身的上下文查询运行，于此阻塞直至任务被取消，以模拟执行中的运行
+	if ctx.Done() != nil {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	if run, ok := r.runs[id]; ok {
+		return lo.ToPtr(*run), nil
+	}
+	return nil, domain.ErrRecordNotFound
+}
+
+func (r *testWorkflowRunRepository) Save(ctx context.Context, workflowRun *domain.WorkflowRun) (*domain.WorkflowRun, error) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	r.runs[workflowRun.Id] = lo.ToPtr(*workflowRun)
+	return workflowRun, nil
+}
+
+func (r *testWorkflowRunRepository) SaveWithCascading(ctx context.Context, workflowRun *domain.WorkflowRun) (*domain.WorkflowRun, error) {
+	return r.Save(ctx, workflowRun)
+}
+
+func (r *testWorkflowRunRepository) Claim(ctx context.Context, runId string, status domain.WorkflowRunStatusType, nodeId string, leaseTTL time.Duration, exprs ...dbx.Expression) (bool, error) {
+	return true, nil
+}
+
+func (r *testWorkflowRunRepository) RenewLease(ctx context.Context, runId string, nodeId string, leaseTTL time.Duration) (bool, error) {
+	return true, nil
+}
+
+func (r *testWorkflowRunRepository) status(id string) domain.WorkflowRunStatusType {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	return r.runs[id].Status
+}
+
+func newTestDispatcher(concurrency int, workflows []*domain.Workflow, runs []*domain.WorkflowRun, processingTasks []*taskInfo) (*workflowDispatcher, *testWorkflowRunRepository) {
+	workflowRunRepo := &testWorkflowRunRepository{
+		runs: lo.SliceToMap(runs, func(r *domain.WorkflowRun) (string, *domain.WorkflowRun) { return r.Id, r }),
+	}
+
+	wd := &workflowDispatcher{
+		concurrency:          concurrency,
+		pendingRunQueue:      make([]*taskInfo, 0),
+		processingTasks:      make(map[string]*taskInfo),
+		groupLastDispatchAts: make(map[string]time.Time),
+		workflowRepo: &testWorkflowRepository{
+			workflows: lo.SliceToMap(workflows, func(w *domain.Workflow) (string, *domain.Workflow) { return w.Id, w }),
+		},
+		workflowRunRepo: workflowRunRepo,
+		syslog:          slog.New(slog.DiscardHandler),
+	}
+	for _, task := range processingTasks {
+		task.ctx, task.cancel = context.WithCancelCause(context.Background())
+		wd.processingTasks[task.RunId] = task
+	}
+
+	return wd, workflowRunRepo
+}
+
+// 停止全部任务，以免被调度的任务阻塞。
+func (wd *workflowDispatcher) stopTestTasks() {
+	wd.taskMtx.Lock()
+	defer wd.taskMtx.Unlock()
+
+	wd.pendingRunQueue = make([]*taskInfo, 0)
+	for _, task := range wd.processingTasks {
+		task.cancel(nil)
+	}
+}
+
+func (wd *workflowDispatcher) testRunIds() (pendingRunIds []string, processingRunIds []string) {
+	wd.taskMtx.RLock()
+	defer wd.taskMtx.RUnlock()
+
+	pendingRunIds = lo.Map(wd.pendingRunQueue, func(t *taskInfo, _ int) string { return t.RunId })
+	processingRunIds = lo.Keys(wd.processingTasks)
+	slices.Sort(processingRunIds)
+	return pendingRunIds, processingRunIds
+}
+
+func TestStartConcurrencyPolicy(t *testing.T) {
+	tests := []struct {
+		name           string
+		policy         domain.WorkflowConcurrencyPolicyType
+		activeRuns     []*domain.WorkflowRun // 同一工作流中已有的运行
+		activeTasks    []string              // 其中在本节点执行中的运行
+		wantQueued     bool
+		wantStatus     domain.WorkflowRunStatusType
+		wantCanceled   []string
+		wantProcessing []string
+	}{
+		{
+			name:           "no active runs",
+			policy:         domain.WorkflowConcurrencyPolicyTypeSkip,
+			wantQueued:     true,
+			wantStatus:     domain.WorkflowRunStatusTypePending,
+			wantProcessing: []string{"blocker"},
+		},
+		{
+			name:           "skip",
+			policy:         domain.WorkflowConcurrencyPolicyTypeSkip,
+			activeRuns:     []*domain.WorkflowRun{{Meta: domain.Meta{Id: "run1"}, WorkflowId: "wf1", Status: domain.WorkflowRunStatusTypeProcessing}},
+			activeTasks:    []string{"run1"},
+			wantQueued:     false,
+			wantStatus:     domain.WorkflowRunStatusTypeCanceled,
+			wantProcessing: []string{"blocker", "run1"},
+		},
+		{
+			name:           "skip on waiting run in database",
+			policy:         domain.WorkflowConcurrencyPolicyTypeSkip,
+			activeRuns:     []*domain.WorkflowRun{{Meta: domain.Meta{Id: "run1"}, WorkflowId: "wf1", Status: domain.WorkflowRunStatusTypeWaiting}},
+			wantQueued:     false,
+			wantStatus:     domain.WorkflowRunStatusTypeCanceled,
+			wantProcessing: []string{"blocker"},
+		},
+		{
+			name:           "queue",
+			policy:         domain.WorkflowConcurrencyPolicyTypeQueue,
+			activeRuns:     []*domain.WorkflowRun{{Meta: domain.Meta{Id: "run1"}, WorkflowId: "wf1", Status: domain.WorkflowRunStatusTypeProcessing}},
+			activeTasks:    []string{"run1"},
+			wantQueued:     true,
+			wantStatus:     domain.WorkflowRunStatusTypePending,
+			wantProcessing: []string{"blocker", "run1"},
+		},
+		{
+			name:           "default to queue",
+			activeRuns:     []*domain.WorkflowRun{{Meta: domain.Meta{Id: "run1"}, WorkflowId: "wf1", Status: domain.WorkflowRunStatusTypeProcessing}},
+			activeTasks:    []string{"run1"},
+			wantQueued:     true,
+			wantStatus:     domain.WorkflowRunStatusTypePending,
+			wantProcessing: []string{"blocker", "run1"},
+		},
+		{
+			name:           "allow",
+			policy:         domain.WorkflowConcurrencyPolicyTypeAllow,
+			activeRuns:     []*domain.WorkflowRun{{Meta: domain.Meta{Id: "run1"}, WorkflowId: "wf1", Status: domain.WorkflowRunStatusTypeProcessing}},
+			activeTasks:    []string{"run1"},
+			wantQueued:     true,
+			wantStatus:     domain.WorkflowRunStatusTypePending,
+			wantProcessing: []string{"blocker", "run1"},
+		},
+		{
+			name:   "cancel running",
+			policy: domain.WorkflowConcurrencyPolicyTypeCancelRunning,
+			activeRuns: []*domain.WorkflowRun{
+				{Meta: domain.Meta{Id: "run1"}, WorkflowId: "wf1", Status: domain.WorkflowRunStatusTypeProcessing},
+				{Meta: domain.Meta{Id: "run2"}, WorkflowId: "wf1", Status: domain.WorkflowRunStatusTypeWaiting},
+			},
+			activeTasks:    []string{"run1"},
+			wantQueued:     true,
+			wantStatus:     domain.WorkflowRunStatusTypePending,
+			wantCanceled:   []string{"run1", "run2"},
+			wantProcessing: []string{"blocker"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			workflows := []*domain.Workflow{
+				{Meta: domain.Meta{Id: "wf1"}, ConcurrencyPolicy: tt.policy},
+				{Meta: domain.Meta{Id: "wf2"}},
+			}
+			runs := []*domain.WorkflowRun{
+				{Meta: domain.Meta{Id: "blocker"}, WorkflowId: "wf2", Status: domain.WorkflowRunStatusTypeProcessing},
+				{Meta: domain.Meta{Id: "new"}, WorkflowId: "wf1", Status: domain.WorkflowRunStatusTypePending},
+			}
+			runs = append(runs, tt.activeRuns...)
+			// 并发数已满，以免新运行被调度
+			tasks := []*taskInfo{{WorkflowId: "wf2", RunId: "blocker"}}
+			for _, runId := range tt.activeTasks {
+				tasks = append(tasks, &taskInfo{WorkflowId: "wf1", RunId: runId, ConcurrencyPolicy: tt.policy})
+			}
+
+			wd, workflowRunRepo := newTestDispatcher(1, workflows, runs, tasks)
+			defer wd.stopTestTasks()
+
+			if err := wd.Start(context.Background(), "new"); err != nil {
+				t.Fatalf("Start() error = %v", err)
+			}
+
+			pendingRunIds, processingRunIds := wd.testRunIds()
+			if queued := slices.Contains(pendingRunIds, "new"); queued != tt.wantQueued {
+				t.Errorf("Start() queued = %v, want %v", queued, tt.wantQueued)
+			}
+			if status := workflowRunRepo.status("new"); status != tt.wantStatus {
+				t.Errorf("Start() status = %v, want %v", status, tt.wantStatus)
+			}
+			if !slices.Equal(processingRunIds, tt.wantProcessing) {
+				t.Errorf("Start() processing = %v, want %v", processingRunIds, tt.wantProcessing)
+			}
+			for _, run := range tt.activeRuns {
+				wantStatus := run.Status
+				if slices.Contains(tt.wantCanceled, run.Id) {
+					wantStatus = domain.WorkflowRunStatusTypeCanceled
+				}
+				if status := workflowRunRepo.status(run.Id); status != wantStatus {
+					t.Errorf("Start() status of %s = %v, want %v", run.Id, status, wantStatus)
+				}
+			}
+			for _, task := range tasks {
+				canceled := task.ctx.Err() != nil
+				if canceled != slices.Contains(tt.wantCanceled, task.RunId) {
+					t.Errorf("Start() task %s canceled = %v", task.RunId, canceled)
+				}
+			}
+		})
+	}
+}
+
+func TestTryNextConcurrency(t *testing.T) {
+	tests := []struct {
+		name            string
+		concurrency     int
+		pendingTasks    []*taskInfo
+		processingTasks []*taskInfo
+		wantDispatched  []string
+	}{
+		{
+			name:        "dispatch all",
+			concurrency: 2,
+			pendingTasks: []*taskInfo{
+				{WorkflowId: "wf1", RunId: "run1"},
+				{WorkflowId: "wf2", RunId: "run2"},
+			},
+			wantDispatched: []string{"run1", "run2"},
+		},
+		{
+			name:        "dispatch until maximum concurrency reached",
+			concurrency: 2,
+			pendingTasks: []*taskInfo{
+				{WorkflowId: "wf1", RunId: "run1"},
+				{WorkflowId: "wf2", RunId: "run2"},
+				{WorkflowId: "wf3", RunId: "run3"},
+			},
+			wantDispatched: []string{"run1", "run2"},
+		},
+		{
+			name:        "same workflow in queue",
+			concurrency: 0,
+			pendingTasks: []*taskInfo{
+				{WorkflowId: "wf1", RunId: "run1", ConcurrencyPolicy: domain.WorkflowConcurrencyPolicyTypeQueue},
+				{WorkflowId: "wf1", RunId: "run2", ConcurrencyPolicy: domain.WorkflowConcurrencyPolicyTypeQueue},
+				{WorkflowId: "wf2", RunId: "run3"},
+			},
+			wantDispatched: []string{"run1", "run3"},
+		},
+		{
+			name:        "same workflow",
+			concurrency: 2,
+			pendingTasks: []*taskInfo{
+				{WorkflowId: "wf1", RunId: "run1", ConcurrencyPolicy: domain.WorkflowConcurrencyPolicyTypeQueue},
+				{WorkflowId: "wf2", RunId: "run2"},
+			},
+			processingTasks: []*taskInfo{
+				{WorkflowId: "wf1", RunId: "run0"},
+			},
+			wantDispatched: []string{"run2"},
+		},
+		{
+			name:        "same workflow allowed",
+			concurrency: 2,
+			pendingTasks: []*taskInfo{
+				{WorkflowId: "wf1", RunId: "run1", ConcurrencyPolicy: domain.WorkflowConcurrencyPolicyTypeAllow},
+			},
+			processingTasks: []*taskInfo{
+				{WorkflowId: "wf1", RunId: "run0"},
+			},
+			wantDispatched: []string{"run1"},
+		},
+		{
+			name:        "same mutex group",
+			concurrency: 0,
+			pendingTasks: []*taskInfo{
+				{WorkflowId: "wf1", RunId: "run1", MutexGroups: []string{"g1", "g2"}},
+				{WorkflowId: "wf2", RunId: "run2", MutexGroups: []string{"g3"}},
+			},
+			processingTasks: []*taskInfo{
+				{WorkflowId: "wf3", RunId: "run0", MutexGroups: []string{"g2"}},
+			},
+			wantDispatched: []string{"run2"},
+		},
+		{
+			name:        "maximum concurrency reached",
+			concurrency: 1,
+			pendingTasks: []*taskInfo{
+				{WorkflowId: "wf1", RunId: "run1", ConcurrencyPolicy: domain.WorkflowConcurrencyPolicyTypeAllow},
+			},
+			processingTasks: []*taskInfo{
+				{WorkflowId: "wf2", RunId: "run0"},
+			},
+			wantDispatched: []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wd, _ := newTestDispatcher(tt.concurrency, nil, nil, tt.processingTasks)
+			defer wd.stopTestTasks()
+
+			wd.pendingRunQueue = slices.Clone(tt.pendingTasks)
+			wd.tryNextAsync()
+
+			pendingRunIds, processingRunIds := wd.testRunIds()
+			dispatched := lo.Without(processingRunIds, lo.Map(tt.processingTasks, func(t *taskInfo, _ int) string { return t.RunId })...)
+			if !slices.Equal(dispatched, tt.wantDispatched) {
+				t.Errorf("tryNextAsync() dispatched = %v, want %v", dispatched, tt.wantDispatched)
+			}
+			for _, runId := range dispatched {
+				if slices.Contains(pendingRunIds, runId) {
+					t.Errorf("tryNextAsync() should remove %s from the queue", runId)
+				}
+			}
+		})
+	}
+}
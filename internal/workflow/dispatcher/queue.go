@@ -0,0 +1,57 @@
+package dispatcher
+
+import (
+	"cmp"
+	"slices"
+
+	"github.com/certimate-go/certimate/internal/domain"
+)
+
+// 按调度顺序对等待队列中的任务进行排序，返回排序后的副本，调用方须持有锁。
+//
+// 排序规则依次为：
+//   - 手动触发的运行优先于其他方式触发的运行；
+//   - 工作流优先级较高者优先；
+//   - 执行中任务数较少的调度分组优先；
+//   - 最近一次被调度较早的调度分组优先，以便各分组轮流分享并发数；
+//   - 先入队者优先。
+func (wd *workflowDispatcher) sortPendingTasks() []*taskInfo {
+	groupProcessingCounts := make(map[string]int)
+	for _, processingTask := range wd.processingTasks {
+		groupProcessingCounts[processingTask.schedulingGroup()]++
+	}
+
+	sorted := slices.Clone(wd.pendingRunQueue)
+	slices.SortStableFunc(sorted, func(a, b *taskInfo) int {
+		if c := cmp.Compare(triggerRank(b.RunTrigger), triggerRank(a.RunTrigger)); c != 0 {
+			return c
+		}
+
+		if c := cmp.Compare(b.Priority, a.Priority); c != 0 {
+			return c
+		}
+
+		groupA, groupB := a.schedulingGroup(), b.schedulingGroup()
+		if groupA != groupB {
+			if c := cmp.Compare(groupProcessingCounts[groupA], groupProcessingCounts[groupB]); c != 0 {
+				return c
+			}
+
+			if c := wd.groupLastDispatchAts[groupA].Compare(wd.groupLastDispatchAts[groupB]); c != 0 {
+				return c
+			}
+		}
+
+		return a.EnqueuedAt.Compare(b.EnqueuedAt)
+	})
+
+	return sorted
+}
+
+func triggerRank(trigger domain.WorkflowTriggerType) int {
+	if trigger == domain.WorkflowTriggerTypeManual {
+		return 1
+	}
+
+	return 0
+}
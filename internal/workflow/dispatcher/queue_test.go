@@ -0,0 +1,131 @@
+package dispatcher
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/samber/lo"
+
+	"github.com/certimate-go/certimate/internal/domain"
+)
+
+func TestSortPendingTasks(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name                 string
+		pendingTasks         []*taskInfo
+		processingTasks      []*taskInfo
+		groupLastDispatchAts map[string]time.Time
+		want                 []string
+	}{
+		{
+			name: "enqueued order",
+			pendingTasks: []*taskInfo{
+				{WorkflowId: "wf1", RunId: "run2", EnqueuedAt: now.Add(2 * time.Second)},
+				{WorkflowId: "wf1", RunId: "run1", EnqueuedAt: now.Add(1 * time.Second)},
+				{WorkflowId: "wf1", RunId: "run3", EnqueuedAt: now.Add(3 * time.Second)},
+			},
+			want: []string{"run1", "run2", "run3"},
+		},
+		{
+			name: "manual trigger first",
+			pendingTasks: []*taskInfo{
+				{WorkflowId: "wf1", RunId: "run1", RunTrigger: domain.WorkflowTriggerTypeScheduled, Priority: 10, EnqueuedAt: now.Add(1 * time.Second)},
+				{WorkflowId: "wf2", RunId: "run2", RunTrigger: domain.WorkflowTriggerTypeManual, EnqueuedAt: now.Add(2 * time.Second)},
+			},
+			want: []string{"run2", "run1"},
+		},
+		{
+			name: "higher priority first",
+			pendingTasks: []*taskInfo{
+				{WorkflowId: "wf1", RunId: "run1", Priority: 0, EnqueuedAt: now.Add(1 * time.Second)},
+				{WorkflowId: "wf2", RunId: "run2", Priority: 5, EnqueuedAt: now.Add(2 * time.Second)},
+				{WorkflowId: "wf3", RunId: "run3", Priority: -1, EnqueuedAt: now.Add(3 * time.Second)},
+			},
+			want: []string{"run2", "run1", "run3"},
+		},
+		{
+			name: "fewer processing tasks in group first",
+			pendingTasks: []*taskInfo{
+				{WorkflowId: "wf1", RunId: "run1", SchedulingGroup: "g1", EnqueuedAt: now.Add(1 * time.Second)},
+				{WorkflowId: "wf2", RunId: "run2", SchedulingGroup: "g2", EnqueuedAt: now.Add(2 * time.Second)},
+			},
+			processingTasks: []*taskInfo{
+				{WorkflowId: "wf3", RunId: "run0", SchedulingGroup: "g1"},
+			},
+			want: []string{"run2", "run1"},
+		},
+		{
+			name: "least recently dispatched group first",
+			pendingTasks: []*taskInfo{
+				{WorkflowId: "wf1", RunId: "run1", SchedulingGroup: "g1", EnqueuedAt: now.Add(1 * time.Second)},
+				{WorkflowId: "wf2", RunId: "run2", SchedulingGroup: "g1", EnqueuedAt: now.Add(2 * time.Second)},
+				{WorkflowId: "wf3", RunId: "run3", SchedulingGroup: "g2", EnqueuedAt: now.Add(3 * time.Second)},
+			},
+			groupLastDispatchAts: map[string]time.Time{
+				"g1": now.Add(-1 * time.Minute),
+				"g2": now.Add(-2 * time.Minute),
+			},
+			want: []string{"run3", "run1", "run2"},
+		},
+		{
+			name: "never dispatched group first",
+			pendingTasks: []*taskInfo{
+				{WorkflowId: "wf1", RunId: "run1", EnqueuedAt: now.Add(1 * time.Second)},
+				{WorkflowId: "wf2", RunId: "run2", EnqueuedAt: now.Add(2 * time.Second)},
+			},
+			groupLastDispatchAts: map[string]time.Time{
+				"workflow:wf1": now.Add(-1 * time.Minute),
+			},
+			want: []string{"run2", "run1"},
+		},
+		{
+			name: "same group in enqueued order",
+			pendingTasks: []*taskInfo{
+				{WorkflowId: "wf2", RunId: "run2", SchedulingGroup: "g1", EnqueuedAt: now.Add(2 * time.Second)},
+				{WorkflowId: "wf1", RunId: "run1", SchedulingGroup: "g1", EnqueuedAt: now.Add(1 * time.Second)},
+			},
+			processingTasks: []*taskInfo{
+				{WorkflowId: "wf3", RunId: "run0", SchedulingGroup: "g1"},
+			},
+			groupLastDispatchAts: map[string]time.Time{
+				"g1": now,
+			},
+			want: []string{"run1", "run2"},
+		},
+		{
+			name: "priority over fairness",
+			pendingTasks: []*taskInfo{
+				{WorkflowId: "wf1", RunId: "run1", SchedulingGroup: "g1", Priority: 1, EnqueuedAt: now.Add(1 * time.Second)},
+				{WorkflowId: "wf2", RunId: "run2", SchedulingGroup: "g2", EnqueuedAt: now.Add(2 * time.Second)},
+			},
+			processingTasks: []*taskInfo{
+				{WorkflowId: "wf3", RunId: "run0", SchedulingGroup: "g1"},
+			},
+			groupLastDispatchAts: map[string]time.Time{
+				"g1": now,
+			},
+			want: []string{"run1", "run2"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			queue := slices.Clone(tt.pendingTasks)
+			wd := &workflowDispatcher{
+				pendingRunQueue:      queue,
+				processingTasks:      lo.SliceToMap(tt.processingTasks, func(t *taskInfo) (string, *taskInfo) { return t.RunId, t }),
+				groupLastDispatchAts: tt.groupLastDispatchAts,
+			}
+
+			got := lo.Map(wd.sortPendingTasks(), func(t *taskInfo, _ int) string { return t.RunId })
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("sortPendingTasks() = %v, want %v", got, tt.want)
+			}
+			if !slices.Equal(queue, tt.pendingTasks) {
+				t.Errorf("sortPendingTasks() should not modify the queue")
+			}
+		})
+	}
+}
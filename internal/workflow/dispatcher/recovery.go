@@ -0,0 +1,117 @@
+package dispatcher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/certimate-go/certimate/internal/domain"
+	xenv "github.com/certimate-go/certimate/pkg/utils/env"
+)
+
+var (
+	envRestartInterrupted = false
+	envMaxAttempts        = 3
+)
+
+func init() {
+	envRestartInterrupted = xenv.GetOrDefaultBool("CERTIMATE_WORKFLOW_RESTART_INTERRUPTED", false)
+	envMaxAttempts = xenv.GetOrDefaultInt("CERTIMATE_WORKFLOW_MAX_ATTEMPTS", 3)
+}
+
+// 调度器停止时用于取消执行中任务的原因，以便与用户主动取消相区分。
+var errDispatcherShutdown = errors.New("workflow dispatcher is shutting down")
+
+// 恢复上次进程退出时遗留的运行，调用方须持有锁。
+// 等待中的运行将按入队顺序重新入队；执行中的运行将被标记为已中断，或在允许时作为新一次尝试重新入队。
+func (wd *workflowDispatcher) recoverRuns(ctx context.Context) error {
+	pendingRuns, err := wd.workflowRunRepo.ListByStatus(ctx, domain.WorkflowRunStatusTypePending)
+	if err != nil {
+		return err
+	}
+
+	// 运行记录最后一次被更新即是其被置为等待状态之时，据之还原入队顺序
+	slices.SortStableFunc(pendingRuns, func(a, b *domain.WorkflowRun) int { return a.UpdatedAt.Compare(b.UpdatedAt) })
+
+	processingRuns, err := wd.workflowRunRepo.ListByStatus(ctx, domain.WorkflowRunStatusTypeProcessing)
+	if err != nil {
+		return err
+	}
+
+	restartRuns := make([]*domain.WorkflowRun, 0)
+	for _, workflowRun := range processingRuns {
+		if restarted, err := wd.recoverInterruptedRun(ctx, workflowRun); err != nil {
+			return err
+		} else if restarted {
+			restartRuns = append(restartRuns, workflowRun)
+		}
+	}
+
+	// 重新执行的运行此前已被调度过，以其开始时间作为入队时间，以便排在其他等待中的运行之前
+	for _, workflowRun := range restartRuns {
+		if err := wd.enqueueRecoveredRun(ctx, workflowRun, workflowRun.StartedAt); err != nil {
+			return err
+		}
+	}
+	for _, workflowRun := range pendingRuns {
+		if err := wd.enqueueRecoveredRun(ctx, workflowRun, workflowRun.UpdatedAt); err != nil {
+			return err
+		}
+	}
+
+	if len(wd.pendingRunQueue) > 0 {
+		wd.syslog.Info(fmt.Sprintf("%d workflow run(s) have been recovered into the queue", len(wd.pendingRunQueue)))
+	}
+
+	return nil
+}
+
+// 将执行中但已无节点在执行的运行标记为已中断，或在允许时作为新一次尝试重新置为等待状态，调用方须持有锁。
+// 集群模式下须先接管该运行，若其仍被其他节点持有有效租约则不做处理。返回值表示运行是否需要重新入队。
+func (wd *workflowDispatcher) recoverInterruptedRun(ctx context.Context, workflowRun *domain.WorkflowRun) (bool, error) {
+	if ok, err := wd.takeOverRun(ctx, workflowRun); err != nil || !ok {
+		return false, err
+	}
+
+	attempt := max(workflowRun.Attempt, 1)
+
+	// 子运行由其父运行负责重新执行
+	if envRestartInterrupted && workflowRun.ParentRunId == "" && attempt < envMaxAttempts {
+		workflowRun.Status = domain.WorkflowRunStatusTypePending
+		workflowRun.Attempt = attempt + 1
+		if _, err := wd.workflowRunRepo.SaveWithCascading(ctx, workflowRun); err != nil {
+			return false, err
+		}
+
+		wd.syslog.Warn(fmt.Sprintf("workflow #%s's run #%s was interrupted, it will be restarted (attempt: %d)", workflowRun.WorkflowId, workflowRun.Id, workflowRun.Attempt))
+		return true, nil
+	}
+
+	workflowRun.Status = domain.WorkflowRunStatusTypeInterrupted
+	workflowRun.EndedAt = time.Now()
+	workflowRun.Error = "the run was interrupted because the process exited unexpectedly"
+	if _, err := wd.workflowRunRepo.SaveWithCascading(ctx, workflowRun); err != nil {
+		return false, err
+	}
+
+	wd.syslog.Warn(fmt.Sprintf("workflow #%s's run #%s was interrupted", workflowRun.WorkflowId, workflowRun.Id))
+	return false, nil
+}
+
+// 将从数据库中恢复的运行加入等待队列，调用方须持有锁。
+func (wd *workflowDispatcher) enqueueRecoveredRun(ctx context.Context, workflowRun *domain.WorkflowRun, enqueuedAt time.Time) error {
+	workflow, err := wd.workflowRepo.GetById(ctx, workflowRun.WorkflowId)
+	if err != nil {
+		if errors.Is(err, domain.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	task := newTaskInfo(workflow, workflowRun)
+	task.EnqueuedAt = enqueuedAt
+	wd.pendingRunQueue = append(wd.pendingRunQueue, task)
+	return nil
+}
@@ -0,0 +1,133 @@
+package dispatcher
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/certimate-go/certimate/internal/domain"
+)
+
+func TestRecoverRuns(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name              string
+		restartEnabled    bool
+		wantQueue         []string
+		wantAttempts      map[string]int
+		wantInterrupted   []string
+		wantPendingStatus []string
+	}{
+		{
+			name:              "restart disabled",
+			restartEnabled:    false,
+			wantQueue:         []string{"pending2", "pending1"},
+			wantAttempts:      map[string]int{"processing1": 1},
+			wantInterrupted:   []string{"processing1", "processing2", "processing3"},
+			wantPendingStatus: []string{"pending1", "pending2"},
+		},
+		{
+			name:              "restart enabled",
+			restartEnabled:    true,
+			wantQueue:         []string{"processing1", "pending2", "pending1"},
+			wantAttempts:      map[string]int{"processing1": 2},
+			wantInterrupted:   []string{"processing2", "processing3"},
+			wantPendingStatus: []string{"pending1", "pending2", "processing1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			restartInterrupted := envRestartInterrupted
+			envRestartInterrupted = tt.restartEnabled
+			defer func() { envRestartInterrupted = restartInterrupted }()
+
+			workflows := []*domain.Workflow{
+				{Meta: domain.Meta{Id: "wf1"}},
+				{Meta: domain.Meta{Id: "wf2"}},
+				{Meta: domain.Meta{Id: "wf3"}},
+				{Meta: domain.Meta{Id: "wf4"}},
+				{Meta: domain.Meta{Id: "wf5"}},
+			}
+			runs := []*domain.WorkflowRun{
+				{Meta: domain.Meta{Id: "pending1", UpdatedAt: now.Add(2 * time.Second)}, WorkflowId: "wf1", Status: domain.WorkflowRunStatusTypePending},
+				{Meta: domain.Meta{Id: "pending2", UpdatedAt: now.Add(1 * time.Second)}, WorkflowId: "wf2", Status: domain.WorkflowRunStatusTypePending},
+				{Meta: domain.Meta{Id: "processing1"}, WorkflowId: "wf3", Status: domain.WorkflowRunStatusTypeProcessing, StartedAt: now, Attempt: 1},
+				// 子运行由其父运行负责重新执行
+				{Meta: domain.Meta{Id: "processing2"}, WorkflowId: "wf4", ParentRunId: "parent", Status: domain.WorkflowRunStatusTypeProcessing, StartedAt: now, Attempt: 1},
+				// 已达最大尝试次数
+				{Meta: domain.Meta{Id: "processing3"}, WorkflowId: "wf5", Status: domain.WorkflowRunStatusTypeProcessing, StartedAt: now, Attempt: envMaxAttempts},
+			}
+
+			wd, workflowRunRepo := newTestDispatcher(0, workflows, runs, nil)
+
+			wd.taskMtx.Lock()
+			err := wd.recoverRuns(context.Background())
+			wd.taskMtx.Unlock()
+			if err != nil {
+				t.Fatalf("recoverRuns() error = %v", err)
+			}
+
+			pendingRunIds, _ := wd.testRunIds()
+			if !slices.Equal(pendingRunIds, tt.wantQueue) {
+				t.Errorf("recoverRuns() queue = %v, want %v", pendingRunIds, tt.wantQueue)
+			}
+			for runId, wantAttempt := range tt.wantAttempts {
+				if attempt := workflowRunRepo.runs[runId].Attempt; attempt != wantAttempt {
+					t.Errorf("recoverRuns() attempt of %s = %d, want %d", runId, attempt, wantAttempt)
+				}
+			}
+			for _, run := range runs {
+				var wantStatus domain.WorkflowRunStatusType
+				switch {
+				case slices.Contains(tt.wantInterrupted, run.Id):
+					wantStatus = domain.WorkflowRunStatusTypeInterrupted
+				case slices.Contains(tt.wantPendingStatus, run.Id):
+					wantStatus = domain.WorkflowRunStatusTypePending
+				}
+				if status := workflowRunRepo.status(run.Id); status != wantStatus {
+					t.Errorf("recoverRuns() status of %s = %v, want %v", run.Id, status, wantStatus)
+				}
+			}
+		})
+	}
+}
+
+func TestBootupDispatchRecoveredRuns(t *testing.T) {
+	now := time.Now()
+
+	workflows := []*domain.Workflow{
+		{Meta: domain.Meta{Id: "wf1"}},
+		{Meta: domain.Meta{Id: "wf2"}},
+		{Meta: domain.Meta{Id: "wf3"}},
+		{Meta: domain.Meta{Id: "wf4"}},
+	}
+	runs := []*domain.WorkflowRun{
+		{Meta: domain.Meta{Id: "run1", UpdatedAt: now.Add(1 * time.Second)}, WorkflowId: "wf1", Status: domain.WorkflowRunStatusTypePending},
+		{Meta: domain.Meta{Id: "run2", UpdatedAt: now.Add(2 * time.Second)}, WorkflowId: "wf2", Status: domain.WorkflowRunStatusTypePending},
+		{Meta: domain.Meta{Id: "run3", UpdatedAt: now.Add(3 * time.Second)}, WorkflowId: "wf3", Status: domain.WorkflowRunStatusTypePending},
+		{Meta: domain.Meta{Id: "run4", UpdatedAt: now.Add(4 * time.Second)}, WorkflowId: "wf4", Status: domain.WorkflowRunStatusTypePending},
+	}
+
+	wd, _ := newTestDispatcher(3, workflows, runs, nil)
+	defer wd.stopTestTasks()
+
+	if err := wd.Bootup(context.Background()); err != nil {
+		t.Fatalf("Bootup() error = %v", err)
+	}
+
+	// 恢复的运行应被同时调度，直至并发数已满
+	var pendingRunIds, processingRunIds []string
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		if pendingRunIds, processingRunIds = wd.testRunIds(); len(processingRunIds) == 3 {
+			break
+		}
+	}
+	if !slices.Equal(processingRunIds, []string{"run1", "run2", "run3"}) {
+		t.Errorf("Bootup() processing = %v, want %v", processingRunIds, []string{"run1", "run2", "run3"})
+	}
+	if !slices.Equal(pendingRunIds, []string{"run4"}) {
+		t.Errorf("Bootup() pending = %v, want %v", pendingRunIds, []string{"run4"})
+	}
+}
@@ -2,12 +2,43 @@ package dispatcher
 
 import (
 	"context"
+	"time"
+
+	"github.com/certimate-go/certimate/internal/domain"
 )
 
 type taskInfo struct {
-	WorkflowId string
-	RunId      string
+	WorkflowId        string
+	RunId             string
+	RunTrigger        domain.WorkflowTriggerType
+	ConcurrencyPolicy domain.WorkflowConcurrencyPolicyType
+	MutexGroups       []string
+	SchedulingGroup   string // 调度分组，为空时以工作流自身为一组
+	Priority          int
+	EnqueuedAt        time.Time
+	DispatchedAt      time.Time
 
 	ctx    context.Context
-	cancel context.CancelFunc
+	cancel context.CancelCauseFunc
+}
+
+func (t *taskInfo) schedulingGroup() string {
+	if t.SchedulingGroup != "" {
+		return t.SchedulingGroup
+	}
+
+	return "workflow:" + t.WorkflowId
+}
+
+func newTaskInfo(workflow *domain.Workflow, workflowRun *domain.WorkflowRun) *taskInfo {
+	return &taskInfo{
+		WorkflowId:        workflow.Id,
+		RunId:             workflowRun.Id,
+		RunTrigger:        workflowRun.Trigger,
+		ConcurrencyPolicy: workflow.ConcurrencyPolicy,
+		MutexGroups:       workflow.MutexGroups,
+		SchedulingGroup:   workflow.SchedulingGroup,
+		Priority:          workflow.Priority,
+		EnqueuedAt:        time.Now(),
+	}
 }
@@ -0,0 +1,141 @@
+package dispatcher
+
+import (
+	"context"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/samber/lo"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/certimate-go/certimate/internal/app"
+	"github.com/certimate-go/certimate/internal/domain"
+	"github.com/certimate-go/certimate/internal/tracing"
+	"github.com/certimate-go/certimate/internal/workflow/livelog"
+	_ "github.com/certimate-go/certimate/migrations"
+)
+
+// 以临时数据目录初始化应用，工作流引擎依赖之。
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "certimate-dispatcher-test-")
+	if err != nil {
+		panic(err)
+	}
+
+	args := os.Args
+	os.Args = []string{args[0], "--dir", dir}
+	app.GetApp()
+	os.Args = args
+
+	if err := app.GetApp().Bootstrap(); err != nil {
+		panic(err)
+	}
+	if err := app.GetApp().RunAllMigrations(); err != nil {
+		panic(err)
+	}
+
+	code := m.Run()
+	app.GetApp().ResetBootstrapState()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+type testWorkflowLogRepository struct {
+	mtx  sync.Mutex
+	logs []*domain.WorkflowLog
+}
+
+func (r *testWorkflowLogRepository) ListByWorkflowRunId(ctx context.Context, workflowRunId string) ([]*domain.WorkflowLog, error) {
+	return nil, nil
+}
+
+func (r *testWorkflowLogRepository) Save(ctx context.Context, workflowLog *domain.WorkflowLog) (*domain.WorkflowLog, error) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	r.logs = append(r.logs, workflowLog)
+	return workflowLog, nil
+}
+
+func TestExecuteTracing(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	tracing.SetupWithExporter(exporter)
+	defer tracing.Teardown()
+
+	graph := &domain.WorkflowGraph{
+		Nodes: []*domain.WorkflowNode{
+			{Id: "start", Type: domain.WorkflowNodeTypeStart, Data: domain.WorkflowNodeData{Name: "start"}},
+			{Id: "end", Type: domain.WorkflowNodeTypeEnd, Data: domain.WorkflowNodeData{Name: "end"}},
+		},
+	}
+	workflows := []*domain.Workflow{
+		{Meta: domain.Meta{Id: "wf1"}},
+	}
+	runs := []*domain.WorkflowRun{
+		{Meta: domain.Meta{Id: "run1"}, WorkflowId: "wf1", Status: domain.WorkflowRunStatusTypePending, Graph: graph},
+		{Meta: domain.Meta{Id: "run2"}, WorkflowId: "wf1", Status: domain.WorkflowRunStatusTypePending, Graph: graph},
+	}
+
+	wd, workflowRunRepo := newTestDispatcher(0, workflows, runs, nil)
+	workflowLogRepo := &testWorkflowLogRepository{}
+	wd.workflowLogRepo = workflowLogRepo
+	wd.liveLogHub = livelog.GetSingletonHub()
+
+	for _, run := range runs {
+		// 以不可取消的上下文执行，以免测试用的运行仓储阻塞之
+		wd.tryExecuteAsync(&taskInfo{WorkflowId: run.WorkflowId, RunId: run.Id, ctx: context.Background()})
+
+		if status := workflowRunRepo.status(run.Id); status != domain.WorkflowRunStatusTypeSucceeded {
+			t.Fatalf("status of %s = %v, want %v", run.Id, status, domain.WorkflowRunStatusTypeSucceeded)
+		}
+	}
+
+	spans := exporter.GetSpans()
+	runSpans := lo.Filter(spans, func(s tracetest.SpanStub, _ int) bool { return s.Name == "workflow.run" })
+	if len(runSpans) != len(runs) {
+		t.Fatalf("expected %d 'workflow.run' spans, got %d", len(runs), len(runSpans))
+	}
+
+	traceIds := make(map[string]string) // Key: RunId
+	for _, runSpan := range runSpans {
+		runId, _ := lo.Find(runSpan.Attributes, func(kv attribute.KeyValue) bool { return kv.Key == "workflow.run.id" })
+		traceId := runSpan.SpanContext.TraceID().String()
+		traceIds[runId.Value.AsString()] = traceId
+
+		// 每次运行对应一条追踪链路，以运行为根 Span
+		if runSpan.Parent.IsValid() {
+			t.Errorf("expected 'workflow.run' of %s to be a root span", runId.Value.AsString())
+		}
+
+		traceSpans := lo.Filter(spans, func(s tracetest.SpanStub, _ int) bool {
+			return s.SpanContext.TraceID() == runSpan.SpanContext.TraceID()
+		})
+		nodeSpans := lo.Filter(traceSpans, func(s tracetest.SpanStub, _ int) bool { return s.Name == "workflow.node" })
+		if len(nodeSpans) != len(graph.Nodes) || len(traceSpans) != len(graph.Nodes)+1 {
+			t.Errorf("expected %d 'workflow.node' spans in the trace of %s, got %d of %d spans", len(graph.Nodes), runId.Value.AsString(), len(nodeSpans), len(traceSpans))
+		}
+		for _, nodeSpan := range nodeSpans {
+			if nodeSpan.Parent.SpanID() != runSpan.SpanContext.SpanID() {
+				t.Errorf("expected 'workflow.node' to be a child of 'workflow.run' of %s", runId.Value.AsString())
+			}
+		}
+	}
+	if len(lo.Uniq(lo.Values(traceIds))) != len(runs) {
+		t.Errorf("expected %d distinct traces, got %v", len(runs), traceIds)
+	}
+
+	// 工作流日志中应记录其所属的追踪 ID
+	for _, run := range runs {
+		logs := lo.Filter(workflowLogRepo.logs, func(l *domain.WorkflowLog, _ int) bool { return l.RunId == run.Id })
+		if len(logs) == 0 {
+			t.Errorf("expected logs of %s", run.Id)
+		}
+		for _, log := range logs {
+			if log.TraceId == "" || log.TraceId != traceIds[run.Id] {
+				t.Errorf("trace id of the log of %s = '%s', want '%s'", run.Id, log.TraceId, traceIds[run.Id])
+			}
+		}
+	}
+}
@@ -0,0 +1,193 @@
+package engine
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/certimate-go/certimate/internal/domain"
+)
+
+// 记录运行中已完成的节点，以便从失败的节点处续跑时跳过之。
+// 同时记录尚未被处理的审批请求，以便运行挂起后再续跑时由审批节点读取审批结果。
+type runCheckpoint struct {
+	mtx       sync.RWMutex
+	completed []string
+	approvals []*domain.WorkflowRunStateApproval
+}
+
+func (c *runCheckpoint) IsCompleted(nodeId string) bool {
+	c.mtx.RLock()
+	defer c.mtx.RUnlock()
+
+	return slices.Contains(c.completed, nodeId)
+}
+
+func (c *runCheckpoint) MarkCompleted(nodeId string) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if !slices.Contains(c.completed, nodeId) {
+		c.completed = append(c.completed, nodeId)
+	}
+}
+
+func (c *runCheckpoint) All() []string {
+	c.mtx.RLock()
+	defer c.mtx.RUnlock()
+
+	return slices.Clone(c.completed)
+}
+
+func (c *runCheckpoint) GetApproval(nodeId string) (*domain.WorkflowRunStateApproval, bool) {
+	c.mtx.RLock()
+	defer c.mtx.RUnlock()
+
+	for _, approval := range c.approvals {
+		if approval.NodeId == nodeId {
+			clone := *approval
+			return &clone, true
+		}
+	}
+
+	return nil, false
+}
+
+func (c *runCheckpoint) SetApproval(approval *domain.WorkflowRunStateApproval) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	clone := *approval
+	c.approvals = slices.DeleteFunc(c.approvals, func(a *domain.WorkflowRunStateApproval) bool { return a.NodeId == approval.NodeId })
+	c.approvals = append(c.approvals, &clone)
+}
+
+func (c *runCheckpoint) RemoveApproval(nodeId string) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	c.approvals = slices.DeleteFunc(c.approvals, func(a *domain.WorkflowRunStateApproval) bool { return a.NodeId == nodeId })
+}
+
+func (c *runCheckpoint) Approvals() []*domain.WorkflowRunStateApproval {
+	c.mtx.RLock()
+	defer c.mtx.RUnlock()
+
+	return slices.Clone(c.approvals)
+}
+
+func newRunCheckpoint(completed []string, approvals []*domain.WorkflowRunStateApproval) *runCheckpoint {
+	return &runCheckpoint{
+		completed: slices.Clone(completed),
+		approvals: slices.Clone(approvals),
+	}
+}
+
+// 从运行状态快照中恢复变量及输入输出。
+// 持久化的输出将从 WorkflowOutput 中重新读取，其余的则从快照中读取。
+func (we *workflowEngine) restoreWorkflowState(wfCtx *WorkflowContext, state *domain.WorkflowRunState) error {
+	wfCtx.SetCheckpoint(newRunCheckpoint(state.CompletedNodeIds, state.Approvals))
+	if len(state.CompletedNodeIds) == 0 {
+		return nil
+	}
+
+	// 先恢复快照中的变量，再以本次运行的初始变量覆盖之，并清除上次运行的错误信息
+	initialVariables := wfCtx.variables.All()
+	for _, variable := range state.Variables {
+		value, err := parseSnapshotValue(variable.Value, variable.ValueType)
+		if err != nil {
+			return fmt.Errorf("failed to restore variable '%s': %w", variable.Key, err)
+		}
+
+		wfCtx.variables.SetScoped(variable.Scope, variable.Key, value, variable.ValueType)
+	}
+	for _, variable := range initialVariables {
+		wfCtx.variables.Add(variable)
+	}
+
+	for _, output := range state.Outputs {
+		value, err := parseSnapshotValue(output.Value, output.ValueType)
+		if err != nil {
+			return fmt.Errorf("failed to restore output '%s' of node #%s: %w", output.Name, output.NodeId, err)
+		}
+
+		wfCtx.inputs.Set(output.NodeId, output.Type, output.Name, value, output.ValueType, false)
+	}
+
+	wfoutputs, err := we.wfoutputRepo.ListByWorkflowRunId(wfCtx.ctx, wfCtx.RunId)
+	if err != nil {
+		return fmt.Errorf("failed to get workflow outputs: %w", err)
+	}
+	for _, wfoutput := range wfoutputs {
+		if !slices.Contains(state.CompletedNodeIds, wfoutput.NodeId) {
+			continue
+		}
+
+		for _, output := range wfoutput.Outputs {
+			value, err := parseSnapshotValue(output.Value, output.ValueType)
+			if err != nil {
+				return fmt.Errorf("failed to restore output '%s' of node #%s: %w", output.Name, wfoutput.NodeId, err)
+			}
+
+			wfCtx.inputs.Set(wfoutput.NodeId, output.Type, output.Name, value, output.ValueType, true)
+		}
+	}
+
+	return nil
+}
+
+// 将当前的变量、非持久化的输入输出及已完成的节点写入运行状态快照。
+func (we *workflowEngine) snapshotWorkflowState(wfCtx *WorkflowContext, state *domain.WorkflowRunState) {
+	if wfCtx.checkpoint != nil {
+		state.CompletedNodeIds = wfCtx.checkpoint.All()
+		state.Approvals = wfCtx.checkpoint.Approvals()
+	}
+
+	state.Variables = make([]*domain.WorkflowRunStateVariable, 0)
+	for _, variable := range wfCtx.variables.All() {
+		state.Variables = append(state.Variables, &domain.WorkflowRunStateVariable{
+			Scope:     variable.Scope,
+			Key:       variable.Key,
+			Value:     formatSnapshotValue(variable.Value, variable.ValueType),
+			ValueType: variable.ValueType,
+		})
+	}
+
+	state.Outputs = make([]*domain.WorkflowRunStateOutput, 0)
+	for _, output := range wfCtx.inputs.All() {
+		if output.Persistent {
+			continue
+		}
+
+		state.Outputs = append(state.Outputs, &domain.WorkflowRunStateOutput{
+			NodeId:    output.NodeId,
+			Type:      output.Type,
+			Name:      output.Name,
+			Value:     formatSnapshotValue(output.Value, output.ValueType),
+			ValueType: output.ValueType,
+		})
+	}
+}
+
+func formatSnapshotValue(value any, valueType string) string {
+	switch valueType {
+	case stateValTypeDateTime:
+		if t, ok := value.(time.Time); ok {
+			if t.IsZero() {
+				return ""
+			}
+			return t.Format(time.RFC3339Nano)
+		}
+	}
+
+	return fmt.Sprintf("%v", value)
+}
+
+func parseSnapshotValue(value string, valueType string) (any, error) {
+	if valueType == stateValTypeDateTime && value == "" {
+		return time.Time{}, nil
+	}
+
+	return parseStateValue(value, valueType)
+}
@@ -0,0 +1,111 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/certimate-go/certimate/internal/domain"
+)
+
+func TestWorkflowEngineResume(t *testing.T) {
+	notAfter := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	failing := true
+	executed := make(map[string]int)
+	observed := make(map[string]any)
+	engine := newTestWorkflowEngine(func(execCtx *NodeExecutionContext, execRes *NodeExecutionResult) error {
+		executed[execCtx.Node.Id]++
+
+		switch execCtx.Node.Id {
+		case "n1":
+			execRes.AddVariable("count", int32(1), stateValTypeNumber)
+			execRes.AddVariable("notAfter", notAfter, stateValTypeDateTime)
+			execRes.AddVariable("notBefore", time.Time{}, stateValTypeDateTime)
+			execRes.AddOutput(stateIOTypeRef, "transient", "a", stateValTypeString)
+			execRes.AddOutputWithPersistent(stateIOTypeRef, "persistent", "b", stateValTypeString)
+
+		case "n2":
+			if failing {
+				return errors.New("test error")
+			}
+
+			for _, key := range []string{"count", "notAfter", "notBefore", stateVarKeyRunTrigger, stateVarKeyErrorNodeId} {
+				if state, ok := execCtx.variables.Get(key); ok {
+					observed[key] = state.Value
+				}
+			}
+			for _, name := range []string{"transient", "persistent"} {
+				if state, ok := execCtx.inputs.Get("n1", name); ok {
+					observed[name] = state.Value
+				}
+			}
+		}
+
+		return nil
+	})
+	engine.executors[NodeTypeStart] = newStartNodeExecutor
+	engine.executors[NodeTypeEnd] = newEndNodeExecutor
+
+	graph := &Graph{Nodes: []*Node{{Id: "start", Type: NodeTypeStart}, newTestNode("n1"), newTestNode("n2"), {Id: "end", Type: NodeTypeEnd}}}
+	execution := WorkflowExecution{
+		WorkflowId: "wf1",
+		RunId:      "run1",
+		RunTrigger: domain.WorkflowTriggerTypeScheduled,
+		RunState:   &domain.WorkflowRunState{},
+		Graph:      graph,
+	}
+	if err := engine.Invoke(context.Background(), execution); err == nil {
+		t.Fatalf("Invoke() error = nil, want error")
+	}
+	if !slices.Equal(execution.RunState.CompletedNodeIds, []string{"start", "n1"}) {
+		t.Fatalf("Invoke() completed nodes = %v, want %v", execution.RunState.CompletedNodeIds, []string{"start", "n1"})
+	}
+
+	// 运行状态快照经由数据库持久化后再续跑
+	stateRaw, err := json.Marshal(execution.RunState)
+	if err != nil {
+		t.Fatal(err)
+	}
+	execution.RunState = &domain.WorkflowRunState{}
+	if err := json.Unmarshal(stateRaw, execution.RunState); err != nil {
+		t.Fatal(err)
+	}
+
+	failing = false
+	execution.RunTrigger = domain.WorkflowTriggerTypeManual
+	if err := engine.Invoke(context.Background(), execution); err != nil {
+		t.Fatalf("Invoke() error = %v", err)
+	}
+
+	if executed["n1"] != 1 || executed["n2"] != 2 {
+		t.Errorf("Invoke() executed = %v, want n1 once and n2 twice", executed)
+	}
+	if !slices.Contains(execution.RunState.CompletedNodeIds, "n2") {
+		t.Errorf("Invoke() completed nodes = %v, want containing n2", execution.RunState.CompletedNodeIds)
+	}
+
+	want := map[string]any{
+		"count":                int32(1),
+		"notAfter":             notAfter,
+		"notBefore":            time.Time{},
+		stateVarKeyRunTrigger:  domain.WorkflowTriggerTypeManual,
+		stateVarKeyErrorNodeId: "",
+		"transient":            "a",
+		"persistent":           "b",
+	}
+	for key, value := range want {
+		if got, ok := observed[key]; !ok {
+			t.Errorf("'%s' is not restored", key)
+		} else if gotTime, ok := got.(time.Time); ok {
+			if !gotTime.Equal(value.(time.Time)) {
+				t.Errorf("'%s' = %v, want %v", key, got, value)
+			}
+		} else if got != value {
+			t.Errorf("'%s' = %v, want %v", key, got, value)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package engine
 
 import (
 	"context"
+	"slices"
 )
 
 type WorkflowContext struct {
@@ -13,6 +14,9 @@ type WorkflowContext struct {
 	variables VariableManager
 	inputs    InOutManager
 
+	checkpoint *runCheckpoint
+	planner    *runPlanner // 仅在试运行时非空
+
 	ctx context.Context
 }
 
@@ -38,6 +42,16 @@ func (c *WorkflowContext) SetInputsManager(manager InOutManager) *WorkflowContex
 	return c
 }
 
+func (c *WorkflowContext) SetCheckpoint(checkpoint *runCheckpoint) *WorkflowContext {
+	c.checkpoint = checkpoint
+	return c
+}
+
+func (c *WorkflowContext) SetPlanner(planner *runPlanner) *WorkflowContext {
+	c.planner = planner
+	return c
+}
+
 func (c *WorkflowContext) SetContext(ctx context.Context) *WorkflowContext {
 	c.ctx = ctx
 	return c
@@ -57,6 +71,45 @@ func (c *WorkflowContext) Clone() *WorkflowContext {
 		variables: c.variables,
 		inputs:    c.inputs,
 
+		checkpoint: c.checkpoint,
+		planner:    c.planner,
+
 		ctx: c.ctx,
 	}
 }
+
+type ExecutingRun struct {
+	WorkflowId string
+	RunId      string
+}
+
+type executingRunContextKey struct{}
+
+type callStackContextKey struct{}
+
+// 在上下文中记录当前正在执行的运行。
+// 子工作流以子运行的方式被调用时，引擎会以子运行覆盖之，以便钩子函数区分日志等归属。
+func withExecutingRun(ctx context.Context, workflowId string, runId string) context.Context {
+	return context.WithValue(ctx, executingRunContextKey{}, ExecutingRun{WorkflowId: workflowId, RunId: runId})
+}
+
+// 获取上下文中记录的当前正在执行的运行。
+func GetExecutingRun(ctx context.Context) (ExecutingRun, bool) {
+	if ctx == nil {
+		return ExecutingRun{}, false
+	}
+
+	run, ok := ctx.Value(executingRunContextKey{}).(ExecutingRun)
+	return run, ok
+}
+
+// 在上下文中压入工作流调用栈，用于检测子工作流的循环调用。
+func withCallStack(ctx context.Context, workflowId string) context.Context {
+	stack := getCallStack(ctx)
+	return context.WithValue(ctx, callStackContextKey{}, append(slices.Clone(stack), workflowId))
+}
+
+func getCallStack(ctx context.Context) []string {
+	stack, _ := ctx.Value(callStackContextKey{}).([]string)
+	return stack
+}
@@ -5,15 +5,20 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"maps"
 	"runtime/debug"
+	"slices"
 	"sync"
 	"time"
 
 	"github.com/samber/lo"
+	"go.opentelemetry.io/otel/attribute"
 
 	"github.com/certimate-go/certimate/internal/app"
 	"github.com/certimate-go/certimate/internal/domain"
+	"github.com/certimate-go/certimate/internal/metrics"
 	"github.com/certimate-go/certimate/internal/repository"
+	"github.com/certimate-go/certimate/internal/tracing"
 	"github.com/certimate-go/certimate/pkg/logging"
 )
 
@@ -24,11 +29,16 @@ type WorkflowExecution struct {
 	RunId               string
 	RunTrigger          domain.WorkflowTriggerType
 	RunAt               time.Time
+	RunInputs           map[string]any           // 运行时的初始变量，将同时作为全局变量和开始节点的作用域变量
+	RunState            *domain.WorkflowRunState // 运行状态快照，非空时将从中恢复并跳过已完成的节点，执行结束后将被更新
+	RunTimeout          time.Duration            // 运行超时时间，零值时表示不限制
 	Graph               *Graph
 }
 
 type WorkflowEngine interface {
 	Invoke(ctx context.Context, execution WorkflowExecution) error
+	// 以试运行模式执行工作流，不会调用任何外部服务、也不会写入任何数据，仅返回各节点的执行计划。
+	Plan(ctx context.Context, execution WorkflowExecution) (*domain.WorkflowPlan, error)
 
 	OnStart(callback func(ctx context.Context) error)
 	OnEnd(callback func(ctx context.Context) error)
@@ -40,7 +50,7 @@ type WorkflowEngine interface {
 }
 
 type workflowEngine struct {
-	executors map[NodeType]NodeExecutor
+	executors map[NodeType]func() NodeExecutor
 
 	hooksMtx           sync.RWMutex
 	onStartHooks       [](func(ctx context.Context) error)
@@ -67,8 +77,46 @@ func (we *workflowEngine) Invoke(ctx context.Context, execution WorkflowExecutio
 		}
 	}()
 
+	ctx = withExecutingRun(ctx, execution.WorkflowId, execution.RunId)
+	ctx = withCallStack(ctx, execution.WorkflowId)
+
+	if execution.RunTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeoutCause(ctx, execution.RunTimeout, ErrRunTimeout)
+		defer cancel()
+	}
+
 	we.fireOnStartHooks(ctx)
 
+	wfCtx := we.newWorkflowContext(ctx, execution)
+	if execution.RunState != nil {
+		if err := we.restoreWorkflowState(wfCtx, execution.RunState); err != nil {
+			we.fireOnErrorHooks(ctx, err)
+			return err
+		}
+	}
+
+	err := we.executeBlocks(wfCtx, execution.Graph.Nodes)
+	if execution.RunState != nil {
+		we.snapshotWorkflowState(wfCtx, execution.RunState)
+	}
+	if err != nil {
+		if !errors.Is(err, ErrTerminated) {
+			if errors.Is(context.Cause(ctx), ErrRunTimeout) {
+				err = fmt.Errorf("%w (timeout: %s)", ErrRunTimeout, execution.RunTimeout)
+			}
+
+			we.fireOnErrorHooks(ctx, err)
+			return err
+		}
+	}
+
+	we.fireOnEndHooks(ctx)
+
+	return nil
+}
+
+func (we *workflowEngine) newWorkflowContext(ctx context.Context, execution WorkflowExecution) *WorkflowContext {
 	wfIOs := newInOutManager()
 
 	wfVars := newVariableManager()
@@ -81,22 +129,21 @@ func (we *workflowEngine) Invoke(ctx context.Context, execution WorkflowExecutio
 	wfVars.Set(stateVarKeyErrorNodeName, "", stateValTypeString)
 	wfVars.Set(stateVarKeyErrorMessage, "", stateValTypeString)
 
-	wfCtx := (&WorkflowContext{}).
+	if len(execution.RunInputs) > 0 && execution.Graph != nil && len(execution.Graph.Nodes) > 0 {
+		startNodeId := execution.Graph.Nodes[0].Id
+		for _, key := range slices.Sorted(maps.Keys(execution.RunInputs)) {
+			value, valueType := inferStateValue(execution.RunInputs[key])
+			wfVars.Set(key, value, valueType)
+			wfVars.SetScoped(startNodeId, key, value, valueType)
+		}
+	}
+
+	return (&WorkflowContext{}).
 		SetExecutingWorkflow(execution.WorkflowId, execution.RunId, execution.Graph).
 		SetEngine(we).
 		SetInputsManager(wfIOs).
 		SetVariablesManager(wfVars).
 		SetContext(ctx)
-	if err := we.executeBlocks(wfCtx, execution.Graph.Nodes); err != nil {
-		if !errors.Is(err, ErrTerminated) {
-			we.fireOnErrorHooks(ctx, err)
-			return err
-		}
-	}
-
-	we.fireOnEndHooks(ctx)
-
-	return nil
 }
 
 func (we *workflowEngine) OnStart(callback func(ctx context.Context) error) {
@@ -142,33 +189,79 @@ func (we *workflowEngine) OnNodeLogging(callback func(ctx context.Context, node
 }
 
 func (we *workflowEngine) executeNode(wfCtx *WorkflowContext, node *Node) error {
-	executor, ok := we.executors[node.Type]
+	// 每次执行都创建新的执行器实例，以免并行执行时相互干扰
+	executorFactory, ok := we.executors[node.Type]
 	if !ok {
 		err := fmt.Errorf("workflow engine: no executor registered for node type: '%s'", node.Type)
 		return err
-	} else {
-		logger := slog.New(logging.NewHookHandler(nil, &logging.HookHandlerOptions{
-			Level: slog.LevelDebug,
-			WriteFunc: func(ctx context.Context, record logging.Record) error {
-				we.fireOnNodeLoggingHooks(ctx, node, record)
-				return nil
-			},
-		}))
-		executor.SetLogger(logger)
 	}
 
+	logger := slog.New(logging.NewHookHandler(nil, &logging.HookHandlerOptions{
+		Level: slog.LevelDebug,
+		WriteFunc: func(_ context.Context, record logging.Record) error {
+			we.fireOnNodeLoggingHooks(wfCtx.ctx, node, record)
+			return nil
+		},
+	}))
+	executor := executorFactory()
+	executor.SetLogger(logger)
+
 	wfCtx.variables.SetScoped(node.Id, stateVarKeyNodeId, node.Id, stateValTypeString)
 	wfCtx.variables.SetScoped(node.Id, stateVarKeyNodeName, node.Data.Name, stateValTypeString)
 
 	// 节点已禁用，直接跳过执行
 	if node.Data.Disabled {
+		if wfCtx.planner != nil {
+			wfCtx.planner.Record(&domain.WorkflowPlanStep{
+				NodeId:   node.Id,
+				NodeName: node.Data.Name,
+				NodeType: node.Type,
+				Action:   domain.WorkflowPlanActionTypeSkip,
+				Reason:   "the node is disabled",
+			})
+		}
+		return nil
+	}
+
+	// 节点已在上次运行中完成，续跑时直接跳过执行
+	if wfCtx.checkpoint != nil && wfCtx.checkpoint.IsCompleted(node.Id) {
+		logger.Info("the node has been completed in the previous run, skipped")
 		return nil
 	}
 
 	we.fireOnNodeStartHooks(wfCtx.ctx, node)
 
 	execCtx := newNodeExecutionContext(wfCtx, node)
-	execRes, err := executor.Execute(execCtx)
+	var execRes *NodeExecutionResult
+	var err error
+	if wfCtx.planner != nil {
+		execRes, err = we.planNode(execCtx, executor)
+	} else {
+		// 节点的执行对应一个 Span，其中的子节点及提供商调用将作为其子 Span
+		spanCtx, span := tracing.Start(execCtx.Context(), "workflow.node",
+			attribute.String("workflow.node.id", node.Id),
+			attribute.String("workflow.node.name", node.Data.Name),
+			attribute.String("workflow.node.type", string(node.Type)),
+			attribute.String("workflow.node.provider", getNodeProvider(node)),
+		)
+		execCtx.SetContext(spanCtx)
+
+		startedAt := time.Now()
+		execRes, err = we.executeWithRetry(execCtx, executor, logger)
+		if !errors.Is(err, ErrWaiting) {
+			metrics.ObserveWorkflowNode(string(node.Type), getNodeProvider(node), err, time.Since(startedAt))
+		}
+
+		if errors.Is(err, ErrWaiting) || errors.Is(err, ErrTerminated) {
+			tracing.End(span, nil)
+		} else {
+			tracing.End(span, err)
+		}
+	}
+	if err != nil && errors.Is(err, ErrWaiting) {
+		// 运行已挂起，节点将在续跑时重新执行，因此既不视为失败、也不记录其完成状态
+		return err
+	}
 	if err != nil && !errors.Is(err, ErrTerminated) {
 		if !errors.Is(err, ErrBlocksException) {
 			wfCtx.variables.Set(stateVarKeyErrorNodeId, node.Id, stateValTypeString)
@@ -196,7 +289,8 @@ func (we *workflowEngine) executeNode(wfCtx *WorkflowContext, node *Node) error
 		}
 
 		execOutputs := lo.Filter(execRes.Outputs, func(state InOutState, _ int) bool { return state.Persistent })
-		if execRes.outputForced || len(execOutputs) > 0 {
+		// 试运行时不持久化输出
+		if (execRes.outputForced || len(execOutputs) > 0) && wfCtx.planner == nil {
 			output := &domain.WorkflowOutput{
 				WorkflowId: execCtx.WorkflowId,
 				RunId:      execCtx.RunId,
@@ -228,6 +322,10 @@ func (we *workflowEngine) executeNode(wfCtx *WorkflowContext, node *Node) error
 		return err
 	}
 
+	if wfCtx.checkpoint != nil {
+		wfCtx.checkpoint.MarkCompleted(node.Id)
+	}
+
 	return nil
 }
 
@@ -246,7 +344,7 @@ func (we *workflowEngine) executeBlocks(wfCtx *WorkflowContext, blocks []*Node)
 			// 如果当前节点是 TryCatch 节点、且在 CatchBlock 分支中没有 End 节点，
 			// 则暂存错误，但继续执行下一个节点，直到当前 Blocks 全部执行完毕。
 			if node.Type == NodeTypeTryCatch {
-				if !errors.Is(err, ErrTerminated) {
+				if !errors.Is(err, ErrTerminated) && !errors.Is(err, ErrWaiting) {
 					errs = append(errs, err)
 					continue
 				}
@@ -335,24 +433,46 @@ func (we *workflowEngine) fireOnNodeLoggingHooks(ctx context.Context, node *Node
 	}
 }
 
+// 获取节点所使用的提供商，用于指标的标签。
+func getNodeProvider(node *Node) string {
+	switch node.Type {
+	case domain.WorkflowNodeTypeBizApply:
+		return node.Data.Config.AsBizApply().Provider
+	case domain.WorkflowNodeTypeBizDeploy:
+		return node.Data.Config.AsBizDeploy().Provider
+	case domain.WorkflowNodeTypeBizNotify:
+		return node.Data.Config.AsBizNotify().Provider
+	}
+
+	return ""
+}
+
 func NewWorkflowEngine() WorkflowEngine {
 	engine := &workflowEngine{
-		executors:    make(map[NodeType]NodeExecutor),
+		executors:    make(map[NodeType]func() NodeExecutor),
 		wfoutputRepo: repository.NewWorkflowOutputRepository(),
 		syslog:       app.GetLogger(),
 	}
-	engine.executors[NodeTypeStart] = newStartNodeExecutor()
-	engine.executors[NodeTypeEnd] = newEndNodeExecutor()
-	engine.executors[NodeTypeDelay] = newDelayNodeExecutor()
-	engine.executors[NodeTypeCondition] = newConditionNodeExecutor()
-	engine.executors[NodeTypeBranchBlock] = newBranchBlockNodeExecutor()
-	engine.executors[NodeTypeTryCatch] = newTryCatchNodeExecutor()
-	engine.executors[NodeTypeTryBlock] = newTryBlockNodeExecutor()
-	engine.executors[NodeTypeCatchBlock] = newCatchBlockNodeExecutor()
-	engine.executors[NodeTypeBizApply] = newBizApplyNodeExecutor()
-	engine.executors[NodeTypeBizUpload] = newBizUploadNodeExecutor()
-	engine.executors[NodeTypeBizMonitor] = newBizMonitorNodeExecutor()
-	engine.executors[NodeTypeBizDeploy] = newBizDeployNodeExecutor()
-	engine.executors[NodeTypeBizNotify] = newBizNotifyNodeExecutor()
+	engine.executors[NodeTypeStart] = newStartNodeExecutor
+	engine.executors[NodeTypeEnd] = newEndNodeExecutor
+	engine.executors[NodeTypeDelay] = newDelayNodeExecutor
+	engine.executors[NodeTypeScript] = newScriptNodeExecutor
+	engine.executors[NodeTypeHttpRequest] = newHttpRequestNodeExecutor
+	engine.executors[NodeTypeApproval] = newApprovalNodeExecutor
+	engine.executors[NodeTypeSetVariable] = newSetVariableNodeExecutor
+	engine.executors[NodeTypeCondition] = newConditionNodeExecutor
+	engine.executors[NodeTypeBranchBlock] = newBranchBlockNodeExecutor
+	engine.executors[NodeTypeTryCatch] = newTryCatchNodeExecutor
+	engine.executors[NodeTypeTryBlock] = newTryBlockNodeExecutor
+	engine.executors[NodeTypeCatchBlock] = newCatchBlockNodeExecutor
+	engine.executors[NodeTypeParallel] = newParallelNodeExecutor
+	engine.executors[NodeTypeParallelBlock] = newParallelBlockNodeExecutor
+	engine.executors[NodeTypeForEach] = newForEachNodeExecutor
+	engine.executors[NodeTypeCallWorkflow] = newCallWorkflowNodeExecutor
+	engine.executors[NodeTypeBizApply] = newBizApplyNodeExecutor
+	engine.executors[NodeTypeBizUpload] = newBizUploadNodeExecutor
+	engine.executors[NodeTypeBizMonitor] = newBizMonitorNodeExecutor
+	engine.executors[NodeTypeBizDeploy] = newBizDeployNodeExecutor
+	engine.executors[NodeTypeBizNotify] = newBizNotifyNodeExecutor
 	return engine
 }
@@ -0,0 +1,86 @@
+package engine
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+
+	"github.com/certimate-go/certimate/internal/domain"
+)
+
+// 测试用的节点类型，其执行逻辑由测试用例指定。
+const testNodeType = NodeType("test")
+
+type testNodeExecutor struct {
+	nodeExecutor
+
+	execute func(execCtx *NodeExecutionContext, execRes *NodeExecutionResult) error
+}
+
+func (ne *testNodeExecutor) Execute(execCtx *NodeExecutionContext) (*NodeExecutionResult, error) {
+	execRes := newNodeExecutionResult(execCtx.Node)
+	err := ne.execute(execCtx, execRes)
+	return execRes, err
+}
+
+type testWorkflowOutputRepository struct {
+	mtx     sync.Mutex
+	outputs []*domain.WorkflowOutput
+}
+
+func (r *testWorkflowOutputRepository) GetByWorkflowIdAndNodeId(ctx context.Context, workflowId string, workflowNodeId string) (*domain.WorkflowOutput, error) {
+	return nil, domain.ErrRecordNotFound
+}
+
+func (r *testWorkflowOutputRepository) ListByWorkflowRunId(ctx context.Context, workflowRunId string) ([]*domain.WorkflowOutput, error) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	outputs := make([]*domain.WorkflowOutput, 0)
+	for _, output := range r.outputs {
+		if output.RunId == workflowRunId {
+			outputs = append(outputs, output)
+		}
+	}
+	return outputs, nil
+}
+
+func (r *testWorkflowOutputRepository) Save(ctx context.Context, workflowOutput *domain.WorkflowOutput) (*domain.WorkflowOutput, error) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	r.outputs = append(r.outputs, workflowOutput)
+	return workflowOutput, nil
+}
+
+// 创建仅包含控制流节点及测试节点的工作流引擎。
+func newTestWorkflowEngine(execute func(execCtx *NodeExecutionContext, execRes *NodeExecutionResult) error) *workflowEngine {
+	engine := &workflowEngine{
+		executors:    make(map[NodeType]func() NodeExecutor),
+		wfoutputRepo: &testWorkflowOutputRepository{},
+		syslog:       slog.New(slog.DiscardHandler),
+	}
+	engine.executors[NodeTypeParallel] = newParallelNodeExecutor
+	engine.executors[NodeTypeParallelBlock] = newParallelBlockNodeExecutor
+	engine.executors[NodeTypeForEach] = newForEachNodeExecutor
+	engine.executors[testNodeType] = func() NodeExecutor {
+		return &testNodeExecutor{execute: execute}
+	}
+
+	return engine
+}
+
+// 执行节点，返回执行后的工作流上下文。
+func (we *workflowEngine) executeTestBlocks(ctx context.Context, nodes ...*Node) (*WorkflowContext, error) {
+	wfCtx := we.newWorkflowContext(ctx, WorkflowExecution{
+		WorkflowId: "wf1",
+		RunId:      "run1",
+		Graph:      &Graph{Nodes: nodes},
+	})
+	err := we.executeBlocks(wfCtx, nodes)
+	return wfCtx, err
+}
+
+func newTestNode(id string) *Node {
+	return &Node{Id: id, Type: testNodeType, Data: domain.WorkflowNodeData{Name: id}}
+}
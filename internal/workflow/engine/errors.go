@@ -9,4 +9,10 @@ var (
 	ErrTerminated = fmt.Errorf("workflow engine: execution was terminated")
 	// 表示工作流引擎在执行子节点时发生异常
 	ErrBlocksException = fmt.Errorf("workflow engine: error occurred when executing blocks")
+	// 表示工作流运行超时
+	ErrRunTimeout = fmt.Errorf("workflow engine: run timed out")
+	// 表示节点执行超时
+	ErrNodeTimeout = fmt.Errorf("workflow engine: node timed out")
+	// 表示工作流运行已挂起，需等待外部决定（例如人工审批）后再续跑
+	ErrWaiting = fmt.Errorf("workflow engine: run is waiting for a decision")
 )
@@ -4,6 +4,8 @@ import (
 	"context"
 	"log/slog"
 	"sync"
+
+	"github.com/certimate-go/certimate/internal/domain"
 )
 
 type NodeExecutor interface {
@@ -12,6 +14,12 @@ type NodeExecutor interface {
 	Execute(execCtx *NodeExecutionContext) (*NodeExecutionResult, error)
 }
 
+// 支持试运行的节点执行器。
+// 试运行时引擎将调用 DryRun 以代替 Execute，其不得调用任何外部服务、也不得写入任何数据。
+type NodeDryRunner interface {
+	DryRun(execCtx *NodeExecutionContext) (*NodeExecutionResult, *domain.WorkflowPlanStep, error)
+}
+
 type nodeExecutor struct {
 	logger *slog.Logger
 }
@@ -51,6 +59,16 @@ func (c *NodeExecutionContext) SetInputsManager(inputs InOutManager) *NodeExecut
 	return c
 }
 
+func (c *NodeExecutionContext) SetCheckpoint(checkpoint *runCheckpoint) *NodeExecutionContext {
+	c.WorkflowContext.SetCheckpoint(checkpoint)
+	return c
+}
+
+func (c *NodeExecutionContext) SetPlanner(planner *runPlanner) *NodeExecutionContext {
+	c.WorkflowContext.SetPlanner(planner)
+	return c
+}
+
 func (c *NodeExecutionContext) SetContext(ctx context.Context) *NodeExecutionContext {
 	c.WorkflowContext.SetContext(ctx)
 	return c
@@ -63,6 +81,8 @@ func newNodeExecutionContext(wfCtx *WorkflowContext, node *Node) *NodeExecutionC
 		SetEngine(wfCtx.engine).
 		SetVariablesManager(wfCtx.variables).
 		SetInputsManager(wfCtx.inputs).
+		SetCheckpoint(wfCtx.checkpoint).
+		SetPlanner(wfCtx.planner).
 		SetContext(wfCtx.ctx)
 }
 
@@ -0,0 +1,180 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pocketbase/pocketbase/tools/security"
+	"github.com/samber/lo"
+
+	"github.com/certimate-go/certimate/internal/app"
+	"github.com/certimate-go/certimate/internal/domain"
+	"github.com/certimate-go/certimate/internal/notify"
+	"github.com/certimate-go/certimate/internal/repository"
+)
+
+const (
+	approvalDefaultSubject = "[Certimate] Approval required: {{ .Workflow.Name }}"
+	approvalDefaultMessage = "The run #{{ .Run.Id }} of workflow \"{{ .Workflow.Name }}\" is waiting for your approval."
+)
+
+/**
+ * Variables:
+ *   - "approval.decision": string
+ *   - "approval.decider": string
+ *   - "approval.comment": string
+ */
+type approvalNodeExecutor struct {
+	nodeExecutor
+
+	accessRepo accessRepository
+}
+
+func (ne *approvalNodeExecutor) Execute(execCtx *NodeExecutionContext) (*NodeExecutionResult, error) {
+	execRes := newNodeExecutionResult(execCtx.Node)
+
+	nodeCfg := execCtx.Node.Data.Config.AsApproval()
+
+	// 审批时需要挂起运行并在之后续跑，循环体及被调用的子工作流中的节点无法续跑
+	if execCtx.checkpoint == nil {
+		return execRes, errors.New("the approval node can not be used inside a loop or a called workflow")
+	}
+
+	// 已有审批结果，据之继续或结束运行
+	// 审批结果仅被使用一次，以便失败后续跑时重新发起审批
+	if approval, ok := execCtx.checkpoint.GetApproval(execCtx.Node.Id); ok && approval.Decision != "" {
+		execCtx.checkpoint.RemoveApproval(execCtx.Node.Id)
+		execCtx.variables.SetScoped(execCtx.Node.Id, stateVarKeyApprovalDecision, string(approval.Decision), stateValTypeString)
+		execCtx.variables.SetScoped(execCtx.Node.Id, stateVarKeyApprovalDecider, approval.Decider, stateValTypeString)
+		execCtx.variables.SetScoped(execCtx.Node.Id, stateVarKeyApprovalComment, approval.Comment, stateValTypeString)
+
+		switch approval.Decision {
+		case domain.WorkflowApprovalDecisionTypeApproved:
+			ne.logger.Info(fmt.Sprintf("approved by %s", approval.Decider), slog.String("comment", approval.Comment))
+			return execRes, nil
+
+		case domain.WorkflowApprovalDecisionTypeRejected:
+			ne.logger.Warn(fmt.Sprintf("rejected by %s", approval.Decider), slog.String("comment", approval.Comment))
+			return execRes, fmt.Errorf("the approval was rejected by %s", approval.Decider)
+
+		case domain.WorkflowApprovalDecisionTypeExpired:
+			ne.logger.Warn("no decision was made before the approval expired")
+			return execRes, fmt.Errorf("the approval expired at %s", approval.ExpiresAt.Format(time.RFC3339))
+
+		default:
+			return execRes, fmt.Errorf("unsupported approval decision: '%s'", approval.Decision)
+		}
+	}
+
+	ne.logger.Info("ready to request approval ...", slog.Any("config", nodeCfg))
+
+	approval := &domain.WorkflowRunStateApproval{
+		NodeId:    execCtx.Node.Id,
+		Token:     security.RandomString(40),
+		ExpiresAt: time.Now().Add(time.Duration(nodeCfg.Timeout) * time.Second),
+	}
+
+	// 读取通知提供商授权
+	providerAccessConfig := make(map[string]any)
+	if nodeCfg.ProviderAccessId != "" {
+		if access, err := ne.accessRepo.GetById(execCtx.Context(), nodeCfg.ProviderAccessId); err != nil {
+			return execRes, fmt.Errorf("failed to get access #%s record: %w", nodeCfg.ProviderAccessId, err)
+		} else {
+			providerAccessConfig = access.Config
+		}
+	}
+
+	// 渲染通知模板，并在末尾附加审批链接
+	subject, err := renderTemplate(execCtx, "subject", lo.CoalesceOrEmpty(nodeCfg.Subject, approvalDefaultSubject), nodeCfg.StrictTemplate)
+	if err != nil {
+		return execRes, fmt.Errorf("failed to render notification subject: %w", err)
+	}
+	message, err := renderTemplate(execCtx, "message", lo.CoalesceOrEmpty(nodeCfg.Message, approvalDefaultMessage), nodeCfg.StrictTemplate)
+	if err != nil {
+		return execRes, fmt.Errorf("failed to render notification message: %w", err)
+	}
+	message = ne.appendApprovalLinks(message, execCtx.RunId, approval)
+
+	// 推送审批请求
+	notifier := notify.NewClient(notify.WithLogger(ne.logger))
+	notifyReq := &notify.SendNotificationRequest{
+		Provider:               domain.NotificationProviderType(nodeCfg.Provider),
+		ProviderAccessConfig:   providerAccessConfig,
+		ProviderExtendedConfig: nodeCfg.ProviderConfig,
+		Subject:                subject,
+		Message:                message,
+	}
+	if _, err := notifier.SendNotification(execCtx.Context(), notifyReq); err != nil {
+		ne.logger.Warn("could not send approval request")
+		return execRes, err
+	}
+
+	// 记录审批请求，运行将被挂起，直至收到审批结果或超时
+	execCtx.checkpoint.SetApproval(approval)
+	ne.logger.Info(fmt.Sprintf("approval requested, waiting for a decision until %s ...", approval.ExpiresAt.Format(time.RFC3339)))
+	return execRes, ErrWaiting
+}
+
+func (ne *approvalNodeExecutor) DryRun(execCtx *NodeExecutionContext) (*NodeExecutionResult, *domain.WorkflowPlanStep, error) {
+	execRes := newNodeExecutionResult(execCtx.Node)
+
+	nodeCfg := execCtx.Node.Data.Config.AsApproval()
+
+	// 校验通知模板
+	if err := parseTemplate("subject", nodeCfg.Subject); err != nil {
+		return execRes, &domain.WorkflowPlanStep{Action: domain.WorkflowPlanActionTypeInvalid, Error: fmt.Sprintf("invalid subject template: %s", err.Error())}, nil
+	}
+	if err := parseTemplate("message", nodeCfg.Message); err != nil {
+		return execRes, &domain.WorkflowPlanStep{Action: domain.WorkflowPlanActionTypeInvalid, Error: fmt.Sprintf("invalid message template: %s", err.Error())}, nil
+	}
+
+	// 校验授权及通知提供商配置
+	providerAccessConfig := make(map[string]any)
+	if nodeCfg.ProviderAccessId != "" {
+		if access, err := ne.accessRepo.GetById(execCtx.Context(), nodeCfg.ProviderAccessId); err != nil {
+			return execRes, &domain.WorkflowPlanStep{Action: domain.WorkflowPlanActionTypeInvalid, Error: fmt.Sprintf("failed to get access #%s record: %s", nodeCfg.ProviderAccessId, err.Error())}, nil
+		} else {
+			providerAccessConfig = access.Config
+		}
+	}
+
+	notifier := notify.NewClient(notify.WithLogger(ne.logger))
+	notifyReq := &notify.SendNotificationRequest{
+		Provider:               domain.NotificationProviderType(nodeCfg.Provider),
+		ProviderAccessConfig:   providerAccessConfig,
+		ProviderExtendedConfig: nodeCfg.ProviderConfig,
+	}
+	if err := notifier.ValidateSendNotificationRequest(notifyReq); err != nil {
+		return execRes, &domain.WorkflowPlanStep{Action: domain.WorkflowPlanActionTypeInvalid, Error: err.Error()}, nil
+	}
+
+	return execRes, &domain.WorkflowPlanStep{Action: domain.WorkflowPlanActionTypeExecute, Reason: fmt.Sprintf("the run will wait for approval (timeout: %s)", time.Duration(nodeCfg.Timeout)*time.Second)}, nil
+}
+
+// 在通知内容末尾附加审批链接。
+// 链接指向的路由见 internal/rest/handlers/webhooks.go，以令牌进行认证，无需登录。
+// 链接仅打开确认页面，须由审批人在页面中手动提交审批结果。
+func (ne *approvalNodeExecutor) appendApprovalLinks(message string, runId string, approval *domain.WorkflowRunStateApproval) string {
+	baseUrl := strings.TrimRight(app.GetApp().Settings().Meta.AppURL, "/")
+	if baseUrl == "" {
+		ne.logger.Warn("the application url is not configured, the approval link will be relative")
+	}
+
+	var sb strings.Builder
+	sb.WriteString(message)
+	sb.WriteString("\n\n")
+	sb.WriteString(fmt.Sprintf("Review: %s/api/webhooks/approvals/%s?token=%s\n", baseUrl, url.PathEscape(runId), url.QueryEscape(approval.Token)))
+	sb.WriteString(fmt.Sprintf("Expires At: %s", approval.ExpiresAt.Format(time.RFC3339)))
+	return sb.String()
+}
+
+func newApprovalNodeExecutor() NodeExecutor {
+	return &approvalNodeExecutor{
+		nodeExecutor: nodeExecutor{logger: slog.Default()},
+		accessRepo:   repository.NewAccessRepository(),
+	}
+}
@@ -138,6 +138,61 @@ func (ne *bizApplyNodeExecutor) Execute(execCtx *NodeExecutionContext) (*NodeExe
 	return execRes, nil
 }
 
+func (ne *bizApplyNodeExecutor) DryRun(execCtx *NodeExecutionContext) (*NodeExecutionResult, *domain.WorkflowPlanStep, error) {
+	execRes := newNodeExecutionResult(execCtx.Node)
+
+	nodeCfg := execCtx.Node.Data.Config.AsBizApply()
+
+	// 查询上次执行结果
+	lastOutput, lastCertificate, err := ne.getLastOutputArtifacts(execCtx)
+	if err != nil {
+		return execRes, nil, err
+	} else if lastCertificate != nil {
+		ne.setOuputsOfResult(execCtx, execRes, lastCertificate, false)
+		ne.setVariablesOfResult(execCtx, execRes, lastCertificate)
+	}
+
+	// 检测是否可以跳过本次执行
+	skippable, reason := ne.checkCanSkip(execCtx, lastOutput, lastCertificate)
+	execRes.AddVariableWithScope(execCtx.Node.Id, stateVarKeyNodeSkipped, skippable, stateValTypeBoolean)
+	if skippable {
+		return execRes, &domain.WorkflowPlanStep{Action: domain.WorkflowPlanActionTypeSkip, Reason: reason}, nil
+	} else if reason == "" {
+		reason = "no found last requested certificate"
+	}
+
+	// 校验授权及质询提供商配置
+	providerAccessConfig := make(map[string]any)
+	if nodeCfg.ProviderAccessId != "" {
+		if access, err := ne.accessRepo.GetById(execCtx.Context(), nodeCfg.ProviderAccessId); err != nil {
+			return execRes, &domain.WorkflowPlanStep{Action: domain.WorkflowPlanActionTypeInvalid, Reason: reason, Error: fmt.Sprintf("failed to get access #%s record: %s", nodeCfg.ProviderAccessId, err.Error())}, nil
+		} else {
+			providerAccessConfig = access.Config
+		}
+	}
+	if nodeCfg.CAProviderAccessId != "" {
+		if _, err := ne.accessRepo.GetById(execCtx.Context(), nodeCfg.CAProviderAccessId); err != nil {
+			return execRes, &domain.WorkflowPlanStep{Action: domain.WorkflowPlanActionTypeInvalid, Reason: reason, Error: fmt.Sprintf("failed to get access #%s record: %s", nodeCfg.CAProviderAccessId, err.Error())}, nil
+		}
+	}
+
+	obtainReq := &certacme.ObtainCertificateRequest{
+		DomainOrIPs:            lo.Concat(nodeCfg.Domains, nodeCfg.IPAddrs),
+		PrivateKeyPEM:          lo.If(nodeCfg.KeySource == BizApplyKeySourceCustom, nodeCfg.KeyContent).Else(""),
+		ChallengeType:          nodeCfg.ChallengeType,
+		Provider:               domain.ACMEChallengeProviderType(nodeCfg.Provider),
+		ProviderAccessConfig:   providerAccessConfig,
+		ProviderExtendedConfig: nodeCfg.ProviderConfig,
+		DnsPropagationTimeout:  nodeCfg.DnsPropagationTimeout,
+		DnsTTL:                 nodeCfg.DnsTTL,
+	}
+	if err := certacme.ValidateObtainCertificateRequest(obtainReq); err != nil {
+		return execRes, &domain.WorkflowPlanStep{Action: domain.WorkflowPlanActionTypeInvalid, Reason: reason, Error: err.Error()}, nil
+	}
+
+	return execRes, &domain.WorkflowPlanStep{Action: domain.WorkflowPlanActionTypeExecute, Reason: reason}, nil
+}
+
 func (ne *bizApplyNodeExecutor) getLastOutputArtifacts(execCtx *NodeExecutionContext) (*domain.WorkflowOutput, *domain.Certificate, error) {
 	lastOutput, err := ne.wfoutputRepo.GetByWorkflowIdAndNodeId(execCtx.Context(), execCtx.WorkflowId, execCtx.Node.Id)
 	if err != nil && !domain.IsRecordNotFoundError(err) {
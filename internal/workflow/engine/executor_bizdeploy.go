@@ -43,22 +43,11 @@ func (ne *bizDeployNodeExecutor) Execute(execCtx *NodeExecutionContext) (*NodeEx
 	}
 
 	// 获取前序节点输出证书
-	var inputCertificate *domain.Certificate
-	if inputState, ok := execCtx.inputs.Get(nodeCfg.CertificateOutputNodeId, "certificate"); ok {
-		if inputStateValue, ok := inputState.Value.(string); ok {
-			s := strings.Split(inputStateValue, "#")
-			if len(s) == 2 {
-				certificate, err := ne.certificateRepo.GetById(execCtx.Context(), s[1])
-				if err != nil {
-					ne.logger.Warn("could not get input certificate")
-					return execRes, err
-				}
-
-				inputCertificate = certificate
-			}
-		}
-	}
-	if inputCertificate == nil {
+	inputCertificate, err := ne.getInputCertificate(execCtx)
+	if err != nil {
+		ne.logger.Warn("could not get input certificate")
+		return execRes, err
+	} else if inputCertificate == nil {
 		return execRes, fmt.Errorf("invalid input certificate")
 	}
 
@@ -109,6 +98,79 @@ func (ne *bizDeployNodeExecutor) Execute(execCtx *NodeExecutionContext) (*NodeEx
 	return execRes, nil
 }
 
+func (ne *bizDeployNodeExecutor) DryRun(execCtx *NodeExecutionContext) (*NodeExecutionResult, *domain.WorkflowPlanStep, error) {
+	execRes := newNodeExecutionResult(execCtx.Node)
+
+	nodeCfg := execCtx.Node.Data.Config.AsBizDeploy()
+
+	// 查询上次执行结果
+	lastOutput, err := ne.getLastOutputArtifacts(execCtx)
+	if err != nil {
+		return execRes, nil, err
+	}
+
+	// 如果前序节点将会签发新证书，则必然需要重新部署
+	var reason string
+	if upstream, ok := execCtx.planner.Get(nodeCfg.CertificateOutputNodeId); ok && upstream.Action == domain.WorkflowPlanActionTypeExecute {
+		reason = fmt.Sprintf("the input certificate will be renewed by node #%s", nodeCfg.CertificateOutputNodeId)
+	} else {
+		inputCertificate, err := ne.getInputCertificate(execCtx)
+		if err != nil {
+			return execRes, nil, err
+		} else if inputCertificate == nil {
+			return execRes, &domain.WorkflowPlanStep{Action: domain.WorkflowPlanActionTypeInvalid, Error: "invalid input certificate"}, nil
+		}
+
+		if lastOutput != nil && inputCertificate.CreatedAt.Before(lastOutput.UpdatedAt) {
+			var skippable bool
+			if skippable, reason = ne.checkCanSkip(execCtx, lastOutput); skippable {
+				execRes.AddVariableWithScope(execCtx.Node.Id, stateVarKeyNodeSkipped, true, stateValTypeBoolean)
+				return execRes, &domain.WorkflowPlanStep{Action: domain.WorkflowPlanActionTypeSkip, Reason: reason}, nil
+			}
+		} else {
+			reason = "the input certificate has not been deployed yet"
+		}
+	}
+	execRes.AddVariableWithScope(execCtx.Node.Id, stateVarKeyNodeSkipped, false, stateValTypeBoolean)
+
+	// 校验授权及部署提供商配置
+	providerAccessConfig := make(map[string]any)
+	if nodeCfg.ProviderAccessId != "" {
+		if access, err := ne.accessRepo.GetById(execCtx.Context(), nodeCfg.ProviderAccessId); err != nil {
+			return execRes, &domain.WorkflowPlanStep{Action: domain.WorkflowPlanActionTypeInvalid, Reason: reason, Error: fmt.Sprintf("failed to get access #%s record: %s", nodeCfg.ProviderAccessId, err.Error())}, nil
+		} else {
+			providerAccessConfig = access.Config
+		}
+	}
+
+	deployer := certmgmt.NewClient(certmgmt.WithLogger(ne.logger))
+	deployReq := &certmgmt.DeployCertificateRequest{
+		Provider:               domain.DeploymentProviderType(nodeCfg.Provider),
+		ProviderAccessConfig:   providerAccessConfig,
+		ProviderExtendedConfig: nodeCfg.ProviderConfig,
+	}
+	if err := deployer.ValidateDeployCertificateRequest(deployReq); err != nil {
+		return execRes, &domain.WorkflowPlanStep{Action: domain.WorkflowPlanActionTypeInvalid, Reason: reason, Error: err.Error()}, nil
+	}
+
+	return execRes, &domain.WorkflowPlanStep{Action: domain.WorkflowPlanActionTypeExecute, Reason: reason}, nil
+}
+
+func (ne *bizDeployNodeExecutor) getInputCertificate(execCtx *NodeExecutionContext) (*domain.Certificate, error) {
+	nodeCfg := execCtx.Node.Data.Config.AsBizDeploy()
+
+	if inputState, ok := execCtx.inputs.Get(nodeCfg.CertificateOutputNodeId, "certificate"); ok {
+		if inputStateValue, ok := inputState.Value.(string); ok {
+			s := strings.Split(inputStateValue, "#")
+			if len(s) == 2 {
+				return ne.certificateRepo.GetById(execCtx.Context(), s[1])
+			}
+		}
+	}
+
+	return nil, nil
+}
+
 func (ne *bizDeployNodeExecutor) getLastOutputArtifacts(execCtx *NodeExecutionContext) (*domain.WorkflowOutput, error) {
 	lastOutput, err := ne.wfoutputRepo.GetByWorkflowIdAndNodeId(execCtx.Context(), execCtx.WorkflowId, execCtx.Node.Id)
 	if err != nil && !domain.IsRecordNotFoundError(err) {
@@ -12,6 +12,7 @@ import (
 	"time"
 
 	"github.com/certimate-go/certimate/internal/app"
+	"github.com/certimate-go/certimate/internal/domain"
 	"github.com/certimate-go/certimate/internal/repository"
 	xcertx509 "github.com/certimate-go/certimate/pkg/utils/cert/x509"
 	xhttp "github.com/certimate-go/certimate/pkg/utils/http"
@@ -119,6 +120,17 @@ func (ne *bizMonitorNodeExecutor) Execute(execCtx *NodeExecutionContext) (*NodeE
 	return execRes, nil
 }
 
+func (ne *bizMonitorNodeExecutor) DryRun(execCtx *NodeExecutionContext) (*NodeExecutionResult, *domain.WorkflowPlanStep, error) {
+	execRes := newNodeExecutionResult(execCtx.Node)
+
+	nodeCfg := execCtx.Node.Data.Config.AsBizMonitor()
+	if nodeCfg.Host == "" {
+		return execRes, &domain.WorkflowPlanStep{Action: domain.WorkflowPlanActionTypeInvalid, Error: "the host to monitor is not specified"}, nil
+	}
+
+	return execRes, &domain.WorkflowPlanStep{Action: domain.WorkflowPlanActionTypeExecute, Reason: fmt.Sprintf("the certificate will be retrieved from %s", nodeCfg.Host)}, nil
+}
+
 func (ne *bizMonitorNodeExecutor) execRetrieveCertificates(execCtx *NodeExecutionContext, addr, domain, requestPath string) ([]*x509.Certificate, error) {
 	transport := xhttp.NewDefaultTransport()
 	transport.DisableKeepAlives = true
@@ -3,9 +3,6 @@ package engine
 import (
 	"fmt"
 	"log/slog"
-	"regexp"
-	"strings"
-	"time"
 
 	"github.com/certimate-go/certimate/internal/domain"
 	"github.com/certimate-go/certimate/internal/notify"
@@ -41,29 +38,14 @@ func (ne *bizNotifyNodeExecutor) Execute(execCtx *NodeExecutionContext) (*NodeEx
 	}
 
 	// 渲染通知模板
-	reMustache := regexp.MustCompile(`\{\{\s*(\$[^\s]+)\s*\}\}`)
-	reMustacheReplacer := func(match string) string {
-		mustache := strings.TrimSpace(match[2 : len(match)-2])
-		if mustache == "" {
-			return match
-		}
-
-		key := mustache[1:]
-		if key == "" {
-			return match
-		} else if key == "now" {
-			return time.Now().Format(time.RFC3339)
-		}
-
-		// TODO: 支持作用域变量
-		if state, ok := execCtx.variables.Get(key); ok {
-			return state.ValueString()
-		}
-
-		return match
+	subject, err := renderTemplate(execCtx, "subject", nodeCfg.Subject, nodeCfg.StrictTemplate)
+	if err != nil {
+		return execRes, fmt.Errorf("failed to render notification subject: %w", err)
+	}
+	message, err := renderTemplate(execCtx, "message", nodeCfg.Message, nodeCfg.StrictTemplate)
+	if err != nil {
+		return execRes, fmt.Errorf("failed to render notification message: %w", err)
 	}
-	subject := reMustache.ReplaceAllStringFunc(nodeCfg.Subject, reMustacheReplacer)
-	message := reMustache.ReplaceAllStringFunc(nodeCfg.Message, reMustacheReplacer)
 
 	// 推送通知
 	notifier := notify.NewClient(notify.WithLogger(ne.logger))
@@ -83,6 +65,47 @@ func (ne *bizNotifyNodeExecutor) Execute(execCtx *NodeExecutionContext) (*NodeEx
 	return execRes, nil
 }
 
+func (ne *bizNotifyNodeExecutor) DryRun(execCtx *NodeExecutionContext) (*NodeExecutionResult, *domain.WorkflowPlanStep, error) {
+	execRes := newNodeExecutionResult(execCtx.Node)
+
+	nodeCfg := execCtx.Node.Data.Config.AsBizNotify()
+
+	// 检测是否可以跳过本次执行
+	if skippable, reason := ne.checkCanSkip(execCtx); skippable {
+		return execRes, &domain.WorkflowPlanStep{Action: domain.WorkflowPlanActionTypeSkip, Reason: reason}, nil
+	}
+
+	// 校验通知模板
+	if err := parseTemplate("subject", nodeCfg.Subject); err != nil {
+		return execRes, &domain.WorkflowPlanStep{Action: domain.WorkflowPlanActionTypeInvalid, Error: fmt.Sprintf("invalid subject template: %s", err.Error())}, nil
+	}
+	if err := parseTemplate("message", nodeCfg.Message); err != nil {
+		return execRes, &domain.WorkflowPlanStep{Action: domain.WorkflowPlanActionTypeInvalid, Error: fmt.Sprintf("invalid message template: %s", err.Error())}, nil
+	}
+
+	// 校验授权及通知提供商配置
+	providerAccessConfig := make(map[string]any)
+	if nodeCfg.ProviderAccessId != "" {
+		if access, err := ne.accessRepo.GetById(execCtx.Context(), nodeCfg.ProviderAccessId); err != nil {
+			return execRes, &domain.WorkflowPlanStep{Action: domain.WorkflowPlanActionTypeInvalid, Error: fmt.Sprintf("failed to get access #%s record: %s", nodeCfg.ProviderAccessId, err.Error())}, nil
+		} else {
+			providerAccessConfig = access.Config
+		}
+	}
+
+	notifier := notify.NewClient(notify.WithLogger(ne.logger))
+	notifyReq := &notify.SendNotificationRequest{
+		Provider:               domain.NotificationProviderType(nodeCfg.Provider),
+		ProviderAccessConfig:   providerAccessConfig,
+		ProviderExtendedConfig: nodeCfg.ProviderConfig,
+	}
+	if err := notifier.ValidateSendNotificationRequest(notifyReq); err != nil {
+		return execRes, &domain.WorkflowPlanStep{Action: domain.WorkflowPlanActionTypeInvalid, Error: err.Error()}, nil
+	}
+
+	return execRes, &domain.WorkflowPlanStep{Action: domain.WorkflowPlanActionTypeExecute}, nil
+}
+
 func (ne *bizNotifyNodeExecutor) checkCanSkip(execCtx *NodeExecutionContext) (_skip bool, _reason string) {
 	thisNodeCfg := execCtx.Node.Data.Config.AsBizNotify()
 	if !thisNodeCfg.SkipOnAllPrevSkipped {
@@ -196,6 +196,40 @@ func (ne *bizUploadNodeExecutor) Execute(execCtx *NodeExecutionContext) (*NodeEx
 	return execRes, nil
 }
 
+func (ne *bizUploadNodeExecutor) DryRun(execCtx *NodeExecutionContext) (*NodeExecutionResult, *domain.WorkflowPlanStep, error) {
+	execRes := newNodeExecutionResult(execCtx.Node)
+
+	nodeCfg := execCtx.Node.Data.Config.AsBizUpload()
+
+	// 查询上次执行结果
+	lastOutput, lastCertificate, err := ne.getLastOutputArtifacts(execCtx)
+	if err != nil {
+		return execRes, nil, err
+	} else if lastCertificate != nil {
+		ne.setOuputsOfResult(execCtx, execRes, lastCertificate, false)
+		ne.setVariablesOfResult(execCtx, execRes, lastCertificate)
+	}
+
+	// 检测是否可以跳过本次执行
+	skippable, reason := ne.checkCanSkip(execCtx, lastOutput, lastCertificate)
+	execRes.AddVariableWithScope(execCtx.Node.Id, stateVarKeyNodeSkipped, skippable, stateValTypeBoolean)
+	if skippable {
+		return execRes, &domain.WorkflowPlanStep{Action: domain.WorkflowPlanActionTypeSkip, Reason: reason}, nil
+	}
+
+	switch nodeCfg.Source {
+	case BizUploadSourceForm, BizUploadSourceLocal:
+	case BizUploadSourceURL:
+		if reason == "" {
+			reason = "the certificate will be downloaded and compared with the last uploaded one"
+		}
+	default:
+		return execRes, &domain.WorkflowPlanStep{Action: domain.WorkflowPlanActionTypeInvalid, Error: fmt.Sprintf("unsupported upload source: '%s'", nodeCfg.Source)}, nil
+	}
+
+	return execRes, &domain.WorkflowPlanStep{Action: domain.WorkflowPlanActionTypeExecute, Reason: reason}, nil
+}
+
 func (ne *bizUploadNodeExecutor) getLastOutputArtifacts(execCtx *NodeExecutionContext) (*domain.WorkflowOutput, *domain.Certificate, error) {
 	lastOutput, err := ne.wfoutputRepo.GetByWorkflowIdAndNodeId(execCtx.Context(), execCtx.WorkflowId, execCtx.Node.Id)
 	if err != nil && !domain.IsRecordNotFoundError(err) {
@@ -0,0 +1,260 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/certimate-go/certimate/internal/domain"
+	"github.com/certimate-go/certimate/internal/repository"
+)
+
+/**
+ * Outputs:
+ *   - all the outputs of the called workflow, re-exported by this node
+ *
+ * Variables:
+ *   - all the global variables changed by the called workflow, re-exported under the scope of this node
+ */
+type callWorkflowNodeExecutor struct {
+	nodeExecutor
+
+	workflowRepo    workflowRepository
+	workflowRunRepo workflowRunRepository
+}
+
+func (ne *callWorkflowNodeExecutor) Execute(execCtx *NodeExecutionContext) (*NodeExecutionResult, error) {
+	var engine *workflowEngine
+	if we, ok := execCtx.engine.(*workflowEngine); !ok {
+		panic("unreachable")
+	} else {
+		engine = we
+	}
+
+	execRes := newNodeExecutionResult(execCtx.Node)
+
+	nodeCfg := execCtx.Node.Data.Config.AsCallWorkflow()
+	ne.logger.Info("ready to call workflow ...", slog.Any("config", nodeCfg))
+
+	// 检测循环调用
+	if nodeCfg.WorkflowId == "" {
+		return execRes, fmt.Errorf("the workflow to call is not specified")
+	} else if slices.Contains(getCallStack(execCtx.Context()), nodeCfg.WorkflowId) {
+		return execRes, fmt.Errorf("circular call of workflow #%s detected", nodeCfg.WorkflowId)
+	}
+
+	// 查询被调用的工作流
+	workflow, err := ne.workflowRepo.GetById(execCtx.Context(), nodeCfg.WorkflowId)
+	if err != nil {
+		return execRes, fmt.Errorf("failed to get workflow #%s record: %w", nodeCfg.WorkflowId, err)
+	} else if workflow.GraphContent == nil {
+		return execRes, fmt.Errorf("the graph content of workflow #%s is empty", workflow.Id)
+	} else if err := workflow.GraphContent.Verify(); err != nil {
+		return execRes, fmt.Errorf("the graph content of workflow #%s is invalid: %w", workflow.Id, err)
+	}
+
+	// 解析输入变量
+	inputs, err := ne.resolveInputs(execCtx)
+	if err != nil {
+		ne.logger.Warn("could not resolve input variables")
+		return execRes, err
+	}
+
+	var runTrigger domain.WorkflowTriggerType
+	if state, ok := execCtx.variables.Get(stateVarKeyRunTrigger); ok {
+		runTrigger = domain.WorkflowTriggerType(state.ValueString())
+	}
+
+	execution := WorkflowExecution{
+		WorkflowId:          workflow.Id,
+		WorkflowName:        workflow.Name,
+		WorkflowDescription: workflow.Description,
+		RunId:               execCtx.RunId,
+		RunTrigger:          runTrigger,
+		RunAt:               time.Now(),
+		Graph:               workflow.GraphContent.Clone(),
+	}
+
+	ctx := withCallStack(execCtx.Context(), workflow.Id)
+
+	switch nodeCfg.Mode {
+	case domain.WorkflowCallWorkflowModeInline:
+		{
+			ne.logger.Info(fmt.Sprintf("execute workflow #%s inline ...", workflow.Id))
+
+			subCtx := engine.newWorkflowContext(ctx, execution)
+			subCtx.SetExecutingWorkflow(execCtx.WorkflowId, execCtx.RunId, execution.Graph)
+			if err := ne.executeSubWorkflow(engine, subCtx, inputs, execRes); err != nil {
+				return execRes, err
+			}
+		}
+
+	case domain.WorkflowCallWorkflowModeChild:
+		{
+			workflowRun := &domain.WorkflowRun{
+				WorkflowId:   workflow.Id,
+				ParentRunId:  execCtx.RunId,
+				Status:       domain.WorkflowRunStatusTypeProcessing,
+				Trigger:      runTrigger,
+				StartedAt:    execution.RunAt,
+				Attempt:      1,
+				Graph:        execution.Graph,
+				GraphVersion: workflow.GraphVersion,
+			}
+			if workflowRun, err = ne.workflowRunRepo.SaveWithCascading(ctx, workflowRun); err != nil {
+				return execRes, fmt.Errorf("failed to save child workflow run: %w", err)
+			}
+
+			ne.logger.Info(fmt.Sprintf("execute workflow #%s as child run #%s ...", workflow.Id, workflowRun.Id))
+
+			execution.RunId = workflowRun.Id
+			subCtx := engine.newWorkflowContext(withExecutingRun(ctx, workflow.Id, workflowRun.Id), execution)
+			subErr := ne.executeSubWorkflow(engine, subCtx, inputs, execRes)
+
+			workflowRun.EndedAt = time.Now()
+			if subErr == nil {
+				workflowRun.Status = domain.WorkflowRunStatusTypeSucceeded
+			} else if errors.Is(subErr, context.Canceled) || errors.Is(subErr, context.DeadlineExceeded) {
+				workflowRun.Status = domain.WorkflowRunStatusTypeCanceled
+			} else {
+				workflowRun.Status = domain.WorkflowRunStatusTypeFailed
+				workflowRun.Error = subErr.Error()
+			}
+			if _, err := ne.workflowRunRepo.SaveWithCascading(context.Background(), workflowRun); err != nil {
+				ne.logger.Warn(fmt.Sprintf("failed to save child workflow run #%s", workflowRun.Id), slog.Any("error", err))
+			}
+
+			if subErr != nil {
+				return execRes, fmt.Errorf("child workflow run #%s %s: %w", workflowRun.Id, workflowRun.Status, subErr)
+			}
+		}
+
+	default:
+		return execRes, fmt.Errorf("unsupported call mode: '%s'", nodeCfg.Mode)
+	}
+
+	ne.logger.Info("workflow call completed")
+	return execRes, nil
+}
+
+func (ne *callWorkflowNodeExecutor) DryRun(execCtx *NodeExecutionContext) (*NodeExecutionResult, *domain.WorkflowPlanStep, error) {
+	execRes := newNodeExecutionResult(execCtx.Node)
+
+	nodeCfg := execCtx.Node.Data.Config.AsCallWorkflow()
+
+	// 仅校验被调用的工作流及输入变量，不会实际调用之
+	if nodeCfg.WorkflowId == "" {
+		return execRes, &domain.WorkflowPlanStep{Action: domain.WorkflowPlanActionTypeInvalid, Error: "the workflow to call is not specified"}, nil
+	} else if slices.Contains(getCallStack(execCtx.Context()), nodeCfg.WorkflowId) {
+		return execRes, &domain.WorkflowPlanStep{Action: domain.WorkflowPlanActionTypeInvalid, Error: fmt.Sprintf("circular call of workflow #%s detected", nodeCfg.WorkflowId)}, nil
+	}
+
+	workflow, err := ne.workflowRepo.GetById(execCtx.Context(), nodeCfg.WorkflowId)
+	if err != nil {
+		return execRes, &domain.WorkflowPlanStep{Action: domain.WorkflowPlanActionTypeInvalid, Error: fmt.Sprintf("failed to get workflow #%s record: %s", nodeCfg.WorkflowId, err.Error())}, nil
+	} else if workflow.GraphContent == nil {
+		return execRes, &domain.WorkflowPlanStep{Action: domain.WorkflowPlanActionTypeInvalid, Error: fmt.Sprintf("the graph content of workflow #%s is empty", workflow.Id)}, nil
+	} else if err := workflow.GraphContent.Verify(); err != nil {
+		return execRes, &domain.WorkflowPlanStep{Action: domain.WorkflowPlanActionTypeInvalid, Error: fmt.Sprintf("the graph content of workflow #%s is invalid: %s", workflow.Id, err.Error())}, nil
+	}
+
+	if _, err := ne.resolveInputs(execCtx); err != nil {
+		return execRes, &domain.WorkflowPlanStep{Action: domain.WorkflowPlanActionTypeInvalid, Error: err.Error()}, nil
+	}
+
+	switch nodeCfg.Mode {
+	case domain.WorkflowCallWorkflowModeInline, domain.WorkflowCallWorkflowModeChild:
+	default:
+		return execRes, &domain.WorkflowPlanStep{Action: domain.WorkflowPlanActionTypeInvalid, Error: fmt.Sprintf("unsupported call mode: '%s'", nodeCfg.Mode)}, nil
+	}
+
+	return execRes, &domain.WorkflowPlanStep{Action: domain.WorkflowPlanActionTypeExecute, Reason: fmt.Sprintf("workflow #%s will be called in '%s' mode", workflow.Id, nodeCfg.Mode)}, nil
+}
+
+func (ne *callWorkflowNodeExecutor) executeSubWorkflow(engine *workflowEngine, subCtx *WorkflowContext, inputs []VariableState, execRes *NodeExecutionResult) error {
+	// 输入变量同时作为全局变量和开始节点的作用域变量，以便分支条件引用
+	startNodeId := subCtx.RunGraph.Nodes[0].Id
+	for _, input := range inputs {
+		subCtx.variables.Set(input.Key, input.Value, input.ValueType)
+		subCtx.variables.SetScoped(startNodeId, input.Key, input.Value, input.ValueType)
+	}
+
+	baseVariables := subCtx.variables.All()
+
+	err := engine.executeBlocks(subCtx, subCtx.RunGraph.Nodes)
+	if err != nil && !errors.Is(err, ErrTerminated) {
+		return err
+	} else if err := subCtx.Context().Err(); err != nil {
+		return err
+	}
+
+	// 子工作流中存在失败的节点（例如被 TryCatch 捕获后由 End 节点结束）时，同样视为失败
+	if state, ok := subCtx.variables.Get(stateVarKeyErrorNodeId); ok && state.ValueString() != "" {
+		errmsg := ""
+		if state, ok := subCtx.variables.Get(stateVarKeyErrorMessage); ok {
+			errmsg = state.ValueString()
+		}
+		return fmt.Errorf("node #%s failed: %s", state.ValueString(), errmsg)
+	}
+
+	// 回传子工作流中发生变化的全局变量及所有输出
+	changedVariables := newVariableManager()
+	mergeVariableManager(changedVariables, baseVariables, subCtx.variables)
+	for _, state := range changedVariables.All() {
+		if state.Scope == "" {
+			execRes.AddVariableWithScope(execRes.node.Id, state.Key, state.Value, state.ValueType)
+		}
+	}
+
+	for _, state := range subCtx.inputs.All() {
+		state.NodeId = execRes.node.Id
+		execRes.addOutputState(state)
+	}
+
+	return nil
+}
+
+func (ne *callWorkflowNodeExecutor) resolveInputs(execCtx *NodeExecutionContext) ([]VariableState, error) {
+	nodeCfg := execCtx.Node.Data.Config.AsCallWorkflow()
+
+	inputs := make([]VariableState, 0, len(nodeCfg.Inputs))
+	for _, input := range nodeCfg.Inputs {
+		if input == nil || input.Name == "" {
+			continue
+		}
+
+		if input.Selector != nil && input.Selector.Name != "" {
+			state, ok := execCtx.variables.GetScoped(input.Selector.Id, input.Selector.Name)
+			if !ok {
+				return nil, fmt.Errorf("variable '%s' referenced by input '%s' not found", input.Selector.Name, input.Name)
+			}
+
+			inputs = append(inputs, VariableState{Key: input.Name, Value: state.Value, ValueType: state.ValueType})
+		} else {
+			value, err := parseStateValue(input.Value, input.ValueType)
+			if err != nil {
+				return nil, fmt.Errorf("invalid value of input '%s': %w", input.Name, err)
+			}
+
+			valueType := input.ValueType
+			if valueType == "" {
+				valueType = stateValTypeString
+			}
+
+			inputs = append(inputs, VariableState{Key: input.Name, Value: value, ValueType: valueType})
+		}
+	}
+
+	return inputs, nil
+}
+
+func newCallWorkflowNodeExecutor() NodeExecutor {
+	return &callWorkflowNodeExecutor{
+		nodeExecutor:    nodeExecutor{logger: slog.Default()},
+		workflowRepo:    repository.NewWorkflowRepository(),
+		workflowRunRepo: repository.NewWorkflowRunRepository(),
+	}
+}
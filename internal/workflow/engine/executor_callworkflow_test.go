@@ -0,0 +1,234 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"testing"
+
+	"github.com/certimate-go/certimate/internal/domain"
+)
+
+type testWorkflowRepository struct {
+	workflows map[string]*domain.Workflow
+}
+
+func (r *testWorkflowRepository) GetById(ctx context.Context, id string) (*domain.Workflow, error) {
+	if workflow, ok := r.workflows[id]; ok {
+		return workflow, nil
+	}
+
+	return nil, domain.ErrRecordNotFound
+}
+
+type testWorkflowRunRepository struct {
+	mtx    sync.Mutex
+	runs   map[string]*domain.WorkflowRun
+	saves  []domain.WorkflowRun
+	nextId int
+}
+
+func (r *testWorkflowRunRepository) GetById(ctx context.Context, id string) (*domain.WorkflowRun, error) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	if run, ok := r.runs[id]; ok {
+		return run, nil
+	}
+
+	return nil, domain.ErrRecordNotFound
+}
+
+func (r *testWorkflowRunRepository) SaveWithCascading(ctx context.Context, workflowRun *domain.WorkflowRun) (*domain.WorkflowRun, error) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	if workflowRun.Id == "" {
+		r.nextId++
+		workflowRun.Id = fmt.Sprintf("child%d", r.nextId)
+	}
+	if r.runs == nil {
+		r.runs = make(map[string]*domain.WorkflowRun)
+	}
+	r.runs[workflowRun.Id] = workflowRun
+	r.saves = append(r.saves, *workflowRun)
+	return workflowRun, nil
+}
+
+// 创建包含调用工作流节点的测试引擎。
+// 被调用的工作流中的测试节点会读取输入变量 "name"，并写入全局变量 "greeting" 及输出 "result"；其配置中含有 "fail" 时返回错误。
+func newTestCallWorkflowEngine(workflows map[string]*domain.Workflow, runRepo *testWorkflowRunRepository) *workflowEngine {
+	engine := newTestWorkflowEngine(func(execCtx *NodeExecutionContext, execRes *NodeExecutionResult) error {
+		if _, ok := execCtx.Node.Data.Config["fail"]; ok {
+			return errors.New("test error")
+		}
+
+		name := ""
+		if state, ok := execCtx.variables.Get("name"); ok {
+			name = state.ValueString()
+		}
+		execRes.AddVariable("greeting", "hello "+name, stateValTypeString)
+		execRes.AddOutput(stateIOTypeRef, "result", execCtx.RunId, stateValTypeString)
+		return nil
+	})
+	engine.executors[NodeTypeStart] = newStartNodeExecutor
+	engine.executors[NodeTypeEnd] = newEndNodeExecutor
+	engine.executors[NodeTypeCallWorkflow] = func() NodeExecutor {
+		return &callWorkflowNodeExecutor{
+			nodeExecutor:    nodeExecutor{logger: slog.Default()},
+			workflowRepo:    &testWorkflowRepository{workflows: workflows},
+			workflowRunRepo: runRepo,
+		}
+	}
+
+	return engine
+}
+
+func newTestSubWorkflow(id string, nodes ...*Node) *domain.Workflow {
+	graph := &domain.WorkflowGraph{}
+	graph.Nodes = append(graph.Nodes, &Node{Id: id + "-start", Type: NodeTypeStart})
+	graph.Nodes = append(graph.Nodes, nodes...)
+	graph.Nodes = append(graph.Nodes, &Node{Id: id + "-end", Type: NodeTypeEnd})
+	return &domain.Workflow{Meta: domain.Meta{Id: id}, Name: id, GraphContent: graph}
+}
+
+func newTestCallWorkflowNode(id string, config domain.WorkflowNodeConfig) *Node {
+	return &Node{Id: id, Type: NodeTypeCallWorkflow, Data: domain.WorkflowNodeData{Name: id, Config: config}}
+}
+
+func TestCallWorkflowNodeExecutor(t *testing.T) {
+	failingNode := newTestNode("sub1")
+	failingNode.Data.Config = domain.WorkflowNodeConfig{"fail": true}
+
+	workflows := map[string]*domain.Workflow{
+		"wf2": newTestSubWorkflow("wf2", newTestNode("sub1")),
+		"wf3": newTestSubWorkflow("wf3", failingNode),
+		"wf4": newTestSubWorkflow("wf4", newTestCallWorkflowNode("call2", domain.WorkflowNodeConfig{"workflowId": "wf4", "mode": "inline"})),
+		"wf5": {Meta: domain.Meta{Id: "wf5"}},
+	}
+	inputs := []map[string]any{
+		{"name": "name", "value": "world"},
+		{"name": "count", "value": "1", "valueType": stateValTypeNumber},
+	}
+
+	tests := []struct {
+		name         string
+		config       domain.WorkflowNodeConfig
+		wantErr      bool
+		wantGreeting string
+		wantResult   string
+		wantRuns     []domain.WorkflowRunStatusType
+	}{
+		{
+			name:         "inline",
+			config:       domain.WorkflowNodeConfig{"workflowId": "wf2", "mode": "inline", "inputs": inputs},
+			wantGreeting: "hello world",
+			wantResult:   "run1",
+		},
+		{
+			name: "inline with input referencing variable",
+			config: domain.WorkflowNodeConfig{"workflowId": "wf2", "mode": "inline", "inputs": []map[string]any{
+				{"name": "name", "selector": map[string]any{"id": "", "name": stateVarKeyWorkflowId, "type": "string"}},
+			}},
+			wantGreeting: "hello wf1",
+			wantResult:   "run1",
+		},
+		{
+			name:         "child",
+			config:       domain.WorkflowNodeConfig{"workflowId": "wf2", "inputs": inputs},
+			wantGreeting: "hello world",
+			wantResult:   "child1",
+			wantRuns:     []domain.WorkflowRunStatusType{domain.WorkflowRunStatusTypeProcessing, domain.WorkflowRunStatusTypeSucceeded},
+		},
+		{
+			name:     "child failed",
+			config:   domain.WorkflowNodeConfig{"workflowId": "wf3", "mode": "child"},
+			wantErr:  true,
+			wantRuns: []domain.WorkflowRunStatusType{domain.WorkflowRunStatusTypeProcessing, domain.WorkflowRunStatusTypeFailed},
+		},
+		{
+			name:    "inline failed",
+			config:  domain.WorkflowNodeConfig{"workflowId": "wf3", "mode": "inline"},
+			wantErr: true,
+		},
+		{
+			name:    "circular call",
+			config:  domain.WorkflowNodeConfig{"workflowId": "wf4", "mode": "inline"},
+			wantErr: true,
+		},
+		{
+			name:    "workflow not specified",
+			config:  domain.WorkflowNodeConfig{"mode": "inline"},
+			wantErr: true,
+		},
+		{
+			name:    "workflow not found",
+			config:  domain.WorkflowNodeConfig{"workflowId": "wf0"},
+			wantErr: true,
+		},
+		{
+			name:    "workflow not published",
+			config:  domain.WorkflowNodeConfig{"workflowId": "wf5"},
+			wantErr: true,
+		},
+		{
+			name: "input referencing undefined variable",
+			config: domain.WorkflowNodeConfig{"workflowId": "wf2", "inputs": []map[string]any{
+				{"name": "name", "selector": map[string]any{"id": "node0", "name": "any", "type": "string"}},
+			}},
+			wantErr: true,
+		},
+		{
+			name:    "invalid input value",
+			config:  domain.WorkflowNodeConfig{"workflowId": "wf2", "inputs": []map[string]any{{"name": "count", "value": "abc", "valueType": stateValTypeNumber}}},
+			wantErr: true,
+		},
+		{
+			name:    "unsupported mode",
+			config:  domain.WorkflowNodeConfig{"workflowId": "wf2", "mode": "any"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runRepo := &testWorkflowRunRepository{}
+			engine := newTestCallWorkflowEngine(workflows, runRepo)
+
+			wfCtx, err := engine.executeTestBlocks(context.Background(), newTestCallWorkflowNode("call1", tt.config))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Execute() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if len(runRepo.saves) != len(tt.wantRuns) {
+				t.Fatalf("Execute() saved %d child runs, want %d", len(runRepo.saves), len(tt.wantRuns))
+			}
+			for i, run := range runRepo.saves {
+				if run.Status != tt.wantRuns[i] {
+					t.Errorf("Execute() child run status #%d = %s, want %s", i, run.Status, tt.wantRuns[i])
+				}
+				if run.ParentRunId != "run1" {
+					t.Errorf("Execute() child run parent = %s, want run1", run.ParentRunId)
+				}
+			}
+			if err != nil {
+				return
+			}
+
+			// 子工作流中的全局变量以调用节点为作用域回传，不会覆盖当前工作流的全局变量
+			if state, ok := wfCtx.variables.GetScoped("call1", "greeting"); !ok || state.ValueString() != tt.wantGreeting {
+				t.Errorf("Execute() scoped variable 'greeting' = %v, want %s", state, tt.wantGreeting)
+			}
+			if _, ok := wfCtx.variables.Get("greeting"); ok {
+				t.Errorf("Execute() global variable 'greeting' should not be set")
+			}
+			if state, ok := wfCtx.variables.Get(stateVarKeyWorkflowId); !ok || state.ValueString() != "wf1" {
+				t.Errorf("Execute() global variable '%s' = %v, want wf1", stateVarKeyWorkflowId, state)
+			}
+			if output, ok := wfCtx.inputs.Get("call1", "result"); !ok || output.ValueString() != tt.wantResult {
+				t.Errorf("Execute() output 'result' = %v, want %s", output, tt.wantResult)
+			}
+		})
+	}
+}
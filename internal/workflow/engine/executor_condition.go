@@ -34,7 +34,7 @@ func (ne *conditionNodeExecutor) Execute(execCtx *NodeExecutionContext) (*NodeEx
 
 		err := engine.executeNode(execCtx.Clone(), node)
 		if err != nil {
-			if errors.Is(err, ErrTerminated) {
+			if errors.Is(err, ErrTerminated) || errors.Is(err, ErrWaiting) {
 				return execRes, err
 			}
 			errs = append(errs, err)
@@ -65,17 +65,7 @@ func (ne *branchBlockNodeExecutor) Execute(execCtx *NodeExecutionContext) (*Node
 	if nodeCfg.Expression == nil {
 		ne.logger.Info("enter this branch without any conditions")
 	} else {
-		variables := lo.Reduce(execCtx.variables.All(), func(acc map[string]map[string]any, state VariableState, _ int) map[string]map[string]any {
-			if _, ok := acc[state.Scope]; !ok {
-				acc[state.Scope] = make(map[string]any)
-			}
-
-			// 这里需要把所有值都转换为字符串形式，因为 Expression.Eval 仅支持字符串类型的值
-			acc[state.Scope][state.Key] = state.ValueString()
-			return acc
-		}, make(map[string]map[string]any))
-
-		rs, err := nodeCfg.Expression.Eval(variables)
+		rs, err := nodeCfg.Expression.Eval(newExprVariables(execCtx.variables))
 		if err != nil {
 			ne.logger.Warn(fmt.Sprintf("failed to eval expr: %+v", err))
 			return execRes, err
@@ -100,6 +90,19 @@ func (ne *branchBlockNodeExecutor) Execute(execCtx *NodeExecutionContext) (*Node
 	return execRes, nil
 }
 
+// 将变量转换为表达式求值时所需的形式，即 Scope -> Key -> Value。
+func newExprVariables(variables VariableManager) map[string]map[string]any {
+	return lo.Reduce(variables.All(), func(acc map[string]map[string]any, state VariableState, _ int) map[string]map[string]any {
+		if _, ok := acc[state.Scope]; !ok {
+			acc[state.Scope] = make(map[string]any)
+		}
+
+		// 这里需要把所有值都转换为字符串形式，因为 Expression.Eval 仅支持字符串类型的值
+		acc[state.Scope][state.Key] = state.ValueString()
+		return acc
+	}, make(map[string]map[string]any))
+}
+
 func newBranchBlockNodeExecutor() NodeExecutor {
 	return &branchBlockNodeExecutor{
 		nodeExecutor: nodeExecutor{logger: slog.Default()},
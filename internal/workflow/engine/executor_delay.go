@@ -5,6 +5,7 @@ import (
 	"log/slog"
 	"time"
 
+	"github.com/certimate-go/certimate/internal/domain"
 	xwait "github.com/certimate-go/certimate/pkg/utils/wait"
 )
 
@@ -23,6 +24,13 @@ func (ne *delayNodeExecutor) Execute(execCtx *NodeExecutionContext) (*NodeExecut
 	return execRes, nil
 }
 
+func (ne *delayNodeExecutor) DryRun(execCtx *NodeExecutionContext) (*NodeExecutionResult, *domain.WorkflowPlanStep, error) {
+	execRes := newNodeExecutionResult(execCtx.Node)
+
+	nodeCfg := execCtx.Node.Data.Config.AsDelay()
+	return execRes, &domain.WorkflowPlanStep{Action: domain.WorkflowPlanActionTypeExecute, Reason: fmt.Sprintf("delay for %d second(s)", nodeCfg.Wait)}, nil
+}
+
 func newDelayNodeExecutor() NodeExecutor {
 	return &delayNodeExecutor{
 		nodeExecutor: nodeExecutor{logger: slog.Default()},
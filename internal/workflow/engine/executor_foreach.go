@@ -0,0 +1,152 @@
+package engine
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/samber/lo"
+
+	"github.com/certimate-go/certimate/internal/domain"
+)
+
+/**
+ * Outputs:
+ *   - json: "results": string
+ *
+ * Variables:
+ *   - "loop.item": string
+ *   - "loop.index": number
+ *   - "loop.total": number
+ *   - "loop.failed": number
+ *   - "loop.attempted": number
+ */
+type forEachNodeExecutor struct {
+	nodeExecutor
+}
+
+type forEachIterationResult struct {
+	Index     int    `json:"index"`
+	Item      string `json:"item"`
+	Succeeded bool   `json:"succeeded"`
+	Error     string `json:"error,omitempty"`
+}
+
+func (ne *forEachNodeExecutor) Execute(execCtx *NodeExecutionContext) (*NodeExecutionResult, error) {
+	var engine *workflowEngine
+	if we, ok := execCtx.engine.(*workflowEngine); !ok {
+		panic("unreachable")
+	} else {
+		engine = we
+	}
+
+	execRes := newNodeExecutionResult(execCtx.Node)
+
+	nodeCfg := execCtx.Node.Data.Config.AsForEach()
+	items, err := ne.resolveItems(execCtx, nodeCfg)
+	if err != nil {
+		ne.logger.Warn("could not resolve loop items")
+		return execRes, err
+	}
+
+	ne.logger.Info(fmt.Sprintf("ready to iterate over %d item(s) ...", len(items)))
+
+	// 循环体中的节点亦可读取总数；因出错中止或被终止时，已尝试的次数可能少于总数
+	execCtx.variables.SetScoped(execCtx.Node.Id, stateVarKeyLoopTotal, int32(len(items)), stateValTypeNumber)
+
+	errs := make([]error, 0)
+	results := make([]*forEachIterationResult, 0, len(items))
+	for i, item := range items {
+		ctx := execCtx.Context()
+		select {
+		case <-ctx.Done():
+			return execRes, ctx.Err()
+		default:
+		}
+
+		execCtx.variables.SetScoped(execCtx.Node.Id, stateVarKeyLoopItem, item, stateValTypeString)
+		execCtx.variables.SetScoped(execCtx.Node.Id, stateVarKeyLoopIndex, int32(i), stateValTypeNumber)
+
+		result := &forEachIterationResult{Index: i, Item: item, Succeeded: true}
+		results = append(results, result)
+
+		// 循环体中的节点会被多次执行，因此不记录其完成状态，续跑时将重新执行整个循环
+		err := engine.executeBlocks(execCtx.Clone().SetCheckpoint(nil), execCtx.Node.Blocks)
+		if err != nil {
+			if errors.Is(err, ErrTerminated) {
+				ne.logger.Info(fmt.Sprintf("iteration #%d (item: '%s') terminated", i, item))
+				ne.setResultsOfLoop(execCtx, execRes, items, results)
+				return execRes, err
+			}
+
+			result.Succeeded = false
+			result.Error = err.Error()
+			errs = append(errs, err)
+			ne.logger.Warn(fmt.Sprintf("iteration #%d (item: '%s') failed", i, item))
+
+			if !nodeCfg.ContinueOnError {
+				break
+			}
+		} else {
+			ne.logger.Info(fmt.Sprintf("iteration #%d (item: '%s') completed", i, item))
+		}
+	}
+
+	ne.setResultsOfLoop(execCtx, execRes, items, results)
+
+	if len(errs) > 0 {
+		return execRes, fmt.Errorf("%w: %w", ErrBlocksException, errors.Join(errs...))
+	}
+
+	ne.logger.Info("iteration completed")
+	return execRes, nil
+}
+
+func (ne *forEachNodeExecutor) resolveItems(execCtx *NodeExecutionContext, nodeCfg domain.WorkflowNodeConfigForForEach) ([]string, error) {
+	var items []string
+
+	switch nodeCfg.Source {
+	case domain.WorkflowForEachSourceStatic:
+		items = nodeCfg.Items
+
+	case domain.WorkflowForEachSourceVariable:
+		if nodeCfg.VariableName == "" {
+			return nil, fmt.Errorf("the loop variable name is empty")
+		}
+
+		state, ok := execCtx.variables.GetScoped(nodeCfg.VariableScope, nodeCfg.VariableName)
+		if !ok {
+			if nodeCfg.VariableScope == "" {
+				return nil, fmt.Errorf("variable '%s' not found", nodeCfg.VariableName)
+			}
+			return nil, fmt.Errorf("variable '%s' not found in node #%s", nodeCfg.VariableName, nodeCfg.VariableScope)
+		}
+
+		items = strings.Split(state.ValueString(), nodeCfg.Separator)
+
+	default:
+		return nil, fmt.Errorf("unsupported loop source: '%s'", nodeCfg.Source)
+	}
+
+	items = lo.Map(items, func(s string, _ int) string { return strings.TrimSpace(s) })
+	items = lo.Filter(items, func(s string, _ int) bool { return s != "" })
+	return items, nil
+}
+
+func (ne *forEachNodeExecutor) setResultsOfLoop(execCtx *NodeExecutionContext, execRes *NodeExecutionResult, items []string, results []*forEachIterationResult) {
+	failed := lo.CountBy(results, func(r *forEachIterationResult) bool { return !r.Succeeded })
+	execRes.AddVariableWithScope(execCtx.Node.Id, stateVarKeyLoopTotal, int32(len(items)), stateValTypeNumber)
+	execRes.AddVariableWithScope(execCtx.Node.Id, stateVarKeyLoopFailed, int32(failed), stateValTypeNumber)
+	execRes.AddVariableWithScope(execCtx.Node.Id, stateVarKeyLoopAttempted, int32(len(results)), stateValTypeNumber)
+
+	resultsJson, _ := json.Marshal(results)
+	execRes.AddOutputWithPersistent(stateIOTypeJSON, "results", string(resultsJson), stateValTypeString)
+}
+
+func newForEachNodeExecutor() NodeExecutor {
+	return &forEachNodeExecutor{
+		nodeExecutor: nodeExecutor{logger: slog.Default()},
+	}
+}
@@ -0,0 +1,213 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/certimate-go/certimate/internal/domain"
+)
+
+func TestForEachNodeExecutor(t *testing.T) {
+	tests := []struct {
+		name           string
+		config         domain.WorkflowNodeConfig
+		variables      []VariableState // 循环前已有的变量
+		failOn         []string
+		terminateOn    string
+		wantErr        bool
+		wantErrIs      error
+		wantIterations []string
+		wantTotal      int
+		wantFailed     int
+		wantAttempted  int
+	}{
+		{
+			name:           "static items",
+			config:         domain.WorkflowNodeConfig{"source": domain.WorkflowForEachSourceStatic, "items": "a; b;;c "},
+			wantIterations: []string{"0:a", "1:b", "2:c"},
+			wantTotal:      3,
+			wantAttempted:  3,
+		},
+		{
+			name:           "empty items",
+			config:         domain.WorkflowNodeConfig{"items": ""},
+			wantIterations: []string{},
+			wantTotal:      0,
+		},
+		{
+			name:           "global variable",
+			config:         domain.WorkflowNodeConfig{"source": domain.WorkflowForEachSourceVariable, "variableName": "domains"},
+			variables:      []VariableState{{Key: "domains", Value: "x.com;y.com", ValueType: stateValTypeString}},
+			wantIterations: []string{"0:x.com", "1:y.com"},
+			wantTotal:      2,
+			wantAttempted:  2,
+		},
+		{
+			name:           "scoped variable with separator",
+			config:         domain.WorkflowNodeConfig{"source": domain.WorkflowForEachSourceVariable, "variableScope": "n0", "variableName": "sans", "separator": ","},
+			variables:      []VariableState{{Scope: "n0", Key: "sans", Value: "a.com, b.com,", ValueType: stateValTypeString}},
+			wantIterations: []string{"0:a.com", "1:b.com"},
+			wantTotal:      2,
+			wantAttempted:  2,
+		},
+		{
+			name:           "variable not found",
+			config:         domain.WorkflowNodeConfig{"source": domain.WorkflowForEachSourceVariable, "variableScope": "n0", "variableName": "sans"},
+			variables:      []VariableState{{Key: "sans", Value: "a.com", ValueType: stateValTypeString}},
+			wantErr:        true,
+			wantIterations: []string{},
+		},
+		{
+			name:           "empty variable name",
+			config:         domain.WorkflowNodeConfig{"source": domain.WorkflowForEachSourceVariable},
+			wantErr:        true,
+			wantIterations: []string{},
+		},
+		{
+			name:           "unsupported source",
+			config:         domain.WorkflowNodeConfig{"source": "any"},
+			wantErr:        true,
+			wantIterations: []string{},
+		},
+		{
+			name:           "stop on error",
+			config:         domain.WorkflowNodeConfig{"items": "a;b;c"},
+			failOn:         []string{"b"},
+			wantErr:        true,
+			wantErrIs:      ErrBlocksException,
+			wantIterations: []string{"0:a", "1:b"},
+			wantTotal:      3,
+		},
+		{
+			name:           "continue on error",
+			config:         domain.WorkflowNodeConfig{"items": "a;b;c", "continueOnError": true},
+			failOn:         []string{"a", "b"},
+			wantErr:        true,
+			wantErrIs:      ErrBlocksException,
+			wantIterations: []string{"0:a", "1:b", "2:c"},
+			wantTotal:      3,
+		},
+		{
+			name:           "terminated",
+			config:         domain.WorkflowNodeConfig{"items": "a;b;c", "continueOnError": true},
+			failOn:         []string{"a"},
+			terminateOn:    "b",
+			wantErr:        true,
+			wantErrIs:      ErrTerminated,
+			wantIterations: []string{"0:a", "1:b"},
+			wantTotal:      3,
+			wantFailed:     1,
+			wantAttempted:  2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mtx sync.Mutex
+			var running, maxRunning int
+			iterations := make([]string, 0)
+			totals := make([]int32, 0)
+
+			engine := newTestWorkflowEngine(func(execCtx *NodeExecutionContext, execRes *NodeExecutionResult) error {
+				if execCtx.Node.Id == "n0" {
+					for _, variable := range tt.variables {
+						execRes.AddVariableWithScope(variable.Scope, variable.Key, variable.Value, variable.ValueType)
+					}
+					return nil
+				}
+
+				mtx.Lock()
+				running++
+				maxRunning = max(maxRunning, running)
+				mtx.Unlock()
+				defer func() {
+					mtx.Lock()
+					running--
+					mtx.Unlock()
+				}()
+				time.Sleep(5 * time.Millisecond)
+
+				item, _ := execCtx.variables.GetScoped("loop1", stateVarKeyLoopItem)
+				index, _ := execCtx.variables.GetScoped("loop1", stateVarKeyLoopIndex)
+				total, _ := execCtx.variables.GetScoped("loop1", stateVarKeyLoopTotal)
+				mtx.Lock()
+				iterations = append(iterations, fmt.Sprintf("%s:%s", index.ValueString(), item.ValueString()))
+				totals = append(totals, total.Value.(int32))
+				mtx.Unlock()
+
+				if slices.Contains(tt.failOn, item.ValueString()) {
+					return errors.New("test error")
+				}
+				if tt.terminateOn == item.ValueString() {
+					execRes.Terminated = true
+				}
+				return nil
+			})
+
+			loopNode := &Node{
+				Id:     "loop1",
+				Type:   NodeTypeForEach,
+				Data:   domain.WorkflowNodeData{Name: "loop1", Config: tt.config},
+				Blocks: []*Node{newTestNode("n1")},
+			}
+
+			wfCtx, err := engine.executeTestBlocks(context.Background(), newTestNode("n0"), loopNode)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Execute() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErrIs != nil && !errors.Is(err, tt.wantErrIs) {
+				t.Errorf("Execute() error = %v, want %v", err, tt.wantErrIs)
+			}
+
+			if !slices.Equal(iterations, tt.wantIterations) {
+				t.Errorf("Execute() iterations = %v, want %v", iterations, tt.wantIterations)
+			}
+			if maxRunning > 1 {
+				t.Errorf("Execute() max running = %d, want 1", maxRunning)
+			}
+			// 循环体中读取的总数应为全部元素的个数
+			for _, total := range totals {
+				if total != int32(tt.wantTotal) {
+					t.Errorf("Execute() loop.total in iteration = %d, want %d", total, tt.wantTotal)
+				}
+			}
+
+			// 执行失败时不会合并节点的变量及输出
+			if err != nil && !errors.Is(err, ErrTerminated) {
+				return
+			}
+
+			if total, ok := wfCtx.variables.GetScoped("loop1", stateVarKeyLoopTotal); !ok || total.Value != int32(tt.wantTotal) {
+				t.Errorf("Execute() loop.total = %v, want %d", total, tt.wantTotal)
+			}
+			if failed, ok := wfCtx.variables.GetScoped("loop1", stateVarKeyLoopFailed); !ok || failed.Value != int32(tt.wantFailed) {
+				t.Errorf("Execute() loop.failed = %v, want %d", failed, tt.wantFailed)
+			}
+			if attempted, ok := wfCtx.variables.GetScoped("loop1", stateVarKeyLoopAttempted); !ok || attempted.Value != int32(tt.wantAttempted) {
+				t.Errorf("Execute() loop.attempted = %v, want %d", attempted, tt.wantAttempted)
+			}
+
+			output, ok := wfCtx.inputs.Get("loop1", "results")
+			if !ok {
+				t.Fatalf("Execute() output 'results' not found")
+			}
+			results := make([]*forEachIterationResult, 0)
+			if err := json.Unmarshal([]byte(output.ValueString()), &results); err != nil {
+				t.Fatalf("failed to unmarshal results: %v", err)
+			}
+			if len(results) != tt.wantAttempted {
+				t.Errorf("Execute() results = %d, want %d", len(results), tt.wantAttempted)
+			}
+			for _, result := range results {
+				if result.Succeeded == slices.Contains(tt.failOn, result.Item) {
+					t.Errorf("Execute() result of '%s' succeeded = %v", result.Item, result.Succeeded)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,350 @@
+package engine
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+
+	"github.com/certimate-go/certimate/internal/domain"
+	"github.com/certimate-go/certimate/internal/repository"
+	"github.com/certimate-go/certimate/internal/tracing"
+	xhttp "github.com/certimate-go/certimate/pkg/utils/http"
+	xjsonpath "github.com/certimate-go/certimate/pkg/utils/jsonpath"
+	xmaps "github.com/certimate-go/certimate/pkg/utils/maps"
+)
+
+/**
+ * Variables:
+ *   - "http.statusCode": number
+ *   - all the variables extracted from the json response by the configured extractors
+ */
+type httpRequestNodeExecutor struct {
+	nodeExecutor
+
+	accessRepo accessRepository
+}
+
+var httpRequestAllowedMethods = map[string]bool{
+	http.MethodGet:     true,
+	http.MethodHead:    true,
+	http.MethodPost:    true,
+	http.MethodPut:     true,
+	http.MethodPatch:   true,
+	http.MethodDelete:  true,
+	http.MethodOptions: true,
+}
+
+const (
+	httpRequestMaxErrorBodyLength = 512
+	httpRequestMaxResponseSize    = 10 * 1024 * 1024
+)
+
+func (ne *httpRequestNodeExecutor) Execute(execCtx *NodeExecutionContext) (*NodeExecutionResult, error) {
+	execRes := newNodeExecutionResult(execCtx.Node)
+
+	nodeCfg := execCtx.Node.Data.Config.AsHttpRequest()
+	ne.logger.Info("ready to send http request ...", slog.String("method", nodeCfg.Method))
+
+	// 读取授权
+	credentials := domain.AccessConfigForWebhook{}
+	if nodeCfg.ProviderAccessId != "" {
+		if access, err := ne.accessRepo.GetById(execCtx.Context(), nodeCfg.ProviderAccessId); err != nil {
+			return execRes, fmt.Errorf("failed to get access #%s record: %w", nodeCfg.ProviderAccessId, err)
+		} else if err := xmaps.Populate(access.Config, &credentials); err != nil {
+			return execRes, fmt.Errorf("failed to populate access config: %w", err)
+		}
+	}
+
+	// 构造请求
+	req, err := ne.buildRequest(execCtx, &credentials)
+	if err != nil {
+		return execRes, err
+	}
+
+	client := resty.New().
+		SetTimeout(time.Duration(nodeCfg.Timeout) * time.Second).
+		SetResponseBodyLimit(httpRequestMaxResponseSize)
+	if credentials.AllowInsecureConnections {
+		client.SetTLSClientConfig(&tls.Config{InsecureSkipVerify: true})
+	}
+	client.SetTransport(tracing.WrapTransport(client.GetClient().Transport))
+
+	// 发送请求
+	ne.logger.Info(fmt.Sprintf("sending http request: %s %s", req.method, req.url.Redacted()))
+	httpReq := client.R().
+		SetContext(execCtx.Context()).
+		SetHeaderMultiValues(req.headers)
+	if req.body != "" {
+		httpReq.SetBody(req.body)
+	}
+	resp, err := httpReq.Execute(req.method, req.url.String())
+	if err != nil {
+		if errors.Is(err, resty.ErrResponseBodyTooLarge) {
+			return execRes, fmt.Errorf("http response body exceeds the limit of %d bytes", httpRequestMaxResponseSize)
+		}
+		return execRes, fmt.Errorf("failed to send http request: %w", err)
+	}
+
+	ne.logger.Info(fmt.Sprintf("http request completed with status code %d", resp.StatusCode()))
+	execRes.AddVariableWithScope(execCtx.Node.Id, stateVarKeyHttpStatusCode, int32(resp.StatusCode()), stateValTypeNumber)
+
+	// 校验响应状态码
+	if !matchHttpStatusCode(nodeCfg.ExpectedStatus, resp.StatusCode()) {
+		body := resp.String()
+		if len(body) > httpRequestMaxErrorBodyLength {
+			body = body[:httpRequestMaxErrorBodyLength] + "..."
+		}
+		return execRes, fmt.Errorf("unexpected http status code %d (expected: %s), response: %s", resp.StatusCode(), strings.Join(nodeCfg.ExpectedStatus, ";"), body)
+	}
+
+	// 从响应中提取变量
+	if len(nodeCfg.Extractors) > 0 {
+		var data any
+		if err := json.Unmarshal(resp.Body(), &data); err != nil {
+			return execRes, fmt.Errorf("failed to parse http response as json: %w", err)
+		}
+
+		for _, extractor := range nodeCfg.Extractors {
+			if extractor == nil || extractor.Name == "" {
+				continue
+			}
+
+			value, err := xjsonpath.Query(data, extractor.Path)
+			if err != nil {
+				if errors.Is(err, xjsonpath.ErrNotFound) {
+					ne.logger.Warn(fmt.Sprintf("no value matched '%s' in the http response, variable '%s' will not be set", extractor.Path, extractor.Name))
+					continue
+				}
+				return execRes, fmt.Errorf("failed to extract variable '%s': %w", extractor.Name, err)
+			}
+
+			stateValue, stateValueType, err := convertHttpResponseValue(value, extractor.ValueType)
+			if err != nil {
+				return execRes, fmt.Errorf("failed to extract variable '%s': %w", extractor.Name, err)
+			}
+
+			execRes.AddVariableWithScope(execCtx.Node.Id, extractor.Name, stateValue, stateValueType)
+		}
+	}
+
+	ne.logger.Info("http request node completed")
+	return execRes, nil
+}
+
+func (ne *httpRequestNodeExecutor) DryRun(execCtx *NodeExecutionContext) (*NodeExecutionResult, *domain.WorkflowPlanStep, error) {
+	execRes := newNodeExecutionResult(execCtx.Node)
+
+	nodeCfg := execCtx.Node.Data.Config.AsHttpRequest()
+
+	credentials := domain.AccessConfigForWebhook{}
+	if nodeCfg.ProviderAccessId != "" {
+		if access, err := ne.accessRepo.GetById(execCtx.Context(), nodeCfg.ProviderAccessId); err != nil {
+			return execRes, &domain.WorkflowPlanStep{Action: domain.WorkflowPlanActionTypeInvalid, Error: fmt.Sprintf("failed to get access #%s record: %s", nodeCfg.ProviderAccessId, err.Error())}, nil
+		} else if err := xmaps.Populate(access.Config, &credentials); err != nil {
+			return execRes, &domain.WorkflowPlanStep{Action: domain.WorkflowPlanActionTypeInvalid, Error: fmt.Sprintf("failed to populate access config: %s", err.Error())}, nil
+		}
+	}
+
+	// 试运行时前序节点未真正执行，其变量可能缺失，因此只检查模板语法而不渲染
+	method := strings.ToUpper(nodeCfg.Method)
+	if !httpRequestAllowedMethods[method] {
+		return execRes, &domain.WorkflowPlanStep{Action: domain.WorkflowPlanActionTypeInvalid, Error: fmt.Sprintf("unsupported http request method '%s'", nodeCfg.Method)}, nil
+	}
+	for _, tmpl := range [][2]string{{"url", nodeCfg.Url}, {"headers", nodeCfg.Headers}, {"body", nodeCfg.Body}} {
+		if err := parseTemplate(tmpl[0], tmpl[1]); err != nil {
+			return execRes, &domain.WorkflowPlanStep{Action: domain.WorkflowPlanActionTypeInvalid, Error: fmt.Sprintf("invalid %s template: %s", tmpl[0], err.Error())}, nil
+		}
+	}
+
+	urlString := joinHttpRequestUrl(credentials.Url, nodeCfg.Url)
+	if u, err := url.Parse(urlString); err == nil {
+		urlString = u.Redacted()
+	}
+	return execRes, &domain.WorkflowPlanStep{Action: domain.WorkflowPlanActionTypeExecute, Reason: fmt.Sprintf("%s %s", method, urlString)}, nil
+}
+
+type httpRequestSpec struct {
+	method  string
+	url     *url.URL
+	headers http.Header
+	body    string
+}
+
+func (ne *httpRequestNodeExecutor) buildRequest(execCtx *NodeExecutionContext, credentials *domain.AccessConfigForWebhook) (*httpRequestSpec, error) {
+	nodeCfg := execCtx.Node.Data.Config.AsHttpRequest()
+	render := func(name string, text string) (string, error) {
+		return renderTemplate(execCtx, name, text, nodeCfg.StrictTemplate)
+	}
+
+	spec := &httpRequestSpec{headers: make(http.Header)}
+
+	spec.method = strings.ToUpper(nodeCfg.Method)
+	if !httpRequestAllowedMethods[spec.method] {
+		return nil, fmt.Errorf("unsupported http request method '%s'", nodeCfg.Method)
+	}
+
+	urlString, err := render("url", nodeCfg.Url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render request url: %w", err)
+	}
+	urlString = joinHttpRequestUrl(credentials.Url, urlString)
+	if spec.url, err = url.Parse(urlString); err != nil {
+		// 解析错误中包含完整的网址，其中可能含有凭据，仅保留错误原因
+		var uerr *url.Error
+		if errors.As(err, &uerr) {
+			err = uerr.Err
+		}
+		return nil, fmt.Errorf("failed to parse request url: %w", err)
+	} else if spec.url.Scheme != "http" && spec.url.Scheme != "https" {
+		return nil, fmt.Errorf("unsupported request url scheme '%s'", spec.url.Scheme)
+	}
+
+	// 授权中的请求标头在前，节点中的请求标头可覆盖之
+	if credentials.HeadersString != "" {
+		h, err := xhttp.ParseHeaders(credentials.HeadersString)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse access headers: %w", err)
+		}
+		for key := range h {
+			spec.headers.Set(key, h.Get(key))
+		}
+	}
+	if nodeCfg.Headers != "" {
+		headersString, err := render("headers", nodeCfg.Headers)
+		if err != nil {
+			return nil, fmt.Errorf("failed to render request headers: %w", err)
+		}
+
+		h, err := xhttp.ParseHeaders(headersString)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse request headers: %w", err)
+		}
+		for key := range h {
+			spec.headers.Set(key, h.Get(key))
+		}
+	}
+
+	if nodeCfg.Body != "" {
+		if spec.body, err = render("body", nodeCfg.Body); err != nil {
+			return nil, fmt.Errorf("failed to render request body: %w", err)
+		}
+		if spec.headers.Get("Content-Type") == "" {
+			spec.headers.Set("Content-Type", "application/json")
+		}
+	}
+
+	return spec, nil
+}
+
+// 拼接请求地址，节点中的地址为空或以 "/" 开头时以授权中的地址为基础。
+func joinHttpRequestUrl(baseUrl string, requestUrl string) string {
+	if baseUrl == "" {
+		return requestUrl
+	}
+
+	if requestUrl == "" {
+		return baseUrl
+	} else if strings.HasPrefix(requestUrl, "/") {
+		return strings.TrimRight(baseUrl, "/") + requestUrl
+	}
+
+	return requestUrl
+}
+
+// 检查响应状态码是否符合期望，期望值可以是具体的状态码（如 "204"），也可以是形如 "2xx" 的通配。
+func matchHttpStatusCode(expected []string, statusCode int) bool {
+	if len(expected) == 0 {
+		expected = []string{"2xx"}
+	}
+
+	code := strconv.Itoa(statusCode)
+	for _, pattern := range expected {
+		pattern = strings.ToLower(strings.TrimSpace(pattern))
+		if len(pattern) != len(code) {
+			continue
+		}
+
+		matched := true
+		for i := range pattern {
+			if pattern[i] != 'x' && pattern[i] != code[i] {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+
+	return false
+}
+
+// 检查期望的响应状态码的格式是否合法。
+func validateHttpStatusCodePattern(pattern string) error {
+	pattern = strings.ToLower(strings.TrimSpace(pattern))
+	if len(pattern) != 3 || pattern[0] < '1' || pattern[0] > '5' {
+		return fmt.Errorf("invalid expected status '%s'", pattern)
+	}
+	for i := 1; i < len(pattern); i++ {
+		if pattern[i] != 'x' && (pattern[i] < '0' || pattern[i] > '9') {
+			return fmt.Errorf("invalid expected status '%s'", pattern)
+		}
+	}
+	return nil
+}
+
+// 将从 JSON 响应中提取的值转换为变量值。
+// 未指定值类型时，字符串、布尔值及整数将保持原类型，其余值将被序列化为 JSON 字符串；
+// 匹配多个值时，各值将以半角分号拼接为一个字符串。
+func convertHttpResponseValue(value any, valueType string) (any, string, error) {
+	if list, ok := value.([]any); ok {
+		items := make([]string, 0, len(list))
+		for _, item := range list {
+			items = append(items, stringifyHttpResponseValue(item))
+		}
+		value = strings.Join(items, ";")
+	}
+
+	if valueType == "" {
+		v, t := inferStateValue(value)
+		return v, t, nil
+	}
+
+	v, err := parseStateValue(stringifyHttpResponseValue(value), valueType)
+	if err != nil {
+		return nil, "", err
+	}
+	return v, valueType, nil
+}
+
+func stringifyHttpResponseValue(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case bool:
+		return strconv.FormatBool(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		valueJson, _ := json.Marshal(v)
+		return string(valueJson)
+	}
+}
+
+func newHttpRequestNodeExecutor() NodeExecutor {
+	return &httpRequestNodeExecutor{
+		nodeExecutor: nodeExecutor{logger: slog.Default()},
+		accessRepo:   repository.NewAccessRepository(),
+	}
+}
@@ -0,0 +1,201 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+
+	"github.com/samber/lo"
+
+	"github.com/certimate-go/certimate/internal/domain"
+)
+
+type parallelNodeExecutor struct {
+	nodeExecutor
+}
+
+func (ne *parallelNodeExecutor) Execute(execCtx *NodeExecutionContext) (*NodeExecutionResult, error) {
+	var engine *workflowEngine
+	if we, ok := execCtx.engine.(*workflowEngine); !ok {
+		panic("unreachable")
+	} else {
+		engine = we
+	}
+
+	execRes := newNodeExecutionResult(execCtx.Node)
+
+	nodeCfg := execCtx.Node.Data.Config.AsParallel()
+	blocks := lo.Filter(execCtx.Node.Blocks, func(n *Node, _ int) bool { return n.Type == NodeTypeParallelBlock })
+	if len(blocks) == 0 {
+		ne.logger.Info("no branches to execute")
+		return execRes, nil
+	}
+
+	// 失败分支数超过阈值时，取消其余尚未完成的分支
+	var cancelThreshold int
+	switch nodeCfg.JoinPolicy {
+	case domain.WorkflowParallelJoinPolicyAll:
+		cancelThreshold = len(blocks)
+	case domain.WorkflowParallelJoinPolicyFailFast:
+		cancelThreshold = 0
+	case domain.WorkflowParallelJoinPolicyTolerate:
+		cancelThreshold = max(0, nodeCfg.MaxFailures)
+	default:
+		return execRes, fmt.Errorf("unsupported join policy: '%s'", nodeCfg.JoinPolicy)
+	}
+
+	concurrency := nodeCfg.Concurrency
+	if concurrency <= 0 || concurrency > len(blocks) {
+		concurrency = len(blocks)
+	}
+
+	ne.logger.Info(fmt.Sprintf("execute %d branch(es) in parallel (concurrency: %d, join policy: %s) ...", len(blocks), concurrency, nodeCfg.JoinPolicy))
+
+	type branchResult struct {
+		started   bool
+		variables VariableManager
+		inputs    InOutManager
+		err       error
+	}
+
+	ctx, cancel := context.WithCancel(execCtx.Context())
+	defer cancel()
+
+	baseVariables := execCtx.variables.All()
+	baseInputs := execCtx.inputs.All()
+
+	var failures int
+	var failuresMtx sync.Mutex
+	var canceledByJoin bool
+
+	results := make([]branchResult, len(blocks))
+	semaphore := make(chan struct{}, concurrency)
+	wg := sync.WaitGroup{}
+	for i, node := range blocks {
+		select {
+		case semaphore <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+
+		// 每个分支使用独立的变量及输入输出副本，待全部分支结束后再按分支顺序合并，
+		// 以免并发写入导致结果不确定。
+		branchCtx := execCtx.Clone().
+			SetVariablesManager(forkVariableManager(execCtx.variables)).
+			SetInputsManager(forkInOutManager(execCtx.inputs)).
+			SetContext(ctx)
+		results[i].started = true
+		results[i].variables = branchCtx.variables
+		results[i].inputs = branchCtx.inputs
+
+		wg.Add(1)
+		go func() {
+			defer func() {
+				if r := recover(); r != nil {
+					results[i].err = fmt.Errorf("workflow engine panic in parallel branch: %v", r)
+				}
+
+				if results[i].err != nil && !errors.Is(results[i].err, ErrTerminated) {
+					failuresMtx.Lock()
+					failures++
+					if failures > cancelThreshold && !canceledByJoin {
+						canceledByJoin = true
+						cancel()
+					}
+					failuresMtx.Unlock()
+				}
+
+				<-semaphore
+				wg.Done()
+			}()
+
+			results[i].err = engine.executeNode(branchCtx, node)
+		}()
+	}
+	wg.Wait()
+
+	if err := execCtx.Context().Err(); err != nil {
+		return execRes, err
+	}
+
+	// 按分支顺序合并变量及输入输出
+	for _, result := range results {
+		if !result.started {
+			continue
+		}
+
+		mergeVariableManager(execCtx.variables, baseVariables, result.variables)
+		mergeInOutManager(execCtx.inputs, baseInputs, result.inputs)
+	}
+
+	errs := make([]error, 0)
+	for i, result := range results {
+		if !result.started {
+			ne.logger.Info(fmt.Sprintf("branch '%s' was skipped, because the parallel execution was aborted", blocks[i].Data.Name))
+			continue
+		}
+
+		if result.err == nil {
+			ne.logger.Info(fmt.Sprintf("branch '%s' completed", blocks[i].Data.Name))
+		} else if errors.Is(result.err, ErrTerminated) {
+			ne.logger.Info(fmt.Sprintf("branch '%s' terminated", blocks[i].Data.Name))
+		} else if canceledByJoin && errors.Is(result.err, context.Canceled) {
+			ne.logger.Info(fmt.Sprintf("branch '%s' was canceled, because the parallel execution was aborted", blocks[i].Data.Name))
+		} else {
+			ne.logger.Warn(fmt.Sprintf("branch '%s' failed", blocks[i].Data.Name))
+			errs = append(errs, result.err)
+		}
+	}
+
+	if terminated := lo.ContainsBy(results, func(r branchResult) bool { return errors.Is(r.err, ErrTerminated) }); terminated {
+		return execRes, ErrTerminated
+	}
+
+	if len(errs) > 0 {
+		if nodeCfg.JoinPolicy == domain.WorkflowParallelJoinPolicyTolerate && len(errs) <= cancelThreshold {
+			ne.logger.Warn(fmt.Sprintf("%d branch(es) failed, but tolerated (max failures: %d)", len(errs), cancelThreshold))
+			return execRes, nil
+		}
+
+		return execRes, fmt.Errorf("%w: %w", ErrBlocksException, errors.Join(errs...))
+	}
+
+	return execRes, nil
+}
+
+func newParallelNodeExecutor() NodeExecutor {
+	return &parallelNodeExecutor{
+		nodeExecutor: nodeExecutor{logger: slog.Default()},
+	}
+}
+
+type parallelBlockNodeExecutor struct {
+	nodeExecutor
+}
+
+func (ne *parallelBlockNodeExecutor) Execute(execCtx *NodeExecutionContext) (*NodeExecutionResult, error) {
+	var engine *workflowEngine
+	if we, ok := execCtx.engine.(*workflowEngine); !ok {
+		panic("unreachable")
+	} else {
+		engine = we
+	}
+
+	execRes := newNodeExecutionResult(execCtx.Node)
+
+	if err := engine.executeBlocks(execCtx.Clone(), execCtx.Node.Blocks); err != nil {
+		return execRes, fmt.Errorf("%w: %w", ErrBlocksException, err)
+	}
+
+	return execRes, nil
+}
+
+func newParallelBlockNodeExecutor() NodeExecutor {
+	return &parallelBlockNodeExecutor{
+		nodeExecutor: nodeExecutor{logger: slog.Default()},
+	}
+}
@@ -0,0 +1,253 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/certimate-go/certimate/internal/domain"
+)
+
+type testParallelBranch struct {
+	sleep     time.Duration
+	err       bool
+	block     bool // 阻塞直至被取消
+	terminate bool
+}
+
+func TestParallelNodeExecutor(t *testing.T) {
+	tests := []struct {
+		name           string
+		config         domain.WorkflowNodeConfig
+		branches       []testParallelBranch
+		wantErr        bool
+		wantErrIs      error
+		wantCompleted  []string
+		wantMaxRunning int
+	}{
+		{
+			name:          "all succeeded",
+			config:        domain.WorkflowNodeConfig{"joinPolicy": domain.WorkflowParallelJoinPolicyAll},
+			branches:      []testParallelBranch{{}, {}, {}},
+			wantCompleted: []string{"n1", "n2", "n3"},
+		},
+		{
+			name:          "default join policy",
+			config:        domain.WorkflowNodeConfig{},
+			branches:      []testParallelBranch{{err: true}, {sleep: 50 * time.Millisecond}},
+			wantErr:       true,
+			wantErrIs:     ErrBlocksException,
+			wantCompleted: []string{"n2"},
+		},
+		{
+			name:          "all waits for other branches",
+			config:        domain.WorkflowNodeConfig{"joinPolicy": domain.WorkflowParallelJoinPolicyAll},
+			branches:      []testParallelBranch{{err: true}, {sleep: 50 * time.Millisecond}, {err: true}},
+			wantErr:       true,
+			wantErrIs:     ErrBlocksException,
+			wantCompleted: []string{"n2"},
+		},
+		{
+			name:          "fail fast cancels running branches",
+			config:        domain.WorkflowNodeConfig{"joinPolicy": domain.WorkflowParallelJoinPolicyFailFast},
+			branches:      []testParallelBranch{{sleep: 10 * time.Millisecond, err: true}, {block: true}, {}},
+			wantErr:       true,
+			wantErrIs:     ErrBlocksException,
+			wantCompleted: []string{"n3"},
+		},
+		{
+			name:          "fail fast skips pending branches",
+			config:        domain.WorkflowNodeConfig{"joinPolicy": domain.WorkflowParallelJoinPolicyFailFast, "concurrency": 1},
+			branches:      []testParallelBranch{{err: true}, {}, {}},
+			wantErr:       true,
+			wantErrIs:     ErrBlocksException,
+			wantCompleted: []string{},
+		},
+		{
+			name:          "tolerate within max failures",
+			config:        domain.WorkflowNodeConfig{"joinPolicy": domain.WorkflowParallelJoinPolicyTolerate, "maxFailures": 1},
+			branches:      []testParallelBranch{{err: true}, {}, {}},
+			wantCompleted: []string{"n2", "n3"},
+		},
+		{
+			name:          "tolerate exceeded",
+			config:        domain.WorkflowNodeConfig{"joinPolicy": domain.WorkflowParallelJoinPolicyTolerate, "maxFailures": 1},
+			branches:      []testParallelBranch{{err: true}, {sleep: 10 * time.Millisecond, err: true}, {block: true}},
+			wantErr:       true,
+			wantErrIs:     ErrBlocksException,
+			wantCompleted: []string{},
+		},
+		{
+			name:           "concurrency limit",
+			config:         domain.WorkflowNodeConfig{"concurrency": 2},
+			branches:       []testParallelBranch{{sleep: 20 * time.Millisecond}, {sleep: 20 * time.Millisecond}, {sleep: 20 * time.Millisecond}, {sleep: 20 * time.Millisecond}},
+			wantCompleted:  []string{"n1", "n2", "n3", "n4"},
+			wantMaxRunning: 2,
+		},
+		{
+			name:           "unlimited concurrency",
+			config:         domain.WorkflowNodeConfig{"concurrency": 0},
+			branches:       []testParallelBranch{{sleep: 50 * time.Millisecond}, {sleep: 50 * time.Millisecond}, {sleep: 50 * time.Millisecond}},
+			wantCompleted:  []string{"n1", "n2", "n3"},
+			wantMaxRunning: 3,
+		},
+		{
+			name:          "terminated",
+			config:        domain.WorkflowNodeConfig{"joinPolicy": domain.WorkflowParallelJoinPolicyAll},
+			branches:      []testParallelBranch{{terminate: true}, {}},
+			wantErr:       true,
+			wantErrIs:     ErrTerminated,
+			wantCompleted: []string{"n2"},
+		},
+		{
+			name:          "unsupported join policy",
+			config:        domain.WorkflowNodeConfig{"joinPolicy": "any"},
+			branches:      []testParallelBranch{{}},
+			wantErr:       true,
+			wantCompleted: []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mtx sync.Mutex
+			var running, maxRunning int
+			completed := make([]string, 0)
+
+			engine := newTestWorkflowEngine(func(execCtx *NodeExecutionContext, execRes *NodeExecutionResult) error {
+				var index int
+				fmt.Sscanf(execCtx.Node.Id, "n%d", &index)
+				branch := tt.branches[index-1]
+
+				mtx.Lock()
+				running++
+				maxRunning = max(maxRunning, running)
+				mtx.Unlock()
+				defer func() {
+					mtx.Lock()
+					running--
+					mtx.Unlock()
+				}()
+
+				if branch.block {
+					select {
+					case <-execCtx.Context().Done():
+						return execCtx.Context().Err()
+					case <-time.After(5 * time.Second):
+					}
+				}
+				time.Sleep(branch.sleep)
+				if branch.err {
+					return errors.New("test error")
+				}
+				if branch.terminate {
+					execRes.Terminated = true
+					return nil
+				}
+
+				mtx.Lock()
+				completed = append(completed, execCtx.Node.Id)
+				mtx.Unlock()
+				return nil
+			})
+
+			parallelNode := &Node{Id: "p1", Type: NodeTypeParallel, Data: domain.WorkflowNodeData{Name: "p1", Config: tt.config}}
+			for i := range tt.branches {
+				parallelNode.Blocks = append(parallelNode.Blocks, &Node{
+					Id:     fmt.Sprintf("b%d", i+1),
+					Type:   NodeTypeParallelBlock,
+					Data:   domain.WorkflowNodeData{Name: fmt.Sprintf("b%d", i+1)},
+					Blocks: []*Node{newTestNode(fmt.Sprintf("n%d", i+1))},
+				})
+			}
+
+			_, err := engine.executeTestBlocks(context.Background(), parallelNode)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Execute() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErrIs != nil && !errors.Is(err, tt.wantErrIs) {
+				t.Errorf("Execute() error = %v, want %v", err, tt.wantErrIs)
+			}
+
+			slices.Sort(completed)
+			if !slices.Equal(completed, tt.wantCompleted) {
+				t.Errorf("Execute() completed = %v, want %v", completed, tt.wantCompleted)
+			}
+			if tt.wantMaxRunning > 0 && maxRunning != tt.wantMaxRunning {
+				t.Errorf("Execute() max running = %d, want %d", maxRunning, tt.wantMaxRunning)
+			}
+		})
+	}
+}
+
+func TestParallelNodeExecutorMerge(t *testing.T) {
+	engine := newTestWorkflowEngine(func(execCtx *NodeExecutionContext, execRes *NodeExecutionResult) error {
+		switch execCtx.Node.Id {
+		case "n0":
+			execRes.AddVariable("shared", "n0", stateValTypeString)
+			execRes.AddVariable("modified", "n0", stateValTypeString)
+			execRes.AddVariable("untouched", "n0", stateValTypeString)
+
+		case "n1":
+			// 晚于 n2 结束，但应先于 n2 合并
+			time.Sleep(50 * time.Millisecond)
+			execRes.AddVariable("shared", "n1", stateValTypeString)
+			execRes.AddVariable("modified", "n1", stateValTypeString)
+			execRes.AddVariableWithScope("n1", "scoped", "n1", stateValTypeString)
+			execRes.AddOutput(stateIOTypeRef, "output", "n1", stateValTypeString)
+
+		case "n2":
+			execRes.AddVariable("shared", "n2", stateValTypeString)
+			execRes.AddVariableWithScope("n2", "scoped", "n2", stateValTypeString)
+			execRes.AddOutput(stateIOTypeRef, "output", "n2", stateValTypeString)
+		}
+		return nil
+	})
+
+	parallelNode := &Node{
+		Id:   "p1",
+		Type: NodeTypeParallel,
+		Data: domain.WorkflowNodeData{Name: "p1"},
+		Blocks: []*Node{
+			{Id: "b1", Type: NodeTypeParallelBlock, Blocks: []*Node{newTestNode("n1")}},
+			{Id: "b2", Type: NodeTypeParallelBlock, Blocks: []*Node{newTestNode("n2")}},
+		},
+	}
+
+	wfCtx, err := engine.executeTestBlocks(context.Background(), newTestNode("n0"), parallelNode)
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+
+	tests := []struct {
+		scope string
+		key   string
+		want  string
+	}{
+		{"", "shared", "n2"},
+		{"", "modified", "n1"},
+		{"", "untouched", "n0"},
+		{"n1", "scoped", "n1"},
+		{"n2", "scoped", "n2"},
+	}
+	for _, tt := range tests {
+		state, ok := wfCtx.variables.GetScoped(tt.scope, tt.key)
+		if !ok {
+			t.Errorf("variable '%s' in scope '%s' not found", tt.key, tt.scope)
+		} else if state.ValueString() != tt.want {
+			t.Errorf("variable '%s' in scope '%s' = %v, want %v", tt.key, tt.scope, state.Value, tt.want)
+		}
+	}
+
+	for _, nodeId := range []string{"n1", "n2"} {
+		state, ok := wfCtx.inputs.Get(nodeId, "output")
+		if !ok {
+			t.Errorf("output of node '%s' not found", nodeId)
+		} else if state.ValueString() != nodeId {
+			t.Errorf("output of node '%s' = %v, want %v", nodeId, state.Value, nodeId)
+		}
+	}
+}
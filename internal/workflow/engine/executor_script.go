@@ -0,0 +1,449 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime/metrics"
+	"strings"
+	"time"
+
+	"github.com/dop251/goja"
+
+	"github.com/certimate-go/certimate/internal/domain"
+	"github.com/certimate-go/certimate/internal/tracing"
+)
+
+/**
+ * Outputs:
+ *   - json: any outputs set by the script through `$outputs.set(name, value)`
+ *
+ * Variables:
+ *   - any variables set by the script through `$vars.set(key, value)`, both global and under the scope of this node
+ */
+type scriptNodeExecutor struct {
+	nodeExecutor
+}
+
+const (
+	scriptMaxCallStackSize    = 1024
+	scriptMaxHttpResponseSize = 10 * 1024 * 1024
+	scriptWatchInterval       = 50 * time.Millisecond
+)
+
+func (ne *scriptNodeExecutor) Execute(execCtx *NodeExecutionContext) (*NodeExecutionResult, error) {
+	execRes := newNodeExecutionResult(execCtx.Node)
+
+	nodeCfg := execCtx.Node.Data.Config.AsScript()
+	ne.logger.Info("ready to run script ...", slog.Int("timeLimit", nodeCfg.TimeLimit), slog.Int("heapGuard", nodeCfg.HeapGuard), slog.Bool("allowNetwork", nodeCfg.AllowNetwork), slog.Bool("allowFileSystem", nodeCfg.AllowFileSystem))
+
+	program, err := compileScript(execCtx.Node.Id, nodeCfg.Source)
+	if err != nil {
+		return execRes, err
+	}
+
+	ctx, cancel := context.WithTimeout(execCtx.Context(), time.Duration(nodeCfg.TimeLimit)*time.Second)
+	defer cancel()
+
+	vm := goja.New()
+	vm.SetFieldNameMapper(goja.TagFieldNameMapper("json", true))
+	vm.SetMaxCallStackSize(scriptMaxCallStackSize)
+	if err := ne.setupRuntime(ctx, vm, execCtx, execRes); err != nil {
+		return execRes, err
+	}
+
+	// 监控脚本的执行时间及进程堆内存的增量，超出限制时中断脚本
+	done := make(chan struct{})
+	defer close(done)
+	go watchScript(ctx, vm, done, uint64(nodeCfg.HeapGuard)*1024*1024)
+
+	if _, err := vm.RunProgram(program); err != nil {
+		var interruptedErr *goja.InterruptedError
+		if errors.As(err, &interruptedErr) {
+			return execRes, fmt.Errorf("script interrupted: %v", interruptedErr.Value())
+		}
+
+		var stackOverflowErr *goja.StackOverflowError
+		if errors.As(err, &stackOverflowErr) {
+			return execRes, errors.New("script interrupted: maximum call stack size exceeded")
+		}
+
+		return execRes, fmt.Errorf("script error: %w", err)
+	}
+
+	ne.logger.Info("script completed")
+	return execRes, nil
+}
+
+func (ne *scriptNodeExecutor) DryRun(execCtx *NodeExecutionContext) (*NodeExecutionResult, *domain.WorkflowPlanStep, error) {
+	execRes := newNodeExecutionResult(execCtx.Node)
+
+	nodeCfg := execCtx.Node.Data.Config.AsScript()
+	if _, err := compileScript(execCtx.Node.Id, nodeCfg.Source); err != nil {
+		return execRes, &domain.WorkflowPlanStep{Action: domain.WorkflowPlanActionTypeInvalid, Error: err.Error()}, nil
+	}
+
+	return execRes, &domain.WorkflowPlanStep{Action: domain.WorkflowPlanActionTypeExecute, Reason: "the script will be run, its variables and outputs are unknown until then"}, nil
+}
+
+// 向脚本运行时注入全局对象：
+//   - console：输出日志；
+//   - $vars：读取或设置变量；
+//   - $inputs：读取前序节点的输出；
+//   - $outputs：设置本节点的输出；
+//   - $http：发起 HTTP 请求，仅当允许访问网络时可用；
+//   - $fs：读写文件，仅当允许访问文件系统时可用。
+func (ne *scriptNodeExecutor) setupRuntime(ctx context.Context, vm *goja.Runtime, execCtx *NodeExecutionContext, execRes *NodeExecutionResult) error {
+	nodeCfg := execCtx.Node.Data.Config.AsScript()
+
+	console := vm.NewObject()
+	consoleLog := func(level slog.Level) func(call goja.FunctionCall) goja.Value {
+		return func(call goja.FunctionCall) goja.Value {
+			args := make([]string, 0, len(call.Arguments))
+			for _, arg := range call.Arguments {
+				args = append(args, arg.String())
+			}
+			ne.logger.Log(ctx, level, strings.Join(args, " "))
+			return goja.Undefined()
+		}
+	}
+	console.Set("log", consoleLog(slog.LevelInfo))
+	console.Set("debug", consoleLog(slog.LevelDebug))
+	console.Set("info", consoleLog(slog.LevelInfo))
+	console.Set("warn", consoleLog(slog.LevelWarn))
+	console.Set("error", consoleLog(slog.LevelError))
+	if err := vm.Set("console", console); err != nil {
+		return err
+	}
+
+	vars := vm.NewObject()
+	vars.Set("get", func(key string) goja.Value {
+		state, ok := execCtx.variables.Get(key)
+		if !ok {
+			return goja.Null()
+		}
+		return scriptToValue(vm, state.Value)
+	})
+	vars.Set("getScoped", func(scope string, key string) goja.Value {
+		state, ok := execCtx.variables.GetScoped(scope, key)
+		if !ok {
+			return goja.Null()
+		}
+		return scriptToValue(vm, state.Value)
+	})
+	vars.Set("set", func(key string, value goja.Value) error {
+		if key == "" {
+			return errors.New("the variable key is required")
+		}
+
+		v, t := inferStateValue(value.Export())
+		execRes.AddVariable(key, v, t)
+		execRes.AddVariableWithScope(execCtx.Node.Id, key, v, t)
+		return nil
+	})
+	if err := vm.Set("$vars", vars); err != nil {
+		return err
+	}
+
+	inputs := vm.NewObject()
+	inputs.Set("get", func(nodeId string, name string) (goja.Value, error) {
+		state, ok := execCtx.inputs.Get(nodeId, name)
+		if !ok {
+			return goja.Null(), nil
+		}
+
+		if state.Type == stateIOTypeJSON {
+			var value any
+			if err := json.Unmarshal([]byte(state.ValueString()), &value); err != nil {
+				return nil, fmt.Errorf("could not parse input '%s' of node #%s as json: %w", name, nodeId, err)
+			}
+			return vm.ToValue(value), nil
+		}
+		return scriptToValue(vm, state.Value), nil
+	})
+	if err := vm.Set("$inputs", inputs); err != nil {
+		return err
+	}
+
+	outputs := vm.NewObject()
+	outputs.Set("set", func(name string, value goja.Value) error {
+		if name == "" {
+			return errors.New("the output name is required")
+		}
+
+		valueJson, err := json.Marshal(value.Export())
+		if err != nil {
+			return fmt.Errorf("could not serialize output '%s' as json: %w", name, err)
+		}
+		execRes.AddOutputWithPersistent(stateIOTypeJSON, name, string(valueJson), stateValTypeString)
+		return nil
+	})
+	if err := vm.Set("$outputs", outputs); err != nil {
+		return err
+	}
+
+	if nodeCfg.AllowNetwork {
+		if err := vm.Set("$http", ne.newHttpObject(ctx, vm)); err != nil {
+			return err
+		}
+	}
+
+	if nodeCfg.AllowFileSystem {
+		if err := vm.Set("$fs", ne.newFsObject(vm, nodeCfg.AllowedPaths)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+type scriptHttpRequest struct {
+	Method  string            `json:"method"`
+	Url     string            `json:"url"`
+	Headers map[string]string `json:"headers"`
+	Body    string            `json:"body"`
+}
+
+type scriptHttpResponse struct {
+	Status  int               `json:"status"`
+	Headers map[string]string `json:"headers"`
+	Body    string            `json:"body"`
+}
+
+func (ne *scriptNodeExecutor) newHttpObject(ctx context.Context, vm *goja.Runtime) *goja.Object {
+	client := &http.Client{Transport: tracing.WrapTransport(nil)}
+
+	obj := vm.NewObject()
+	obj.Set("request", func(options goja.Value) (*scriptHttpResponse, error) {
+		var req scriptHttpRequest
+		if err := vm.ExportTo(options, &req); err != nil {
+			return nil, fmt.Errorf("invalid request options: %w", err)
+		}
+		if req.Method == "" {
+			req.Method = http.MethodGet
+		}
+
+		httpReq, err := http.NewRequestWithContext(ctx, strings.ToUpper(req.Method), req.Url, strings.NewReader(req.Body))
+		if err != nil {
+			return nil, err
+		}
+		for k, v := range req.Headers {
+			httpReq.Header.Set(k, v)
+		}
+
+		ne.logger.Info(fmt.Sprintf("script sends http request: %s %s", httpReq.Method, httpReq.URL.Redacted()))
+		httpResp, err := client.Do(httpReq)
+		if err != nil {
+			return nil, err
+		}
+		defer httpResp.Body.Close()
+
+		body, err := io.ReadAll(io.LimitReader(httpResp.Body, scriptMaxHttpResponseSize))
+		if err != nil {
+			return nil, err
+		}
+
+		resp := &scriptHttpResponse{
+			Status:  httpResp.StatusCode,
+			Headers: make(map[string]string),
+			Body:    string(body),
+		}
+		for k := range httpResp.Header {
+			resp.Headers[strings.ToLower(k)] = httpResp.Header.Get(k)
+		}
+		return resp, nil
+	})
+
+	return obj
+}
+
+func (ne *scriptNodeExecutor) newFsObject(vm *goja.Runtime, allowedPaths []string) *goja.Object {
+	resolvePath := func(path string) (string, error) {
+		// 未指定允许读写的目录时，不允许访问任何路径
+		if len(allowedPaths) == 0 {
+			return "", errors.New("no paths are allowed to access")
+		}
+
+		absPath, err := filepath.Abs(path)
+		if err != nil {
+			return "", err
+		}
+
+		// 解析符号链接后再比较，以免经由允许的目录中的符号链接访问其外的路径
+		realPath, err := evalSymlinksPartially(absPath)
+		if err != nil {
+			return "", err
+		}
+
+		for _, allowedPath := range allowedPaths {
+			if strings.TrimSpace(allowedPath) == "" {
+				continue
+			}
+
+			allowedAbsPath, err := filepath.Abs(allowedPath)
+			if err != nil {
+				continue
+			}
+
+			allowedRealPath, err := evalSymlinksPartially(allowedAbsPath)
+			if err != nil {
+				continue
+			}
+
+			if rel, err := filepath.Rel(allowedRealPath, realPath); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+				return realPath, nil
+			}
+		}
+
+		return "", fmt.Errorf("access to path '%s' is not allowed", path)
+	}
+
+	obj := vm.NewObject()
+	obj.Set("exists", func(path string) (bool, error) {
+		absPath, err := resolvePath(path)
+		if err != nil {
+			return false, err
+		}
+
+		if _, err := os.Stat(absPath); err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				return false, nil
+			}
+			return false, err
+		}
+		return true, nil
+	})
+	obj.Set("readFile", func(path string) (string, error) {
+		absPath, err := resolvePath(path)
+		if err != nil {
+			return "", err
+		}
+
+		data, err := os.ReadFile(absPath)
+		if err != nil {
+			return "", err
+		}
+		return string(data), nil
+	})
+	obj.Set("writeFile", func(path string, content string) error {
+		absPath, err := resolvePath(path)
+		if err != nil {
+			return err
+		}
+
+		ne.logger.Info(fmt.Sprintf("script writes file: %s", absPath))
+		if err := os.MkdirAll(filepath.Dir(absPath), 0o755); err != nil {
+			return err
+		}
+		return os.WriteFile(absPath, []byte(content), 0o644)
+	})
+
+	return obj
+}
+
+// 解析路径中的符号链接。路径尚不存在时（如将要写入的文件），解析其已存在的最深的上级目录，再拼接其余部分。
+func evalSymlinksPartially(absPath string) (string, error) {
+	rest := ""
+	for current := absPath; ; {
+		realPath, err := filepath.EvalSymlinks(current)
+		if err == nil {
+			return filepath.Join(realPath, rest), nil
+		} else if !errors.Is(err, os.ErrNotExist) {
+			return "", err
+		}
+
+		parent := filepath.Dir(current)
+		if parent == current {
+			return absPath, nil
+		}
+
+		rest = filepath.Join(filepath.Base(current), rest)
+		current = parent
+	}
+}
+
+// 编译脚本，仅检查语法而不执行。
+func compileScript(name string, source string) (*goja.Program, error) {
+	if strings.TrimSpace(source) == "" {
+		return nil, errors.New("the script source is empty")
+	}
+
+	program, err := goja.Compile(name, source, true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile script: %w", err)
+	}
+
+	return program, nil
+}
+
+// 将变量值转换为脚本中的值，日期时间将被转换为 JavaScript 的 Date 对象。
+func scriptToValue(vm *goja.Runtime, value any) goja.Value {
+	if t, ok := value.(time.Time); ok {
+		if t.IsZero() {
+			return goja.Null()
+		}
+
+		date, err := vm.New(vm.Get("Date"), vm.ToValue(t.UnixMilli()))
+		if err != nil {
+			return vm.ToValue(t.Format(time.RFC3339))
+		}
+		return date
+	}
+
+	return vm.ToValue(value)
+}
+
+// 监控脚本的执行，当超出时间限制、或进程堆内存的增量超出保护阈值时中断脚本。
+//
+// 注意，堆内存保护是尽力而为的、针对整个进程的保护，而非单个脚本的内存限制：
+// Go 运行时无法统计单个 goroutine 的内存占用，因此这里以脚本开始执行后整个进程堆内存的增量作为近似值。
+// 并行执行的其他节点、其他运行乃至 HTTP 服务的内存分配都会计入其中，可能导致脚本被误中断；
+// 而垃圾回收也可能掩盖脚本自身的内存分配。它仅用于防止失控的脚本耗尽进程内存。
+func watchScript(ctx context.Context, vm *goja.Runtime, done <-chan struct{}, heapGuard uint64) {
+	samples := []metrics.Sample{{Name: "/memory/classes/heap/objects:bytes"}}
+	readHeapBytes := func() uint64 {
+		metrics.Read(samples)
+		if samples[0].Value.Kind() != metrics.KindUint64 {
+			return 0
+		}
+		return samples[0].Value.Uint64()
+	}
+
+	baseline := readHeapBytes()
+
+	ticker := time.NewTicker(scriptWatchInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+
+		case <-ctx.Done():
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				vm.Interrupt("time limit exceeded")
+			} else {
+				vm.Interrupt(ctx.Err().Error())
+			}
+			return
+
+		case <-ticker.C:
+			if current := readHeapBytes(); current > baseline && current-baseline > heapGuard {
+				vm.Interrupt("process heap growth guard exceeded")
+				return
+			}
+		}
+	}
+}
+
+func newScriptNodeExecutor() NodeExecutor {
+	return &scriptNodeExecutor{
+		nodeExecutor: nodeExecutor{logger: slog.Default()},
+	}
+}
@@ -0,0 +1,271 @@
+package engine
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/certimate-go/certimate/internal/domain"
+)
+
+func executeTestScript(t *testing.T, config domain.WorkflowNodeConfig) (*WorkflowContext, error) {
+	t.Helper()
+
+	engine := newTestWorkflowEngine(nil)
+	engine.executors[NodeTypeScript] = newScriptNodeExecutor
+
+	node := &Node{
+		Id:   "script1",
+		Type: NodeTypeScript,
+		Data: domain.WorkflowNodeData{Name: "script1", Config: config},
+	}
+	return engine.executeTestBlocks(context.Background(), node)
+}
+
+func TestScriptNodeExecutor(t *testing.T) {
+	tests := []struct {
+		name       string
+		config     domain.WorkflowNodeConfig
+		wantErr    bool
+		wantVars   map[string]any
+		wantOutput string
+	}{
+		{
+			name: "variables and outputs",
+			config: domain.WorkflowNodeConfig{"source": `
+				const runId = $vars.get("run.id");
+				$vars.set("greeting", "hello " + runId);
+				$vars.set("count", 1 + 2);
+				$vars.set("missing", $vars.get("any") === null);
+				$outputs.set("result", { runId: runId, items: [1, 2] });
+			`},
+			wantVars:   map[string]any{"greeting": "hello run1", "count": int32(3), "missing": true},
+			wantOutput: `{"items":[1,2],"runId":"run1"}`,
+		},
+		{
+			name:    "syntax error",
+			config:  domain.WorkflowNodeConfig{"source": `const a = ;`},
+			wantErr: true,
+		},
+		{
+			name:    "thrown error",
+			config:  domain.WorkflowNodeConfig{"source": `throw new Error("test error");`},
+			wantErr: true,
+		},
+		{
+			name:    "time limit exceeded",
+			config:  domain.WorkflowNodeConfig{"source": `while (true) {}`, "timeLimit": 1},
+			wantErr: true,
+		},
+		{
+			name:    "stack overflow",
+			config:  domain.WorkflowNodeConfig{"source": `function f() { return f(); } f();`},
+			wantErr: true,
+		},
+		{
+			name: "network and file system are not allowed by default",
+			config: domain.WorkflowNodeConfig{"source": `
+				$vars.set("http", typeof $http);
+				$vars.set("fs", typeof $fs);
+			`},
+			wantVars: map[string]any{"http": "undefined", "fs": "undefined"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wfCtx, err := executeTestScript(t, tt.config)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Execute() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			for key, want := range tt.wantVars {
+				if state, ok := wfCtx.variables.Get(key); !ok || state.Value != want {
+					t.Errorf("Execute() variable '%s' = %v, want %v", key, state, want)
+				}
+				if state, ok := wfCtx.variables.GetScoped("script1", key); !ok || state.Value != want {
+					t.Errorf("Execute() scoped variable '%s' = %v, want %v", key, state, want)
+				}
+			}
+			if tt.wantOutput != "" {
+				if output, ok := wfCtx.inputs.Get("script1", "result"); !ok || output.ValueString() != tt.wantOutput {
+					t.Errorf("Execute() output 'result' = %v, want %s", output, tt.wantOutput)
+				}
+			}
+		})
+	}
+}
+
+func TestScriptNodeExecutorFileSystem(t *testing.T) {
+	// 目录结构：
+	//   allowed/a.txt
+	//   allowed/link -> outside
+	//   allowed/linkfile -> outside/secret.txt
+	//   outside/secret.txt
+	//   alias -> allowed
+	root := t.TempDir()
+	allowedDir := filepath.Join(root, "allowed")
+	outsideDir := filepath.Join(root, "outside")
+	for _, dir := range []string{allowedDir, outsideDir} {
+		if err := os.Mkdir(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(allowedDir, "a.txt"), []byte("a"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(outsideDir, "secret.txt"), []byte("secret"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(outsideDir, filepath.Join(allowedDir, "link")); err != nil {
+		t.Skipf("symlinks are not supported: %v", err)
+	}
+	if err := os.Symlink(filepath.Join(outsideDir, "secret.txt"), filepath.Join(allowedDir, "linkfile")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(allowedDir, filepath.Join(root, "alias")); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name         string
+		allowedPaths string
+		source       string
+		wantErr      bool
+		wantResult   any
+	}{
+		{
+			name:         "read allowed file",
+			allowedPaths: allowedDir,
+			source:       `$vars.set("result", $fs.readFile(` + strconv.Quote(filepath.Join(allowedDir, "a.txt")) + `));`,
+			wantResult:   "a",
+		},
+		{
+			name:         "read allowed file through allowed alias",
+			allowedPaths: filepath.Join(root, "alias"),
+			source:       `$vars.set("result", $fs.readFile(` + strconv.Quote(filepath.Join(root, "alias", "a.txt")) + `));`,
+			wantResult:   "a",
+		},
+		{
+			name:         "write new file in allowed directory",
+			allowedPaths: allowedDir,
+			source: `
+				$fs.writeFile(` + strconv.Quote(filepath.Join(allowedDir, "sub", "b.txt")) + `, "b");
+				$vars.set("result", $fs.readFile(` + strconv.Quote(filepath.Join(allowedDir, "sub", "b.txt")) + `));
+			`,
+			wantResult: "b",
+		},
+		{
+			name:         "check existence",
+			allowedPaths: allowedDir,
+			source:       `$vars.set("result", $fs.exists(` + strconv.Quote(filepath.Join(allowedDir, "a.txt")) + `) && !$fs.exists(` + strconv.Quote(filepath.Join(allowedDir, "b.txt")) + `));`,
+			wantResult:   true,
+		},
+		{
+			name:         "multiple allowed paths",
+			allowedPaths: " ;" + outsideDir + ";" + allowedDir,
+			source:       `$vars.set("result", $fs.readFile(` + strconv.Quote(filepath.Join(allowedDir, "a.txt")) + `));`,
+			wantResult:   "a",
+		},
+		{
+			name:         "read file outside",
+			allowedPaths: allowedDir,
+			source:       `$fs.readFile(` + strconv.Quote(filepath.Join(outsideDir, "secret.txt")) + `);`,
+			wantErr:      true,
+		},
+		{
+			name:         "read file outside by relative path",
+			allowedPaths: allowedDir,
+			source:       `$fs.readFile(` + strconv.Quote(allowedDir+string(filepath.Separator)+filepath.Join("..", "outside", "secret.txt")) + `);`,
+			wantErr:      true,
+		},
+		{
+			name:         "read file outside through symlinked directory",
+			allowedPaths: allowedDir,
+			source:       `$fs.readFile(` + strconv.Quote(filepath.Join(allowedDir, "link", "secret.txt")) + `);`,
+			wantErr:      true,
+		},
+		{
+			name:         "read file outside through symlinked file",
+			allowedPaths: allowedDir,
+			source:       `$fs.readFile(` + strconv.Quote(filepath.Join(allowedDir, "linkfile")) + `);`,
+			wantErr:      true,
+		},
+		{
+			name:         "write new file outside through symlinked directory",
+			allowedPaths: allowedDir,
+			source:       `$fs.writeFile(` + strconv.Quote(filepath.Join(allowedDir, "link", "new", "c.txt")) + `, "c");`,
+			wantErr:      true,
+		},
+		{
+			name:         "no allowed paths",
+			allowedPaths: "",
+			source:       `$fs.readFile(` + strconv.Quote(filepath.Join(allowedDir, "a.txt")) + `);`,
+			wantErr:      true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wfCtx, err := executeTestScript(t, domain.WorkflowNodeConfig{
+				"source":          tt.source,
+				"allowFileSystem": true,
+				"allowedPaths":    tt.allowedPaths,
+			})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Execute() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			if state, ok := wfCtx.variables.Get("result"); !ok || state.Value != tt.wantResult {
+				t.Errorf("Execute() result = %v, want %v", state, tt.wantResult)
+			}
+		})
+	}
+
+	if _, err := os.Stat(filepath.Join(outsideDir, "new")); !os.IsNotExist(err) {
+		t.Errorf("the directory outside should not be created")
+	}
+}
+
+func TestEvalSymlinksPartially(t *testing.T) {
+	root, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	targetDir := filepath.Join(root, "target")
+	if err := os.Mkdir(targetDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(targetDir, filepath.Join(root, "link")); err != nil {
+		t.Skipf("symlinks are not supported: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		path string
+		want string
+	}{
+		{"existing directory", targetDir, targetDir},
+		{"symlink", filepath.Join(root, "link"), targetDir},
+		{"non-existent file in symlinked directory", filepath.Join(root, "link", "a", "b.txt"), filepath.Join(targetDir, "a", "b.txt")},
+		{"non-existent file", filepath.Join(root, "a", "b.txt"), filepath.Join(root, "a", "b.txt")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := evalSymlinksPartially(tt.path)
+			if err != nil {
+				t.Fatalf("evalSymlinksPartially() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("evalSymlinksPartially() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
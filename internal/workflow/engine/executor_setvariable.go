@@ -0,0 +1,189 @@
+package engine
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/samber/lo"
+
+	"github.com/certimate-go/certimate/internal/domain"
+	"github.com/certimate-go/certimate/internal/domain/expr"
+)
+
+// 由工作流引擎维护的内置变量，不允许被赋值。
+var reservedVariableKeys = []string{
+	stateVarKeyWorkflowId,
+	stateVarKeyWorkflowName,
+	stateVarKeyWorkflowDescription,
+	stateVarKeyRunId,
+	stateVarKeyRunTrigger,
+	stateVarKeyNodeId,
+	stateVarKeyNodeName,
+	stateVarKeyErrorNodeId,
+	stateVarKeyErrorNodeName,
+	stateVarKeyErrorMessage,
+}
+
+/**
+ * Variables:
+ *   - all the assigned variables, under the scope of this node (and also globally if specified)
+ */
+type setVariableNodeExecutor struct {
+	nodeExecutor
+}
+
+func (ne *setVariableNodeExecutor) Execute(execCtx *NodeExecutionContext) (*NodeExecutionResult, error) {
+	execRes := newNodeExecutionResult(execCtx.Node)
+
+	nodeCfg := execCtx.Node.Data.Config.AsSetVariable()
+	ne.logger.Info(fmt.Sprintf("ready to assign %d variable(s) ...", len(nodeCfg.Assignments)))
+
+	// 于变量的副本中求值，以便后续的赋值引用之；赋值结果仅经由执行结果返回，待节点执行成功后再由引擎合并
+	assignCtx := newNodeExecutionContext(execCtx.Clone().SetVariablesManager(forkVariableManager(execCtx.variables)), execCtx.Node)
+	for _, assignment := range nodeCfg.Assignments {
+		if assignment == nil {
+			continue
+		}
+
+		value, valueType, err := ne.evalAssignment(assignCtx, assignment, nodeCfg.StrictTemplate)
+		if err != nil {
+			ne.logger.Warn(fmt.Sprintf("could not assign variable '%s'", assignment.Name))
+			return execRes, fmt.Errorf("failed to assign variable '%s': %w", assignment.Name, err)
+		}
+
+		assignCtx.variables.SetScoped(execCtx.Node.Id, assignment.Name, value, valueType)
+		execRes.AddVariableWithScope(execCtx.Node.Id, assignment.Name, value, valueType)
+		if assignment.Global {
+			assignCtx.variables.Set(assignment.Name, value, valueType)
+			execRes.AddVariable(assignment.Name, value, valueType)
+		}
+
+		ne.logger.Info(fmt.Sprintf("variable '%s' assigned", assignment.Name), slog.Any("value", value), slog.String("valueType", valueType), slog.Bool("global", assignment.Global))
+	}
+
+	ne.logger.Info("assignment completed")
+	return execRes, nil
+}
+
+func (ne *setVariableNodeExecutor) evalAssignment(execCtx *NodeExecutionContext, assignment *domain.WorkflowNodeSetVariableAssignment, strictTemplate bool) (any, string, error) {
+	if assignment.Name == "" {
+		return nil, "", fmt.Errorf("the variable name is empty")
+	} else if slices.Contains(reservedVariableKeys, assignment.Name) {
+		return nil, "", fmt.Errorf("the variable '%s' is reserved", assignment.Name)
+	}
+
+	switch assignment.Source {
+	case "", domain.WorkflowSetVariableSourceConstant:
+		value, err := parseStateValue(assignment.Value, assignment.ValueType)
+		if err != nil {
+			return nil, "", err
+		}
+		return value, lo.CoalesceOrEmpty(assignment.ValueType, stateValTypeString), nil
+
+	case domain.WorkflowSetVariableSourceTemplate:
+		text, err := renderTemplate(execCtx, assignment.Name, assignment.Value, strictTemplate)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to render template: %w", err)
+		}
+
+		value, err := parseStateValue(text, assignment.ValueType)
+		if err != nil {
+			return nil, "", err
+		}
+		return value, lo.CoalesceOrEmpty(assignment.ValueType, stateValTypeString), nil
+
+	case domain.WorkflowSetVariableSourceExpression:
+		if assignment.Expression == nil {
+			return nil, "", fmt.Errorf("the expression is empty")
+		}
+
+		rs, err := assignment.Expression.Eval(newExprVariables(execCtx.variables))
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to eval expression: %w", err)
+		}
+
+		value, valueType, err := convertExprResultToStateValue(rs)
+		if err != nil {
+			return nil, "", err
+		}
+
+		// 指定了值类型时，按之转换求值结果
+		if assignment.ValueType != "" && assignment.ValueType != valueType {
+			value, err = parseStateValue(formatSnapshotValue(value, valueType), assignment.ValueType)
+			if err != nil {
+				return nil, "", err
+			}
+			valueType = assignment.ValueType
+		}
+
+		return value, valueType, nil
+
+	default:
+		return nil, "", fmt.Errorf("unsupported value source: '%s'", assignment.Source)
+	}
+}
+
+// 将表达式的求值结果转换为变量值。
+// 其中，时间间隔将被转换为秒数，列表将被转换为以半角分号分隔的字符串。
+func convertExprResultToStateValue(rs *expr.EvalResult) (any, string, error) {
+	if rs.IsNull() {
+		return nil, "", fmt.Errorf("the expression is evaluated as null")
+	}
+
+	switch rs.Type {
+	case expr.String:
+		v, err := rs.GetString()
+		if err != nil {
+			return nil, "", err
+		}
+		return v, stateValTypeString, nil
+
+	case expr.Number:
+		v, err := rs.GetFloat64()
+		if err != nil {
+			return nil, "", err
+		}
+		value, valueType := inferStateValue(v)
+		return value, valueType, nil
+
+	case expr.Boolean:
+		v, err := rs.GetBool()
+		if err != nil {
+			return nil, "", err
+		}
+		return v, stateValTypeBoolean, nil
+
+	case expr.DateTime:
+		v, err := rs.GetTime()
+		if err != nil {
+			return nil, "", err
+		}
+		return v, stateValTypeDateTime, nil
+
+	case expr.Duration:
+		v, err := rs.GetDuration()
+		if err != nil {
+			return nil, "", err
+		}
+		value, valueType := inferStateValue(v.Seconds())
+		return value, valueType, nil
+
+	case expr.List:
+		v, err := rs.GetList()
+		if err != nil {
+			return nil, "", err
+		}
+		return strings.Join(v, ";"), stateValTypeString, nil
+
+	default:
+		return nil, "", fmt.Errorf("unsupported expression result type: '%s'", rs.Type)
+	}
+}
+
+func newSetVariableNodeExecutor() NodeExecutor {
+	return &setVariableNodeExecutor{
+		nodeExecutor: nodeExecutor{logger: slog.Default()},
+	}
+}
@@ -0,0 +1,133 @@
+package engine
+
+import (
+	"context"
+	"testing"
+
+	"github.com/certimate-go/certimate/internal/domain"
+)
+
+func TestSetVariableNodeExecutor(t *testing.T) {
+	tests := []struct {
+		name        string
+		assignments []map[string]any
+		wantErr     bool
+		wantScoped  map[string]any
+		wantGlobal  map[string]any
+	}{
+		{
+			name: "constants",
+			assignments: []map[string]any{
+				{"name": "a", "value": "hello"},
+				{"name": "b", "value": "42", "valueType": stateValTypeNumber},
+				{"name": "c", "value": "true", "valueType": stateValTypeBoolean, "global": true},
+			},
+			wantScoped: map[string]any{"a": "hello", "b": int32(42), "c": true},
+			wantGlobal: map[string]any{"c": true},
+		},
+		{
+			name: "template referencing previous assignment",
+			assignments: []map[string]any{
+				{"name": "a", "value": "world"},
+				{"name": "b", "source": domain.WorkflowSetVariableSourceTemplate, "value": `hello {{ scoped "set1" "a" }}`},
+			},
+			wantScoped: map[string]any{"a": "world", "b": "hello world"},
+		},
+		{
+			name: "expression referencing previous assignment",
+			assignments: []map[string]any{
+				{"name": "a", "value": "1", "valueType": stateValTypeNumber},
+				{"name": "b", "source": domain.WorkflowSetVariableSourceExpression, "expression": map[string]any{
+					"type":     "arithmetic",
+					"operator": "add",
+					"left":     map[string]any{"type": "var", "selector": map[string]any{"id": "set1", "name": "a", "type": "number"}},
+					"right":    map[string]any{"type": "const", "value": "2", "valueType": "number"},
+				}},
+			},
+			wantScoped: map[string]any{"a": int32(1), "b": int32(3)},
+		},
+		{
+			name: "reserved variable",
+			assignments: []map[string]any{
+				{"name": "a", "value": "hello", "global": true},
+				{"name": stateVarKeyRunId, "value": "run2"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "empty variable name",
+			assignments: []map[string]any{
+				{"name": "", "value": "hello"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid constant",
+			assignments: []map[string]any{
+				{"name": "a", "value": "hello", "global": true},
+				{"name": "b", "value": "abc", "valueType": stateValTypeNumber},
+			},
+			wantErr: true,
+		},
+		{
+			name: "unsupported source",
+			assignments: []map[string]any{
+				{"name": "a", "source": "any"},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine := newTestWorkflowEngine(nil)
+			engine.executors[NodeTypeSetVariable] = newSetVariableNodeExecutor
+
+			node := &Node{
+				Id:   "set1",
+				Type: NodeTypeSetVariable,
+				Data: domain.WorkflowNodeData{Name: "set1", Config: domain.WorkflowNodeConfig{"assignments": tt.assignments}},
+			}
+
+			wfCtx, err := engine.executeTestBlocks(context.Background(), node)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Execute() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			// 执行失败时，此前已求值的赋值亦不应写入变量
+			if err != nil {
+				for _, assignment := range tt.assignments {
+					name, _ := assignment["name"].(string)
+					if _, ok := wfCtx.variables.GetScoped("set1", name); ok {
+						t.Errorf("Execute() variable '%s' should not be assigned", name)
+					}
+					if _, ok := wfCtx.variables.Get(name); ok && name != stateVarKeyRunId {
+						t.Errorf("Execute() global variable '%s' should not be assigned", name)
+					}
+				}
+				if state, _ := wfCtx.variables.Get(stateVarKeyRunId); state.Value != "run1" {
+					t.Errorf("Execute() reserved variable = %v, want %v", state.Value, "run1")
+				}
+				return
+			}
+
+			for key, want := range tt.wantScoped {
+				if state, ok := wfCtx.variables.GetScoped("set1", key); !ok || state.Value != want {
+					t.Errorf("Execute() variable '%s' = %v, want %v", key, state, want)
+				}
+			}
+			for key, want := range tt.wantGlobal {
+				if state, ok := wfCtx.variables.Get(key); !ok || state.Value != want {
+					t.Errorf("Execute() global variable '%s' = %v, want %v", key, state, want)
+				}
+			}
+			for key := range tt.wantScoped {
+				if _, ok := tt.wantGlobal[key]; ok {
+					continue
+				}
+				if _, ok := wfCtx.variables.Get(key); ok {
+					t.Errorf("Execute() variable '%s' should not be global", key)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/certimate-go/certimate/internal/domain"
+)
+
+// 记录试运行时各节点的执行计划。
+type runPlanner struct {
+	mtx  sync.RWMutex
+	plan *domain.WorkflowPlan
+}
+
+func (p *runPlanner) Record(step *domain.WorkflowPlanStep) {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	p.plan.Steps = append(p.plan.Steps, step)
+}
+
+func (p *runPlanner) Update(step *domain.WorkflowPlanStep, fn func(step *domain.WorkflowPlanStep)) {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	fn(step)
+	if step.Action == domain.WorkflowPlanActionTypeInvalid {
+		p.plan.Valid = false
+	}
+}
+
+// 获取指定节点最近一次记录的执行计划。
+func (p *runPlanner) Get(nodeId string) (domain.WorkflowPlanStep, bool) {
+	p.mtx.RLock()
+	defer p.mtx.RUnlock()
+
+	for i := len(p.plan.Steps) - 1; i >= 0; i-- {
+		if p.plan.Steps[i].NodeId == nodeId {
+			return *p.plan.Steps[i], true
+		}
+	}
+
+	return domain.WorkflowPlanStep{}, false
+}
+
+func (p *runPlanner) Result() *domain.WorkflowPlan {
+	p.mtx.RLock()
+	defer p.mtx.RUnlock()
+
+	steps := make([]*domain.WorkflowPlanStep, 0, len(p.plan.Steps))
+	for _, step := range p.plan.Steps {
+		stepCopy := *step
+		steps = append(steps, &stepCopy)
+	}
+
+	return &domain.WorkflowPlan{
+		WorkflowId: p.plan.WorkflowId,
+		Valid:      p.plan.Valid,
+		Steps:      steps,
+	}
+}
+
+func newRunPlanner(workflowId string) *runPlanner {
+	return &runPlanner{
+		plan: &domain.WorkflowPlan{
+			WorkflowId: workflowId,
+			Valid:      true,
+			Steps:      make([]*domain.WorkflowPlanStep, 0),
+		},
+	}
+}
+
+func (we *workflowEngine) Plan(ctx context.Context, execution WorkflowExecution) (*domain.WorkflowPlan, error) {
+	ctx = withExecutingRun(ctx, execution.WorkflowId, execution.RunId)
+	ctx = withCallStack(ctx, execution.WorkflowId)
+
+	planner := newRunPlanner(execution.WorkflowId)
+	wfCtx := we.newWorkflowContext(ctx, execution)
+	wfCtx.SetPlanner(planner)
+
+	// 控制流节点的错误已记录在对应节点的执行计划中，此时仅提前结束试运行
+	if err := we.executeBlocks(wfCtx, execution.Graph.Nodes); err != nil {
+		if ctx.Err() != nil {
+			return nil, err
+		}
+	}
+
+	return planner.Result(), nil
+}
+
+// 以试运行模式执行节点。
+// 支持试运行的执行器将以 DryRun 代替 Execute；其余的（通常是控制流节点）则照常执行，以便求值条件分支等。
+func (we *workflowEngine) planNode(execCtx *NodeExecutionContext, executor NodeExecutor) (*NodeExecutionResult, error) {
+	step := &domain.WorkflowPlanStep{
+		NodeId:   execCtx.Node.Id,
+		NodeName: execCtx.Node.Data.Name,
+		NodeType: execCtx.Node.Type,
+		Action:   domain.WorkflowPlanActionTypeExecute,
+	}
+	execCtx.planner.Record(step)
+
+	var execRes *NodeExecutionResult
+	var execStep *domain.WorkflowPlanStep
+	var err error
+	if dryRunner, ok := executor.(NodeDryRunner); ok {
+		execRes, execStep, err = dryRunner.DryRun(execCtx)
+	} else {
+		execRes, err = executor.Execute(execCtx)
+	}
+
+	execCtx.planner.Update(step, func(step *domain.WorkflowPlanStep) {
+		if execStep != nil {
+			step.Action = execStep.Action
+			step.Reason = execStep.Reason
+			step.Error = execStep.Error
+		}
+
+		if err != nil && !errors.Is(err, ErrTerminated) && !errors.Is(err, ErrBlocksException) {
+			step.Action = domain.WorkflowPlanActionTypeInvalid
+			step.Error = err.Error()
+		}
+	})
+
+	return execRes, err
+}
@@ -0,0 +1,84 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+
+	"github.com/certimate-go/certimate/internal/domain"
+)
+
+func TestWorkflowEnginePlan(t *testing.T) {
+	outputRepo := &testWorkflowOutputRepository{}
+	engine := newTestWorkflowEngine(func(execCtx *NodeExecutionContext, execRes *NodeExecutionResult) error {
+		if _, ok := execCtx.Node.Data.Config["fail"]; ok {
+			return errors.New("test error")
+		}
+
+		execRes.AddOutputWithPersistent(stateIOTypeRef, "result", "a", stateValTypeString)
+		return nil
+	})
+	engine.wfoutputRepo = outputRepo
+	engine.executors[NodeTypeStart] = newStartNodeExecutor
+	engine.executors[NodeTypeEnd] = newEndNodeExecutor
+	engine.executors[NodeTypeDelay] = newDelayNodeExecutor
+	engine.executors[NodeTypeCallWorkflow] = func() NodeExecutor {
+		return &callWorkflowNodeExecutor{
+			nodeExecutor:    nodeExecutor{logger: slog.Default()},
+			workflowRepo:    &testWorkflowRepository{},
+			workflowRunRepo: &testWorkflowRunRepository{},
+		}
+	}
+
+	disabledNode := newTestNode("n2")
+	disabledNode.Data.Disabled = true
+	failingNode := newTestNode("n3")
+	failingNode.Data.Config = domain.WorkflowNodeConfig{"fail": true}
+
+	plan, err := engine.Plan(context.Background(), WorkflowExecution{
+		WorkflowId: "wf1",
+		Graph: &Graph{Nodes: []*Node{
+			{Id: "start", Type: NodeTypeStart},
+			{Id: "delay1", Type: NodeTypeDelay, Data: domain.WorkflowNodeData{Config: domain.WorkflowNodeConfig{"wait": 60}}},
+			newTestNode("n1"),
+			disabledNode,
+			{Id: "call1", Type: NodeTypeCallWorkflow, Data: domain.WorkflowNodeData{Config: domain.WorkflowNodeConfig{"workflowId": "wf2"}}},
+			failingNode,
+			{Id: "end", Type: NodeTypeEnd},
+		}},
+	})
+	if err != nil {
+		t.Fatalf("Plan() error = %v", err)
+	}
+
+	if plan.WorkflowId != "wf1" || plan.Valid {
+		t.Errorf("Plan() = %s valid %v, want wf1 valid false", plan.WorkflowId, plan.Valid)
+	}
+
+	// 支持试运行的节点校验失败时继续试运行，其余节点执行失败时则提前结束
+	wantActions := map[string]domain.WorkflowPlanActionType{
+		"start":  domain.WorkflowPlanActionTypeExecute,
+		"delay1": domain.WorkflowPlanActionTypeExecute,
+		"n1":     domain.WorkflowPlanActionTypeExecute,
+		"n2":     domain.WorkflowPlanActionTypeSkip,
+		"call1":  domain.WorkflowPlanActionTypeInvalid,
+		"n3":     domain.WorkflowPlanActionTypeInvalid,
+	}
+	if len(plan.Steps) != len(wantActions) {
+		t.Fatalf("Plan() steps = %d, want %d", len(plan.Steps), len(wantActions))
+	}
+	for _, step := range plan.Steps {
+		if step.Action != wantActions[step.NodeId] {
+			t.Errorf("Plan() action of node #%s = %s, want %s", step.NodeId, step.Action, wantActions[step.NodeId])
+		}
+		if step.Action == domain.WorkflowPlanActionTypeInvalid && step.Error == "" {
+			t.Errorf("Plan() error of node #%s is empty", step.NodeId)
+		}
+	}
+
+	// 试运行时不持久化输出
+	if len(outputRepo.outputs) != 0 {
+		t.Errorf("Plan() saved %d outputs, want 0", len(outputRepo.outputs))
+	}
+}
@@ -0,0 +1,132 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"math/rand/v2"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/certimate-go/certimate/internal/domain"
+)
+
+const (
+	retryDefaultInterval = 5 * time.Second
+	retryMaxInterval     = 1 * time.Hour
+)
+
+// 按节点的重试策略执行节点。
+// 以下情况不会重试：
+//   - 节点主动终止执行（例如 End 节点）；
+//   - 运行已挂起（例如 Approval 节点等待审批）；
+//   - 子节点执行失败（例如 Condition、TryCatch 等容器节点），子节点应自行配置重试策略；
+//   - 上下文已取消或超时（节点自身配置的超时除外）。
+func (we *workflowEngine) executeWithRetry(execCtx *NodeExecutionContext, executor NodeExecutor, logger *slog.Logger) (*NodeExecutionResult, error) {
+	policy := execCtx.Node.Data.Retry
+
+	maxAttempts := 1
+	if policy != nil && policy.MaxAttempts > 1 {
+		maxAttempts = policy.MaxAttempts
+	}
+
+	for attempt := 1; ; attempt++ {
+		execRes, err := we.executeAttempt(execCtx, executor)
+		if err == nil || attempt >= maxAttempts || !isRetryableError(execCtx.Context(), policy, err) {
+			if err != nil && attempt > 1 {
+				logger.Warn(fmt.Sprintf("attempt %d/%d failed, no more retries", attempt, maxAttempts))
+			}
+			return execRes, err
+		}
+
+		delay := calcRetryDelay(policy, attempt)
+		logger.Warn(fmt.Sprintf("attempt %d/%d failed, retry in %s ...", attempt, maxAttempts, delay), slog.Any("error", err))
+
+		ctx := execCtx.Context()
+		select {
+		case <-ctx.Done():
+			return execRes, ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
+
+// 执行节点一次，如果节点配置了超时时间，则以之作为本次执行的截止时间。
+func (we *workflowEngine) executeAttempt(execCtx *NodeExecutionContext, executor NodeExecutor) (*NodeExecutionResult, error) {
+	if execCtx.Node.Data.Timeout <= 0 {
+		return executor.Execute(execCtx)
+	}
+
+	timeout := time.Duration(execCtx.Node.Data.Timeout) * time.Second
+	ctx, cancel := context.WithTimeoutCause(execCtx.Context(), timeout, ErrNodeTimeout)
+	defer cancel()
+
+	execRes, err := executor.Execute(newNodeExecutionContext(&execCtx.WorkflowContext, execCtx.Node).SetContext(ctx))
+	if err != nil && execCtx.Context().Err() == nil && errors.Is(context.Cause(ctx), ErrNodeTimeout) {
+		err = fmt.Errorf("%w (timeout: %s)", ErrNodeTimeout, timeout)
+	}
+
+	return execRes, err
+}
+
+func isRetryableError(ctx context.Context, policy *domain.WorkflowNodeRetryPolicy, err error) bool {
+	if errors.Is(err, ErrTerminated) || errors.Is(err, ErrBlocksException) || errors.Is(err, ErrWaiting) {
+		return false
+	}
+
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) || ctx.Err() != nil {
+		return false
+	}
+
+	if len(policy.RetryOn) == 0 {
+		return true
+	}
+
+	errmsg := err.Error()
+	for _, pattern := range policy.RetryOn {
+		if pattern == "" {
+			continue
+		}
+
+		// 无效的正则表达式将被视为普通的子字符串
+		if re, rerr := regexp.Compile(pattern); rerr == nil {
+			if re.MatchString(errmsg) {
+				return true
+			}
+		} else if strings.Contains(errmsg, pattern) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func calcRetryDelay(policy *domain.WorkflowNodeRetryPolicy, attempt int) time.Duration {
+	interval := retryDefaultInterval
+	if policy.Interval > 0 {
+		interval = time.Duration(policy.Interval) * time.Second
+	}
+
+	delay := interval
+	if policy.Backoff == domain.WorkflowNodeRetryBackoffExponential {
+		delay = time.Duration(float64(interval) * math.Pow(2, float64(attempt-1)))
+
+		maxInterval := retryMaxInterval
+		if policy.MaxInterval > 0 {
+			maxInterval = time.Duration(policy.MaxInterval) * time.Second
+		}
+		if delay <= 0 || delay > maxInterval {
+			delay = maxInterval
+		}
+	}
+
+	if policy.Jitter > 0 {
+		jitter := math.Min(policy.Jitter, 1)
+		delay = time.Duration(float64(delay) * (1 + jitter*(rand.Float64()*2-1)))
+	}
+
+	return delay.Round(time.Millisecond)
+}
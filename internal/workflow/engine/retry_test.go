@@ -0,0 +1,250 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/certimate-go/certimate/internal/domain"
+)
+
+func TestIsRetryableError(t *testing.T) {
+	canceledCtx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name   string
+		ctx    context.Context
+		policy *domain.WorkflowNodeRetryPolicy
+		err    error
+		want   bool
+	}{
+		{
+			name:   "any error",
+			policy: &domain.WorkflowNodeRetryPolicy{MaxAttempts: 3},
+			err:    errors.New("connection reset by peer"),
+			want:   true,
+		},
+		{
+			name:   "terminated",
+			policy: &domain.WorkflowNodeRetryPolicy{MaxAttempts: 3},
+			err:    ErrTerminated,
+			want:   false,
+		},
+		{
+			name:   "blocks exception",
+			policy: &domain.WorkflowNodeRetryPolicy{MaxAttempts: 3},
+			err:    fmt.Errorf("%w: test", ErrBlocksException),
+			want:   false,
+		},
+		{
+			name:   "waiting",
+			policy: &domain.WorkflowNodeRetryPolicy{MaxAttempts: 3},
+			err:    ErrWaiting,
+			want:   false,
+		},
+		{
+			name:   "context canceled",
+			policy: &domain.WorkflowNodeRetryPolicy{MaxAttempts: 3},
+			err:    context.Canceled,
+			want:   false,
+		},
+		{
+			name:   "context deadline exceeded",
+			policy: &domain.WorkflowNodeRetryPolicy{MaxAttempts: 3},
+			err:    fmt.Errorf("request failed: %w", context.DeadlineExceeded),
+			want:   false,
+		},
+		{
+			name:   "node timeout",
+			policy: &domain.WorkflowNodeRetryPolicy{MaxAttempts: 3},
+			err:    fmt.Errorf("%w (timeout: 1s)", ErrNodeTimeout),
+			want:   true,
+		},
+		{
+			name:   "run canceled",
+			ctx:    canceledCtx,
+			policy: &domain.WorkflowNodeRetryPolicy{MaxAttempts: 3},
+			err:    errors.New("connection reset by peer"),
+			want:   false,
+		},
+		{
+			name:   "regexp matched",
+			policy: &domain.WorkflowNodeRetryPolicy{MaxAttempts: 3, RetryOn: []string{"^rate limit", "status code: 5\\d\\d"}},
+			err:    errors.New("unexpected status code: 503"),
+			want:   true,
+		},
+		{
+			name:   "regexp not matched",
+			policy: &domain.WorkflowNodeRetryPolicy{MaxAttempts: 3, RetryOn: []string{"^rate limit", "status code: 5\\d\\d"}},
+			err:    errors.New("unexpected status code: 403"),
+			want:   false,
+		},
+		{
+			name:   "invalid regexp as substring",
+			policy: &domain.WorkflowNodeRetryPolicy{MaxAttempts: 3, RetryOn: []string{"quota [exceeded"}},
+			err:    errors.New("error: quota [exceeded]"),
+			want:   true,
+		},
+		{
+			name:   "empty pattern ignored",
+			policy: &domain.WorkflowNodeRetryPolicy{MaxAttempts: 3, RetryOn: []string{""}},
+			err:    errors.New("connection reset by peer"),
+			want:   false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := tt.ctx
+			if ctx == nil {
+				ctx = context.Background()
+			}
+
+			if got := isRetryableError(ctx, tt.policy, tt.err); got != tt.want {
+				t.Errorf("isRetryableError() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCalcRetryDelay(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  *domain.WorkflowNodeRetryPolicy
+		attempt int
+		wantMin time.Duration
+		wantMax time.Duration
+	}{
+		{
+			name:    "default interval",
+			policy:  &domain.WorkflowNodeRetryPolicy{},
+			attempt: 3,
+			wantMin: retryDefaultInterval,
+			wantMax: retryDefaultInterval,
+		},
+		{
+			name:    "fixed",
+			policy:  &domain.WorkflowNodeRetryPolicy{Backoff: domain.WorkflowNodeRetryBackoffFixed, Interval: 10},
+			attempt: 3,
+			wantMin: 10 * time.Second,
+			wantMax: 10 * time.Second,
+		},
+		{
+			name:    "exponential first attempt",
+			policy:  &domain.WorkflowNodeRetryPolicy{Backoff: domain.WorkflowNodeRetryBackoffExponential, Interval: 2},
+			attempt: 1,
+			wantMin: 2 * time.Second,
+			wantMax: 2 * time.Second,
+		},
+		{
+			name:    "exponential doubled",
+			policy:  &domain.WorkflowNodeRetryPolicy{Backoff: domain.WorkflowNodeRetryBackoffExponential, Interval: 2},
+			attempt: 4,
+			wantMin: 16 * time.Second,
+			wantMax: 16 * time.Second,
+		},
+		{
+			name:    "exponential capped by max interval",
+			policy:  &domain.WorkflowNodeRetryPolicy{Backoff: domain.WorkflowNodeRetryBackoffExponential, Interval: 2, MaxInterval: 30},
+			attempt: 10,
+			wantMin: 30 * time.Second,
+			wantMax: 30 * time.Second,
+		},
+		{
+			name:    "exponential capped by default max interval",
+			policy:  &domain.WorkflowNodeRetryPolicy{Backoff: domain.WorkflowNodeRetryBackoffExponential, Interval: 60},
+			attempt: 100,
+			wantMin: retryMaxInterval,
+			wantMax: retryMaxInterval,
+		},
+		{
+			name:    "jitter",
+			policy:  &domain.WorkflowNodeRetryPolicy{Interval: 10, Jitter: 0.2},
+			attempt: 1,
+			wantMin: 8 * time.Second,
+			wantMax: 12 * time.Second,
+		},
+		{
+			name:    "jitter clamped",
+			policy:  &domain.WorkflowNodeRetryPolicy{Interval: 10, Jitter: 5},
+			attempt: 1,
+			wantMin: 0,
+			wantMax: 20 * time.Second,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i := 0; i < 100; i++ {
+				got := calcRetryDelay(tt.policy, tt.attempt)
+				if got < tt.wantMin || got > tt.wantMax {
+					t.Fatalf("calcRetryDelay() = %v, want in [%v, %v]", got, tt.wantMin, tt.wantMax)
+				}
+			}
+		})
+	}
+}
+
+func TestWorkflowEngineTimeout(t *testing.T) {
+	engine := newTestWorkflowEngine(func(execCtx *NodeExecutionContext, execRes *NodeExecutionResult) error {
+		if _, ok := execCtx.Node.Data.Config["hang"]; !ok {
+			return nil
+		}
+
+		<-execCtx.Context().Done()
+		return fmt.Errorf("request failed: %w", execCtx.Context().Err())
+	})
+
+	newTimeoutNode := func(id string, timeout int, hang bool) *Node {
+		node := newTestNode(id)
+		node.Data.Timeout = timeout
+		if hang {
+			node.Data.Config = domain.WorkflowNodeConfig{"hang": true}
+		}
+		return node
+	}
+
+	tests := []struct {
+		name       string
+		runTimeout time.Duration
+		nodes      []*Node
+		wantErr    error
+	}{
+		{
+			name:  "completed in time",
+			nodes: []*Node{newTimeoutNode("n1", 1, false), newTimeoutNode("n2", 0, false)},
+		},
+		{
+			name:    "node timed out",
+			nodes:   []*Node{newTimeoutNode("n1", 1, false), newTimeoutNode("n2", 1, true)},
+			wantErr: ErrNodeTimeout,
+		},
+		{
+			name:       "run timed out",
+			runTimeout: 100 * time.Millisecond,
+			nodes:      []*Node{newTimeoutNode("n1", 0, false), newTimeoutNode("n2", 10, true)},
+			wantErr:    ErrRunTimeout,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := engine.Invoke(context.Background(), WorkflowExecution{
+				WorkflowId: "wf1",
+				RunId:      "run1",
+				RunTimeout: tt.runTimeout,
+				Graph:      &Graph{Nodes: tt.nodes},
+			})
+			if tt.wantErr == nil {
+				if err != nil {
+					t.Errorf("Invoke() error = %v, want nil", err)
+				}
+				return
+			}
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Invoke() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -1,9 +1,13 @@
 package engine
 
 import (
+	"encoding/json"
 	"fmt"
+	"math"
+	"reflect"
 	"slices"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 )
@@ -155,6 +159,28 @@ func newVariableManager() VariableManager {
 	}
 }
 
+// 基于已有的变量管理器派生出一个副本，二者之间的修改互不影响。
+func forkVariableManager(m VariableManager) VariableManager {
+	return &variableManager{
+		states: m.All(),
+	}
+}
+
+// 将派生副本中相较于基线快照发生变化的变量合并回目标变量管理器。
+func mergeVariableManager(dst VariableManager, base []VariableState, src VariableManager) {
+	for _, state := range src.All() {
+		unchanged := slices.ContainsFunc(base, func(item VariableState) bool {
+			return item.Scope == state.Scope &&
+				item.Key == state.Key &&
+				item.ValueType == state.ValueType &&
+				reflect.DeepEqual(item.Value, state.Value)
+		})
+		if !unchanged {
+			dst.Add(state)
+		}
+	}
+}
+
 type InOutState struct {
 	NodeId     string
 	Type       string
@@ -278,6 +304,86 @@ func newInOutManager() InOutManager {
 	}
 }
 
+// 基于已有的输入输出管理器派生出一个副本，二者之间的修改互不影响。
+func forkInOutManager(m InOutManager) InOutManager {
+	return &inoutManager{
+		states: m.All(),
+	}
+}
+
+// 将派生副本中相较于基线快照发生变化的输入输出合并回目标输入输出管理器。
+func mergeInOutManager(dst InOutManager, base []InOutState, src InOutManager) {
+	for _, state := range src.All() {
+		unchanged := slices.ContainsFunc(base, func(item InOutState) bool {
+			return item.NodeId == state.NodeId &&
+				item.Name == state.Name &&
+				item.Type == state.Type &&
+				item.ValueType == state.ValueType &&
+				item.Persistent == state.Persistent &&
+				reflect.DeepEqual(item.Value, state.Value)
+		})
+		if !unchanged {
+			dst.Add(state)
+		}
+	}
+}
+
+// 将字符串形式的值按指定类型解析为变量值。
+func parseStateValue(value string, valueType string) (any, error) {
+	switch valueType {
+	case "", stateValTypeString:
+		return value, nil
+	case stateValTypeNumber:
+		v, err := strconv.ParseInt(strings.TrimSpace(value), 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse '%s' as a number: %w", value, err)
+		}
+		return int32(v), nil
+	case stateValTypeBoolean:
+		v, err := strconv.ParseBool(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("could not parse '%s' as a boolean: %w", value, err)
+		}
+		return v, nil
+	case stateValTypeDateTime:
+		v, err := time.Parse(time.RFC3339, strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("could not parse '%s' as a datetime: %w", value, err)
+		}
+		return v, nil
+	default:
+		return nil, fmt.Errorf("unsupported value type: '%s'", valueType)
+	}
+}
+
+// 根据值的实际类型推断变量类型，无法识别的类型将被序列化为 JSON 字符串。
+func inferStateValue(value any) (any, string) {
+	switch v := value.(type) {
+	case nil:
+		return "", stateValTypeString
+	case string:
+		return v, stateValTypeString
+	case bool:
+		return v, stateValTypeBoolean
+	case int:
+		return int32(v), stateValTypeNumber
+	case int32:
+		return v, stateValTypeNumber
+	case int64:
+		return int32(v), stateValTypeNumber
+	case float64:
+		if v == math.Trunc(v) && v >= math.MinInt32 && v <= math.MaxInt32 {
+			return int32(v), stateValTypeNumber
+		}
+		return strconv.FormatFloat(v, 'f', -1, 64), stateValTypeString
+	case time.Time:
+		return v, stateValTypeDateTime
+	default:
+		valueJson, _ := json.Marshal(v)
+		return string(valueJson), stateValTypeString
+	}
+}
+
 const (
 	stateValTypeBoolean  = "boolean"
 	stateValTypeDateTime = "datetime"
@@ -286,7 +392,8 @@ const (
 )
 
 const (
-	stateIOTypeRef = "ref"
+	stateIOTypeRef  = "ref"
+	stateIOTypeJSON = "json"
 )
 
 const (
@@ -298,6 +405,15 @@ const (
 	stateVarKeyNodeId                     = "node.id"                     // ValueType: "string"
 	stateVarKeyNodeName                   = "node.name"                   // ValueType: "string"
 	stateVarKeyNodeSkipped                = "node.skipped"                // ValueType: "boolean"
+	stateVarKeyLoopItem                   = "loop.item"                   // ValueType: "string"
+	stateVarKeyLoopIndex                  = "loop.index"                  // ValueType: "number"
+	stateVarKeyLoopTotal                  = "loop.total"                  // ValueType: "number"
+	stateVarKeyLoopFailed                 = "loop.failed"                 // ValueType: "number"
+	stateVarKeyLoopAttempted              = "loop.attempted"              // ValueType: "number"
+	stateVarKeyHttpStatusCode             = "http.statusCode"             // ValueType: "number"
+	stateVarKeyApprovalDecision           = "approval.decision"           // ValueType: "string"
+	stateVarKeyApprovalDecider            = "approval.decider"            // ValueType: "string"
+	stateVarKeyApprovalComment            = "approval.comment"            // ValueType: "string"
 	stateVarKeyErrorNodeId                = "error.nodeId"                // ValueType: "string"
 	stateVarKeyErrorNodeName              = "error.nodeName"              // ValueType: "string"
 	stateVarKeyErrorMessage               = "error.message"               // ValueType: "string"
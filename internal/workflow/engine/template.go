@@ -0,0 +1,364 @@
+package engine
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// 通知模板的渲染数据，即模板中的 "." 。
+type templateData struct {
+	Workflow templateWorkflowData
+	Run      templateRunData
+	Nodes    []*templateNodeData // 本次运行中已执行过的节点（不含当前节点），按执行的先后顺序排列
+	Now      time.Time
+}
+
+type templateWorkflowData struct {
+	Id          string
+	Name        string
+	Description string
+}
+
+type templateRunData struct {
+	Id      string
+	Trigger string
+}
+
+type templateNodeData struct {
+	Id      string
+	Name    string
+	Type    string
+	Skipped bool
+	Failed  bool
+
+	funcs *templateFuncs
+}
+
+// 获取该节点的作用域变量，模板中的用法形如 `{{ .Var "certificate.daysLeft" }}`。
+func (n *templateNodeData) Var(key string) (any, error) {
+	return n.funcs.scoped(n.Id, key)
+}
+
+// 获取该节点所输出证书的摘要，模板中的用法形如 `{{ .CertificateSummary }}`。
+func (n *templateNodeData) CertificateSummary() (string, error) {
+	return n.funcs.certSummary(n.Id)
+}
+
+// 模板中可用的函数集合。
+// 出于安全考虑，其中不包含任何可访问文件系统、网络或环境变量的函数。
+type templateFuncs struct {
+	variables VariableManager
+	strict    bool // 严格模式下，引用不存在的变量将导致渲染出错；否则将原样保留占位符
+}
+
+func (f *templateFuncs) lookup(scope string, key string) (any, bool) {
+	if f.variables == nil {
+		return nil, false
+	}
+
+	state, ok := f.variables.GetScoped(scope, key)
+	if !ok {
+		return nil, false
+	}
+	return state.Value, true
+}
+
+func (f *templateFuncs) global(key string) (any, error) {
+	if value, ok := f.lookup("", key); ok {
+		return value, nil
+	}
+
+	if f.strict {
+		return nil, fmt.Errorf("variable '%s' is not defined", key)
+	}
+	return fmt.Sprintf("{{ $%s }}", key), nil
+}
+
+func (f *templateFuncs) scoped(scope string, key string) (any, error) {
+	if value, ok := f.lookup(scope, key); ok {
+		return value, nil
+	}
+
+	if f.strict {
+		return nil, fmt.Errorf("variable '%s' of node #%s is not defined", key, scope)
+	}
+	return fmt.Sprintf("{{ $%s }}", key), nil
+}
+
+func (f *templateFuncs) has(key string) bool {
+	_, ok := f.lookup("", key)
+	return ok
+}
+
+func (f *templateFuncs) hasScoped(scope string, key string) bool {
+	_, ok := f.lookup(scope, key)
+	return ok
+}
+
+func (f *templateFuncs) certSummary(scope ...string) (string, error) {
+	if len(scope) > 1 {
+		return "", errors.New("certSummary accepts at most one node id")
+	}
+
+	nodeId := ""
+	if len(scope) == 1 {
+		nodeId = scope[0]
+	}
+
+	commonName, ok := f.lookup(nodeId, stateVarKeyCertificateCommonName)
+	if !ok {
+		if f.strict {
+			if nodeId == "" {
+				return "", errors.New("no certificate is available")
+			}
+			return "", fmt.Errorf("no certificate is available on node #%s", nodeId)
+		}
+		return "", nil
+	}
+
+	subjectAltNames, _ := f.lookup(nodeId, stateVarKeyCertificateSubjectAltNames)
+	notBefore, _ := f.lookup(nodeId, stateVarKeyCertificateNotBefore)
+	notAfter, _ := f.lookup(nodeId, stateVarKeyCertificateNotAfter)
+	daysLeft, _ := f.lookup(nodeId, stateVarKeyCertificateDaysLeft)
+	validity, _ := f.lookup(nodeId, stateVarKeyCertificateValidity)
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Common Name: %s\n", templateToString(commonName)))
+	sb.WriteString(fmt.Sprintf("Subject Alternative Names: %s\n", strings.Join(strings.Split(templateToString(subjectAltNames), ";"), ", ")))
+	sb.WriteString(fmt.Sprintf("Valid From: %s\n", templateToString(notBefore)))
+	sb.WriteString(fmt.Sprintf("Valid To: %s\n", templateToString(notAfter)))
+	sb.WriteString(fmt.Sprintf("Days Left: %s\n", templateToString(daysLeft)))
+	sb.WriteString(fmt.Sprintf("Validity: %s", templateToString(validity)))
+	return sb.String(), nil
+}
+
+func (f *templateFuncs) FuncMap() template.FuncMap {
+	return template.FuncMap{
+		"var":         f.global,
+		"scoped":      f.scoped,
+		"has":         f.has,
+		"hasScoped":   f.hasScoped,
+		"certSummary": f.certSummary,
+		"now":         time.Now,
+		"str":         templateToString,
+		"date":        templateFormatDate,
+		"inZone":      templateInZone,
+		"daysUntil":   templateDaysUntil,
+		"default":     templateDefault,
+		"upper":       strings.ToUpper,
+		"lower":       strings.ToLower,
+		"trim":        strings.TrimSpace,
+		"replace":     strings.ReplaceAll,
+		"contains":    strings.Contains,
+		"hasPrefix":   strings.HasPrefix,
+		"hasSuffix":   strings.HasSuffix,
+		"split":       strings.Split,
+		"join":        func(sep string, elems []string) string { return strings.Join(elems, sep) },
+	}
+}
+
+// 将变量值转换为字符串，其规则与 [VariableState.ValueString] 保持一致。
+func templateToString(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case time.Time:
+		if v.IsZero() {
+			return "-"
+		}
+		return v.Format(time.RFC3339)
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+func templateToTime(value any) (time.Time, error) {
+	switch v := value.(type) {
+	case time.Time:
+		return v, nil
+	case string:
+		if v == "" || v == "-" {
+			return time.Time{}, nil
+		}
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("could not parse '%s' as a datetime: %w", v, err)
+		}
+		return t, nil
+	default:
+		return time.Time{}, fmt.Errorf("could not convert %T to a datetime", value)
+	}
+}
+
+// 格式化日期时间，布局字符串可以是 Go 的布局字符串，也可以是 "RFC3339"、"DateTime"、"DateOnly"、"TimeOnly" 之一。
+// 模板中的用法形如 `{{ var "certificate.notAfter" | date "2006-01-02" }}`。
+func templateFormatDate(layout string, value any) (string, error) {
+	t, err := templateToTime(value)
+	if err != nil {
+		return "", err
+	}
+	if t.IsZero() {
+		return "-", nil
+	}
+
+	switch layout {
+	case "RFC3339":
+		layout = time.RFC3339
+	case "DateTime":
+		layout = time.DateTime
+	case "DateOnly":
+		layout = time.DateOnly
+	case "TimeOnly":
+		layout = time.TimeOnly
+	}
+	return t.Format(layout), nil
+}
+
+// 将日期时间转换到指定的时区，时区名称为 IANA 时区数据库中的名称，如 "Asia/Shanghai"。
+func templateInZone(name string, value any) (time.Time, error) {
+	t, err := templateToTime(value)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("unknown time zone '%s': %w", name, err)
+	}
+	return t.In(loc), nil
+}
+
+// 计算距离指定日期时间还有多少天（向下取整），已过期时返回负数。
+func templateDaysUntil(value any) (int, error) {
+	t, err := templateToTime(value)
+	if err != nil {
+		return 0, err
+	}
+	return int(time.Until(t).Hours() / 24), nil
+}
+
+// 值为空时返回默认值，模板中的用法形如 `{{ var "workflow.description" | default "N/A" }}`。
+func templateDefault(defaultValue any, value any) any {
+	switch v := value.(type) {
+	case nil:
+		return defaultValue
+	case string:
+		if v == "" {
+			return defaultValue
+		}
+	case time.Time:
+		if v.IsZero() {
+			return defaultValue
+		}
+	}
+	return value
+}
+
+var (
+	reTemplateLegacyPlaceholder = regexp.MustCompile(`\{\{\s*\$([^\s{}]+)\s*\}\}`)
+	reTemplateDeclaredVariable  = regexp.MustCompile(`\$(\w+)\s*(?:,\s*\$(\w+)\s*)?:?=`)
+)
+
+// 为兼容旧版，将形如 `{{ $certificate.domain }}` 的占位符改写为 `{{ str (var "certificate.domain") }}`。
+// 模板中通过 `:=` 或 range 声明的模板变量（如 `{{ $node.Name }}`）不会被改写。
+func rewriteLegacyTemplatePlaceholders(text string) string {
+	declared := map[string]struct{}{"": {}}
+	for _, matches := range reTemplateDeclaredVariable.FindAllStringSubmatch(text, -1) {
+		for _, name := range matches[1:] {
+			if name != "" {
+				declared[name] = struct{}{}
+			}
+		}
+	}
+
+	return reTemplateLegacyPlaceholder.ReplaceAllStringFunc(text, func(match string) string {
+		key := reTemplateLegacyPlaceholder.FindStringSubmatch(match)[1]
+		root, _, _ := strings.Cut(key, ".")
+		if _, ok := declared[root]; ok {
+			return match
+		}
+
+		if key == "now" {
+			return "{{ str now }}"
+		}
+		return fmt.Sprintf("{{ str (var %q) }}", key)
+	})
+}
+
+func newTemplate(name string, text string, funcs *templateFuncs) (*template.Template, error) {
+	tmpl := template.New(name).Funcs(funcs.FuncMap())
+	if funcs.strict {
+		tmpl = tmpl.Option("missingkey=error")
+	}
+
+	return tmpl.Parse(rewriteLegacyTemplatePlaceholders(text))
+}
+
+// 检查模板语法是否正确，不会对模板求值。
+func parseTemplate(name string, text string) error {
+	_, err := newTemplate(name, text, &templateFuncs{})
+	return err
+}
+
+// 基于当前节点执行上下文中的变量渲染模板。
+func renderTemplate(execCtx *NodeExecutionContext, name string, text string, strict bool) (string, error) {
+	funcs := &templateFuncs{variables: execCtx.variables, strict: strict}
+
+	tmpl, err := newTemplate(name, text, funcs)
+	if err != nil {
+		return "", err
+	}
+
+	data := &templateData{Now: time.Now()}
+	data.Workflow.Id = templateToString(templateLookupOrZero(funcs, "", stateVarKeyWorkflowId))
+	data.Workflow.Name = templateToString(templateLookupOrZero(funcs, "", stateVarKeyWorkflowName))
+	data.Workflow.Description = templateToString(templateLookupOrZero(funcs, "", stateVarKeyWorkflowDescription))
+	data.Run.Id = templateToString(templateLookupOrZero(funcs, "", stateVarKeyRunId))
+	data.Run.Trigger = templateToString(templateLookupOrZero(funcs, "", stateVarKeyRunTrigger))
+	data.Nodes = make([]*templateNodeData, 0)
+
+	errorNodeId := templateToString(templateLookupOrZero(funcs, "", stateVarKeyErrorNodeId))
+	for _, variable := range execCtx.variables.All() {
+		if variable.Scope == "" || variable.Key != stateVarKeyNodeId {
+			continue
+		}
+		if execCtx.Node != nil && variable.Scope == execCtx.Node.Id {
+			continue
+		}
+
+		nodeData := &templateNodeData{
+			Id:     variable.Scope,
+			Name:   templateToString(templateLookupOrZero(funcs, variable.Scope, stateVarKeyNodeName)),
+			Failed: errorNodeId != "" && errorNodeId == variable.Scope,
+			funcs:  funcs,
+		}
+		if skipped, ok := funcs.lookup(variable.Scope, stateVarKeyNodeSkipped); ok {
+			nodeData.Skipped, _ = skipped.(bool)
+		}
+		if execCtx.RunGraph != nil {
+			if node, ok := execCtx.RunGraph.GetNodeById(variable.Scope); ok {
+				nodeData.Type = string(node.Type)
+			}
+		}
+		data.Nodes = append(data.Nodes, nodeData)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
+
+func templateLookupOrZero(funcs *templateFuncs, scope string, key string) any {
+	value, _ := funcs.lookup(scope, key)
+	return value
+}
@@ -0,0 +1,165 @@
+package engine
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/certimate-go/certimate/internal/domain"
+)
+
+func TestRewriteLegacyTemplatePlaceholders(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{
+			name: "legacy placeholders",
+			text: "Certificate ${DOMAIN}: {{ $certificate.domain }} expires in {{$certificate.daysLeft}} days",
+			want: `Certificate ${DOMAIN}: {{ str (var "certificate.domain") }} expires in {{ str (var "certificate.daysLeft") }} days`,
+		},
+		{
+			name: "legacy now",
+			text: "Now: {{ $now }}",
+			want: "Now: {{ str now }}",
+		},
+		{
+			name: "declared variables",
+			text: `{{ $name := "a" }}{{ $name }}{{ range $i, $node := .Nodes }}{{ $i }}{{ $node.Name }}{{ end }}{{ $certificate.domain }}`,
+			want: `{{ $name := "a" }}{{ $name }}{{ range $i, $node := .Nodes }}{{ $i }}{{ $node.Name }}{{ end }}{{ str (var "certificate.domain") }}`,
+		},
+		{
+			name: "root variable",
+			text: "{{ $.Workflow.Name }}",
+			want: "{{ $.Workflow.Name }}",
+		},
+		{
+			name: "pipelines are not placeholders",
+			text: `{{ $certificate.domain | upper }}`,
+			want: `{{ $certificate.domain | upper }}`,
+		},
+		{
+			name: "plain text",
+			text: "no placeholders",
+			want: "no placeholders",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := rewriteLegacyTemplatePlaceholders(tt.text); got != tt.want {
+				t.Errorf("rewriteLegacyTemplatePlaceholders() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTemplate(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		wantErr bool
+	}{
+		{"legacy placeholders", "{{ $certificate.domain }}", false},
+		{"functions", `{{ var "certificate.notAfter" | date "DateOnly" | default "-" }}`, false},
+		{"unclosed action", "{{ .Workflow.Name", true},
+		{"unknown function", `{{ env "HOME" }}`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := parseTemplate("test", tt.text); (err != nil) != tt.wantErr {
+				t.Errorf("parseTemplate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRenderTemplate(t *testing.T) {
+	notAfter := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	engine := newTestWorkflowEngine(nil)
+	wfCtx := engine.newWorkflowContext(context.Background(), WorkflowExecution{
+		WorkflowId:   "wf1",
+		WorkflowName: "My Workflow",
+		RunId:        "run1",
+		RunTrigger:   domain.WorkflowTriggerTypeManual,
+	})
+	wfCtx.variables.SetScoped("apply1", stateVarKeyNodeId, "apply1", stateValTypeString)
+	wfCtx.variables.SetScoped("apply1", stateVarKeyNodeName, "Apply", stateValTypeString)
+	wfCtx.variables.SetScoped("apply1", stateVarKeyCertificateCommonName, "example.com", stateValTypeString)
+	wfCtx.variables.SetScoped("apply1", stateVarKeyCertificateNotAfter, notAfter, stateValTypeDateTime)
+	wfCtx.variables.Set(stateVarKeyCertificateCommonName, "example.com", stateValTypeString)
+	wfCtx.variables.Set(stateVarKeyCertificateNotAfter, notAfter, stateValTypeDateTime)
+	wfCtx.variables.SetScoped("notify1", stateVarKeyNodeId, "notify1", stateValTypeString)
+	execCtx := newNodeExecutionContext(wfCtx, &Node{Id: "notify1", Type: NodeTypeBizNotify})
+
+	tests := []struct {
+		name    string
+		text    string
+		strict  bool
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "data",
+			text: "{{ .Workflow.Name }} #{{ .Run.Id }} ({{ .Run.Trigger }})",
+			want: "My Workflow #run1 (manual)",
+		},
+		{
+			name: "legacy placeholders",
+			text: "{{ $certificate.commonName }} expires at {{ $certificate.notAfter }}",
+			want: "example.com expires at 2026-03-01T12:00:00Z",
+		},
+		{
+			name: "scoped variables",
+			text: `{{ scoped "apply1" "certificate.commonName" }}`,
+			want: "example.com",
+		},
+		{
+			name: "nodes exclude the current node",
+			text: `{{ range .Nodes }}{{ .Name }}: {{ .Var "certificate.commonName" }};{{ end }}`,
+			want: "Apply: example.com;",
+		},
+		{
+			name: "functions",
+			text: `{{ var "certificate.notAfter" | inZone "Asia/Shanghai" | date "DateTime" }} {{ var "workflow.description" | default "N/A" }} {{ upper "a" }}`,
+			want: "2026-03-01 20:00:00 N/A A",
+		},
+		{
+			name: "undefined variable",
+			text: "{{ $certificate.daysLeft }}",
+			want: "{{ $certificate.daysLeft }}",
+		},
+		{
+			name:    "undefined variable in strict mode",
+			text:    "{{ $certificate.daysLeft }}",
+			strict:  true,
+			wantErr: true,
+		},
+		{
+			name:    "invalid date",
+			text:    `{{ var "certificate.commonName" | date "DateOnly" }}`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := renderTemplate(execCtx, "test", tt.text, tt.strict)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("renderTemplate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && got != tt.want {
+				t.Errorf("renderTemplate() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	summary, err := renderTemplate(execCtx, "test", `{{ certSummary "apply1" }}`, true)
+	if err != nil {
+		t.Fatalf("renderTemplate() error = %v", err)
+	}
+	if !strings.Contains(summary, "Common Name: example.com") || !strings.Contains(summary, "Valid To: 2026-03-01T12:00:00Z") {
+		t.Errorf("renderTemplate() certSummary = %s", summary)
+	}
+}
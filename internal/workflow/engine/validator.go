@@ -0,0 +1,653 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/samber/lo"
+
+	"github.com/certimate-go/certimate/internal/certacme"
+	"github.com/certimate-go/certimate/internal/certmgmt"
+	"github.com/certimate-go/certimate/internal/domain"
+	"github.com/certimate-go/certimate/internal/domain/expr"
+	"github.com/certimate-go/certimate/internal/notify"
+	"github.com/certimate-go/certimate/internal/repository"
+	xjsonpath "github.com/certimate-go/certimate/pkg/utils/jsonpath"
+)
+
+// 工作流图的静态校验器，在保存或发布前检查各节点配置的完整性和一致性，不会调用任何外部服务。
+type GraphValidator interface {
+	// 校验工作流图。
+	// 校验通过时返回 nil，否则返回 [domain.WorkflowGraphValidationError]。
+	Validate(ctx context.Context, graph *Graph) error
+}
+
+type graphValidator struct {
+	accessRepo accessRepository
+}
+
+var _ GraphValidator = (*graphValidator)(nil)
+
+func (v *graphValidator) Validate(ctx context.Context, graph *Graph) error {
+	verr := &domain.WorkflowGraphValidationError{}
+	if graph == nil || len(graph.Nodes) == 0 {
+		verr.AddError("the graph is empty")
+		return verr
+	} else if err := graph.Verify(); err != nil {
+		verr.AddError(err.Error())
+	}
+
+	vCtx := &graphValidationContext{
+		ctx:       ctx,
+		nodes:     make([]graphValidationEntry, 0),
+		positions: make(map[string]int),
+		errs:      verr,
+	}
+	vCtx.flatten(graph.Nodes, false, nil)
+
+	// 节点 ID 必须在工作流中唯一，否则变量作用域、输出引用等都将发生混淆
+	for i, entry := range vCtx.nodes {
+		if entry.node.Id == "" {
+			verr.AddNodeError(entry.node, "", "the node id is empty")
+			continue
+		}
+
+		if _, ok := vCtx.positions[entry.node.Id]; ok {
+			verr.AddNodeError(entry.node, "", fmt.Sprintf("duplicate node id '%s'", entry.node.Id))
+			continue
+		}
+
+		vCtx.positions[entry.node.Id] = i
+	}
+
+	for i, entry := range vCtx.nodes {
+		// 已禁用的节点（及其子节点）在运行时将被跳过，无需校验其配置
+		if entry.disabled {
+			continue
+		}
+
+		switch entry.node.Type {
+		case NodeTypeBranchBlock:
+			v.validateBranchBlockNode(vCtx, i)
+		case NodeTypeScript:
+			v.validateScriptNode(vCtx, i)
+		case NodeTypeHttpRequest:
+			v.validateHttpRequestNode(vCtx, i)
+		case NodeTypeApproval:
+			v.validateApprovalNode(vCtx, i)
+		case NodeTypeSetVariable:
+			v.validateSetVariableNode(vCtx, i)
+		case NodeTypeBizApply:
+			v.validateBizApplyNode(vCtx, i)
+		case NodeTypeBizDeploy:
+			v.validateBizDeployNode(vCtx, i)
+		case NodeTypeBizNotify:
+			v.validateBizNotifyNode(vCtx, i)
+		}
+	}
+
+	if verr.HasErrors() {
+		return verr
+	}
+
+	return nil
+}
+
+func (v *graphValidator) validateBranchBlockNode(vCtx *graphValidationContext, pos int) {
+	node := vCtx.nodes[pos].node
+
+	exprRaw, ok := node.Data.Config["expression"]
+	if !ok || exprRaw == nil {
+		return
+	}
+
+	exprBytes, _ := json.Marshal(exprRaw)
+	expression, err := expr.UnmarshalExpr(exprBytes)
+	if err != nil {
+		vCtx.errs.AddNodeError(node, "expression", fmt.Sprintf("failed to parse expression: %s", err.Error()))
+		return
+	}
+
+	valueType, err := expr.InferType(expression, func(selector expr.ExprValueSelector) error {
+		return v.resolveVariableSelector(vCtx, pos, selector)
+	})
+	if err != nil {
+		vCtx.errs.AddNodeError(node, "expression", err.Error())
+	} else if valueType != expr.Boolean {
+		vCtx.errs.AddNodeError(node, "expression", fmt.Sprintf("the expression must be evaluated as a boolean, but got a %s", valueType))
+	}
+}
+
+// 检查表达式中引用的变量是否由前序节点提供，且其值类型相匹配。
+func (v *graphValidator) resolveVariableSelector(vCtx *graphValidationContext, pos int, selector expr.ExprValueSelector) error {
+	scopeNode, ok := vCtx.lookupPreceding(pos, selector.Id)
+	if !ok {
+		return fmt.Errorf("the variable '%s' refers to node #%s, which does not exist or is not executed before this node", selector.Name, selector.Id)
+	}
+
+	knownVars, restricted := getNodeScopedVariableTypes(scopeNode)
+	if !restricted {
+		return nil
+	}
+
+	valueType, ok := knownVars[selector.Name]
+	if !ok {
+		return fmt.Errorf("unknown variable '%s' of node #%s", selector.Name, selector.Id)
+	}
+
+	// 条件分支求值时，所有变量都将被转换为字符串，因此以下情况也是合法的：
+	//   - 日期时间类型的变量作为字符串使用；
+	//   - 字符串类型的变量作为以半角分号分隔的列表使用。
+	switch {
+	case selector.Type == "", string(selector.Type) == valueType:
+	case valueType == stateValTypeDateTime && selector.Type == expr.String:
+	case valueType == stateValTypeString && selector.Type == expr.List:
+	default:
+		return fmt.Errorf("the variable '%s' of node #%s is a %s, but is used as a %s", selector.Name, selector.Id, valueType, selector.Type)
+	}
+
+	return nil
+}
+
+func (v *graphValidator) validateScriptNode(vCtx *graphValidationContext, pos int) {
+	node := vCtx.nodes[pos].node
+	nodeCfg := node.Data.Config.AsScript()
+
+	if strings.TrimSpace(nodeCfg.Source) == "" {
+		vCtx.errs.AddNodeError(node, "source", "the script source is required")
+	} else if _, err := compileScript(node.Id, nodeCfg.Source); err != nil {
+		vCtx.errs.AddNodeError(node, "source", err.Error())
+	}
+
+	if nodeCfg.TimeLimit <= 0 {
+		vCtx.errs.AddNodeError(node, "timeLimit", "the time limit must be greater than 0")
+	}
+	if nodeCfg.HeapGuard <= 0 {
+		vCtx.errs.AddNodeError(node, "memoryLimit", "the heap growth guard must be greater than 0 (it is a best-effort guard on the heap growth of the whole process, not a per-script memory limit)")
+	}
+	if nodeCfg.AllowFileSystem && !lo.SomeBy(nodeCfg.AllowedPaths, func(p string) bool { return strings.TrimSpace(p) != "" }) {
+		vCtx.errs.AddNodeError(node, "allowedPaths", "at least one allowed path is required when the file system access is allowed")
+	}
+}
+
+func (v *graphValidator) validateHttpRequestNode(vCtx *graphValidationContext, pos int) {
+	node := vCtx.nodes[pos].node
+	nodeCfg := node.Data.Config.AsHttpRequest()
+
+	if !httpRequestAllowedMethods[strings.ToUpper(nodeCfg.Method)] {
+		vCtx.errs.AddNodeError(node, "method", fmt.Sprintf("unsupported http request method '%s'", nodeCfg.Method))
+	}
+
+	if nodeCfg.Url == "" && nodeCfg.ProviderAccessId == "" {
+		vCtx.errs.AddNodeError(node, "url", "the url is required")
+	}
+	if err := parseTemplate("url", nodeCfg.Url); err != nil {
+		vCtx.errs.AddNodeError(node, "url", err.Error())
+	}
+	if err := parseTemplate("headers", nodeCfg.Headers); err != nil {
+		vCtx.errs.AddNodeError(node, "headers", err.Error())
+	}
+	if err := parseTemplate("body", nodeCfg.Body); err != nil {
+		vCtx.errs.AddNodeError(node, "body", err.Error())
+	}
+
+	if _, ok := v.checkProviderAccess(vCtx, node, "providerAccessId", nodeCfg.ProviderAccessId, string(domain.AccessProviderTypeWebhook)); !ok {
+		return
+	}
+
+	if nodeCfg.Timeout <= 0 {
+		vCtx.errs.AddNodeError(node, "timeout", "the timeout must be greater than 0")
+	}
+
+	for _, pattern := range nodeCfg.ExpectedStatus {
+		if err := validateHttpStatusCodePattern(pattern); err != nil {
+			vCtx.errs.AddNodeError(node, "expectedStatus", err.Error())
+		}
+	}
+
+	names := make(map[string]struct{})
+	for i, extractor := range nodeCfg.Extractors {
+		field := fmt.Sprintf("extractors[%d]", i)
+		if extractor == nil || extractor.Name == "" {
+			vCtx.errs.AddNodeError(node, field, "the variable name is required")
+			continue
+		}
+
+		if _, ok := names[extractor.Name]; ok {
+			vCtx.errs.AddNodeError(node, field, fmt.Sprintf("duplicate variable name '%s'", extractor.Name))
+		}
+		names[extractor.Name] = struct{}{}
+
+		if _, err := xjsonpath.Compile(extractor.Path); err != nil {
+			vCtx.errs.AddNodeError(node, field, err.Error())
+		}
+
+		switch extractor.ValueType {
+		case "", stateValTypeString, stateValTypeNumber, stateValTypeBoolean, stateValTypeDateTime:
+		default:
+			vCtx.errs.AddNodeError(node, field, fmt.Sprintf("unsupported value type '%s'", extractor.ValueType))
+		}
+	}
+}
+
+func (v *graphValidator) validateSetVariableNode(vCtx *graphValidationContext, pos int) {
+	node := vCtx.nodes[pos].node
+
+	// 解析失败时 AsSetVariable 将返回空列表，因此这里需要自行解析以报告错误
+	assignments := make([]*domain.WorkflowNodeSetVariableAssignment, 0)
+	if raw := node.Data.Config["assignments"]; raw != nil {
+		assignmentsRaw, _ := json.Marshal(raw)
+		if err := json.Unmarshal(assignmentsRaw, &assignments); err != nil {
+			vCtx.errs.AddNodeError(node, "assignments", fmt.Sprintf("failed to parse assignments: %s", err.Error()))
+			return
+		}
+	}
+	if len(assignments) == 0 {
+		vCtx.errs.AddNodeError(node, "assignments", "at least one assignment is required")
+		return
+	}
+
+	names := make(map[string]struct{})
+	for i, assignment := range assignments {
+		field := fmt.Sprintf("assignments[%d]", i)
+		if assignment == nil {
+			vCtx.errs.AddNodeError(node, field, "the assignment is empty")
+			continue
+		}
+
+		if assignment.Name == "" {
+			vCtx.errs.AddNodeError(node, field+".name", "the variable name is required")
+		} else if slices.Contains(reservedVariableKeys, assignment.Name) {
+			vCtx.errs.AddNodeError(node, field+".name", fmt.Sprintf("the variable '%s' is reserved", assignment.Name))
+		} else if _, ok := names[assignment.Name]; ok {
+			vCtx.errs.AddNodeError(node, field+".name", fmt.Sprintf("duplicate variable '%s'", assignment.Name))
+		} else {
+			names[assignment.Name] = struct{}{}
+		}
+
+		switch assignment.ValueType {
+		case "", stateValTypeString, stateValTypeNumber, stateValTypeBoolean, stateValTypeDateTime:
+		default:
+			vCtx.errs.AddNodeError(node, field+".valueType", fmt.Sprintf("unsupported value type '%s'", assignment.ValueType))
+			continue
+		}
+
+		switch assignment.Source {
+		case "", domain.WorkflowSetVariableSourceConstant:
+			if _, err := parseStateValue(assignment.Value, assignment.ValueType); err != nil {
+				vCtx.errs.AddNodeError(node, field+".value", err.Error())
+			}
+
+		case domain.WorkflowSetVariableSourceTemplate:
+			if err := parseTemplate(assignment.Name, assignment.Value); err != nil {
+				vCtx.errs.AddNodeError(node, field+".value", err.Error())
+			}
+
+		case domain.WorkflowSetVariableSourceExpression:
+			if assignment.Expression == nil {
+				vCtx.errs.AddNodeError(node, field+".expression", "the expression is required")
+				continue
+			}
+
+			if _, err := expr.InferType(assignment.Expression, func(selector expr.ExprValueSelector) error {
+				return v.resolveVariableSelector(vCtx, pos, selector)
+			}); err != nil {
+				vCtx.errs.AddNodeError(node, field+".expression", err.Error())
+			}
+
+		default:
+			vCtx.errs.AddNodeError(node, field+".source", fmt.Sprintf("unsupported value source '%s'", assignment.Source))
+		}
+	}
+}
+
+func (v *graphValidator) validateApprovalNode(vCtx *graphValidationContext, pos int) {
+	entry := vCtx.nodes[pos]
+	node := entry.node
+	nodeCfg := node.Data.Config.AsApproval()
+
+	// 等待审批时运行将被挂起，而并行分支及循环体无法从中途续跑
+	for _, ancestor := range entry.ancestors {
+		if ancestor.Type == NodeTypeParallel || ancestor.Type == NodeTypeForEach {
+			vCtx.errs.AddNodeError(node, "", fmt.Sprintf("the approval node can not be used inside a %s node", ancestor.Type))
+			break
+		}
+	}
+
+	if nodeCfg.Timeout <= 0 {
+		vCtx.errs.AddNodeError(node, "timeout", "the timeout must be greater than 0")
+	}
+
+	if nodeCfg.Provider == "" {
+		vCtx.errs.AddNodeError(node, "provider", "the provider is required")
+		return
+	}
+
+	if err := parseTemplate("subject", nodeCfg.Subject); err != nil {
+		vCtx.errs.AddNodeError(node, "subject", err.Error())
+	}
+	if err := parseTemplate("message", nodeCfg.Message); err != nil {
+		vCtx.errs.AddNodeError(node, "message", err.Error())
+	}
+
+	providerAccess, ok := v.checkProviderAccess(vCtx, node, "providerAccessId", nodeCfg.ProviderAccessId, nodeCfg.Provider)
+	if !ok {
+		return
+	}
+
+	notifier := notify.NewClient()
+	notifyReq := &notify.SendNotificationRequest{
+		Provider:               domain.NotificationProviderType(nodeCfg.Provider),
+		ProviderAccessConfig:   v.getAccessConfig(providerAccess),
+		ProviderExtendedConfig: nodeCfg.ProviderConfig,
+	}
+	if err := notifier.ValidateSendNotificationRequest(notifyReq); err != nil {
+		vCtx.errs.AddNodeError(node, "providerConfig", err.Error())
+	}
+}
+
+func (v *graphValidator) validateBizApplyNode(vCtx *graphValidationContext, pos int) {
+	node := vCtx.nodes[pos].node
+	nodeCfg := node.Data.Config.AsBizApply()
+
+	if len(nodeCfg.Domains) == 0 && len(nodeCfg.IPAddrs) == 0 {
+		vCtx.errs.AddNodeError(node, "domains", "at least one domain or ip address is required")
+	}
+	if nodeCfg.ContactEmail == "" {
+		vCtx.errs.AddNodeError(node, "contactEmail", "the contact email is required")
+	}
+	if nodeCfg.ChallengeType == "" {
+		vCtx.errs.AddNodeError(node, "challengeType", "the challenge type is required")
+	}
+	if nodeCfg.Provider == "" {
+		vCtx.errs.AddNodeError(node, "provider", "the provider is required")
+		return
+	}
+
+	providerAccess, ok := v.checkProviderAccess(vCtx, node, "providerAccessId", nodeCfg.ProviderAccessId, nodeCfg.Provider)
+	if !ok {
+		return
+	}
+	if nodeCfg.CAProvider != "" {
+		if _, ok := v.checkProviderAccess(vCtx, node, "caProviderAccessId", nodeCfg.CAProviderAccessId, nodeCfg.CAProvider); !ok {
+			return
+		}
+	}
+
+	obtainReq := &certacme.ObtainCertificateRequest{
+		ChallengeType:          nodeCfg.ChallengeType,
+		Provider:               domain.ACMEChallengeProviderType(nodeCfg.Provider),
+		ProviderAccessConfig:   v.getAccessConfig(providerAccess),
+		ProviderExtendedConfig: nodeCfg.ProviderConfig,
+		DnsPropagationTimeout:  nodeCfg.DnsPropagationTimeout,
+		DnsTTL:                 nodeCfg.DnsTTL,
+	}
+	if err := certacme.ValidateObtainCertificateRequest(obtainReq); err != nil {
+		vCtx.errs.AddNodeError(node, "providerConfig", err.Error())
+	}
+}
+
+func (v *graphValidator) validateBizDeployNode(vCtx *graphValidationContext, pos int) {
+	node := vCtx.nodes[pos].node
+	nodeCfg := node.Data.Config.AsBizDeploy()
+
+	if nodeCfg.CertificateOutputNodeId == "" {
+		vCtx.errs.AddNodeError(node, "certificateOutputNodeId", "the certificate output node is required")
+	} else if _, ok := vCtx.positions[nodeCfg.CertificateOutputNodeId]; !ok {
+		vCtx.errs.AddNodeError(node, "certificateOutputNodeId", fmt.Sprintf("the node #%s does not exist", nodeCfg.CertificateOutputNodeId))
+	} else if refNode, ok := vCtx.lookupPreceding(pos, nodeCfg.CertificateOutputNodeId); !ok {
+		vCtx.errs.AddNodeError(node, "certificateOutputNodeId", fmt.Sprintf("the node #%s is not executed before this node", nodeCfg.CertificateOutputNodeId))
+	} else if refNode.Type != NodeTypeBizApply && refNode.Type != NodeTypeBizUpload && refNode.Type != NodeTypeCallWorkflow {
+		vCtx.errs.AddNodeError(node, "certificateOutputNodeId", fmt.Sprintf("the node #%s does not output any certificate", nodeCfg.CertificateOutputNodeId))
+	}
+
+	if nodeCfg.Provider == "" {
+		vCtx.errs.AddNodeError(node, "provider", "the provider is required")
+		return
+	}
+
+	providerAccess, ok := v.checkProviderAccess(vCtx, node, "providerAccessId", nodeCfg.ProviderAccessId, nodeCfg.Provider)
+	if !ok {
+		return
+	}
+
+	deployer := certmgmt.NewClient()
+	deployReq := &certmgmt.DeployCertificateRequest{
+		Provider:               domain.DeploymentProviderType(nodeCfg.Provider),
+		ProviderAccessConfig:   v.getAccessConfig(providerAccess),
+		ProviderExtendedConfig: nodeCfg.ProviderConfig,
+	}
+	if err := deployer.ValidateDeployCertificateRequest(deployReq); err != nil {
+		vCtx.errs.AddNodeError(node, "providerConfig", err.Error())
+	}
+}
+
+func (v *graphValidator) validateBizNotifyNode(vCtx *graphValidationContext, pos int) {
+	node := vCtx.nodes[pos].node
+	nodeCfg := node.Data.Config.AsBizNotify()
+
+	if nodeCfg.Provider == "" {
+		vCtx.errs.AddNodeError(node, "provider", "the provider is required")
+		return
+	}
+
+	if err := parseTemplate("subject", nodeCfg.Subject); err != nil {
+		vCtx.errs.AddNodeError(node, "subject", err.Error())
+	}
+	if err := parseTemplate("message", nodeCfg.Message); err != nil {
+		vCtx.errs.AddNodeError(node, "message", err.Error())
+	}
+
+	providerAccess, ok := v.checkProviderAccess(vCtx, node, "providerAccessId", nodeCfg.ProviderAccessId, nodeCfg.Provider)
+	if !ok {
+		return
+	}
+
+	notifier := notify.NewClient()
+	notifyReq := &notify.SendNotificationRequest{
+		Provider:               domain.NotificationProviderType(nodeCfg.Provider),
+		ProviderAccessConfig:   v.getAccessConfig(providerAccess),
+		ProviderExtendedConfig: nodeCfg.ProviderConfig,
+	}
+	if err := notifier.ValidateSendNotificationRequest(notifyReq); err != nil {
+		vCtx.errs.AddNodeError(node, "providerConfig", err.Error())
+	}
+}
+
+// 检查节点引用的授权记录是否存在，且其提供商与节点的提供商相匹配。
+// 各类提供商的常量值中，短横线前的部分始终等于授权提供商类型。
+func (v *graphValidator) checkProviderAccess(vCtx *graphValidationContext, node *Node, field string, accessId string, provider string) (*domain.Access, bool) {
+	if accessId == "" {
+		return nil, true
+	}
+
+	access, err := v.accessRepo.GetById(vCtx.ctx, accessId)
+	if err != nil {
+		if domain.IsRecordNotFoundError(err) {
+			vCtx.errs.AddNodeError(node, field, fmt.Sprintf("the access #%s does not exist", accessId))
+		} else {
+			vCtx.errs.AddNodeError(node, field, fmt.Sprintf("failed to get access #%s record: %s", accessId, err.Error()))
+		}
+		return nil, false
+	}
+
+	expectedAccessProvider, _, _ := strings.Cut(provider, "-")
+	if access.Provider != expectedAccessProvider {
+		vCtx.errs.AddNodeError(node, field, fmt.Sprintf("the access #%s is of provider '%s', which does not match provider '%s'", accessId, access.Provider, provider))
+		return access, false
+	}
+
+	return access, true
+}
+
+func (v *graphValidator) getAccessConfig(access *domain.Access) map[string]any {
+	if access == nil || access.Config == nil {
+		return make(map[string]any)
+	}
+
+	return access.Config
+}
+
+type graphValidationEntry struct {
+	node      *Node
+	disabled  bool    // 节点自身或其任一祖先节点是否已禁用
+	ancestors []*Node // 节点的祖先节点，由外向内排列
+}
+
+type graphValidationContext struct {
+	ctx       context.Context
+	nodes     []graphValidationEntry // 按执行顺序（即先序遍历）展开的所有节点
+	positions map[string]int         // 节点 ID 到其在 nodes 中位置的映射
+	errs      *domain.WorkflowGraphValidationError
+}
+
+func (c *graphValidationContext) flatten(blocks []*Node, disabled bool, ancestors []*Node) {
+	for _, node := range blocks {
+		nodeDisabled := disabled || node.Data.Disabled
+		c.nodes = append(c.nodes, graphValidationEntry{node: node, disabled: nodeDisabled, ancestors: ancestors})
+		if len(node.Blocks) > 0 {
+			c.flatten(node.Blocks, nodeDisabled, append(slices.Clone(ancestors), node))
+		}
+	}
+}
+
+// 查找在指定位置之前执行的节点。
+// 仅接受该节点的祖先节点，以及该节点或其祖先节点的前序兄弟节点；条件、异常处理、并行等节点的各分支互为兄弟节点，但并非先后执行，因此不予接受。
+func (c *graphValidationContext) lookupPreceding(pos int, nodeId string) (*Node, bool) {
+	refPos, ok := c.positions[nodeId]
+	if !ok || refPos >= pos {
+		return nil, false
+	}
+
+	entry := c.nodes[pos]
+	refEntry := c.nodes[refPos]
+	if slices.Contains(entry.ancestors, refEntry.node) {
+		return refEntry.node, true
+	}
+
+	if len(refEntry.ancestors) > 0 {
+		refParent := refEntry.ancestors[len(refEntry.ancestors)-1]
+		if !slices.Contains(entry.ancestors, refParent) {
+			return nil, false
+		}
+
+		switch refParent.Type {
+		case NodeTypeCondition, NodeTypeTryCatch, NodeTypeParallel:
+			return nil, false
+		}
+	}
+
+	return refEntry.node, true
+}
+
+// 获取指定节点可提供的作用域变量及其值类型。
+// 第二个返回值为 false 时表示该节点的变量是动态的，无法静态校验。
+func getNodeScopedVariableTypes(node *Node) (map[string]string, bool) {
+	vars := map[string]string{
+		stateVarKeyNodeId:   stateValTypeString,
+		stateVarKeyNodeName: stateValTypeString,
+	}
+
+	certificateVars := map[string]string{
+		stateVarKeyCertificateDomain:          stateValTypeString,
+		stateVarKeyCertificateDomains:         stateValTypeString,
+		stateVarKeyCertificateCommonName:      stateValTypeString,
+		stateVarKeyCertificateSubjectAltNames: stateValTypeString,
+		stateVarKeyCertificateNotBefore:       stateValTypeDateTime,
+		stateVarKeyCertificateNotAfter:        stateValTypeDateTime,
+		stateVarKeyCertificateHoursLeft:       stateValTypeNumber,
+		stateVarKeyCertificateDaysLeft:        stateValTypeNumber,
+		stateVarKeyCertificateValidity:        stateValTypeBoolean,
+	}
+
+	switch node.Type {
+	case NodeTypeStart, NodeTypeCallWorkflow, NodeTypeScript:
+		// 开始节点的变量来自运行时输入，调用工作流节点的变量来自被调用的工作流，脚本节点的变量由脚本自行设置
+		return nil, false
+
+	case NodeTypeBizApply, NodeTypeBizUpload:
+		vars[stateVarKeyNodeSkipped] = stateValTypeBoolean
+		for k, t := range certificateVars {
+			vars[k] = t
+		}
+
+	case NodeTypeBizMonitor:
+		for k, t := range certificateVars {
+			vars[k] = t
+		}
+
+	case NodeTypeBizDeploy:
+		vars[stateVarKeyNodeSkipped] = stateValTypeBoolean
+
+	case NodeTypeHttpRequest:
+		vars[stateVarKeyHttpStatusCode] = stateValTypeNumber
+		for _, extractor := range node.Data.Config.AsHttpRequest().Extractors {
+			if extractor == nil || extractor.Name == "" {
+				continue
+			}
+
+			if extractor.ValueType == "" {
+				// 未指定值类型时，变量的实际类型取决于响应内容
+				return nil, false
+			}
+			vars[extractor.Name] = extractor.ValueType
+		}
+
+	case NodeTypeSetVariable:
+		for _, assignment := range node.Data.Config.AsSetVariable().Assignments {
+			if assignment == nil || assignment.Name == "" {
+				continue
+			}
+
+			valueType := assignment.ValueType
+			if valueType == "" && assignment.Source == domain.WorkflowSetVariableSourceExpression {
+				// 未指定值类型时，变量的实际类型取决于表达式的求值结果
+				if assignment.Expression == nil {
+					return nil, false
+				}
+
+				exprType, err := expr.InferType(assignment.Expression, nil)
+				if err != nil {
+					return nil, false
+				}
+
+				switch exprType {
+				case expr.String, expr.List:
+					valueType = stateValTypeString
+				case expr.Number, expr.Duration:
+					valueType = stateValTypeNumber
+				case expr.Boolean:
+					valueType = stateValTypeBoolean
+				case expr.DateTime:
+					valueType = stateValTypeDateTime
+				default:
+					return nil, false
+				}
+			}
+			vars[assignment.Name] = lo.CoalesceOrEmpty(valueType, stateValTypeString)
+		}
+
+	case NodeTypeApproval:
+		vars[stateVarKeyApprovalDecision] = stateValTypeString
+		vars[stateVarKeyApprovalDecider] = stateValTypeString
+		vars[stateVarKeyApprovalComment] = stateValTypeString
+
+	case NodeTypeForEach:
+		vars[stateVarKeyLoopItem] = stateValTypeString
+		vars[stateVarKeyLoopIndex] = stateValTypeNumber
+		vars[stateVarKeyLoopTotal] = stateValTypeNumber
+		vars[stateVarKeyLoopFailed] = stateValTypeNumber
+		vars[stateVarKeyLoopAttempted] = stateValTypeNumber
+	}
+
+	return vars, true
+}
+
+func NewGraphValidator() GraphValidator {
+	return &graphValidator{
+		accessRepo: repository.NewAccessRepository(),
+	}
+}
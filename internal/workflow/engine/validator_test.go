@@ -0,0 +1,287 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/certimate-go/certimate/internal/domain"
+)
+
+type testAccessRepository struct {
+	accesses map[string]*domain.Access
+}
+
+func (r *testAccessRepository) GetById(ctx context.Context, id string) (*domain.Access, error) {
+	if access, ok := r.accesses[id]; ok {
+		return access, nil
+	}
+
+	return nil, domain.ErrRecordNotFound
+}
+
+func newTestValidationNode(id string, nodeType NodeType, config domain.WorkflowNodeConfig, blocks ...*Node) *Node {
+	return &Node{Id: id, Type: nodeType, Data: domain.WorkflowNodeData{Name: id, Config: config}, Blocks: blocks}
+}
+
+func newTestValidationGraph(nodes ...*Node) *Graph {
+	graph := &Graph{}
+	graph.Nodes = append(graph.Nodes, newTestValidationNode("start", NodeTypeStart, nil))
+	graph.Nodes = append(graph.Nodes, nodes...)
+	graph.Nodes = append(graph.Nodes, newTestValidationNode("end", NodeTypeEnd, nil))
+	return graph
+}
+
+func newTestDeployNode(id string, certificateOutputNodeId string) *Node {
+	return newTestValidationNode(id, NodeTypeBizDeploy, domain.WorkflowNodeConfig{"certificateOutputNodeId": certificateOutputNodeId})
+}
+
+func newTestComparisonExpr(nodeId string, name string, valueType string) map[string]any {
+	return map[string]any{
+		"type":     "comparison",
+		"operator": "eq",
+		"left":     map[string]any{"type": "var", "selector": map[string]any{"id": nodeId, "name": name, "type": valueType}},
+		"right":    map[string]any{"type": "const", "value": "1", "valueType": valueType},
+	}
+}
+
+func TestGraphValidator(t *testing.T) {
+	tests := []struct {
+		name       string
+		graph      *Graph
+		wantErrs   []string // 形如 "节点 ID:字段名"
+		wantNoErrs []string
+	}{
+		{
+			name:     "empty graph",
+			graph:    &Graph{},
+			wantErrs: []string{":"},
+		},
+		{
+			name: "duplicate node id",
+			graph: newTestValidationGraph(
+				newTestValidationNode("apply1", NodeTypeBizApply, nil),
+				newTestValidationNode("apply1", NodeTypeBizApply, nil),
+			),
+			wantErrs: []string{"apply1:"},
+		},
+		{
+			name: "expression referencing preceding node",
+			graph: newTestValidationGraph(
+				newTestValidationNode("apply1", NodeTypeBizApply, nil),
+				newTestValidationNode("cond1", NodeTypeCondition, nil,
+					newTestValidationNode("branch1", NodeTypeBranchBlock, domain.WorkflowNodeConfig{"expression": newTestComparisonExpr("apply1", stateVarKeyCertificateDaysLeft, "number")}),
+					newTestValidationNode("branch2", NodeTypeBranchBlock, domain.WorkflowNodeConfig{"expression": newTestComparisonExpr("apply1", stateVarKeyCertificateDaysLeft, "string")}),
+					newTestValidationNode("branch3", NodeTypeBranchBlock, domain.WorkflowNodeConfig{"expression": newTestComparisonExpr("apply1", "any", "number")}),
+					newTestValidationNode("branch4", NodeTypeBranchBlock, domain.WorkflowNodeConfig{"expression": newTestComparisonExpr("apply2", stateVarKeyCertificateDaysLeft, "number")}),
+				),
+				newTestValidationNode("apply2", NodeTypeBizApply, nil),
+			),
+			wantErrs:   []string{"branch2:expression", "branch3:expression", "branch4:expression"},
+			wantNoErrs: []string{"branch1:expression"},
+		},
+		{
+			name: "expression referencing loop variables",
+			graph: newTestValidationGraph(
+				newTestValidationNode("loop1", NodeTypeForEach, domain.WorkflowNodeConfig{"items": "a;b"},
+					newTestValidationNode("cond1", NodeTypeCondition, nil,
+						newTestValidationNode("branch1", NodeTypeBranchBlock, domain.WorkflowNodeConfig{"expression": newTestComparisonExpr("loop1", stateVarKeyLoopIndex, "number")}),
+					),
+				),
+			),
+			wantNoErrs: []string{"branch1:expression"},
+		},
+		{
+			name: "certificate output node",
+			graph: newTestValidationGraph(
+				newTestDeployNode("deploy1", "apply1"),
+				newTestValidationNode("apply1", NodeTypeBizApply, nil),
+				newTestDeployNode("deploy2", "apply1"),
+				newTestDeployNode("deploy3", "deploy2"),
+				newTestDeployNode("deploy4", "apply0"),
+				newTestDeployNode("deploy5", ""),
+			),
+			wantErrs:   []string{"deploy1:certificateOutputNodeId", "deploy3:certificateOutputNodeId", "deploy4:certificateOutputNodeId", "deploy5:certificateOutputNodeId"},
+			wantNoErrs: []string{"deploy2:certificateOutputNodeId"},
+		},
+		{
+			name: "access provider mismatch",
+			graph: newTestValidationGraph(
+				newTestValidationNode("apply1", NodeTypeBizApply, nil),
+				newTestValidationNode("deploy1", NodeTypeBizDeploy, domain.WorkflowNodeConfig{"certificateOutputNodeId": "apply1", "provider": "aliyun-cdn", "providerAccessId": "access1"}),
+				newTestValidationNode("deploy2", NodeTypeBizDeploy, domain.WorkflowNodeConfig{"certificateOutputNodeId": "apply1", "provider": "aliyun-cdn", "providerAccessId": "access0"}),
+			),
+			wantErrs: []string{"deploy1:providerAccessId", "deploy2:providerAccessId"},
+		},
+		{
+			name: "set variable assignments",
+			graph: newTestValidationGraph(
+				newTestValidationNode("set1", NodeTypeSetVariable, domain.WorkflowNodeConfig{"assignments": []map[string]any{
+					{"name": "a", "value": "1"},
+					{"name": "a", "value": "2"},
+					{"name": stateVarKeyRunId, "value": "3"},
+					{"name": "b", "value": "abc", "valueType": stateValTypeNumber},
+					{"name": "c", "source": "any"},
+				}}),
+				newTestValidationNode("set2", NodeTypeSetVariable, domain.WorkflowNodeConfig{}),
+			),
+			wantErrs:   []string{"set1:assignments[1].name", "set1:assignments[2].name", "set1:assignments[3].value", "set1:assignments[4].source", "set2:assignments"},
+			wantNoErrs: []string{"set1:assignments[0].name"},
+		},
+		{
+			name: "approval inside parallel",
+			graph: newTestValidationGraph(
+				newTestValidationNode("approval1", NodeTypeApproval, nil),
+				newTestValidationNode("parallel1", NodeTypeParallel, nil,
+					newTestValidationNode("block1", NodeTypeParallelBlock, nil,
+						newTestValidationNode("approval2", NodeTypeApproval, nil),
+					),
+				),
+			),
+			wantErrs:   []string{"approval2:"},
+			wantNoErrs: []string{"approval1:"},
+		},
+		{
+			name: "disabled nodes",
+			graph: newTestValidationGraph(
+				&Node{Id: "set1", Type: NodeTypeSetVariable, Data: domain.WorkflowNodeData{Name: "set1", Disabled: true}},
+				&Node{Id: "deploy1", Type: NodeTypeBizDeploy, Data: domain.WorkflowNodeData{Name: "deploy1", Disabled: true}},
+				&Node{
+					Id:     "loop1",
+					Type:   NodeTypeForEach,
+					Data:   domain.WorkflowNodeData{Name: "loop1", Disabled: true},
+					Blocks: []*Node{newTestDeployNode("deploy2", "")},
+				},
+			),
+			wantNoErrs: []string{"set1:assignments", "deploy1:certificateOutputNodeId", "deploy2:certificateOutputNodeId"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			validator := &graphValidator{
+				accessRepo: &testAccessRepository{
+					accesses: map[string]*domain.Access{"access1": {Meta: domain.Meta{Id: "access1"}, Provider: "tencentcloud"}},
+				},
+			}
+
+			err := validator.Validate(context.Background(), tt.graph)
+			gotErrs := make([]string, 0)
+			if err != nil {
+				var verr *domain.WorkflowGraphValidationError
+				if !errors.As(err, &verr) {
+					t.Fatalf("Validate() error = %v, want a graph validation error", err)
+				}
+
+				if len(verr.Errors) > 0 {
+					gotErrs = append(gotErrs, ":")
+				}
+				for _, nodeErr := range verr.Nodes {
+					for _, fieldErr := range nodeErr.Errors {
+						gotErrs = append(gotErrs, fmt.Sprintf("%s:%s", nodeErr.NodeId, fieldErr.Field))
+					}
+				}
+			}
+
+			for _, want := range tt.wantErrs {
+				if !slices.Contains(gotErrs, want) {
+					t.Errorf("Validate() errors = %v, want '%s'", gotErrs, want)
+				}
+			}
+			for _, want := range tt.wantNoErrs {
+				if slices.Contains(gotErrs, want) {
+					t.Errorf("Validate() errors = %v, want no '%s'", gotErrs, want)
+				}
+			}
+		})
+	}
+}
+
+func TestGraphValidatorLookupPreceding(t *testing.T) {
+	graph := newTestValidationGraph(
+		newTestValidationNode("apply1", NodeTypeBizApply, nil),
+		newTestValidationNode("cond1", NodeTypeCondition, nil,
+			newTestValidationNode("branch1", NodeTypeBranchBlock, nil,
+				newTestValidationNode("apply2", NodeTypeBizApply, nil),
+				newTestDeployNode("deploy1", "apply1"),
+				newTestDeployNode("deploy2", "apply2"),
+			),
+			newTestValidationNode("branch2", NodeTypeBranchBlock, nil,
+				newTestDeployNode("deploy3", "apply2"),
+			),
+		),
+		newTestValidationNode("parallel1", NodeTypeParallel, nil,
+			newTestValidationNode("block1", NodeTypeParallelBlock, nil,
+				newTestValidationNode("apply3", NodeTypeBizApply, nil),
+			),
+			newTestValidationNode("block2", NodeTypeParallelBlock, nil,
+				newTestDeployNode("deploy4", "apply3"),
+			),
+		),
+		newTestValidationNode("try1", NodeTypeTryCatch, nil,
+			newTestValidationNode("tryblock1", NodeTypeTryBlock, nil,
+				newTestValidationNode("apply4", NodeTypeBizApply, nil),
+			),
+			newTestValidationNode("catchblock1", NodeTypeCatchBlock, nil,
+				newTestDeployNode("deploy5", "apply4"),
+			),
+		),
+		newTestDeployNode("deploy6", "apply2"),
+		newTestDeployNode("deploy7", "apply1"),
+	)
+
+	tests := []struct {
+		nodeId string
+		refId  string
+		want   bool
+	}{
+		// 前序兄弟节点
+		{"deploy7", "apply1", true},
+		{"deploy7", "cond1", true},
+		// 祖先节点的前序兄弟节点
+		{"deploy1", "apply1", true},
+		// 同一分支中的前序节点
+		{"deploy2", "apply2", true},
+		// 祖先节点
+		{"deploy1", "cond1", true},
+		{"deploy1", "branch1", true},
+		// 后序节点
+		{"apply1", "deploy7", false},
+		// 自身
+		{"deploy7", "deploy7", false},
+		// 条件节点的其他分支
+		{"deploy3", "apply2", false},
+		{"deploy3", "branch1", false},
+		// 并行节点的其他分支
+		{"deploy4", "apply3", false},
+		{"deploy4", "block1", false},
+		// 异常处理节点的其他分支
+		{"deploy5", "apply4", false},
+		// 前序兄弟节点中的子节点
+		{"deploy6", "apply2", false},
+		// 不存在的节点
+		{"deploy7", "apply0", false},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s refers to %s", tt.nodeId, tt.refId), func(t *testing.T) {
+			vCtx := &graphValidationContext{
+				ctx:       context.Background(),
+				nodes:     make([]graphValidationEntry, 0),
+				positions: make(map[string]int),
+				errs:      &domain.WorkflowGraphValidationError{},
+			}
+			vCtx.flatten(graph.Nodes, false, nil)
+			for i, entry := range vCtx.nodes {
+				vCtx.positions[entry.node.Id] = i
+			}
+
+			node, ok := vCtx.lookupPreceding(vCtx.positions[tt.nodeId], tt.refId)
+			if ok != tt.want {
+				t.Errorf("lookupPreceding() ok = %v, want %v", ok, tt.want)
+			} else if ok && node.Id != tt.refId {
+				t.Errorf("lookupPreceding() node = %s, want %s", node.Id, tt.refId)
+			}
+		})
+	}
+}
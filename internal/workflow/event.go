@@ -0,0 +1,127 @@
+package workflow
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/certimate-go/certimate/internal/app"
+	"github.com/certimate-go/certimate/internal/domain"
+	"github.com/certimate-go/certimate/internal/domain/dtos"
+	xmaps "github.com/certimate-go/certimate/pkg/utils/maps"
+)
+
+const (
+	eventVarKeyPrefix     = "event"
+	eventVarKeyType       = "event.type"
+	eventVarKeyChainDepth = "event.chainDepth"
+
+	// 由事件触发的工作流再次触发其他工作流时的最大链路深度，以免相互触发导致死循环
+	eventChainMaxDepth = 8
+)
+
+func (s *WorkflowService) subscribeEvents() {
+	for _, eventType := range []domain.EventType{
+		domain.EventTypeCertificateExpiring,
+		domain.EventTypeCertificateRevoked,
+		domain.EventTypeWorkflowRunFinished,
+	} {
+		s.eventBus.Subscribe(eventType, s.onEvent)
+	}
+}
+
+func (s *WorkflowService) onEvent(ctx context.Context, event *domain.Event) {
+	workflows, err := s.workflowRepo.ListEnabledEventTriggered(ctx, event.Type)
+	if err != nil {
+		app.GetLogger().Error(fmt.Sprintf("failed to list workflows triggered by event '%s'", event.Type), slog.Any("error", err))
+		return
+	}
+
+	for _, workflow := range workflows {
+		if !matchWorkflowTriggerEvent(workflow, event) {
+			continue
+		}
+
+		// 证书即将过期事件每天都会发布，同一证书仅触发一次
+		certificateId := xmaps.GetString(event.Data, "certificateId")
+		validityNotAfter, _ := event.Data["validityNotAfter"].(time.Time)
+		if event.Type == domain.EventTypeCertificateExpiring {
+			if fired, err := s.workflowRepo.MarkCertificateExpiringFired(ctx, workflow.Id, certificateId, validityNotAfter); err != nil {
+				app.GetLogger().Warn(fmt.Sprintf("failed to mark workflow #%s as triggered by certificate #%s", workflow.Id, certificateId), slog.Any("error", err))
+				continue
+			} else if !fired {
+				continue
+			}
+		}
+
+		app.GetLogger().Info(fmt.Sprintf("workflow #%s is triggered by event '%s' ...", workflow.Id, event.Type))
+
+		_, err := s.StartRun(ctx, &dtos.WorkflowStartRunReq{
+			WorkflowId: workflow.Id,
+			RunTrigger: domain.WorkflowTriggerTypeEvent,
+			RunInputs:  buildEventRunInputs(event),
+		})
+		if err != nil {
+			app.GetLogger().Warn(fmt.Sprintf("failed to start event-triggered run for workflow #%s", workflow.Id), slog.Any("error", err))
+
+			// 启动失败时清除触发记录，以便下次检查时重新触发
+			if event.Type == domain.EventTypeCertificateExpiring {
+				if err := s.workflowRepo.ClearCertificateExpiringFired(ctx, workflow.Id, certificateId, validityNotAfter); err != nil {
+					app.GetLogger().Warn(fmt.Sprintf("failed to unmark workflow #%s as triggered by certificate #%s", workflow.Id, certificateId), slog.Any("error", err))
+				}
+			}
+		}
+	}
+}
+
+func matchWorkflowTriggerEvent(workflow *domain.Workflow, event *domain.Event) bool {
+	config := workflow.TriggerEvent
+	if config == nil || config.EventType != event.Type {
+		return false
+	}
+
+	switch event.Type {
+	case domain.EventTypeCertificateExpiring:
+		return xmaps.GetInt(event.Data, "daysLeft") <= config.ExpiringDays
+
+	case domain.EventTypeCertificateRevoked:
+		return true
+
+	case domain.EventTypeWorkflowRunFinished:
+		{
+			sourceWorkflowId := xmaps.GetString(event.Data, "workflowId")
+			if sourceWorkflowId == workflow.Id {
+				return false
+			} else if config.WorkflowId != "" && config.WorkflowId != sourceWorkflowId {
+				return false
+			}
+
+			runStatus := config.RunStatus
+			if runStatus == "" {
+				runStatus = domain.WorkflowRunStatusTypeFailed
+			}
+			if xmaps.GetString(event.Data, "status") != runStatus.String() {
+				return false
+			}
+
+			return xmaps.GetInt(event.Data, "chainDepth") < eventChainMaxDepth
+		}
+	}
+
+	return false
+}
+
+// 将事件数据展开为工作流变量。
+// 例如证书即将过期事件将被展开为 "event.type"、"event.certificateId"、"event.daysLeft" 等。
+func buildEventRunInputs(event *domain.Event) map[string]any {
+	inputs := make(map[string]any, len(event.Data)+1)
+	inputs[eventVarKeyType] = event.Type.String()
+	for key, value := range event.Data {
+		if t, ok := value.(time.Time); ok {
+			value = t.Format(time.RFC3339)
+		}
+		inputs[eventVarKeyPrefix+"."+key] = value
+	}
+	return inputs
+}
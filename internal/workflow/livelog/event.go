@@ -0,0 +1,42 @@
+package livelog
+
+import (
+	"time"
+
+	"github.com/certimate-go/certimate/internal/domain"
+)
+
+func NewLogEvent(log *domain.WorkflowLog) *domain.WorkflowRunEvent {
+	return &domain.WorkflowRunEvent{
+		Type:      domain.WorkflowRunEventTypeLog,
+		RunId:     log.RunId,
+		NodeId:    log.NodeId,
+		NodeName:  log.NodeName,
+		Log:       log,
+		Timestamp: time.UnixMilli(log.TimestampMilli),
+	}
+}
+
+func NewNodeEvent(eventType domain.WorkflowRunEventType, runId string, nodeId string, nodeName string, err error) *domain.WorkflowRunEvent {
+	event := &domain.WorkflowRunEvent{
+		Type:      eventType,
+		RunId:     runId,
+		NodeId:    nodeId,
+		NodeName:  nodeName,
+		Timestamp: time.Now(),
+	}
+	if err != nil {
+		event.Error = err.Error()
+	}
+	return event
+}
+
+func NewStatusEvent(runId string, status domain.WorkflowRunStatusType, errmsg string) *domain.WorkflowRunEvent {
+	return &domain.WorkflowRunEvent{
+		Type:      domain.WorkflowRunEventTypeStatus,
+		RunId:     runId,
+		Status:    status,
+		Error:     errmsg,
+		Timestamp: time.Now(),
+	}
+}
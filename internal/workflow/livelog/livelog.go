@@ -0,0 +1,207 @@
+package livelog
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/certimate-go/certimate/internal/domain"
+)
+
+const (
+	// 每个运行最多保留的事件数，超出后将丢弃最早的事件
+	journalMaxEvents = 10000
+	// 运行结束后事件日志的保留时长，以便客户端断线后续传
+	journalRetention = 5 * time.Minute
+	// 订阅者的缓冲区大小，缓冲区已满时将断开该订阅者，由其携带最后收到的事件 ID 重新订阅
+	subscriberBufferSize = 256
+)
+
+// Hub 维护本进程中执行的各个运行的实时事件日志。
+//
+// 事件日志仅存在于执行该运行的进程中；对于由其他节点执行、或已结束较久的运行，应从数据库中读取已持久化的日志。
+type Hub interface {
+	// 开启运行的事件日志，已有时将替换之。
+	// initial 为此前已持久化的日志等（例如续跑前的日志），将作为最初的事件。
+	Open(runId string, initial []*domain.WorkflowRunEvent)
+	// 关闭运行的事件日志，订阅者将在收到全部事件后结束。
+	// 关闭后事件日志仍将保留一段时间，以便客户端断线后续传。
+	Close(runId string)
+	// 发布事件，运行尚未开启事件日志时将被忽略。
+	Publish(event *domain.WorkflowRunEvent)
+	// 订阅运行的事件。
+	// 返回值依次为：指定事件 ID 之后的历史事件、后续事件的通道（事件日志关闭或订阅者过慢时将被关闭）、用于取消订阅的函数、以及运行是否已开启事件日志。
+	// 事件 ID 无法识别时，将返回全部历史事件。
+	Subscribe(runId string, lastEventId string) (backlog []*domain.WorkflowRunEvent, events <-chan *domain.WorkflowRunEvent, unsubscribe func(), ok bool)
+}
+
+type journal struct {
+	mtx         sync.Mutex
+	epoch       string // 用于区分同一运行的多次执行，以免续传时混淆事件 ID
+	seq         int64
+	events      []*domain.WorkflowRunEvent
+	closed      bool
+	subscribers map[chan *domain.WorkflowRunEvent]struct{}
+}
+
+type hub struct {
+	journals    map[string]*journal // Key: RunId
+	journalsMtx sync.RWMutex
+}
+
+var _ Hub = (*hub)(nil)
+
+func (h *hub) Open(runId string, initial []*domain.WorkflowRunEvent) {
+	j := &journal{
+		epoch:       strconv.FormatInt(time.Now().UnixNano(), 36),
+		events:      make([]*domain.WorkflowRunEvent, 0, len(initial)),
+		subscribers: make(map[chan *domain.WorkflowRunEvent]struct{}),
+	}
+	for _, event := range initial {
+		j.append(event)
+	}
+
+	h.journalsMtx.Lock()
+	prev := h.journals[runId]
+	h.journals[runId] = j
+	h.journalsMtx.Unlock()
+
+	if prev != nil {
+		prev.close()
+	}
+}
+
+func (h *hub) Close(runId string) {
+	h.journalsMtx.RLock()
+	j := h.journals[runId]
+	h.journalsMtx.RUnlock()
+
+	if j == nil {
+		return
+	}
+
+	j.close()
+
+	time.AfterFunc(journalRetention, func() {
+		h.journalsMtx.Lock()
+		defer h.journalsMtx.Unlock()
+
+		// 期间可能已被再次开启
+		if h.journals[runId] == j {
+			delete(h.journals, runId)
+		}
+	})
+}
+
+func (h *hub) Publish(event *domain.WorkflowRunEvent) {
+	if event == nil {
+		return
+	}
+
+	h.journalsMtx.RLock()
+	j := h.journals[event.RunId]
+	h.journalsMtx.RUnlock()
+
+	if j == nil {
+		return
+	}
+
+	j.mtx.Lock()
+	defer j.mtx.Unlock()
+
+	if j.closed {
+		return
+	}
+
+	j.append(event)
+	for ch := range j.subscribers {
+		select {
+		case ch <- event:
+		default:
+			delete(j.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+func (h *hub) Subscribe(runId string, lastEventId string) ([]*domain.WorkflowRunEvent, <-chan *domain.WorkflowRunEvent, func(), bool) {
+	h.journalsMtx.RLock()
+	j := h.journals[runId]
+	h.journalsMtx.RUnlock()
+
+	if j == nil {
+		return nil, nil, func() {}, false
+	}
+
+	j.mtx.Lock()
+	defer j.mtx.Unlock()
+
+	backlog := j.eventsAfter(lastEventId)
+
+	ch := make(chan *domain.WorkflowRunEvent, subscriberBufferSize)
+	if j.closed {
+		close(ch)
+		return backlog, ch, func() {}, true
+	}
+
+	j.subscribers[ch] = struct{}{}
+	unsubscribe := func() {
+		j.mtx.Lock()
+		defer j.mtx.Unlock()
+
+		if _, ok := j.subscribers[ch]; ok {
+			delete(j.subscribers, ch)
+			close(ch)
+		}
+	}
+
+	return backlog, ch, unsubscribe, true
+}
+
+// 追加事件并为之分配 ID，调用方须持有锁。
+func (j *journal) append(event *domain.WorkflowRunEvent) {
+	j.seq++
+	event.Id = fmt.Sprintf("%s-%d", j.epoch, j.seq)
+
+	j.events = append(j.events, event)
+	if len(j.events) > journalMaxEvents {
+		j.events = j.events[len(j.events)-journalMaxEvents:]
+	}
+}
+
+// 返回指定事件 ID 之后的事件，调用方须持有锁。
+func (j *journal) eventsAfter(lastEventId string) []*domain.WorkflowRunEvent {
+	seq := int64(0)
+	if epoch, s, ok := strings.Cut(lastEventId, "-"); ok && epoch == j.epoch {
+		seq, _ = strconv.ParseInt(s, 10, 64)
+	}
+
+	// 事件 ID 中的序号是连续的，据之定位
+	skip := seq - (j.seq - int64(len(j.events)))
+	skip = max(0, min(skip, int64(len(j.events))))
+	return slices.Clone(j.events[skip:])
+}
+
+func (j *journal) close() {
+	j.mtx.Lock()
+	defer j.mtx.Unlock()
+
+	if j.closed {
+		return
+	}
+
+	j.closed = true
+	for ch := range j.subscribers {
+		delete(j.subscribers, ch)
+		close(ch)
+	}
+}
+
+func newHub() *hub {
+	return &hub{
+		journals: make(map[string]*journal),
+	}
+}
@@ -0,0 +1,181 @@
+package livelog
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/certimate-go/certimate/internal/domain"
+)
+
+func newTestEvent(runId string, nodeId string) *domain.WorkflowRunEvent {
+	return &domain.WorkflowRunEvent{Type: domain.WorkflowRunEventTypeNodeStart, RunId: runId, NodeId: nodeId}
+}
+
+func eventNodeIds(events []*domain.WorkflowRunEvent) []string {
+	nodeIds := make([]string, 0, len(events))
+	for _, event := range events {
+		nodeIds = append(nodeIds, event.NodeId)
+	}
+	return nodeIds
+}
+
+func TestHubSubscribe(t *testing.T) {
+	h := newHub()
+	h.Open("run1", []*domain.WorkflowRunEvent{newTestEvent("run1", "n1"), newTestEvent("run1", "n2")})
+	h.Publish(newTestEvent("run1", "n3"))
+	h.Publish(newTestEvent("run1", "n4"))
+	h.Publish(newTestEvent("run2", "n5")) // 尚未开启事件日志的运行将被忽略
+
+	all, _, unsubscribe, ok := h.Subscribe("run1", "")
+	if !ok {
+		t.Fatalf("Subscribe() ok = false, want true")
+	}
+	unsubscribe()
+
+	if got := eventNodeIds(all); !slices.Equal(got, []string{"n1", "n2", "n3", "n4"}) {
+		t.Fatalf("Subscribe() backlog = %v, want %v", got, []string{"n1", "n2", "n3", "n4"})
+	}
+	ids := make(map[string]struct{})
+	for _, event := range all {
+		ids[event.Id] = struct{}{}
+	}
+	if len(ids) != len(all) {
+		t.Fatalf("Subscribe() backlog has duplicate event ids")
+	}
+
+	tests := []struct {
+		name        string
+		lastEventId string
+		want        []string
+	}{
+		{"resume from the first event", all[0].Id, []string{"n2", "n3", "n4"}},
+		{"resume from the last event", all[3].Id, []string{}},
+		{"resume from an event of another epoch", "0-2", []string{"n1", "n2", "n3", "n4"}},
+		{"resume from an unrecognized event id", "db-2", []string{"n1", "n2", "n3", "n4"}},
+		{"resume from an event in the future", fmt.Sprintf("%s-%d", h.journals["run1"].epoch, 100), []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backlog, _, unsubscribe, _ := h.Subscribe("run1", tt.lastEventId)
+			defer unsubscribe()
+
+			if got := eventNodeIds(backlog); !slices.Equal(got, tt.want) {
+				t.Errorf("Subscribe() backlog = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if _, _, _, ok := h.Subscribe("run2", ""); ok {
+		t.Errorf("Subscribe() ok = true for a run without event log, want false")
+	}
+}
+
+func TestHubReopen(t *testing.T) {
+	h := newHub()
+	h.Open("run1", nil)
+	h.Publish(newTestEvent("run1", "n1"))
+	backlog, events, unsubscribe, _ := h.Subscribe("run1", "")
+	defer unsubscribe()
+	lastEventId := backlog[len(backlog)-1].Id
+
+	// 再次执行同一运行时（例如续跑），旧的订阅者将被断开，且其事件 ID 不应被新的事件日志识别
+	h.Open("run1", []*domain.WorkflowRunEvent{newTestEvent("run1", "n1")})
+	h.Publish(newTestEvent("run1", "n2"))
+	if _, ok := <-events; ok {
+		t.Errorf("the subscriber of the previous event log should be closed")
+	}
+
+	backlog, _, unsubscribe2, _ := h.Subscribe("run1", lastEventId)
+	defer unsubscribe2()
+	if got := eventNodeIds(backlog); !slices.Equal(got, []string{"n1", "n2"}) {
+		t.Errorf("Subscribe() backlog = %v, want %v", got, []string{"n1", "n2"})
+	}
+}
+
+func TestHubLiveEvents(t *testing.T) {
+	h := newHub()
+	h.Open("run1", nil)
+
+	backlog, events, unsubscribe, _ := h.Subscribe("run1", "")
+	defer unsubscribe()
+	if len(backlog) != 0 {
+		t.Fatalf("Subscribe() backlog = %v, want empty", eventNodeIds(backlog))
+	}
+
+	h.Publish(newTestEvent("run1", "n1"))
+	h.Publish(newTestEvent("run1", "n2"))
+	h.Close("run1")
+	h.Publish(newTestEvent("run1", "n3")) // 关闭后发布的事件将被忽略
+
+	received := make([]*domain.WorkflowRunEvent, 0)
+	for event := range events {
+		received = append(received, event)
+	}
+	if got := eventNodeIds(received); !slices.Equal(got, []string{"n1", "n2"}) {
+		t.Fatalf("received = %v, want %v", got, []string{"n1", "n2"})
+	}
+
+	// 关闭后仍可续传
+	backlog, events, unsubscribe2, ok := h.Subscribe("run1", received[0].Id)
+	defer unsubscribe2()
+	if !ok {
+		t.Fatalf("Subscribe() ok = false after closed, want true")
+	}
+	if got := eventNodeIds(backlog); !slices.Equal(got, []string{"n2"}) {
+		t.Errorf("Subscribe() backlog = %v, want %v", got, []string{"n2"})
+	}
+	if _, ok := <-events; ok {
+		t.Errorf("Subscribe() events should be closed after closed")
+	}
+}
+
+func TestHubSlowSubscriber(t *testing.T) {
+	h := newHub()
+	h.Open("run1", nil)
+
+	_, events, unsubscribe, _ := h.Subscribe("run1", "")
+	defer unsubscribe()
+	for i := 0; i <= subscriberBufferSize; i++ {
+		h.Publish(newTestEvent("run1", fmt.Sprintf("n%d", i)))
+	}
+
+	// 缓冲区已满时断开订阅者，其可凭最后收到的事件 ID 续传
+	var last *domain.WorkflowRunEvent
+	count := 0
+	for event := range events {
+		last = event
+		count++
+	}
+	if count != subscriberBufferSize {
+		t.Fatalf("received %d events, want %d", count, subscriberBufferSize)
+	}
+
+	backlog, _, unsubscribe2, _ := h.Subscribe("run1", last.Id)
+	defer unsubscribe2()
+	if got := eventNodeIds(backlog); !slices.Equal(got, []string{fmt.Sprintf("n%d", subscriberBufferSize)}) {
+		t.Errorf("Subscribe() backlog = %v, want %v", got, []string{fmt.Sprintf("n%d", subscriberBufferSize)})
+	}
+}
+
+func TestHubJournalLimit(t *testing.T) {
+	h := newHub()
+	h.Open("run1", nil)
+	for i := 0; i < journalMaxEvents+10; i++ {
+		h.Publish(newTestEvent("run1", fmt.Sprintf("n%d", i)))
+	}
+
+	// 续传的事件已被丢弃时，返回仍保留的全部事件
+	epoch := h.journals["run1"].epoch
+	backlog, _, unsubscribe, _ := h.Subscribe("run1", fmt.Sprintf("%s-%d", epoch, 5))
+	unsubscribe()
+	if len(backlog) != journalMaxEvents || backlog[0].NodeId != "n10" {
+		t.Errorf("Subscribe() backlog = %d events starting from %s, want %d events starting from n10", len(backlog), backlog[0].NodeId, journalMaxEvents)
+	}
+
+	backlog, _, unsubscribe, _ = h.Subscribe("run1", fmt.Sprintf("%s-%d", epoch, journalMaxEvents+5))
+	unsubscribe()
+	if len(backlog) != 5 || backlog[0].NodeId != fmt.Sprintf("n%d", journalMaxEvents+5) {
+		t.Errorf("Subscribe() backlog = %v, want the last 5 events", eventNodeIds(backlog))
+	}
+}
@@ -0,0 +1,17 @@
+package livelog
+
+import (
+	"sync"
+)
+
+var (
+	instance     Hub
+	instanceOnce sync.Once
+)
+
+func GetSingletonHub() Hub {
+	instanceOnce.Do(func() {
+		instance = newHub()
+	})
+	return instance
+}
@@ -2,17 +2,40 @@ package workflow
 
 import (
 	"context"
+	"fmt"
+	"log/slog"
+	"time"
 
 	"github.com/pocketbase/pocketbase/core"
 
 	"github.com/certimate-go/certimate/internal/app"
 	"github.com/certimate-go/certimate/internal/domain"
+	"github.com/certimate-go/certimate/internal/eventbus"
+	"github.com/certimate-go/certimate/internal/metrics"
+	"github.com/certimate-go/certimate/internal/workflow/livelog"
+	xmaps "github.com/certimate-go/certimate/pkg/utils/maps"
 )
 
 func registerWorkflowRecordEvents() {
 	pb := app.GetApp()
+	pb.OnRecordCreate(domain.CollectionNameWorkflow).BindFunc(func(e *core.RecordEvent) error {
+		validateWorkflowRecordGraphDraft(e.Context, e.Record)
+		return e.Next()
+	})
+	pb.OnRecordUpdate(domain.CollectionNameWorkflow).BindFunc(func(e *core.RecordEvent) error {
+		validateWorkflowRecordGraphDraft(e.Context, e.Record)
+		return e.Next()
+	})
 	pb.OnRecordCreateRequest(domain.CollectionNameWorkflow).BindFunc(func(e *core.RecordRequestEvent) error {
-		if err := e.Next(); err != nil {
+		onWorkflowRecordBeforeCreateOrUpdate(e.Record)
+		published := bumpWorkflowRecordGraphVersion(e.Record)
+		if published {
+			if err := validateWorkflowRecordGraph(e.Request.Context(), e.Record); err != nil {
+				return respondWorkflowGraphValidationError(e, err)
+			}
+		}
+
+		if err := saveWorkflowRecord(e, published); err != nil {
 			return err
 		}
 
@@ -24,7 +47,15 @@ func registerWorkflowRecordEvents() {
 		return nil
 	})
 	pb.OnRecordUpdateRequest(domain.CollectionNameWorkflow).BindFunc(func(e *core.RecordRequestEvent) error {
-		if err := e.Next(); err != nil {
+		onWorkflowRecordBeforeCreateOrUpdate(e.Record)
+		published := bumpWorkflowRecordGraphVersion(e.Record)
+		if published {
+			if err := validateWorkflowRecordGraph(e.Request.Context(), e.Record); err != nil {
+				return respondWorkflowGraphValidationError(e, err)
+			}
+		}
+
+		if err := saveWorkflowRecord(e, published); err != nil {
 			return err
 		}
 
@@ -49,9 +80,61 @@ func registerWorkflowRecordEvents() {
 	})
 }
 
-func onWorkflowRecordCreateOrUpdate(_ context.Context, _ core.App, record *core.Record) error {
-	scheduler := app.GetScheduler()
+func registerWorkflowVersionRecordEvents() {
+	pb := app.GetApp()
+	pb.OnRecordUpdateRequest(domain.CollectionNameWorkflowVersion).BindFunc(func(e *core.RecordRequestEvent) error {
+		// 版本一经创建即不可变更
+		return e.BadRequestError("workflow versions are immutable", nil)
+	})
+	pb.OnRecordDeleteRequest(domain.CollectionNameWorkflowVersion).BindFunc(func(e *core.RecordRequestEvent) error {
+		// 版本仅随工作流一并级联删除
+		return e.BadRequestError("workflow versions are immutable", nil)
+	})
+}
+
+func registerWorkflowRunRecordEvents() {
+	pb := app.GetApp()
+	pb.OnRecordAfterUpdateSuccess(domain.CollectionNameWorkflowRun).BindFunc(func(e *core.RecordEvent) error {
+		publishWorkflowRunStatusEvent(e.Record)
+		onWorkflowRunRecordUpdate(e.Context, e.Record)
+		return e.Next()
+	})
+}
+
+// 保存工作流记录。发布了新的工作流图时，在同一事务中创建其版本记录，以免工作流记录与版本记录不一致。
+// 事务提交后才会写入响应。
+func saveWorkflowRecord(e *core.RecordRequestEvent, published bool) error {
+	if !published {
+		return e.Next()
+	}
+
+	pbApp := e.App
+	defer func() { e.App = pbApp }()
+
+	return pbApp.RunInTransaction(func(txApp core.App) error {
+		e.App = txApp
+
+		if err := e.Next(); err != nil {
+			return err
+		}
+
+		if err := onWorkflowRecordGraphPublished(e); err != nil {
+			app.GetLogger().Error(err.Error())
+			return err
+		}
 
+		return nil
+	})
+}
+
+func onWorkflowRecordBeforeCreateOrUpdate(record *core.Record) {
+	// 如果是 Webhook 触发且尚未生成密钥，自动生成之
+	if record.GetString("trigger") == domain.WorkflowTriggerTypeWebhook.String() && record.GetString("webhookSecret") == "" {
+		record.Set("webhookSecret", generateWebhookSecret())
+	}
+}
+
+func onWorkflowRecordCreateOrUpdate(_ context.Context, _ core.App, record *core.Record) error {
 	// 向数据库插入/更新时，同时更新定时任务
 	enabled := record.GetBool("enabled")
 	trigger := record.GetString("trigger")
@@ -59,23 +142,89 @@ func onWorkflowRecordCreateOrUpdate(_ context.Context, _ core.App, record *core.
 
 	// 如果非定时触发或未启用，移除定时任务
 	if !enabled || trigger != domain.WorkflowTriggerTypeScheduled.String() {
-		scheduler.Remove(buildPbJobKey(record.Id))
+		unregisterWorkflowJob(thisSvcInst(), record.Id, true)
 		return nil
 	}
 
 	// 反之，重新添加定时任务
-	if err := registerWorkflowJob(thisSvcInst(), record.Id, triggerCron); err != nil {
+	var triggerCronOptions *domain.WorkflowTriggerCronOptions
+	if raw := record.GetString("triggerCronOptions"); raw != "" && raw != "null" {
+		triggerCronOptions = &domain.WorkflowTriggerCronOptions{}
+		if err := record.UnmarshalJSONField("triggerCronOptions", triggerCronOptions); err != nil {
+			return fmt.Errorf("field 'triggerCronOptions' is malformed")
+		}
+	}
+
+	if err := registerWorkflowJob(thisSvcInst(), record.Id, triggerCron, triggerCronOptions, record.GetDateTime("deferredRunAt").Time()); err != nil {
 		return err
 	}
 
 	return nil
 }
 
-func onWorkflowRecordDelete(_ context.Context, _ core.App, record *core.Record) error {
-	scheduler := app.GetScheduler()
+func onWorkflowRecordGraphPublished(e *core.RecordRequestEvent) error {
+	author := ""
+	if e.Auth != nil {
+		author = e.Auth.Email()
+	}
+
+	note := ""
+	if info, err := e.RequestInfo(); err == nil {
+		note, _ = info.Body[workflowVersionNoteRequestKey].(string)
+	}
 
+	return createWorkflowRecordGraphVersion(e.App, e.Record, author, note)
+}
+
+func onWorkflowRecordDelete(_ context.Context, _ core.App, record *core.Record) error {
 	// 从数据库删除时，同时移除定时任务
-	scheduler.Remove(buildPbJobKey(record.Id))
+	unregisterWorkflowJob(thisSvcInst(), record.Id, false)
 
 	return nil
 }
+
+func publishWorkflowRunStatusEvent(record *core.Record) {
+	status := record.GetString("status")
+	if status == record.Original().GetString("status") {
+		return
+	}
+
+	livelog.GetSingletonHub().Publish(livelog.NewStatusEvent(record.Id, domain.WorkflowRunStatusType(status), record.GetString("error")))
+}
+
+func onWorkflowRunRecordUpdate(ctx context.Context, record *core.Record) {
+	status := domain.WorkflowRunStatusType(record.GetString("status"))
+	if !status.IsFinished() || domain.WorkflowRunStatusType(record.Original().GetString("status")).IsFinished() {
+		return
+	}
+
+	var duration time.Duration
+	if startedAt, endedAt := record.GetDateTime("startedAt").Time(), record.GetDateTime("endedAt").Time(); !startedAt.IsZero() && endedAt.After(startedAt) {
+		duration = endedAt.Sub(startedAt)
+	}
+	metrics.ObserveWorkflowRun(status.String(), record.GetString("trigger"), duration)
+
+	// 子工作流运行的结果将体现在其父级运行中，不单独发布事件
+	if record.GetString("parentRunRef") != "" {
+		return
+	}
+
+	// 记录事件链路深度，以免工作流之间相互触发导致死循环
+	chainDepth := 0
+	if record.GetString("trigger") == domain.WorkflowTriggerTypeEvent.String() {
+		inputs := make(map[string]any)
+		record.UnmarshalJSONField("inputs", &inputs)
+		chainDepth = xmaps.GetInt(inputs, eventVarKeyChainDepth) + 1
+	}
+
+	event := domain.NewEvent(domain.EventTypeWorkflowRunFinished, map[string]any{
+		"workflowId": record.GetString("workflowRef"),
+		"runId":      record.Id,
+		"status":     status.String(),
+		"error":      record.GetString("error"),
+		"chainDepth": chainDepth,
+	})
+	if err := eventbus.GetSingletonEventBus().Publish(ctx, event); err != nil {
+		app.GetLogger().Error("failed to publish workflow run finished event", slog.Any("error", err))
+	}
+}
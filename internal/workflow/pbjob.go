@@ -4,44 +4,174 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
-
-	"github.com/pocketbase/pocketbase/tools/cron"
-	"github.com/samber/lo"
+	"sync"
+	"time"
 
 	"github.com/certimate-go/certimate/internal/app"
+	"github.com/certimate-go/certimate/internal/cluster"
 	"github.com/certimate-go/certimate/internal/domain"
 	"github.com/certimate-go/certimate/internal/domain/dtos"
 )
 
-func registerWorkflowJob(workflowSrv *WorkflowService, workflowId string, triggerCron string) error {
+// 推迟中的定时运行的计时器，以工作流 ID 为键。
+// 到期后因随机延迟或禁止运行的时间窗口而尚未开始的运行将被记录在工作流上（见 [domain.Workflow.DeferredRunAt]），期间再次到期的触发将被合并。
+// 计时器仅用于准时开始运行；进程重启、主节点变更后，由每分钟执行的 [startDueDeferredRuns] 开始已到期的运行。
+// 服务可能存在多个实例（如 REST 路由与定时任务各自持有一个），因此须为包级变量。
+var (
+	deferredRunTimers    = make(map[string]*time.Timer)
+	deferredRunTimersMtx sync.Mutex
+)
+
+func registerWorkflowJob(workflowSrv *WorkflowService, workflowId string, triggerCron string, triggerCronOptions *domain.WorkflowTriggerCronOptions, deferredRunAt time.Time) error {
 	scheduler := app.GetScheduler()
 
-	jobKey := buildPbJobKey(workflowId)
-	job, _ := lo.Find(scheduler.Jobs(), func(j *cron.Job) bool { return j.Id() == jobKey })
-	if job != nil && job.Expression() == triggerCron {
-		return nil
+	schedule, err := newWorkflowSchedule(triggerCron, triggerCronOptions)
+	if err != nil {
+		app.GetLogger().Error(fmt.Sprintf("failed to register cron job for workflow #%s", workflowId), slog.Any("error", err))
+		return fmt.Errorf("failed to add cron job: %w", err)
 	}
 
-	err := scheduler.Add(jobKey, triggerCron, func() {
-		app.GetLogger().Info(fmt.Sprintf("workflow #%s is triggered ...", workflowId))
+	// 调度器按服务器本地时区运行，时区不同时须每分钟自行判断是否到期
+	jobExpr := triggerCron
+	if !schedule.IsLocal() {
+		jobExpr = "* * * * *"
+	}
 
-		_, err := workflowSrv.StartRun(context.Background(), &dtos.WorkflowStartRunReq{
-			WorkflowId: workflowId,
-			RunTrigger: domain.WorkflowTriggerTypeScheduled,
-		})
-		if err != nil {
-			app.GetLogger().Warn(fmt.Sprintf("failed to start scheduled run for workflow #%s", workflowId), slog.Any("error", err))
+	// 集群模式下仅由主节点触发，以免同一定时任务被多个实例重复触发
+	err = scheduler.Add(buildPbJobKey(workflowId), jobExpr, cluster.LeaderOnly(func() {
+		now := time.Now()
+		if !schedule.IsDue(now) {
+			return
 		}
-	})
+
+		fireScheduledRun(workflowSrv, workflowId, schedule, now)
+	}))
 	if err != nil {
 		app.GetLogger().Error(fmt.Sprintf("failed to register cron job for workflow #%s", workflowId), slog.Any("error", err))
 		return fmt.Errorf("failed to add cron job: %w", err)
 	}
 
-	app.GetLogger().Info(fmt.Sprintf("registered cron job for workflow #%s", workflowId), slog.String("cron", triggerCron))
+	// 重新注册时保留推迟中的运行
+	if !deferredRunAt.IsZero() {
+		armDeferredRunTimer(workflowSrv, workflowId, deferredRunAt)
+	}
+
+	app.GetLogger().Info(fmt.Sprintf("registered cron job for workflow #%s", workflowId), slog.String("cron", triggerCron), slog.String("timezone", schedule.location.String()))
 	return nil
 }
 
+// 移除定时任务。cancelDeferred 表示是否同时取消推迟中的运行，仅当工作流不再定时触发时才应取消。
+func unregisterWorkflowJob(workflowSrv *WorkflowService, workflowId string, cancelDeferred bool) {
+	app.GetScheduler().Remove(buildPbJobKey(workflowId))
+
+	deferredRunTimersMtx.Lock()
+	if timer, ok := deferredRunTimers[workflowId]; ok {
+		timer.Stop()
+		delete(deferredRunTimers, workflowId)
+	}
+	deferredRunTimersMtx.Unlock()
+
+	if cancelDeferred {
+		if err := workflowSrv.workflowRepo.CancelDeferredRun(context.Background(), workflowId); err != nil {
+			app.GetLogger().Warn(fmt.Sprintf("failed to cancel the deferred run of workflow #%s", workflowId), slog.Any("error", err))
+		}
+	}
+}
+
+func fireScheduledRun(workflowSrv *WorkflowService, workflowId string, schedule *workflowSchedule, due time.Time) {
+	runAt, deferred := schedule.ResolveRunAt(due, randomJitter)
+	if !runAt.After(time.Now()) {
+		startScheduledRun(workflowSrv, workflowId)
+		return
+	}
+
+	// 先持久化，以免进程退出、主节点变更时丢失推迟中的运行
+	ok, err := workflowSrv.workflowRepo.DeferRun(context.Background(), workflowId, runAt)
+	if err != nil {
+		app.GetLogger().Warn(fmt.Sprintf("failed to defer scheduled run for workflow #%s", workflowId), slog.Any("error", err))
+		return
+	} else if !ok {
+		app.GetLogger().Info(fmt.Sprintf("workflow #%s is triggered, but merged into the deferred one", workflowId))
+		return
+	}
+
+	if deferred {
+		app.GetLogger().Info(fmt.Sprintf("workflow #%s is triggered, but deferred due to blackout window", workflowId), slog.Time("runAt", runAt))
+	} else {
+		app.GetLogger().Info(fmt.Sprintf("workflow #%s is triggered, it will start after jitter", workflowId), slog.Time("runAt", runAt))
+	}
+
+	armDeferredRunTimer(workflowSrv, workflowId, runAt)
+}
+
+func armDeferredRunTimer(workflowSrv *WorkflowService, workflowId string, runAt time.Time) {
+	deferredRunTimersMtx.Lock()
+	defer deferredRunTimersMtx.Unlock()
+
+	if timer, ok := deferredRunTimers[workflowId]; ok {
+		timer.Stop()
+	}
+
+	var timer *time.Timer
+	timer = time.AfterFunc(time.Until(runAt), func() {
+		deferredRunTimersMtx.Lock()
+		if deferredRunTimers[workflowId] == timer {
+			delete(deferredRunTimers, workflowId)
+		}
+		deferredRunTimersMtx.Unlock()
+
+		// 等待期间主节点可能已变更，留待新的主节点开始之
+		if !cluster.IsLeader() {
+			return
+		}
+
+		startDeferredRun(workflowSrv, workflowId, runAt)
+	})
+	deferredRunTimers[workflowId] = timer
+}
+
+// 开始推迟中的运行，须先认领之，以免计时器与每分钟的检查、或多个节点重复开始同一运行。
+func startDeferredRun(workflowSrv *WorkflowService, workflowId string, runAt time.Time) {
+	claimed, err := workflowSrv.workflowRepo.ClaimDeferredRun(context.Background(), workflowId, runAt)
+	if err != nil {
+		app.GetLogger().Warn(fmt.Sprintf("failed to claim the deferred run of workflow #%s", workflowId), slog.Any("error", err))
+		return
+	} else if !claimed {
+		return
+	}
+
+	startScheduledRun(workflowSrv, workflowId)
+}
+
+// 开始已到期的推迟中的运行，包括进程重启、主节点变更前遗留的运行。
+func startDueDeferredRuns(workflowSrv *WorkflowService) {
+	workflows, err := workflowSrv.workflowRepo.ListDeferredRunDue(context.Background(), time.Now())
+	if err != nil {
+		app.GetLogger().Warn("failed to list the deferred scheduled runs", slog.Any("error", err))
+		return
+	}
+
+	for _, workflow := range workflows {
+		if !workflow.Enabled || workflow.Trigger != domain.WorkflowTriggerTypeScheduled {
+			continue
+		}
+
+		startDeferredRun(workflowSrv, workflow.Id, workflow.DeferredRunAt)
+	}
+}
+
+func startScheduledRun(workflowSrv *WorkflowService, workflowId string) {
+	app.GetLogger().Info(fmt.Sprintf("workflow #%s is triggered ...", workflowId))
+
+	_, err := workflowSrv.StartRun(context.Background(), &dtos.WorkflowStartRunReq{
+		WorkflowId: workflowId,
+		RunTrigger: domain.WorkflowTriggerTypeScheduled,
+	})
+	if err != nil {
+		app.GetLogger().Warn(fmt.Sprintf("failed to start scheduled run for workflow #%s", workflowId), slog.Any("error", err))
+	}
+}
+
 func buildPbJobKey(workflowId string) string {
 	return fmt.Sprintf("workflow#%s", workflowId)
 }
@@ -0,0 +1,162 @@
+package workflow
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"github.com/pocketbase/pocketbase/tools/cron"
+
+	"github.com/certimate-go/certimate/internal/domain"
+)
+
+const (
+	// 查找下一次到期时间时的最大跨度，超出后视为不再到期
+	scheduleLookaheadLimit = 366 * 24 * time.Hour
+	// 连续推迟的最大次数，以免禁止运行的时间窗口首尾相接时陷入死循环
+	scheduleDeferralLimit = 100
+)
+
+// 工作流的定时触发计划，在 Cron 表达式的基础上支持时区、随机延迟与禁止运行的时间窗口。
+type workflowSchedule struct {
+	cron      *cron.Schedule
+	location  *time.Location
+	jitter    time.Duration
+	blackouts []*workflowScheduleBlackout
+}
+
+type workflowScheduleBlackout struct {
+	startsAt time.Time
+	endsAt   time.Time
+	cron     *cron.Schedule
+	duration time.Duration
+}
+
+func newWorkflowSchedule(triggerCron string, options *domain.WorkflowTriggerCronOptions) (*workflowSchedule, error) {
+	schedule, err := cron.NewSchedule(triggerCron)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cron expression: %w", err)
+	}
+
+	ws := &workflowSchedule{
+		cron:      schedule,
+		location:  time.Local,
+		blackouts: make([]*workflowScheduleBlackout, 0),
+	}
+	if options == nil {
+		return ws, nil
+	}
+
+	if options.Timezone != "" {
+		location, err := time.LoadLocation(options.Timezone)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timezone '%s': %w", options.Timezone, err)
+		}
+		ws.location = location
+	}
+
+	if options.Jitter < 0 {
+		return nil, fmt.Errorf("invalid jitter: must be non-negative")
+	}
+	ws.jitter = time.Duration(options.Jitter) * time.Second
+
+	for i, window := range options.Blackouts {
+		if window == nil {
+			continue
+		}
+
+		if window.Cron != "" {
+			blackoutCron, err := cron.NewSchedule(window.Cron)
+			if err != nil {
+				return nil, fmt.Errorf("invalid cron expression of blackout window #%d: %w", i, err)
+			} else if window.Duration <= 0 {
+				return nil, fmt.Errorf("invalid duration of blackout window #%d: must be positive", i)
+			}
+
+			ws.blackouts = append(ws.blackouts, &workflowScheduleBlackout{cron: blackoutCron, duration: time.Duration(window.Duration) * time.Second})
+		} else {
+			if window.StartsAt.IsZero() || window.EndsAt.IsZero() || !window.EndsAt.After(window.StartsAt) {
+				return nil, fmt.Errorf("invalid time range of blackout window #%d", i)
+			}
+
+			ws.blackouts = append(ws.blackouts, &workflowScheduleBlackout{startsAt: window.StartsAt, endsAt: window.EndsAt})
+		}
+	}
+
+	return ws, nil
+}
+
+// 返回按计划的时区是否与服务器本地时区一致。
+// 一致时可直接将 Cron 表达式交由调度器处理，否则须每分钟自行判断是否到期。
+func (ws *workflowSchedule) IsLocal() bool {
+	return ws.location == time.Local
+}
+
+// 返回指定时间按计划的时区是否到期。
+func (ws *workflowSchedule) IsDue(t time.Time) bool {
+	return ws.cron.IsDue(cron.NewMoment(t.In(ws.location)))
+}
+
+// 返回指定时间之后的下一次到期时间，超出查找跨度时返回零值。
+func (ws *workflowSchedule) Next(after time.Time) time.Time {
+	t := after.In(ws.location).Truncate(time.Minute).Add(time.Minute)
+	for limit := t.Add(scheduleLookaheadLimit); t.Before(limit); t = t.Add(time.Minute) {
+		if ws.IsDue(t) {
+			return t
+		}
+	}
+
+	return time.Time{}
+}
+
+// 计算到期后的实际运行时间：先施加随机延迟，若落入禁止运行的时间窗口则推迟至窗口结束后（并再次施加随机延迟）。
+// 随机延迟由调用方提供，以便预览时计算其上下界。第二个返回值表示是否被推迟。
+func (ws *workflowSchedule) ResolveRunAt(due time.Time, jitterFn func(time.Duration) time.Duration) (time.Time, bool) {
+	runAt := due.Add(jitterFn(ws.jitter))
+	deferred := false
+	for i := 0; i < scheduleDeferralLimit; i++ {
+		endsAt, ok := ws.blackoutEndsAt(runAt)
+		if !ok {
+			break
+		}
+
+		runAt = endsAt.Add(jitterFn(ws.jitter))
+		deferred = true
+	}
+
+	return runAt, deferred
+}
+
+// 返回指定时间所处的禁止运行的时间窗口的结束时间，多个窗口重叠时取最晚者。
+func (ws *workflowSchedule) blackoutEndsAt(t time.Time) (time.Time, bool) {
+	var endsAt time.Time
+	for _, window := range ws.blackouts {
+		if window.cron == nil {
+			if !t.Before(window.startsAt) && t.Before(window.endsAt) && window.endsAt.After(endsAt) {
+				endsAt = window.endsAt
+			}
+			continue
+		}
+
+		// 周期性的窗口，向前回溯窗口时长内最近一次开始的时间
+		start := t.In(ws.location).Truncate(time.Minute)
+		for since := t.Add(-window.duration); start.After(since); start = start.Add(-time.Minute) {
+			if window.cron.IsDue(cron.NewMoment(start)) {
+				if end := start.Add(window.duration); end.After(endsAt) {
+					endsAt = end
+				}
+				break
+			}
+		}
+	}
+
+	return endsAt, !endsAt.IsZero()
+}
+
+func randomJitter(max time.Duration) time.Duration {
+	if max <= 0 {
+		return 0
+	}
+
+	return rand.N(max + 1)
+}
@@ -0,0 +1,364 @@
+package workflow
+
+import (
+	"testing"
+	"time"
+
+	"github.com/certimate-go/certimate/internal/domain"
+)
+
+func mustLoadLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatalf("failed to load location '%s': %v", name, err)
+	}
+	return location
+}
+
+func TestNewWorkflowSchedule(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		cron    string
+		options *domain.WorkflowTriggerCronOptions
+		wantErr bool
+	}{
+		{
+			name: "no options",
+			cron: "0 0 * * *",
+		},
+		{
+			name:    "invalid cron",
+			cron:    "invalid",
+			wantErr: true,
+		},
+		{
+			name:    "invalid timezone",
+			cron:    "0 0 * * *",
+			options: &domain.WorkflowTriggerCronOptions{Timezone: "Mars/Olympus_Mons"},
+			wantErr: true,
+		},
+		{
+			name:    "negative jitter",
+			cron:    "0 0 * * *",
+			options: &domain.WorkflowTriggerCronOptions{Jitter: -1},
+			wantErr: true,
+		},
+		{
+			name: "invalid blackout cron",
+			cron: "0 0 * * *",
+			options: &domain.WorkflowTriggerCronOptions{Blackouts: []*domain.WorkflowTriggerBlackoutWindow{
+				{Cron: "invalid", Duration: 60},
+			}},
+			wantErr: true,
+		},
+		{
+			name: "non-positive blackout duration",
+			cron: "0 0 * * *",
+			options: &domain.WorkflowTriggerCronOptions{Blackouts: []*domain.WorkflowTriggerBlackoutWindow{
+				{Cron: "0 0 * * *"},
+			}},
+			wantErr: true,
+		},
+		{
+			name: "reversed blackout time range",
+			cron: "0 0 * * *",
+			options: &domain.WorkflowTriggerCronOptions{Blackouts: []*domain.WorkflowTriggerBlackoutWindow{
+				{StartsAt: now.Add(time.Hour), EndsAt: now},
+			}},
+			wantErr: true,
+		},
+		{
+			name: "nil blackout is ignored",
+			cron: "0 0 * * *",
+			options: &domain.WorkflowTriggerCronOptions{Blackouts: []*domain.WorkflowTriggerBlackoutWindow{
+				nil,
+				{StartsAt: now, EndsAt: now.Add(time.Hour)},
+			}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newWorkflowSchedule(tt.cron, tt.options)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("newWorkflowSchedule() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestWorkflowScheduleNext(t *testing.T) {
+	shanghai := mustLoadLocation(t, "Asia/Shanghai")
+	newYork := mustLoadLocation(t, "America/New_York")
+
+	tests := []struct {
+		name     string
+		cron     string
+		timezone string
+		after    time.Time
+		want     time.Time
+	}{
+		{
+			name:  "next minute",
+			cron:  "* * * * *",
+			after: time.Date(2026, 1, 1, 0, 0, 30, 0, time.UTC),
+			want:  time.Date(2026, 1, 1, 0, 1, 0, 0, time.UTC),
+		},
+		{
+			name:  "exclusive of the given time",
+			cron:  "0 0 * * *",
+			after: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+			want:  time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "evaluated in the schedule timezone",
+			cron:     "0 9 * * *",
+			timezone: "Asia/Shanghai",
+			after:    time.Date(2026, 1, 1, 2, 0, 0, 0, time.UTC),
+			want:     time.Date(2026, 1, 2, 9, 0, 0, 0, shanghai),
+		},
+		{
+			name:     "day boundary in the schedule timezone",
+			cron:     "0 0 * * 1",
+			timezone: "Asia/Shanghai",
+			after:    time.Date(2026, 1, 4, 15, 0, 0, 0, time.UTC), // 2026-01-04 23:00 (Sunday) in Shanghai
+			want:     time.Date(2026, 1, 5, 0, 0, 0, 0, shanghai),
+		},
+		{
+			name:     "skips the nonexistent time at the start of dst",
+			cron:     "30 2 * * *",
+			timezone: "America/New_York",
+			after:    time.Date(2026, 3, 7, 12, 0, 0, 0, newYork),
+			want:     time.Date(2026, 3, 9, 2, 30, 0, 0, newYork),
+		},
+		{
+			name:     "first occurrence of the repeated time at the end of dst",
+			cron:     "30 1 * * *",
+			timezone: "America/New_York",
+			after:    time.Date(2026, 10, 31, 12, 0, 0, 0, newYork),
+			want:     time.Date(2026, 11, 1, 5, 30, 0, 0, time.UTC), // 01:30 EDT
+		},
+		{
+			name:  "never due within the lookahead limit",
+			cron:  "0 0 30 2 *",
+			after: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+			want:  time.Time{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options := &domain.WorkflowTriggerCronOptions{Timezone: tt.timezone}
+			if tt.timezone == "" {
+				options.Timezone = "UTC"
+			}
+
+			schedule, err := newWorkflowSchedule(tt.cron, options)
+			if err != nil {
+				t.Fatalf("failed to create schedule: %v", err)
+			}
+
+			if got := schedule.Next(tt.after); !got.Equal(tt.want) {
+				t.Errorf("Next() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWorkflowScheduleBlackoutEndsAt(t *testing.T) {
+	shanghai := mustLoadLocation(t, "Asia/Shanghai")
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		timezone  string
+		blackouts []*domain.WorkflowTriggerBlackoutWindow
+		at        time.Time
+		want      time.Time
+		wantOk    bool
+	}{
+		{
+			name:      "fixed window, inside",
+			blackouts: []*domain.WorkflowTriggerBlackoutWindow{{StartsAt: base, EndsAt: base.Add(2 * time.Hour)}},
+			at:        base.Add(time.Hour),
+			want:      base.Add(2 * time.Hour),
+			wantOk:    true,
+		},
+		{
+			name:      "fixed window, inclusive start",
+			blackouts: []*domain.WorkflowTriggerBlackoutWindow{{StartsAt: base, EndsAt: base.Add(2 * time.Hour)}},
+			at:        base,
+			want:      base.Add(2 * time.Hour),
+			wantOk:    true,
+		},
+		{
+			name:      "fixed window, exclusive end",
+			blackouts: []*domain.WorkflowTriggerBlackoutWindow{{StartsAt: base, EndsAt: base.Add(2 * time.Hour)}},
+			at:        base.Add(2 * time.Hour),
+		},
+		{
+			name:      "cron window, inclusive start",
+			blackouts: []*domain.WorkflowTriggerBlackoutWindow{{Cron: "0 2 * * *", Duration: 3600}},
+			at:        base.Add(2 * time.Hour),
+			want:      base.Add(3 * time.Hour),
+			wantOk:    true,
+		},
+		{
+			name:      "cron window, looks back within the duration",
+			blackouts: []*domain.WorkflowTriggerBlackoutWindow{{Cron: "0 2 * * *", Duration: 3600}},
+			at:        base.Add(2*time.Hour + 59*time.Minute + 30*time.Second),
+			want:      base.Add(3 * time.Hour),
+			wantOk:    true,
+		},
+		{
+			name:      "cron window, exclusive end",
+			blackouts: []*domain.WorkflowTriggerBlackoutWindow{{Cron: "0 2 * * *", Duration: 3600}},
+			at:        base.Add(3 * time.Hour),
+		},
+		{
+			name:      "cron window, before start",
+			blackouts: []*domain.WorkflowTriggerBlackoutWindow{{Cron: "0 2 * * *", Duration: 3600}},
+			at:        base.Add(time.Hour + 59*time.Minute),
+		},
+		{
+			name:      "cron window, spans midnight",
+			blackouts: []*domain.WorkflowTriggerBlackoutWindow{{Cron: "0 23 * * *", Duration: 4 * 3600}},
+			at:        base.Add(time.Hour),
+			want:      base.Add(3 * time.Hour),
+			wantOk:    true,
+		},
+		{
+			name:      "cron window, evaluated in the schedule timezone",
+			timezone:  "Asia/Shanghai",
+			blackouts: []*domain.WorkflowTriggerBlackoutWindow{{Cron: "0 9 * * *", Duration: 3600}},
+			at:        time.Date(2026, 1, 1, 1, 30, 0, 0, time.UTC), // 09:30 in Shanghai
+			want:      time.Date(2026, 1, 1, 10, 0, 0, 0, shanghai),
+			wantOk:    true,
+		},
+		{
+			name: "overlapping windows, the latest end wins",
+			blackouts: []*domain.WorkflowTriggerBlackoutWindow{
+				{StartsAt: base, EndsAt: base.Add(2 * time.Hour)},
+				{Cron: "0 1 * * *", Duration: 3 * 3600},
+			},
+			at:     base.Add(time.Hour + 30*time.Minute),
+			want:   base.Add(4 * time.Hour),
+			wantOk: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options := &domain.WorkflowTriggerCronOptions{Timezone: tt.timezone, Blackouts: tt.blackouts}
+			if tt.timezone == "" {
+				options.Timezone = "UTC"
+			}
+
+			schedule, err := newWorkflowSchedule("0 0 * * *", options)
+			if err != nil {
+				t.Fatalf("failed to create schedule: %v", err)
+			}
+
+			got, ok := schedule.blackoutEndsAt(tt.at)
+			if ok != tt.wantOk || !got.Equal(tt.want) {
+				t.Errorf("blackoutEndsAt() = %v, %v, want %v, %v", got, ok, tt.want, tt.wantOk)
+			}
+		})
+	}
+}
+
+func TestWorkflowScheduleResolveRunAt(t *testing.T) {
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	maxJitter := func(max time.Duration) time.Duration { return max }
+
+	tests := []struct {
+		name         string
+		jitter       int
+		blackouts    []*domain.WorkflowTriggerBlackoutWindow
+		due          time.Time
+		want         time.Time
+		wantDeferred bool
+	}{
+		{
+			name: "no jitter, no blackout",
+			due:  base,
+			want: base,
+		},
+		{
+			name:   "jitter",
+			jitter: 300,
+			due:    base,
+			want:   base.Add(5 * time.Minute),
+		},
+		{
+			name:         "due inside a blackout",
+			blackouts:    []*domain.WorkflowTriggerBlackoutWindow{{StartsAt: base, EndsAt: base.Add(time.Hour)}},
+			due:          base,
+			want:         base.Add(time.Hour),
+			wantDeferred: true,
+		},
+		{
+			name:         "jitter falls into a blackout",
+			jitter:       600,
+			blackouts:    []*domain.WorkflowTriggerBlackoutWindow{{StartsAt: base.Add(5 * time.Minute), EndsAt: base.Add(time.Hour)}},
+			due:          base,
+			want:         base.Add(time.Hour + 10*time.Minute),
+			wantDeferred: true,
+		},
+		{
+			name: "adjacent blackouts are chained",
+			blackouts: []*domain.WorkflowTriggerBlackoutWindow{
+				{StartsAt: base, EndsAt: base.Add(time.Hour)},
+				{StartsAt: base.Add(time.Hour), EndsAt: base.Add(2 * time.Hour)},
+			},
+			due:          base,
+			want:         base.Add(2 * time.Hour),
+			wantDeferred: true,
+		},
+		{
+			name:         "recurring blackout",
+			blackouts:    []*domain.WorkflowTriggerBlackoutWindow{{Cron: "0 * * * *", Duration: 1800}},
+			due:          base.Add(10 * time.Minute),
+			want:         base.Add(30 * time.Minute),
+			wantDeferred: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule, err := newWorkflowSchedule("0 0 * * *", &domain.WorkflowTriggerCronOptions{Timezone: "UTC", Jitter: tt.jitter, Blackouts: tt.blackouts})
+			if err != nil {
+				t.Fatalf("failed to create schedule: %v", err)
+			}
+
+			got, deferred := schedule.ResolveRunAt(tt.due, maxJitter)
+			if !got.Equal(tt.want) || deferred != tt.wantDeferred {
+				t.Errorf("ResolveRunAt() = %v, %v, want %v, %v", got, deferred, tt.want, tt.wantDeferred)
+			}
+		})
+	}
+
+	// 测试禁止运行的时间窗口首尾相接且覆盖全天时，推迟次数受限而不会陷入死循环
+	schedule, err := newWorkflowSchedule("0 0 * * *", &domain.WorkflowTriggerCronOptions{
+		Timezone:  "UTC",
+		Blackouts: []*domain.WorkflowTriggerBlackoutWindow{{Cron: "* * * * *", Duration: 60}},
+	})
+	if err != nil {
+		t.Fatalf("failed to create schedule: %v", err)
+	}
+	if got, deferred := schedule.ResolveRunAt(base, maxJitter); !deferred || !got.Equal(base.Add(scheduleDeferralLimit*time.Minute)) {
+		t.Errorf("ResolveRunAt() = %v, %v, want %v, true", got, deferred, base.Add(scheduleDeferralLimit*time.Minute))
+	}
+}
+
+func TestRandomJitter(t *testing.T) {
+	if got := randomJitter(0); got != 0 {
+		t.Errorf("randomJitter(0) = %v, want 0", got)
+	}
+
+	for i := 0; i < 100; i++ {
+		if got := randomJitter(time.Second); got < 0 || got > time.Second {
+			t.Fatalf("randomJitter(1s) = %v, want within [0, 1s]", got)
+		}
+	}
+}
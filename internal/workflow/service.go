@@ -8,42 +8,72 @@ import (
 	"time"
 
 	"github.com/pocketbase/dbx"
+	"github.com/samber/lo"
 
 	"github.com/certimate-go/certimate/internal/app"
+	"github.com/certimate-go/certimate/internal/cluster"
 	"github.com/certimate-go/certimate/internal/domain"
 	"github.com/certimate-go/certimate/internal/domain/dtos"
+	"github.com/certimate-go/certimate/internal/eventbus"
+	"github.com/certimate-go/certimate/internal/metrics"
 	"github.com/certimate-go/certimate/internal/settings"
 	"github.com/certimate-go/certimate/internal/workflow/dispatcher"
+	"github.com/certimate-go/certimate/internal/workflow/engine"
+	"github.com/certimate-go/certimate/internal/workflow/livelog"
 )
 
 type WorkflowService struct {
 	dispatcher dispatcher.WorkflowDispatcher
+	eventBus   eventbus.EventBus
 
-	workflowRepo    workflowRepository
-	workflowRunRepo workflowRunRepository
+	workflowRepo        workflowRepository
+	workflowRunRepo     workflowRunRepository
+	workflowVersionRepo workflowVersionRepository
+	workflowLogRepo     workflowLogRepository
 }
 
-func NewWorkflowService(workflowRepo workflowRepository, workflowRunRepo workflowRunRepository) *WorkflowService {
+func NewWorkflowService(workflowRepo workflowRepository, workflowRunRepo workflowRunRepository, workflowVersionRepo workflowVersionRepository, workflowLogRepo workflowLogRepository) *WorkflowService {
 	srv := &WorkflowService{
 		dispatcher: dispatcher.GetSingletonDispatcher(),
+		eventBus:   eventbus.GetSingletonEventBus(),
 
-		workflowRepo:    workflowRepo,
-		workflowRunRepo: workflowRunRepo,
+		workflowRepo:        workflowRepo,
+		workflowRunRepo:     workflowRunRepo,
+		workflowVersionRepo: workflowVersionRepo,
+		workflowLogRepo:     workflowLogRepo,
 	}
 	return srv
 }
 
 func (s *WorkflowService) InitSchedule(ctx context.Context) error {
 	// 每日清理工作流运行历史
-	app.GetScheduler().MustAdd("cleanupWorkflowHistoryRuns", "0 0 * * *", func() {
+	app.GetScheduler().MustAdd("cleanupWorkflowHistoryRuns", "0 0 * * *", cluster.LeaderOnly(func() {
 		s.cleanupHistoryRuns(context.Background())
-	})
+	}))
 
 	// 初始化工作流调度器
 	if err := s.dispatcher.Bootup(ctx); err != nil {
 		panic(err)
 	}
 
+	// 每分钟检查等待审批的运行是否已超时，重启前已超时的运行将立即被处理
+	app.GetScheduler().MustAdd("expireWorkflowApprovals", "* * * * *", cluster.LeaderOnly(func() {
+		s.expireApprovals(context.Background())
+	}))
+	cluster.LeaderOnly(func() { s.expireApprovals(ctx) })()
+
+	// 每分钟开始已到期的推迟中的定时运行，重启前已到期的运行将立即被处理
+	app.GetScheduler().MustAdd("startDeferredWorkflowRuns", "* * * * *", cluster.LeaderOnly(func() {
+		startDueDeferredRuns(s)
+	}))
+	cluster.LeaderOnly(func() { startDueDeferredRuns(s) })()
+
+	// 订阅事件以触发工作流
+	s.subscribeEvents()
+
+	// 注册调度器的指标
+	s.registerMetrics()
+
 	// 注册工作流后台任务
 	{
 		workflows, err := s.workflowRepo.ListEnabledScheduled(ctx)
@@ -53,7 +83,7 @@ func (s *WorkflowService) InitSchedule(ctx context.Context) error {
 
 		var errs []error
 		for _, workflow := range workflows {
-			if err := registerWorkflowJob(s, workflow.Id, workflow.TriggerCron); err != nil {
+			if err := registerWorkflowJob(s, workflow.Id, workflow.TriggerCron, workflow.TriggerCronOptions, workflow.DeferredRunAt); err != nil {
 				errs = append(errs, err)
 			}
 		}
@@ -71,6 +101,8 @@ func (s *WorkflowService) GetStatistics(ctx context.Context) (*dtos.WorkflowStat
 		Concurrency:      stats.Concurrency,
 		PendingRunIds:    stats.PendingRunIds,
 		ProcessingRunIds: stats.ProcessingRunIds,
+		PendingRuns:      lo.Map(stats.PendingRuns, func(run dispatcher.StatisticsRun, _ int) *dtos.WorkflowStatisticsRun { return toStatisticsRunDto(run) }),
+		ProcessingRuns:   lo.Map(stats.ProcessingRuns, func(run dispatcher.StatisticsRun, _ int) *dtos.WorkflowStatisticsRun { return toStatisticsRunDto(run) }),
 	}, nil
 }
 
@@ -80,8 +112,11 @@ func (s *WorkflowService) StartRun(ctx context.Context, req *dtos.WorkflowStartR
 		return nil, err
 	}
 
-	if req.RunTrigger == domain.WorkflowTriggerTypeManual && (workflow.LastRunStatus == domain.WorkflowRunStatusTypePending || workflow.LastRunStatus == domain.WorkflowRunStatusTypeProcessing) {
-		return nil, fmt.Errorf("workflow is already pending or processing")
+	// 并发策略为允许同时执行或取消已有运行时，交由调度器处理
+	if req.RunTrigger == domain.WorkflowTriggerTypeManual &&
+		workflow.ConcurrencyPolicy != domain.WorkflowConcurrencyPolicyTypeAllow && workflow.ConcurrencyPolicy != domain.WorkflowConcurrencyPolicyTypeCancelRunning &&
+		(workflow.LastRunStatus == domain.WorkflowRunStatusTypePending || workflow.LastRunStatus == domain.WorkflowRunStatusTypeProcessing || workflow.LastRunStatus == domain.WorkflowRunStatusTypeWaiting) {
+		return nil, fmt.Errorf("workflow is already pending, processing or waiting")
 	} else if workflow.GraphContent == nil {
 		return nil, fmt.Errorf("workflow graph content is empty")
 	} else if err := workflow.GraphContent.Verify(); err != nil {
@@ -89,11 +124,14 @@ func (s *WorkflowService) StartRun(ctx context.Context, req *dtos.WorkflowStartR
 	}
 
 	workflowRun := &domain.WorkflowRun{
-		WorkflowId: workflow.Id,
-		Status:     domain.WorkflowRunStatusTypePending,
-		Trigger:    req.RunTrigger,
-		StartedAt:  time.Now(),
-		Graph:      workflow.GraphContent.Clone(),
+		WorkflowId:   workflow.Id,
+		Status:       domain.WorkflowRunStatusTypePending,
+		Trigger:      req.RunTrigger,
+		StartedAt:    time.Now(),
+		Attempt:      1,
+		Graph:        workflow.GraphContent.Clone(),
+		GraphVersion: workflow.GraphVersion,
+		Inputs:       req.RunInputs,
 	}
 	if resp, err := s.workflowRunRepo.Save(ctx, workflowRun); err != nil {
 		return nil, err
@@ -119,8 +157,10 @@ func (s *WorkflowService) CancelRun(ctx context.Context, req *dtos.WorkflowCance
 		return nil, err
 	} else if workflowRun.WorkflowId != workflow.Id {
 		return nil, fmt.Errorf("workflow run not found")
-	} else if workflowRun.Status != domain.WorkflowRunStatusTypePending && workflowRun.Status != domain.WorkflowRunStatusTypeProcessing {
-		return nil, fmt.Errorf("workflow run is not pending or processing")
+	} else if workflowRun.Status != domain.WorkflowRunStatusTypePending && workflowRun.Status != domain.WorkflowRunStatusTypeProcessing && workflowRun.Status != domain.WorkflowRunStatusTypeWaiting {
+		return nil, fmt.Errorf("workflow run is not pending, processing or waiting")
+	} else if workflowRun.ParentRunId != "" {
+		return nil, fmt.Errorf("workflow run is a child run, please cancel its parent run #%s instead", workflowRun.ParentRunId)
 	}
 
 	if err := s.dispatcher.Cancel(ctx, workflowRun.Id); err != nil {
@@ -130,16 +170,443 @@ func (s *WorkflowService) CancelRun(ctx context.Context, req *dtos.WorkflowCance
 	return &dtos.WorkflowCancelRunResp{}, nil
 }
 
+func (s *WorkflowService) ResumeRun(ctx context.Context, req *dtos.WorkflowResumeRunReq) (*dtos.WorkflowResumeRunResp, error) {
+	workflow, err := s.workflowRepo.GetById(ctx, req.WorkflowId)
+	if err != nil {
+		return nil, err
+	}
+
+	// 以该工作流尚未结束的运行记录判断，与调度器的并发检查保持一致
+	if activeRuns, err := s.workflowRunRepo.ListActiveByWorkflowId(ctx, workflow.Id); err != nil {
+		return nil, err
+	} else if len(activeRuns) > 0 {
+		return nil, fmt.Errorf("workflow is already pending, processing or waiting in run #%s", activeRuns[0].Id)
+	}
+
+	workflowRun, err := s.workflowRunRepo.GetById(ctx, req.RunId)
+	if err != nil {
+		return nil, err
+	} else if workflowRun.WorkflowId != workflow.Id {
+		return nil, fmt.Errorf("workflow run not found")
+	} else if workflowRun.Status != domain.WorkflowRunStatusTypeFailed && workflowRun.Status != domain.WorkflowRunStatusTypeTimeout && workflowRun.Status != domain.WorkflowRunStatusTypeInterrupted {
+		return nil, fmt.Errorf("workflow run is not failed, timed out or interrupted")
+	} else if workflowRun.ParentRunId != "" {
+		return nil, fmt.Errorf("workflow run is a child run, please resume its parent run #%s instead", workflowRun.ParentRunId)
+	} else if workflowRun.State == nil || len(workflowRun.State.CompletedNodeIds) == 0 {
+		return nil, fmt.Errorf("workflow run has no completed nodes to skip, please start a new run instead")
+	}
+
+	// 沿用原运行记录（包括其运行图），已完成的节点及其输出将在执行时被复用
+	workflowRun.Status = domain.WorkflowRunStatusTypePending
+	workflowRun.EndedAt = time.Time{}
+	workflowRun.Attempt = max(workflowRun.Attempt, 1) + 1
+	workflowRun.Error = ""
+	if _, err := s.workflowRunRepo.SaveWithCascading(ctx, workflowRun); err != nil {
+		return nil, err
+	}
+
+	if err := s.dispatcher.Start(ctx, workflowRun.Id); err != nil {
+		return nil, err
+	}
+
+	return &dtos.WorkflowResumeRunResp{RunId: workflowRun.Id}, nil
+}
+
+// 推送运行的实时事件，直至运行结束或上下文被取消。
+// 首个事件为运行的当前状态；此后每隔一段时间将以空事件作为心跳，以免连接因空闲而被中间代理断开。
+func (s *WorkflowService) StreamRunEvents(ctx context.Context, req *dtos.WorkflowStreamRunEventsReq, emit func(event *domain.WorkflowRunEvent) error) error {
+	workflowRun, err := s.workflowRunRepo.GetById(ctx, req.RunId)
+	if err != nil {
+		return err
+	} else if workflowRun.WorkflowId != req.WorkflowId {
+		return domain.ErrRecordNotFound
+	}
+
+	// 运行不在本进程中执行时，从数据库中读取已持久化的日志
+	backlog, events, unsubscribe, ok := livelog.GetSingletonHub().Subscribe(workflowRun.Id, req.LastEventId)
+	if !ok {
+		return s.streamPersistedRunEvents(ctx, workflowRun, req.LastEventId, emit)
+	}
+	defer unsubscribe()
+
+	// 状态快照不带事件 ID，以免影响续传
+	if err := emit(livelog.NewStatusEvent(workflowRun.Id, workflowRun.Status, workflowRun.Error)); err != nil {
+		return err
+	}
+	for _, event := range backlog {
+		if err := emit(event); err != nil {
+			return err
+		}
+	}
+
+	heartbeat := time.NewTicker(runEventsHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case <-heartbeat.C:
+			if err := emit(nil); err != nil {
+				return err
+			}
+
+		case event, ok := <-events:
+			if !ok {
+				return nil
+			}
+
+			if err := emit(event); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (s *WorkflowService) DecideApproval(ctx context.Context, req *dtos.WorkflowDecideApprovalReq) (*dtos.WorkflowDecideApprovalResp, error) {
+	if req.Decision != domain.WorkflowApprovalDecisionTypeApproved && req.Decision != domain.WorkflowApprovalDecisionTypeRejected {
+		return nil, domain.NewError(400, fmt.Sprintf("invalid decision '%s'", req.Decision))
+	}
+
+	workflowRun, err := s.workflowRunRepo.GetById(ctx, req.RunId)
+	if err != nil {
+		return nil, err
+	} else if workflowRun.WorkflowId != req.WorkflowId {
+		return nil, fmt.Errorf("workflow run not found")
+	}
+
+	approval, err := findPendingApproval(workflowRun, req.NodeId)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.decideApproval(ctx, workflowRun, approval, req.Decision, req.Decider, req.Comment); err != nil {
+		return nil, err
+	}
+
+	return &dtos.WorkflowDecideApprovalResp{RunId: workflowRun.Id}, nil
+}
+
+func (s *WorkflowService) DecideApprovalByToken(ctx context.Context, req *dtos.WorkflowDecideApprovalReq) (*dtos.WorkflowDecideApprovalResp, error) {
+	if req.Decision != domain.WorkflowApprovalDecisionTypeApproved && req.Decision != domain.WorkflowApprovalDecisionTypeRejected {
+		return nil, domain.NewError(400, fmt.Sprintf("invalid decision '%s'", req.Decision))
+	}
+
+	workflowRun, err := s.workflowRunRepo.GetById(ctx, req.RunId)
+	if err != nil {
+		if domain.IsRecordNotFoundError(err) {
+			return nil, domain.ErrUnauthorized
+		}
+		return nil, err
+	}
+
+	// 令牌不匹配、审批请求已超时或已被审批、运行已不再等待审批时，均视为未授权，以免泄露运行状态
+	approval, ok := findPendingApprovalByToken(workflowRun, req.Token, time.Now())
+	if !ok {
+		return nil, domain.ErrUnauthorized
+	}
+
+	if err := s.decideApproval(ctx, workflowRun, approval, req.Decision, req.Decider, req.Comment); err != nil {
+		return nil, err
+	}
+
+	return &dtos.WorkflowDecideApprovalResp{RunId: workflowRun.Id}, nil
+}
+
+func (s *WorkflowService) PlanRun(ctx context.Context, req *dtos.WorkflowPlanRunReq) (*dtos.WorkflowPlanRunResp, error) {
+	workflow, err := s.workflowRepo.GetById(ctx, req.WorkflowId)
+	if err != nil {
+		return nil, err
+	}
+
+	graph := workflow.GraphContent
+	if req.UseDraft {
+		graph = workflow.GraphDraft
+	}
+	if graph == nil || len(graph.Nodes) == 0 {
+		return nil, fmt.Errorf("workflow graph is empty")
+	} else if err := graph.Verify(); err != nil {
+		return nil, fmt.Errorf("workflow graph is invalid: %w", err)
+	}
+
+	// 试运行不会创建运行记录，也不会调用任何外部服务
+	plan, err := engine.NewWorkflowEngine().Plan(ctx, engine.WorkflowExecution{
+		WorkflowId:          workflow.Id,
+		WorkflowName:        workflow.Name,
+		WorkflowDescription: workflow.Description,
+		RunTrigger:          domain.WorkflowTriggerTypeManual,
+		RunAt:               time.Now(),
+		RunInputs:           req.RunInputs,
+		Graph:               graph.Clone(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &dtos.WorkflowPlanRunResp{WorkflowPlan: plan}, nil
+}
+
+func (s *WorkflowService) ValidateGraph(ctx context.Context, req *dtos.WorkflowValidateGraphReq) (*dtos.WorkflowValidateGraphResp, error) {
+	workflow, err := s.workflowRepo.GetById(ctx, req.WorkflowId)
+	if err != nil {
+		return nil, err
+	}
+
+	graph := req.Graph
+	if graph == nil {
+		graph = workflow.GraphContent
+		if req.UseDraft {
+			graph = workflow.GraphDraft
+		}
+	}
+
+	if err := engine.NewGraphValidator().Validate(ctx, graph); err != nil {
+		var verr *domain.WorkflowGraphValidationError
+		if !errors.As(err, &verr) {
+			return nil, err
+		}
+
+		return &dtos.WorkflowValidateGraphResp{Valid: false, WorkflowGraphValidationError: verr}, nil
+	}
+
+	return &dtos.WorkflowValidateGraphResp{Valid: true}, nil
+}
+
+func (s *WorkflowService) PreviewSchedule(ctx context.Context, req *dtos.WorkflowPreviewScheduleReq) (*dtos.WorkflowPreviewScheduleResp, error) {
+	workflow, err := s.workflowRepo.GetById(ctx, req.WorkflowId)
+	if err != nil {
+		return nil, err
+	}
+
+	triggerCron, triggerCronOptions := req.TriggerCron, req.TriggerCronOptions
+	if triggerCron == "" {
+		triggerCron, triggerCronOptions = workflow.TriggerCron, workflow.TriggerCronOptions
+	}
+	if triggerCron == "" {
+		return nil, fmt.Errorf("workflow has no cron expression")
+	}
+
+	schedule, err := newWorkflowSchedule(triggerCron, triggerCronOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	count := req.Count
+	if count <= 0 {
+		count = 5
+	} else if count > 50 {
+		count = 50
+	}
+
+	runs := make([]*dtos.WorkflowScheduledRun, 0, count)
+	for due := schedule.Next(time.Now()); !due.IsZero() && len(runs) < count; due = schedule.Next(due) {
+		earliestAt, deferred := schedule.ResolveRunAt(due, func(time.Duration) time.Duration { return 0 })
+		latestAt, _ := schedule.ResolveRunAt(due, func(max time.Duration) time.Duration { return max })
+
+		// 同一禁止运行的时间窗口内到期的多次触发将被合并为一次
+		if deferred && len(runs) > 0 && runs[len(runs)-1].EarliestAt.Equal(earliestAt) {
+			continue
+		}
+
+		runs = append(runs, &dtos.WorkflowScheduledRun{
+			ScheduledAt: due,
+			EarliestAt:  earliestAt.In(schedule.location),
+			LatestAt:    latestAt.In(schedule.location),
+			Deferred:    deferred,
+		})
+	}
+
+	return &dtos.WorkflowPreviewScheduleResp{
+		Timezone: schedule.location.String(),
+		Runs:     runs,
+	}, nil
+}
+
+func (s *WorkflowService) ListVersions(ctx context.Context, req *dtos.WorkflowListVersionsReq) (*dtos.WorkflowListVersionsResp, error) {
+	workflow, err := s.workflowRepo.GetById(ctx, req.WorkflowId)
+	if err != nil {
+		return nil, err
+	}
+
+	versions, err := s.workflowVersionRepo.ListByWorkflowId(ctx, workflow.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dtos.WorkflowListVersionsResp{
+		CurrentVersion: workflow.GraphVersion,
+		Items:          versions,
+	}, nil
+}
+
+func (s *WorkflowService) DiffVersions(ctx context.Context, req *dtos.WorkflowDiffVersionsReq) (*dtos.WorkflowDiffVersionsResp, error) {
+	fromVersion, err := s.workflowVersionRepo.GetByWorkflowIdAndVersion(ctx, req.WorkflowId, req.FromVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	toVersion, err := s.workflowVersionRepo.GetByWorkflowIdAndVersion(ctx, req.WorkflowId, req.ToVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dtos.WorkflowDiffVersionsResp{
+		FromVersion: fromVersion.Version,
+		ToVersion:   toVersion.Version,
+		Changes:     domain.DiffWorkflowGraphs(fromVersion.Graph, toVersion.Graph),
+	}, nil
+}
+
+func (s *WorkflowService) RestoreVersion(ctx context.Context, req *dtos.WorkflowRestoreVersionReq) (*dtos.WorkflowRestoreVersionResp, error) {
+	workflow, err := s.workflowRepo.GetById(ctx, req.WorkflowId)
+	if err != nil {
+		return nil, err
+	}
+
+	version, err := s.workflowVersionRepo.GetByWorkflowIdAndVersion(ctx, workflow.Id, req.Version)
+	if err != nil {
+		return nil, err
+	} else if err := engine.NewGraphValidator().Validate(ctx, version.Graph); err != nil {
+		return nil, fmt.Errorf("failed to restore version %d: %w", version.Version, err)
+	}
+
+	// 版本不可变，恢复旧版本时将以其内容发布一个新版本
+	note := req.Note
+	if note == "" {
+		note = fmt.Sprintf("restored from version %d", version.Version)
+	}
+
+	workflow.GraphDraft = version.Graph
+	workflow.GraphContent = version.Graph
+	workflow.HasDraft = false
+	workflow.HasContent = true
+	workflow.GraphVersion++
+	if _, err := s.workflowRepo.SaveWithVersion(ctx, workflow, &domain.WorkflowVersion{
+		Version: workflow.GraphVersion,
+		Graph:   version.Graph,
+		Author:  req.Author,
+		Note:    note,
+	}); err != nil {
+		return nil, err
+	}
+
+	return &dtos.WorkflowRestoreVersionResp{Version: workflow.GraphVersion}, nil
+}
+
+func (s *WorkflowService) TriggerWebhook(ctx context.Context, req *dtos.WorkflowTriggerWebhookReq) (*dtos.WorkflowTriggerWebhookResp, error) {
+	workflow, err := s.workflowRepo.GetById(ctx, req.WorkflowId)
+	if err != nil {
+		return nil, err
+	} else if !workflow.Enabled || workflow.Trigger != domain.WorkflowTriggerTypeWebhook || workflow.WebhookSecret == "" {
+		return nil, domain.ErrRecordNotFound
+	}
+
+	if !verifyWebhookRequest(workflow.WebhookSecret, req, time.Now()) {
+		return nil, domain.ErrUnauthorized
+	}
+
+	inputs, err := parseWebhookPayload(req.Payload)
+	if err != nil {
+		return nil, domain.NewError(400, err.Error())
+	}
+
+	app.GetLogger().Info(fmt.Sprintf("workflow #%s is triggered by webhook ...", workflow.Id))
+
+	res, err := s.StartRun(ctx, &dtos.WorkflowStartRunReq{
+		WorkflowId: workflow.Id,
+		RunTrigger: domain.WorkflowTriggerTypeWebhook,
+		RunInputs:  inputs,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &dtos.WorkflowTriggerWebhookResp{RunId: res.RunId}, nil
+}
+
+func (s *WorkflowService) RotateWebhookSecret(ctx context.Context, req *dtos.WorkflowRotateWebhookSecretReq) (*dtos.WorkflowRotateWebhookSecretResp, error) {
+	workflow, err := s.workflowRepo.GetById(ctx, req.WorkflowId)
+	if err != nil {
+		return nil, err
+	}
+
+	workflow.WebhookSecret = generateWebhookSecret()
+	if _, err := s.workflowRepo.Save(ctx, workflow); err != nil {
+		return nil, err
+	}
+
+	return &dtos.WorkflowRotateWebhookSecretResp{Secret: workflow.WebhookSecret}, nil
+}
+
 func (s *WorkflowService) Shutdown(ctx context.Context) {
 	s.dispatcher.Shutdown(ctx)
 }
 
+// 注册工作流调度器的监控指标。
+func (s *WorkflowService) registerMetrics() {
+	metrics.RegisterGaugeFunc("workflow_dispatcher", "concurrency", "Maximum number of workflow runs that can be processed concurrently.", nil, func() []metrics.GaugeSample {
+		return []metrics.GaugeSample{{Value: float64(s.dispatcher.GetStatistics().Concurrency)}}
+	})
+	metrics.RegisterGaugeFunc("workflow_dispatcher", "pending_runs", "Number of workflow runs waiting in the dispatcher queue.", nil, func() []metrics.GaugeSample {
+		return []metrics.GaugeSample{{Value: float64(len(s.dispatcher.GetStatistics().PendingRuns))}}
+	})
+	metrics.RegisterGaugeFunc("workflow_dispatcher", "processing_runs", "Number of workflow runs being processed by the dispatcher.", nil, func() []metrics.GaugeSample {
+		return []metrics.GaugeSample{{Value: float64(len(s.dispatcher.GetStatistics().ProcessingRuns))}}
+	})
+}
+
+// 记录审批结果，并将运行重新入队，由审批节点据之继续或结束运行。
+func (s *WorkflowService) decideApproval(ctx context.Context, workflowRun *domain.WorkflowRun, approval *domain.WorkflowRunStateApproval, decision domain.WorkflowApprovalDecisionType, decider string, comment string) error {
+	approval.Decision = decision
+	approval.Decider = decider
+	approval.Comment = comment
+	approval.DecidedAt = time.Now()
+
+	// 以条件更新保存，同一审批请求被多个请求或节点并发处理时，仅有一个能成功
+	workflowRun.Status = domain.WorkflowRunStatusTypePending
+	if saved, err := s.workflowRunRepo.SaveWithCascadingIfUnchanged(ctx, workflowRun, domain.WorkflowRunStatusTypeWaiting); err != nil {
+		return err
+	} else if !saved {
+		return domain.NewError(409, "workflow run has been changed, please refresh and try again")
+	}
+
+	app.GetLogger().Info(fmt.Sprintf("workflow #%s's run #%s is %s by %s at node #%s", workflowRun.WorkflowId, workflowRun.Id, decision, decider, approval.NodeId))
+
+	return s.dispatcher.Start(ctx, workflowRun.Id)
+}
+
+func (s *WorkflowService) expireApprovals(ctx context.Context) error {
+	workflowRuns, err := s.workflowRunRepo.ListByStatus(ctx, domain.WorkflowRunStatusTypeWaiting)
+	if err != nil {
+		app.GetLogger().Error("failed to get waiting workflow runs", slog.Any("error", err))
+		return err
+	}
+
+	var errs []error
+	for _, workflowRun := range workflowRuns {
+		expired := findExpiredApprovals(workflowRun, time.Now())
+		if len(expired) == 0 {
+			continue
+		}
+
+		// 同一运行中同一时间至多只有一个等待审批的节点，这里仅处理第一个即可
+		if err := s.decideApproval(ctx, workflowRun, expired[0], domain.WorkflowApprovalDecisionTypeExpired, approvalDeciderSystem, ""); err != nil {
+			app.GetLogger().Error(fmt.Sprintf("failed to expire the approval of workflow run #%s", workflowRun.Id), slog.Any("error", err))
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
 func (s *WorkflowService) cleanupHistoryRuns(ctx context.Context) error {
 	globalSettingsForPersistence := settings.GetGlobalSettingsForPersistence()
 	if globalSettingsForPersistence.WorkflowRunsRetentionMaxDays != 0 {
 		ret, err := s.workflowRunRepo.DeleteWithExprs(ctx,
 			dbx.NewExp(fmt.Sprintf("status!='%s'", domain.WorkflowRunStatusTypePending)),
 			dbx.NewExp(fmt.Sprintf("status!='%s'", domain.WorkflowRunStatusTypeProcessing)),
+			dbx.NewExp(fmt.Sprintf("status!='%s'", domain.WorkflowRunStatusTypeWaiting)),
 			dbx.NewExp(fmt.Sprintf("endedAt<DATETIME('now', '-%d days')", globalSettingsForPersistence.WorkflowRunsRetentionMaxDays)),
 		)
 		if err != nil {
@@ -154,3 +621,19 @@ func (s *WorkflowService) cleanupHistoryRuns(ctx context.Context) error {
 
 	return nil
 }
+
+func toStatisticsRunDto(run dispatcher.StatisticsRun) *dtos.WorkflowStatisticsRun {
+	dto := &dtos.WorkflowStatisticsRun{
+		WorkflowId: run.WorkflowId,
+		RunId:      run.RunId,
+		Priority:   run.Priority,
+		Position:   run.Position,
+		EnqueuedAt: run.EnqueuedAt,
+		WaitTime:   run.WaitTime.Milliseconds(),
+	}
+	if !run.DispatchedAt.IsZero() {
+		dto.DispatchedAt = &run.DispatchedAt
+	}
+
+	return dto
+}
@@ -2,6 +2,7 @@ package workflow
 
 import (
 	"context"
+	"time"
 
 	"github.com/pocketbase/dbx"
 
@@ -10,13 +11,33 @@ import (
 
 type workflowRepository interface {
 	ListEnabledScheduled(ctx context.Context) ([]*domain.Workflow, error)
+	ListEnabledEventTriggered(ctx context.Context, eventType domain.EventType) ([]*domain.Workflow, error)
+	ListDeferredRunDue(ctx context.Context, before time.Time) ([]*domain.Workflow, error)
 	GetById(ctx context.Context, id string) (*domain.Workflow, error)
 	Save(ctx context.Context, workflow *domain.Workflow) (*domain.Workflow, error)
+	SaveWithVersion(ctx context.Context, workflow *domain.Workflow, workflowVersion *domain.WorkflowVersion) (*domain.Workflow, error)
+	DeferRun(ctx context.Context, id string, runAt time.Time) (bool, error)
+	ClaimDeferredRun(ctx context.Context, id string, runAt time.Time) (bool, error)
+	CancelDeferredRun(ctx context.Context, id string) error
+	MarkCertificateExpiringFired(ctx context.Context, id string, certificateId string, validityNotAfter time.Time) (bool, error)
+	ClearCertificateExpiringFired(ctx context.Context, id string, certificateId string, validityNotAfter time.Time) error
 }
 
 type workflowRunRepository interface {
+	ListByStatus(ctx context.Context, status domain.WorkflowRunStatusType) ([]*domain.WorkflowRun, error)
+	ListActiveByWorkflowId(ctx context.Context, workflowId string) ([]*domain.WorkflowRun, error)
 	GetById(ctx context.Context, id string) (*domain.WorkflowRun, error)
 	Save(ctx context.Context, workflowRun *domain.WorkflowRun) (*domain.WorkflowRun, error)
 	SaveWithCascading(ctx context.Context, workflowRun *domain.WorkflowRun) (*domain.WorkflowRun, error)
+	SaveWithCascadingIfUnchanged(ctx context.Context, workflowRun *domain.WorkflowRun, status domain.WorkflowRunStatusType) (bool, error)
 	DeleteWithExprs(ctx context.Context, exprs ...dbx.Expression) (int, error)
 }
+
+type workflowLogRepository interface {
+	ListByWorkflowRunId(ctx context.Context, workflowRunId string) ([]*domain.WorkflowLog, error)
+}
+
+type workflowVersionRepository interface {
+	ListByWorkflowId(ctx context.Context, workflowId string) ([]*domain.WorkflowVersion, error)
+	GetByWorkflowIdAndVersion(ctx context.Context, workflowId string, version int) (*domain.WorkflowVersion, error)
+}
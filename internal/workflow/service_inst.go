@@ -16,6 +16,8 @@ func thisSvcInst() *WorkflowService {
 		thisSvc = NewWorkflowService(
 			repository.NewWorkflowRepository(),
 			repository.NewWorkflowRunRepository(),
+			repository.NewWorkflowVersionRepository(),
+			repository.NewWorkflowLogRepository(),
 		)
 	})
 	return thisSvc
@@ -0,0 +1,79 @@
+package workflow
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/certimate-go/certimate/internal/domain"
+	"github.com/certimate-go/certimate/internal/workflow/livelog"
+)
+
+const (
+	runEventsHeartbeatInterval = 15 * time.Second
+	runEventsPollInterval      = 2 * time.Second
+)
+
+// 轮询数据库以推送已持久化的日志与状态变化，用于不在本进程中执行的运行（如由集群中其他节点执行、或已结束较久）。
+// 此时无法获知节点的开始与结束事件；日志的事件 ID 以 "db-" 为前缀，后接已推送的日志条数。
+func (s *WorkflowService) streamPersistedRunEvents(ctx context.Context, workflowRun *domain.WorkflowRun, lastEventId string, emit func(event *domain.WorkflowRunEvent) error) error {
+	sent := 0
+	if n, ok := strings.CutPrefix(lastEventId, "db-"); ok {
+		sent, _ = strconv.Atoi(n)
+	}
+
+	lastStatus := domain.WorkflowRunStatusType("")
+	lastEmitAt := time.Now()
+
+	poll := time.NewTicker(runEventsPollInterval)
+	defer poll.Stop()
+
+	for {
+		logs, err := s.workflowLogRepo.ListByWorkflowRunId(ctx, workflowRun.Id)
+		if err != nil {
+			return err
+		}
+
+		for i := sent; i < len(logs); i++ {
+			event := livelog.NewLogEvent(logs[i])
+			event.Id = fmt.Sprintf("db-%d", i+1)
+			if err := emit(event); err != nil {
+				return err
+			}
+			lastEmitAt = time.Now()
+		}
+		sent = max(sent, len(logs))
+
+		if workflowRun.Status != lastStatus {
+			if err := emit(livelog.NewStatusEvent(workflowRun.Id, workflowRun.Status, workflowRun.Error)); err != nil {
+				return err
+			}
+			lastStatus = workflowRun.Status
+			lastEmitAt = time.Now()
+		}
+
+		if workflowRun.Status.IsFinished() {
+			return nil
+		}
+
+		if time.Since(lastEmitAt) >= runEventsHeartbeatInterval {
+			if err := emit(nil); err != nil {
+				return err
+			}
+			lastEmitAt = time.Now()
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-poll.C:
+		}
+
+		workflowRun, err = s.workflowRunRepo.GetById(ctx, workflowRun.Id)
+		if err != nil {
+			return err
+		}
+	}
+}
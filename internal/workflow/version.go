@@ -0,0 +1,119 @@
+package workflow
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+
+	"github.com/pocketbase/pocketbase/core"
+
+	"github.com/certimate-go/certimate/internal/domain"
+	"github.com/certimate-go/certimate/internal/workflow/engine"
+)
+
+// 发布工作流图时，可在请求体中携带此字段作为版本说明，其不会被保存到工作流记录中。
+const workflowVersionNoteRequestKey = "versionNote"
+
+// 检测工作流记录是否发布了新的工作流图，是则递增其版本号。
+func bumpWorkflowRecordGraphVersion(record *core.Record) bool {
+	if !record.GetBool("hasContent") {
+		return false
+	}
+
+	var newGraph any
+	if err := json.Unmarshal([]byte(record.GetString("graphContent")), &newGraph); err != nil || newGraph == nil {
+		return false
+	}
+
+	if !record.IsNew() {
+		var oldGraph any
+		json.Unmarshal([]byte(record.Original().GetString("graphContent")), &oldGraph)
+		if reflect.DeepEqual(oldGraph, newGraph) {
+			return false
+		}
+	}
+
+	record.Set("graphVersion", record.GetInt("graphVersion")+1)
+	return true
+}
+
+// 为新发布的工作流图创建不可变的版本记录。
+// txApp 须为保存工作流记录的事务，以便二者一同提交或回滚。
+func createWorkflowRecordGraphVersion(txApp core.App, record *core.Record, author string, note string) error {
+	collection, err := txApp.FindCollectionByNameOrId(domain.CollectionNameWorkflowVersion)
+	if err != nil {
+		return err
+	}
+
+	versionRecord := core.NewRecord(collection)
+	versionRecord.Set("workflowRef", record.Id)
+	versionRecord.Set("version", record.GetInt("graphVersion"))
+	versionRecord.Set("graph", record.Get("graphContent"))
+	versionRecord.Set("author", author)
+	versionRecord.Set("note", note)
+	return txApp.Save(versionRecord)
+}
+
+// 发布前对工作流图进行静态校验。
+func validateWorkflowRecordGraph(ctx context.Context, record *core.Record) error {
+	graph := &domain.WorkflowGraph{}
+	if err := record.UnmarshalJSONField("graphContent", graph); err != nil {
+		return err
+	}
+
+	return engine.NewGraphValidator().Validate(ctx, graph)
+}
+
+// 保存草稿时对工作流图进行静态校验，校验结果将记录在工作流记录中，以便前端按节点展示错误。
+// 与发布时不同，草稿允许不完整，校验不通过时不会阻止保存。
+func validateWorkflowRecordGraphDraft(ctx context.Context, record *core.Record) {
+	var newGraph any
+	json.Unmarshal([]byte(record.GetString("graphDraft")), &newGraph)
+	if !record.IsNew() {
+		var oldGraph any
+		json.Unmarshal([]byte(record.Original().GetString("graphDraft")), &oldGraph)
+		if reflect.DeepEqual(oldGraph, newGraph) {
+			return
+		}
+	}
+
+	graph := &domain.WorkflowGraph{}
+	if newGraph == nil {
+		record.Set("graphDraftErrors", nil)
+		return
+	} else if err := record.UnmarshalJSONField("graphDraft", graph); err != nil {
+		record.Set("graphDraftErrors", &domain.WorkflowGraphValidationError{Errors: []string{"field 'graphDraft' is malformed"}})
+		return
+	}
+
+	if err := engine.NewGraphValidator().Validate(ctx, graph); err != nil {
+		var verr *domain.WorkflowGraphValidationError
+		if !errors.As(err, &verr) {
+			verr = &domain.WorkflowGraphValidationError{Errors: []string{err.Error()}}
+		}
+
+		record.Set("graphDraftErrors", verr)
+		return
+	}
+
+	record.Set("graphDraftErrors", nil)
+}
+
+// 以 PocketBase 的错误响应格式返回校验错误。
+// 由于 [router.ApiError] 会过滤掉非表单字段类型的错误数据，这里需直接写入响应体，以保留按节点归集的错误列表。
+func respondWorkflowGraphValidationError(e *core.RecordRequestEvent, err error) error {
+	var verr *domain.WorkflowGraphValidationError
+	if !errors.As(err, &verr) {
+		return e.BadRequestError(err.Error(), nil)
+	}
+
+	return e.JSON(http.StatusBadRequest, map[string]any{
+		"status":  http.StatusBadRequest,
+		"message": verr.Error(),
+		"data": map[string]any{
+			"graphContent": verr,
+		},
+	})
+}
@@ -0,0 +1,120 @@
+package workflow
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pocketbase/pocketbase/tools/security"
+
+	"github.com/certimate-go/certimate/internal/domain/dtos"
+)
+
+const (
+	webhookVarKeyPayload = "webhook.payload"
+
+	webhookPayloadMaxDepth = 8
+	webhookPayloadMaxKeys  = 256
+
+	// HMAC 签名中的时间戳与当前时间所允许的最大偏差
+	webhookSignatureTolerance = 5 * time.Minute
+)
+
+func generateWebhookSecret() string {
+	return security.RandomString(40)
+}
+
+// 校验 Webhook 请求，支持以下两种方式之一：
+//   - 令牌认证：请求标头中携带的令牌与密钥完全一致；
+//   - HMAC 签名：请求标头中携带 Unix 时间戳（单位：秒），及以密钥对 "{时间戳}.{请求体}" 进行 HMAC-SHA256 运算后的十六进制字符串，可带有 "sha256=" 前缀。
+//     时间戳与当前时间相差超过 [webhookSignatureTolerance] 时拒绝之，以免请求被截获后重放。
+func verifyWebhookRequest(secret string, req *dtos.WorkflowTriggerWebhookReq, now time.Time) bool {
+	if secret == "" {
+		return false
+	}
+
+	if req.Token != "" {
+		return subtle.ConstantTimeCompare([]byte(req.Token), []byte(secret)) == 1
+	}
+
+	if req.Signature != "" {
+		timestamp, err := strconv.ParseInt(req.Timestamp, 10, 64)
+		if err != nil {
+			return false
+		} else if d := now.Sub(time.Unix(timestamp, 0)); d > webhookSignatureTolerance || d < -webhookSignatureTolerance {
+			return false
+		}
+
+		signature, err := hex.DecodeString(strings.TrimPrefix(req.Signature, "sha256="))
+		if err != nil {
+			return false
+		}
+
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write([]byte(req.Timestamp))
+		mac.Write([]byte("."))
+		mac.Write(req.Payload)
+		return hmac.Equal(signature, mac.Sum(nil))
+	}
+
+	return false
+}
+
+// 将 JSON 格式的请求体展开为工作流变量。
+// 例如请求体 `{"domain":"example.com","meta":{"ttl":60}}` 将被展开为：
+//   - "webhook.payload": `{"domain":"example.com","meta":{"ttl":60}}`
+//   - "webhook.payload.domain": "example.com"
+//   - "webhook.payload.meta.ttl": 60
+func parseWebhookPayload(payload []byte) (map[string]any, error) {
+	inputs := make(map[string]any)
+
+	payload = bytes.TrimSpace(payload)
+	if len(payload) == 0 {
+		return inputs, nil
+	}
+
+	var data any
+	if err := json.Unmarshal(payload, &data); err != nil {
+		return nil, fmt.Errorf("the webhook payload is not a valid json: %w", err)
+	}
+
+	inputs[webhookVarKeyPayload] = string(payload)
+	if dict, ok := data.(map[string]any); ok {
+		if err := flattenWebhookPayload(inputs, webhookVarKeyPayload, dict, 1); err != nil {
+			return nil, err
+		}
+	}
+
+	return inputs, nil
+}
+
+func flattenWebhookPayload(inputs map[string]any, prefix string, dict map[string]any, depth int) error {
+	for key, value := range dict {
+		if len(inputs) >= webhookPayloadMaxKeys {
+			return fmt.Errorf("the webhook payload has too many fields (limit: %d)", webhookPayloadMaxKeys)
+		}
+
+		path := prefix + "." + key
+		switch v := value.(type) {
+		case map[string]any:
+			if depth >= webhookPayloadMaxDepth {
+				return fmt.Errorf("the webhook payload is too deep (limit: %d)", webhookPayloadMaxDepth)
+			}
+			if err := flattenWebhookPayload(inputs, path, v, depth+1); err != nil {
+				return err
+			}
+
+		default:
+			inputs[path] = v
+		}
+	}
+
+	return nil
+}
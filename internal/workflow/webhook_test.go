@@ -0,0 +1,206 @@
+package workflow
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/certimate-go/certimate/internal/domain/dtos"
+)
+
+func signWebhookPayload(secret string, timestamp string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestVerifyWebhookRequest(t *testing.T) {
+	const secret = "s3cr3t"
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	payload := []byte(`{"domain":"example.com"}`)
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+	signature := signWebhookPayload(secret, timestamp, payload)
+
+	tests := []struct {
+		name   string
+		secret string
+		req    *dtos.WorkflowTriggerWebhookReq
+		want   bool
+	}{
+		{
+			name:   "valid token",
+			secret: secret,
+			req:    &dtos.WorkflowTriggerWebhookReq{Token: secret},
+			want:   true,
+		},
+		{
+			name:   "invalid token",
+			secret: secret,
+			req:    &dtos.WorkflowTriggerWebhookReq{Token: "s3cr3"},
+		},
+		{
+			name:   "invalid token takes precedence over valid signature",
+			secret: secret,
+			req:    &dtos.WorkflowTriggerWebhookReq{Token: "s3cr3", Signature: signature, Timestamp: timestamp, Payload: payload},
+		},
+		{
+			name:   "empty secret",
+			secret: "",
+			req:    &dtos.WorkflowTriggerWebhookReq{Token: ""},
+		},
+		{
+			name:   "no credentials",
+			secret: secret,
+			req:    &dtos.WorkflowTriggerWebhookReq{Payload: payload},
+		},
+		{
+			name:   "valid signature",
+			secret: secret,
+			req:    &dtos.WorkflowTriggerWebhookReq{Signature: signature, Timestamp: timestamp, Payload: payload},
+			want:   true,
+		},
+		{
+			name:   "valid signature with prefix",
+			secret: secret,
+			req:    &dtos.WorkflowTriggerWebhookReq{Signature: "sha256=" + signature, Timestamp: timestamp, Payload: payload},
+			want:   true,
+		},
+		{
+			name:   "tampered payload",
+			secret: secret,
+			req:    &dtos.WorkflowTriggerWebhookReq{Signature: signature, Timestamp: timestamp, Payload: []byte(`{"domain":"example.org"}`)},
+		},
+		{
+			name:   "signature of another secret",
+			secret: secret,
+			req:    &dtos.WorkflowTriggerWebhookReq{Signature: signWebhookPayload("another", timestamp, payload), Timestamp: timestamp, Payload: payload},
+		},
+		{
+			name:   "signature without timestamp",
+			secret: secret,
+			req:    &dtos.WorkflowTriggerWebhookReq{Signature: signWebhookPayload(secret, "", payload), Payload: payload},
+		},
+		{
+			name:   "signature of another timestamp",
+			secret: secret,
+			req:    &dtos.WorkflowTriggerWebhookReq{Signature: signature, Timestamp: strconv.FormatInt(now.Unix()-1, 10), Payload: payload},
+		},
+		{
+			name:   "malformed signature",
+			secret: secret,
+			req:    &dtos.WorkflowTriggerWebhookReq{Signature: "sha256=xyz", Timestamp: timestamp, Payload: payload},
+		},
+		{
+			name:   "timestamp within tolerance",
+			secret: secret,
+			req: func() *dtos.WorkflowTriggerWebhookReq {
+				timestamp := strconv.FormatInt(now.Add(-webhookSignatureTolerance).Unix(), 10)
+				return &dtos.WorkflowTriggerWebhookReq{Signature: signWebhookPayload(secret, timestamp, payload), Timestamp: timestamp, Payload: payload}
+			}(),
+			want: true,
+		},
+		{
+			name:   "timestamp too old",
+			secret: secret,
+			req: func() *dtos.WorkflowTriggerWebhookReq {
+				timestamp := strconv.FormatInt(now.Add(-webhookSignatureTolerance-time.Second).Unix(), 10)
+				return &dtos.WorkflowTriggerWebhookReq{Signature: signWebhookPayload(secret, timestamp, payload), Timestamp: timestamp, Payload: payload}
+			}(),
+		},
+		{
+			name:   "timestamp too new",
+			secret: secret,
+			req: func() *dtos.WorkflowTriggerWebhookReq {
+				timestamp := strconv.FormatInt(now.Add(webhookSignatureTolerance+time.Second).Unix(), 10)
+				return &dtos.WorkflowTriggerWebhookReq{Signature: signWebhookPayload(secret, timestamp, payload), Timestamp: timestamp, Payload: payload}
+			}(),
+		},
+		{
+			name:   "malformed timestamp",
+			secret: secret,
+			req: func() *dtos.WorkflowTriggerWebhookReq {
+				timestamp := now.Format(time.RFC3339)
+				return &dtos.WorkflowTriggerWebhookReq{Signature: signWebhookPayload(secret, timestamp, payload), Timestamp: timestamp, Payload: payload}
+			}(),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := verifyWebhookRequest(tt.secret, tt.req, now); got != tt.want {
+				t.Errorf("verifyWebhookRequest() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseWebhookPayload(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload string
+		want    map[string]any
+		wantErr bool
+	}{
+		{
+			name:    "empty payload",
+			payload: " \n",
+			want:    map[string]any{},
+		},
+		{
+			name:    "nested object",
+			payload: `{"domain":"example.com","meta":{"ttl":60,"tags":["a","b"]}}`,
+			want: map[string]any{
+				webhookVarKeyPayload:                `{"domain":"example.com","meta":{"ttl":60,"tags":["a","b"]}}`,
+				webhookVarKeyPayload + ".domain":    "example.com",
+				webhookVarKeyPayload + ".meta.ttl":  float64(60),
+				webhookVarKeyPayload + ".meta.tags": []any{"a", "b"},
+			},
+		},
+		{
+			name:    "non-object payload",
+			payload: `["a","b"]`,
+			want:    map[string]any{webhookVarKeyPayload: `["a","b"]`},
+		},
+		{
+			name:    "invalid json",
+			payload: `{"domain":`,
+			wantErr: true,
+		},
+		{
+			name:    "too deep",
+			payload: strings.Repeat(`{"a":`, webhookPayloadMaxDepth+1) + "1" + strings.Repeat(`}`, webhookPayloadMaxDepth+1),
+			wantErr: true,
+		},
+		{
+			name: "too many fields",
+			payload: func() string {
+				fields := make([]string, 0, webhookPayloadMaxKeys)
+				for i := 0; i < webhookPayloadMaxKeys; i++ {
+					fields = append(fields, `"k`+strconv.Itoa(i)+`":1`)
+				}
+				return "{" + strings.Join(fields, ",") + "}"
+			}(),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseWebhookPayload([]byte(tt.payload))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseWebhookPayload() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseWebhookPayload() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
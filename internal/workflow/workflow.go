@@ -6,6 +6,8 @@ import (
 
 func Setup() {
 	registerWorkflowRecordEvents()
+	registerWorkflowRunRecordEvents()
+	registerWorkflowVersionRecordEvents()
 }
 
 func Teardown() {
@@ -15,9 +15,12 @@ import (
 
 	"github.com/certimate-go/certimate/cmd"
 	"github.com/certimate-go/certimate/internal/app"
+	"github.com/certimate-go/certimate/internal/certificate"
+	"github.com/certimate-go/certimate/internal/cluster"
 	"github.com/certimate-go/certimate/internal/rest/routes"
 	"github.com/certimate-go/certimate/internal/scheduler"
 	"github.com/certimate-go/certimate/internal/settings"
+	"github.com/certimate-go/certimate/internal/tracing"
 	"github.com/certimate-go/certimate/internal/workflow"
 	"github.com/certimate-go/certimate/ui"
 
@@ -69,7 +72,10 @@ func main() {
 		})
 
 		pb.OnServe().BindFunc(func(e *core.ServeEvent) error {
+			tracing.Setup()
+			cluster.Setup()
 			scheduler.Setup()
+			certificate.Setup()
 			workflow.Setup()
 			routes.BindRouter(e.Router)
 
@@ -84,6 +90,8 @@ func main() {
 		pb.OnTerminate().BindFunc(func(e *core.TerminateEvent) error {
 			if pb.IsBootstrapped() {
 				workflow.Teardown()
+				cluster.Teardown()
+				tracing.Teardown()
 			}
 
 			return e.Next()
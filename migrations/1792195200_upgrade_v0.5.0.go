@@ -0,0 +1,676 @@
+package migrations
+
+import (
+	"errors"
+	"slices"
+
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		tracer := NewTracer("v0.5.0")
+		tracer.Printf("go ...")
+
+		// update collection `workflow`
+		//   - add field `triggerCronOptions`
+		//   - add field `deferredRunAt`
+		//   - add field `triggerEvent`
+		//   - add field `triggerEventFired`
+		//   - add field `webhookSecret`
+		//   - add field `runTimeout`
+		//   - add field `concurrencyPolicy`
+		//   - add field `mutexGroups`
+		//   - add field `priority`
+		//   - add field `schedulingGroup`
+		//   - add field `graphVersion`
+		//   - add field `graphDraftErrors`
+		//   - modify field `trigger`
+		//   - modify field `lastRunStatus`
+		{
+			collection, err := app.FindCollectionByNameOrId("tovyif5ax6j62ur")
+			if err != nil {
+				return err
+			}
+
+			if err := collection.Fields.AddMarshaledJSONAt(5, []byte(`{
+				"hidden": false,
+				"id": "json1829076341",
+				"maxSize": 0,
+				"name": "triggerEvent",
+				"presentable": false,
+				"required": false,
+				"system": false,
+				"type": "json"
+			}`)); err != nil {
+				return err
+			}
+
+			if err := collection.Fields.AddMarshaledJSONAt(6, []byte(`{
+				"hidden": false,
+				"id": "json2460385926",
+				"maxSize": 0,
+				"name": "triggerEventFired",
+				"presentable": false,
+				"required": false,
+				"system": false,
+				"type": "json"
+			}`)); err != nil {
+				return err
+			}
+
+			if err := collection.Fields.AddMarshaledJSONAt(6, []byte(`{
+				"autogeneratePattern": "",
+				"hidden": false,
+				"id": "text2906524375",
+				"max": 0,
+				"min": 0,
+				"name": "webhookSecret",
+				"pattern": "",
+				"presentable": false,
+				"primaryKey": false,
+				"required": false,
+				"system": false,
+				"type": "text"
+			}`)); err != nil {
+				return err
+			}
+
+			if err := collection.Fields.AddMarshaledJSONAt(7, []byte(`{
+				"hidden": false,
+				"id": "number2581297434",
+				"max": null,
+				"min": 0,
+				"name": "runTimeout",
+				"onlyInt": true,
+				"presentable": false,
+				"required": false,
+				"system": false,
+				"type": "number"
+			}`)); err != nil {
+				return err
+			}
+
+			if err := collection.Fields.AddMarshaledJSONAt(13, []byte(`{
+				"hidden": false,
+				"id": "number1587448267",
+				"max": null,
+				"min": 0,
+				"name": "graphVersion",
+				"onlyInt": true,
+				"presentable": false,
+				"required": false,
+				"system": false,
+				"type": "number"
+			}`)); err != nil {
+				return err
+			}
+
+			if err := collection.Fields.AddMarshaledJSONAt(8, []byte(`{
+				"hidden": false,
+				"id": "select3254418613",
+				"maxSelect": 1,
+				"name": "concurrencyPolicy",
+				"presentable": false,
+				"required": false,
+				"system": false,
+				"type": "select",
+				"values": [
+					"allow",
+					"skip",
+					"queue",
+					"cancelRunning"
+				]
+			}`)); err != nil {
+				return err
+			}
+
+			if err := collection.Fields.AddMarshaledJSONAt(9, []byte(`{
+				"hidden": false,
+				"id": "json2671840509",
+				"maxSize": 0,
+				"name": "mutexGroups",
+				"presentable": false,
+				"required": false,
+				"system": false,
+				"type": "json"
+			}`)); err != nil {
+				return err
+			}
+
+			if err := collection.Fields.AddMarshaledJSONAt(10, []byte(`{
+				"hidden": false,
+				"id": "number1655102503",
+				"max": null,
+				"min": null,
+				"name": "priority",
+				"onlyInt": true,
+				"presentable": false,
+				"required": false,
+				"system": false,
+				"type": "number"
+			}`)); err != nil {
+				return err
+			}
+
+			if err := collection.Fields.AddMarshaledJSONAt(11, []byte(`{
+				"autogeneratePattern": "",
+				"hidden": false,
+				"id": "text3429085233",
+				"max": 0,
+				"min": 0,
+				"name": "schedulingGroup",
+				"pattern": "",
+				"presentable": false,
+				"primaryKey": false,
+				"required": false,
+				"system": false,
+				"type": "text"
+			}`)); err != nil {
+				return err
+			}
+
+			if err := collection.Fields.AddMarshaledJSONAt(5, []byte(`{
+				"hidden": false,
+				"id": "json1437165934",
+				"maxSize": 0,
+				"name": "triggerCronOptions",
+				"presentable": false,
+				"required": false,
+				"system": false,
+				"type": "json"
+			}`)); err != nil {
+				return err
+			}
+
+			if err := collection.Fields.AddMarshaledJSON([]byte(`{
+				"hidden": false,
+				"id": "date3527581403",
+				"max": "",
+				"min": "",
+				"name": "deferredRunAt",
+				"presentable": false,
+				"required": false,
+				"system": false,
+				"type": "date"
+			}`)); err != nil {
+				return err
+			}
+
+			if err := collection.Fields.AddMarshaledJSON([]byte(`{
+				"hidden": false,
+				"id": "json3841590542",
+				"maxSize": 0,
+				"name": "graphDraftErrors",
+				"presentable": false,
+				"required": false,
+				"system": false,
+				"type": "json"
+			}`)); err != nil {
+				return err
+			}
+
+			if field, ok := collection.Fields.GetByName("trigger").(*core.SelectField); ok {
+				for _, value := range []string{"webhook", "event"} {
+					if !slices.Contains(field.Values, value) {
+						field.Values = append(field.Values, value)
+					}
+				}
+			}
+
+			if field, ok := collection.Fields.GetByName("lastRunStatus").(*core.SelectField); ok {
+				for _, value := range []string{"timeout", "waiting", "interrupted"} {
+					if !slices.Contains(field.Values, value) {
+						field.Values = append(field.Values, value)
+					}
+				}
+			}
+
+			if err := app.Save(collection); err != nil {
+				return err
+			}
+
+			tracer.Printf("collection '%s' updated", collection.Name)
+		}
+
+		// update collection `workflow_run`
+		//   - add field `parentRunRef`
+		//   - add field `graphVersion`
+		//   - add field `inputs`
+		//   - add field `state`
+		//   - add field `attempt`
+		//   - add field `claimedBy`
+		//   - add field `leaseExpiresAt`
+		//   - modify field `trigger`
+		//   - modify field `status`
+		{
+			collection, err := app.FindCollectionByNameOrId("qjp8lygssgwyqyz")
+			if err != nil {
+				return err
+			}
+
+			if err := collection.Fields.AddMarshaledJSONAt(2, []byte(`{
+				"cascadeDelete": true,
+				"collectionId": "qjp8lygssgwyqyz",
+				"hidden": false,
+				"id": "relation1652973213",
+				"maxSelect": 1,
+				"minSelect": 0,
+				"name": "parentRunRef",
+				"presentable": false,
+				"required": false,
+				"system": false,
+				"type": "relation"
+			}`)); err != nil {
+				return err
+			}
+
+			if err := collection.Fields.AddMarshaledJSONAt(8, []byte(`{
+				"hidden": false,
+				"id": "number3587284917",
+				"max": null,
+				"min": 0,
+				"name": "graphVersion",
+				"onlyInt": true,
+				"presentable": false,
+				"required": false,
+				"system": false,
+				"type": "number"
+			}`)); err != nil {
+				return err
+			}
+
+			if err := collection.Fields.AddMarshaledJSONAt(9, []byte(`{
+				"hidden": false,
+				"id": "json3112513070",
+				"maxSize": 5000000,
+				"name": "inputs",
+				"presentable": false,
+				"required": false,
+				"system": false,
+				"type": "json"
+			}`)); err != nil {
+				return err
+			}
+
+			if err := collection.Fields.AddMarshaledJSONAt(10, []byte(`{
+				"hidden": false,
+				"id": "json2744374011",
+				"maxSize": 0,
+				"name": "state",
+				"presentable": false,
+				"required": false,
+				"system": false,
+				"type": "json"
+			}`)); err != nil {
+				return err
+			}
+
+			if err := collection.Fields.AddMarshaledJSONAt(11, []byte(`{
+				"hidden": false,
+				"id": "number1990542658",
+				"max": null,
+				"min": 0,
+				"name": "attempt",
+				"onlyInt": true,
+				"presentable": false,
+				"required": false,
+				"system": false,
+				"type": "number"
+			}`)); err != nil {
+				return err
+			}
+
+			if err := collection.Fields.AddMarshaledJSONAt(12, []byte(`{
+				"autogeneratePattern": "",
+				"hidden": false,
+				"id": "text2538413657",
+				"max": 0,
+				"min": 0,
+				"name": "claimedBy",
+				"pattern": "",
+				"presentable": false,
+				"primaryKey": false,
+				"required": false,
+				"system": false,
+				"type": "text"
+			}`)); err != nil {
+				return err
+			}
+
+			if err := collection.Fields.AddMarshaledJSONAt(13, []byte(`{
+				"hidden": false,
+				"id": "date1843507981",
+				"max": "",
+				"min": "",
+				"name": "leaseExpiresAt",
+				"presentable": false,
+				"required": false,
+				"system": false,
+				"type": "date"
+			}`)); err != nil {
+				return err
+			}
+
+			if field, ok := collection.Fields.GetByName("trigger").(*core.SelectField); ok {
+				for _, value := range []string{"webhook", "event"} {
+					if !slices.Contains(field.Values, value) {
+						field.Values = append(field.Values, value)
+					}
+				}
+			}
+
+			if field, ok := collection.Fields.GetByName("status").(*core.SelectField); ok {
+				for _, value := range []string{"timeout", "waiting", "interrupted"} {
+					if !slices.Contains(field.Values, value) {
+						field.Values = append(field.Values, value)
+					}
+				}
+			}
+
+			collection.AddIndex("idx_Kq3xWb8rTe", false, "`parentRunRef`", "")
+
+			if err := app.Save(collection); err != nil {
+				return err
+			}
+
+			tracer.Printf("collection '%s' updated", collection.Name)
+		}
+
+		// update collection `workflow_logs`
+		//   - add field `traceId`
+		{
+			collection, err := app.FindCollectionByNameOrId("pbc_1682296116")
+			if err != nil {
+				return err
+			}
+
+			if err := collection.Fields.AddMarshaledJSONAt(9, []byte(`{
+				"autogeneratePattern": "",
+				"hidden": false,
+				"id": "text1956260437",
+				"max": 0,
+				"min": 0,
+				"name": "traceId",
+				"pattern": "",
+				"presentable": false,
+				"primaryKey": false,
+				"required": false,
+				"system": false,
+				"type": "text"
+			}`)); err != nil {
+				return err
+			}
+
+			collection.AddIndex("idx_Tz7pLs2cVd", false, "`traceId`", "")
+
+			if err := app.Save(collection); err != nil {
+				return err
+			}
+
+			tracer.Printf("collection '%s' updated", collection.Name)
+		}
+
+		// create collection `workflow_version`
+		{
+			jsonData := `[
+				{
+					"fields": [
+						{
+							"autogeneratePattern": "[a-z0-9]{15}",
+							"hidden": false,
+							"id": "text3208210256",
+							"max": 15,
+							"min": 15,
+							"name": "id",
+							"pattern": "^[a-z0-9]+$",
+							"presentable": false,
+							"primaryKey": true,
+							"required": true,
+							"system": true,
+							"type": "text"
+						},
+						{
+							"cascadeDelete": true,
+							"collectionId": "tovyif5ax6j62ur",
+							"hidden": false,
+							"id": "relation3371272342",
+							"maxSelect": 1,
+							"minSelect": 0,
+							"name": "workflowRef",
+							"presentable": false,
+							"required": false,
+							"system": false,
+							"type": "relation"
+						},
+						{
+							"hidden": false,
+							"id": "number2057364212",
+							"max": null,
+							"min": 0,
+							"name": "version",
+							"onlyInt": true,
+							"presentable": false,
+							"required": false,
+							"system": false,
+							"type": "number"
+						},
+						{
+							"hidden": false,
+							"id": "json3362412466",
+							"maxSize": 5000000,
+							"name": "graph",
+							"presentable": false,
+							"required": false,
+							"system": false,
+							"type": "json"
+						},
+						{
+							"autogeneratePattern": "",
+							"hidden": false,
+							"id": "text3182418120",
+							"max": 0,
+							"min": 0,
+							"name": "author",
+							"pattern": "",
+							"presentable": false,
+							"primaryKey": false,
+							"required": false,
+							"system": false,
+							"type": "text"
+						},
+						{
+							"autogeneratePattern": "",
+							"hidden": false,
+							"id": "text3065852031",
+							"max": 0,
+							"min": 0,
+							"name": "note",
+							"pattern": "",
+							"presentable": false,
+							"primaryKey": false,
+							"required": false,
+							"system": false,
+							"type": "text"
+						},
+						{
+							"hidden": false,
+							"id": "autodate2990389176",
+							"name": "created",
+							"onCreate": true,
+							"onUpdate": false,
+							"presentable": false,
+							"system": false,
+							"type": "autodate"
+						},
+						{
+							"hidden": false,
+							"id": "autodate3332085495",
+							"name": "updated",
+							"onCreate": true,
+							"onUpdate": true,
+							"presentable": false,
+							"system": false,
+							"type": "autodate"
+						}
+					],
+					"id": "xw7mxn3q4rb8v2k",
+					"indexes": [
+						"CREATE INDEX ` + "`" + `idx_Vr4nT8qLwZ` + "`" + ` ON ` + "`" + `workflow_version` + "`" + ` (` + "`" + `workflowRef` + "`" + `)",
+						"CREATE UNIQUE INDEX ` + "`" + `idx_Hc2pY6mJsD` + "`" + ` ON ` + "`" + `workflow_version` + "`" + ` (` + "`" + `workflowRef` + "`" + `, ` + "`" + `version` + "`" + `)"
+					],
+					"name": "workflow_version",
+					"system": false,
+					"type": "base",
+					"listRule": null,
+					"viewRule": null,
+					"createRule": null,
+					"updateRule": null,
+					"deleteRule": null
+				}
+			]`
+			if err := app.ImportCollectionsByMarshaledJSON([]byte(jsonData), false); err != nil {
+				return err
+			}
+
+			tracer.Printf("collection 'workflow_version' created")
+		}
+
+		// create collection `cluster_lease`
+		{
+			jsonData := `[
+				{
+					"fields": [
+						{
+							"autogeneratePattern": "[a-z0-9]{15}",
+							"hidden": false,
+							"id": "text3208210256",
+							"max": 15,
+							"min": 15,
+							"name": "id",
+							"pattern": "^[a-z0-9]+$",
+							"presentable": false,
+							"primaryKey": true,
+							"required": true,
+							"system": true,
+							"type": "text"
+						},
+						{
+							"autogeneratePattern": "",
+							"hidden": false,
+							"id": "text1579384326",
+							"max": 0,
+							"min": 0,
+							"name": "name",
+							"pattern": "",
+							"presentable": false,
+							"primaryKey": false,
+							"required": true,
+							"system": false,
+							"type": "text"
+						},
+						{
+							"autogeneratePattern": "",
+							"hidden": false,
+							"id": "text2452836134",
+							"max": 0,
+							"min": 0,
+							"name": "holder",
+							"pattern": "",
+							"presentable": false,
+							"primaryKey": false,
+							"required": false,
+							"system": false,
+							"type": "text"
+						},
+						{
+							"hidden": false,
+							"id": "date261981154",
+							"max": "",
+							"min": "",
+							"name": "expiresAt",
+							"presentable": false,
+							"required": false,
+							"system": false,
+							"type": "date"
+						},
+						{
+							"hidden": false,
+							"id": "autodate2990389176",
+							"name": "created",
+							"onCreate": true,
+							"onUpdate": false,
+							"presentable": false,
+							"system": false,
+							"type": "autodate"
+						},
+						{
+							"hidden": false,
+							"id": "autodate3332085495",
+							"name": "updated",
+							"onCreate": true,
+							"onUpdate": true,
+							"presentable": false,
+							"system": false,
+							"type": "autodate"
+						}
+					],
+					"id": "c7lus2tr8le4se9",
+					"indexes": [
+						"CREATE UNIQUE INDEX ` + "`" + `idx_Nf5kR2wQxM` + "`" + ` ON ` + "`" + `cluster_lease` + "`" + ` (` + "`" + `name` + "`" + `)"
+					],
+					"name": "cluster_lease",
+					"system": false,
+					"type": "base"
+				}
+			]`
+			if err := app.ImportCollectionsByMarshaledJSON([]byte(jsonData), false); err != nil {
+				return err
+			}
+
+			tracer.Printf("collection 'cluster_lease' created")
+		}
+
+		// migrate data
+		//   - create the initial version for published workflows
+		{
+			collection, err := app.FindCollectionByNameOrId("xw7mxn3q4rb8v2k")
+			if err != nil {
+				return err
+			}
+
+			records, err := app.FindAllRecords("workflow")
+			if err != nil {
+				return err
+			}
+
+			for _, record := range records {
+				if !record.GetBool("hasContent") || record.GetInt("graphVersion") > 0 {
+					continue
+				}
+
+				versionRecord := core.NewRecord(collection)
+				versionRecord.Set("workflowRef", record.Id)
+				versionRecord.Set("version", 1)
+				versionRecord.Set("graph", record.Get("graphContent"))
+				versionRecord.Set("note", "initial version")
+				if err := app.Save(versionRecord); err != nil {
+					return err
+				}
+
+				record.Set("graphVersion", 1)
+				if err := app.Save(record); err != nil {
+					return err
+				}
+
+				tracer.Printf("record #%s in collection '%s' updated", record.Id, record.Collection().Name)
+			}
+		}
+
+		tracer.Printf("done")
+		return nil
+	}, func(app core.App) error {
+		return errors.ErrUnsupported
+	})
+}
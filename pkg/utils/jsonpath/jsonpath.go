@@ -0,0 +1,216 @@
+package jsonpath
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// 未找到匹配的值时返回的错误。
+var ErrNotFound = errors.New("jsonpath: no value matched")
+
+type segmentKind int
+
+const (
+	segmentKindKey segmentKind = iota
+	segmentKindIndex
+	segmentKindWildcard
+)
+
+type segment struct {
+	kind  segmentKind
+	key   string
+	index int
+}
+
+// 已编译的 JSONPath 表达式。
+type Path struct {
+	raw      string
+	segments []segment
+	multiple bool
+}
+
+// 编译 JSONPath 表达式。
+// 仅支持常用的子集：成员访问（如 "$.a.b"、"$['a']"）、数组下标（如 "$.a[0]"、"$.a[-1]"）以及通配符（如 "$.a[*]"、"$.a.*"）。
+// 根节点标识符 "$" 可以省略。
+//
+// 入参：
+//   - path: JSONPath 表达式。
+//
+// 出参：
+//   - 已编译的表达式。
+//   - 错误。
+func Compile(path string) (*Path, error) {
+	s := strings.TrimSpace(path)
+	if s == "" {
+		return nil, errors.New("jsonpath: empty path")
+	}
+
+	p := &Path{raw: path, segments: make([]segment, 0)}
+
+	i := 0
+	if s[0] == '$' {
+		i++
+	}
+	for i < len(s) {
+		switch {
+		case s[i] == '.' || (i == 0 && s[i] != '['):
+			if s[i] == '.' {
+				i++
+			}
+			if i < len(s) && s[i] == '.' {
+				return nil, fmt.Errorf("jsonpath: recursive descent is not supported in '%s'", path)
+			}
+			if i < len(s) && s[i] == '*' {
+				p.segments = append(p.segments, segment{kind: segmentKindWildcard})
+				p.multiple = true
+				i++
+				continue
+			}
+
+			start := i
+			for i < len(s) && s[i] != '.' && s[i] != '[' {
+				i++
+			}
+			if start == i {
+				return nil, fmt.Errorf("jsonpath: empty member name at position %d in '%s'", start, path)
+			}
+			p.segments = append(p.segments, segment{kind: segmentKindKey, key: s[start:i]})
+
+		case s[i] == '[':
+			i++
+			if i < len(s) && (s[i] == '\'' || s[i] == '"') {
+				quote := s[i]
+				i++
+
+				var sb strings.Builder
+				for i < len(s) && s[i] != quote {
+					if s[i] == '\\' && i+1 < len(s) {
+						i++
+					}
+					sb.WriteByte(s[i])
+					i++
+				}
+				if i+1 >= len(s) || s[i+1] != ']' {
+					return nil, fmt.Errorf("jsonpath: unterminated bracket in '%s'", path)
+				}
+				i += 2
+				p.segments = append(p.segments, segment{kind: segmentKindKey, key: sb.String()})
+				continue
+			}
+
+			end := strings.IndexByte(s[i:], ']')
+			if end < 0 {
+				return nil, fmt.Errorf("jsonpath: unterminated bracket in '%s'", path)
+			}
+			content := strings.TrimSpace(s[i : i+end])
+			i += end + 1
+
+			if content == "*" {
+				p.segments = append(p.segments, segment{kind: segmentKindWildcard})
+				p.multiple = true
+				continue
+			}
+
+			index, err := strconv.Atoi(content)
+			if err != nil {
+				return nil, fmt.Errorf("jsonpath: invalid array index '%s' in '%s'", content, path)
+			}
+			p.segments = append(p.segments, segment{kind: segmentKindIndex, index: index})
+
+		default:
+			return nil, fmt.Errorf("jsonpath: unexpected character '%c' at position %d in '%s'", s[i], i, path)
+		}
+	}
+
+	return p, nil
+}
+
+// 返回表达式是否可能匹配多个值（即含有通配符）。
+func (p *Path) IsMultiple() bool {
+	return p.multiple
+}
+
+func (p *Path) String() string {
+	return p.raw
+}
+
+// 从已解析的 JSON 数据中查询值。
+//
+// 入参：
+//   - data: 由 [encoding/json] 解析得到的数据。
+//
+// 出参：
+//   - 匹配的值。表达式含有通配符时，返回所有匹配值组成的 []any。
+//   - 错误。表达式不含通配符且未找到匹配的值时返回 [ErrNotFound]。
+func (p *Path) Query(data any) (any, error) {
+	values := []any{data}
+	for _, seg := range p.segments {
+		next := make([]any, 0, len(values))
+		for _, value := range values {
+			switch seg.kind {
+			case segmentKindKey:
+				if m, ok := value.(map[string]any); ok {
+					if v, ok := m[seg.key]; ok {
+						next = append(next, v)
+					}
+				}
+
+			case segmentKindIndex:
+				if a, ok := value.([]any); ok {
+					index := seg.index
+					if index < 0 {
+						index += len(a)
+					}
+					if index >= 0 && index < len(a) {
+						next = append(next, a[index])
+					}
+				}
+
+			case segmentKindWildcard:
+				switch v := value.(type) {
+				case map[string]any:
+					keys := make([]string, 0, len(v))
+					for k := range v {
+						keys = append(keys, k)
+					}
+					slices.Sort(keys)
+					for _, k := range keys {
+						next = append(next, v[k])
+					}
+				case []any:
+					next = append(next, v...)
+				}
+			}
+		}
+		values = next
+	}
+
+	if p.multiple {
+		return values, nil
+	}
+	if len(values) == 0 {
+		return nil, ErrNotFound
+	}
+	return values[0], nil
+}
+
+// 编译 JSONPath 表达式并从已解析的 JSON 数据中查询值。
+//
+// 入参：
+//   - data: 由 [encoding/json] 解析得到的数据。
+//   - path: JSONPath 表达式。
+//
+// 出参：
+//   - 匹配的值。
+//   - 错误。
+func Query(data any, path string) (any, error) {
+	p, err := Compile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.Query(data)
+}
@@ -0,0 +1,73 @@
+package jsonpath_test
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+
+	xjsonpath "github.com/certimate-go/certimate/pkg/utils/jsonpath"
+)
+
+func TestUtil(t *testing.T) {
+	var data any
+	json.Unmarshal([]byte(`{
+		"code": 0,
+		"data": {
+			"id": "abc",
+			"a.b": true,
+			"items": [
+				{ "name": "foo", "size": 1 },
+				{ "name": "bar", "size": 2 }
+			]
+		}
+	}`), &data)
+
+	t.Run("Query", func(t *testing.T) {
+		testCases := []struct {
+			path     string
+			expected any
+		}{
+			{"$.code", float64(0)},
+			{"$.data.id", "abc"},
+			{"data.id", "abc"},
+			{"$['data']['a.b']", true},
+			{`$["data"].items[0].name`, "foo"},
+			{"$.data.items[-1].size", float64(2)},
+			{"$.data.items[*].name", []any{"foo", "bar"}},
+			{"$.data.items[*].missing", []any{}},
+			{"$.data.items[0].*", []any{"foo", float64(1)}},
+		}
+
+		for _, tc := range testCases {
+			actual, err := xjsonpath.Query(data, tc.path)
+			if err != nil {
+				t.Errorf("Query(%q) error = %v", tc.path, err)
+				continue
+			}
+			if !reflect.DeepEqual(actual, tc.expected) {
+				t.Errorf("Query(%q) = %v, want %v", tc.path, actual, tc.expected)
+			}
+		}
+	})
+
+	t.Run("QueryNotFound", func(t *testing.T) {
+		testCases := []string{"$.nope", "$.data.items[5]", "$.code.id", "$.data[0]"}
+
+		for _, tc := range testCases {
+			if _, err := xjsonpath.Query(data, tc); !errors.Is(err, xjsonpath.ErrNotFound) {
+				t.Errorf("Query(%q) error = %v, want ErrNotFound", tc, err)
+			}
+		}
+	})
+
+	t.Run("CompileError", func(t *testing.T) {
+		testCases := []string{"", "$..name", "$.data[", "$.data[abc]", "$.data['id'", "$.", "$x"}
+
+		for _, tc := range testCases {
+			if _, err := xjsonpath.Compile(tc); err == nil {
+				t.Errorf("Compile(%q) expected error, got nil", tc)
+			}
+		}
+	})
+}